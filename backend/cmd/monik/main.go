@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"time"
 
 	"monik-enterprise/internal/api"
 	"monik-enterprise/internal/config"
@@ -25,43 +28,186 @@ func main() {
 
 	// Load configuration
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+
+	// "monik migrate ..." manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(cfg, os.Args[2:]))
+	}
+
+	// Structured logger shared by every component; pkg/logger messages are routed to it
+	logService, err := service.NewLoggerService(cfg.Logging)
+	if err != nil {
+		log.Fatal("Failed to initialize logger:", err)
+	}
+	defer logService.Close()
+	logger.SetSink(func(level, message string) {
+		logService.Log(service.LogLevelFromString(level), service.ComponentApp, "", message, nil)
+	})
+
+	// OpenTelemetry tracing of collection cycles, RouterOS commands, database writes and
+	// HTTP requests
+	shutdownTracing, err := service.InitTracing(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatal("Failed to initialize tracing:", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownTracing(ctx)
+	}()
+	if cfg.Tracing.Enabled {
+		logService.Info(service.ComponentApp, "startup", "Tracing enabled", map[string]interface{}{
+			"exporter": cfg.Tracing.Exporter,
+			"endpoint": cfg.Tracing.OTLPEndpoint,
+		})
+	}
 
 	// Initialize database
-	db := database.InitDB(cfg.Database.Path)
+	db := database.InitDB(cfg.Database)
 	defer database.CloseDB()
 
-	// Run database migrations
-	database.RunMigrations(db)
+	// Run database migrations, or refuse to start on an outdated schema when they are
+	// applied manually with "monik migrate up"
+	if cfg.Database.AutoMigrate {
+		if err := database.RunMigrations(db, cfg); err != nil {
+			log.Fatal("Failed to run database migrations:", err)
+		}
+	} else {
+		pending, err := database.NewMigrator(db, cfg).Pending()
+		if err != nil {
+			log.Fatal("Failed to check database migrations:", err)
+		}
+		if len(pending) > 0 {
+			log.Fatalf("%d database migrations are pending, run \"monik migrate up\" first", len(pending))
+		}
+	}
 
-	// Initialize services
-	routerService := service.NewMikroTikService(cfg.Router)
+	// Initialize router registry and seed it from ROUTER_* settings when empty
+	registry := service.NewRouterRegistry(db, cfg.Router)
+	registry.SetLogger(logService)
+	defer registry.Close()
+	defaultRouter, err := registry.EnsureDefaultRouter()
+	if err != nil {
+		log.Fatal("Failed to initialize router registry:", err)
+	}
+
+	// WAN detection and worker pool operate on the default router
+	routerService, err := registry.Collector(*defaultRouter)
+	if err != nil {
+		log.Fatal("Failed to initialize default router collector:", err)
+	}
 
-	// Initialize WAN detection service
+	// Initialize WAN detection service (requires the binary API backend)
 	wanService := service.NewWANDetectionService(cfg.WAN)
-	wanService.SetRouterClient(routerService.GetClient())
+	wanService.SetLogger(logService)
+	if apiService, ok := routerService.(*service.MikroTikService); ok {
+		wanService.SetRouterClient(apiService.GetClient())
+	}
 
 	// Initialize worker pool
 	workerPool := service.NewWorkerPool(cfg.Worker, routerService)
+	workerPool.SetLogger(logService)
 	workerPool.Start()
 
 	// Initialize WebSocket manager
 	wsManager := websocket.NewWebSocketManager()
-	// Note: wsManager is already started in NewWebSocketManager()
+	wsManager.SetLogger(logService)
+	wsManager.Start()
+
+	// Notifications deliver alert, quota and WAN failover events to the routed channels
+	notificationService := service.NewNotificationService(db, cfg.Notify)
+	notificationService.SetLogger(logService)
+	notificationService.Start()
+	defer notificationService.Stop()
+	wanService.SetNotifier(notificationService)
+
+	// Billing cycles decide how daily usage is bucketed and reported
+	billingService := service.NewBillingService(db, cfg.Billing)
+	billingService.SetLogger(logService)
+
+	// Quota limits are checked against billing cycle usage and enforced on the routers
+	quotaService := service.NewQuotaService(db, cfg.Quota, billingService, registry, wsManager, notificationService)
+	quotaService.SetLogger(logService)
+	quotaService.Start()
+	defer quotaService.Stop()
+
+	// Maintenance windows and silences suppress alerts and mark history
+	maintenanceService := service.NewMaintenanceService(db)
+	maintenanceService.SetLogger(logService)
+
+	// Alert rules are evaluated against every poll result
+	alertService := service.NewAlertService(db, cfg.Alerts, wsManager, notificationService, maintenanceService)
+	alertService.SetLogger(logService)
+	alertService.Start()
+	defer alertService.Stop()
+
+	// Outages record when routers could not be reached
+	outageService := service.NewOutageService(db)
+	outageService.SetLogger(logService)
 
 	// Initialize monitoring service
-	monitoringService := service.NewMonitoringService(db, routerService, wanService, wsManager)
+	monitoringService := service.NewMonitoringService(db, cfg.Polling, cfg.Snapshot, registry, billingService, quotaService, alertService, maintenanceService, outageService, wanService, wsManager)
+	monitoringService.SetLogger(logService)
+
+	// System health is computed from router round trips, database writes, the worker
+	// queue and the WebSocket and WAN detection counters
+	metricsService := service.NewMetricsService(cfg.Metrics, wsManager)
+	metricsService.SetWorkerPool(workerPool)
+	metricsService.SetWANDetectionMetrics(wanService.GetDetectionStats())
+	monitoringService.SetMetrics(metricsService)
+	if cfg.Metrics.Enabled {
+		metricsService.Start()
+		defer metricsService.Stop()
+	}
 
 	// Start monitoring service
 	go monitoringService.Start()
 
+	// Aggregate traffic snapshots into rollups and apply retention
+	rollupService := service.NewRollupService(db, cfg.Retention, cfg.Snapshot)
+	rollupService.SetLogger(logService)
+	rollupService.Start()
+	defer rollupService.Stop()
+
+	// Audit entries logged through logService are also persisted to the hash-chained
+	// audit trail
+	auditService := service.NewAuditService(db)
+	auditService.SetLogger(logService)
+	logService.SetAuditService(auditService)
+
+	// Users, tokens and API keys guarding the API
+	authService := service.NewAuthService(db, cfg.Auth)
+	authService.SetLogger(logService)
+	if err := authService.EnsureAdmin(); err != nil {
+		logService.Error(service.ComponentAuth, "startup", "Failed to create the admin user", err, nil)
+	}
+	if cfg.Auth.JWTSecret == "" {
+		logService.Warn(service.ComponentAuth, "startup", "AUTH_JWT_SECRET is not set, tokens will be invalidated on restart", nil)
+	}
+	if !cfg.Auth.Enabled {
+		logService.Warn(service.ComponentApp, "startup", "AUTH_ENABLED=false, the API and WebSocket are open to anyone who can reach them", nil)
+	}
+	wsManager.SetAllowedOrigins(cfg.Server.CORSOrigins)
+
+	// Prometheus exporter behind /metrics
+	var prometheusExporter *service.PrometheusExporter
+	if cfg.Metrics.PrometheusEnabled {
+		prometheusExporter = service.NewPrometheusExporter(db, registry, monitoringService, quotaService, workerPool, wsManager, wanService)
+	}
+
 	// Initialize API handlers
-	handlers := api.NewHandlers(db, monitoringService, wanService, workerPool, wsManager)
+	handlers := api.NewHandlers(db, monitoringService, wanService, workerPool, wsManager, rollupService, billingService, quotaService, alertService, notificationService, maintenanceService, outageService, authService, auditService, logService, prometheusExporter, metricsService)
 
 	// Setup routes
-	r := router.SetupRoutes(handlers)
+	r := router.SetupRoutes(handlers, cfg.Server.CORSOrigins)
 
 	// Start server
-	log.Printf("Starting server on %s:%d", cfg.Server.Host, cfg.Server.Port)
+	logService.LogSystemEvent("startup", "Starting server", map[string]interface{}{
+		"address": cfg.Server.Address(),
+	})
 
 	// Handle graceful shutdown
 	go func() {
@@ -71,6 +217,6 @@ func main() {
 	}()
 
 	// Wait for interrupt signal to gracefully shutdown
-	log.Println("Server started. Press Ctrl+C to shutdown.")
+	logService.LogSystemEvent("startup", "Server started. Press Ctrl+C to shutdown.", nil)
 	select {}
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"monik-enterprise/internal/config"
+	"monik-enterprise/internal/database"
+)
+
+const migrateUsage = `Usage: monik migrate <command> [flags]
+
+Commands:
+  status             List migrations and whether they are applied
+  up [-to N]         Apply pending migrations, optionally only up to version N
+  down [-steps N]    Revert the last N applied migrations (default 1)
+
+Flags:
+  -dry-run           Run the migrations in a transaction that is rolled back
+`
+
+// runMigrate implements the "monik migrate" command and returns the exit code
+func runMigrate(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	command := args[0]
+	if command != "status" && command != "up" && command != "down" {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+	flags := flag.NewFlagSet("migrate "+command, flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "roll back after running the migrations")
+	to := flags.Int64("to", 0, "apply migrations up to this version (0 = latest)")
+	steps := flags.Int("steps", 1, "number of migrations to revert")
+	flags.Usage = func() { fmt.Fprint(os.Stderr, migrateUsage) }
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	db := database.InitDB(cfg.Database)
+	defer database.CloseDB()
+
+	migrator := database.NewMigrator(db, cfg)
+	migrator.DryRun = *dryRun
+
+	switch command {
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read migration status: %v\n", err)
+			return 1
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%4d  %-36s %s\n", status.Version, status.Name, state)
+		}
+	case "up":
+		applied, err := migrator.Up(*to)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Migration failed after %d applied: %v\n", applied, err)
+			return 1
+		}
+		if !*dryRun {
+			if err := database.EnsureTimescale(db, cfg.Database); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to set up TimescaleDB hypertable: %v\n", err)
+				return 1
+			}
+		}
+		fmt.Printf("%d migrations applied%s\n", applied, dryRunSuffix(*dryRun))
+	case "down":
+		if *steps < 1 {
+			fmt.Fprintln(os.Stderr, "-steps must be at least 1")
+			return 2
+		}
+		reverted, err := migrator.Down(*steps)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Migration failed after %d reverted: %v\n", reverted, err)
+			return 1
+		}
+		fmt.Printf("%d migrations reverted%s\n", reverted, dryRunSuffix(*dryRun))
+	}
+	return 0
+}
+
+func dryRunSuffix(dryRun bool) string {
+	if dryRun {
+		return " (dry run, rolled back)"
+	}
+	return ""
+}
@@ -0,0 +1,324 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"monik-enterprise/internal/models"
+	"monik-enterprise/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// alertRuleRequest is the payload accepted when creating or replacing an alert rule
+type alertRuleRequest struct {
+	Name          string `json:"name" binding:"required"`
+	Expression    string `json:"expression" binding:"required"` // e.g. "rx_rate > 800 for 5m"
+	RouterID      uint   `json:"router_id"`                     // 0 matches every router
+	InterfaceName string `json:"interface_name"`                // glob pattern, empty matches every interface
+	Severity      string `json:"severity"`                      // info, warning (default) or critical
+	Description   string `json:"description"`
+	Enabled       *bool  `json:"enabled"` // defaults to true
+}
+
+// alertAckRequest is the payload accepted when acknowledging an alert
+type alertAckRequest struct {
+	By string `json:"by"`
+}
+
+func (req alertRuleRequest) toRule() models.AlertRule {
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	return models.AlertRule{
+		Name:          req.Name,
+		Expression:    req.Expression,
+		RouterID:      req.RouterID,
+		InterfaceName: req.InterfaceName,
+		Severity:      req.Severity,
+		Description:   req.Description,
+		Enabled:       enabled,
+	}
+}
+
+// GetAlerts returns alerts, newest first
+// GET /api/v1/alerts?state=firing&router_id=1&interface=ether1&rule_id=2&limit=100
+func (h *Handlers) GetAlerts(c *gin.Context) {
+	routerID, err := h.resolveRouterID(c, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	filter := service.AlertFilter{
+		State:         c.Query("state"),
+		RouterID:      routerID,
+		InterfaceName: c.Query("interface"),
+		Limit:         100,
+	}
+	switch filter.State {
+	case "", "active", service.AlertStatePending, service.AlertStateFiring, service.AlertStateResolved:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid state parameter (active, pending, firing or resolved)",
+		})
+		return
+	}
+	if ruleStr := c.Query("rule_id"); ruleStr != "" {
+		ruleID, err := strconv.ParseUint(ruleStr, 10, 64)
+		if err != nil || ruleID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid rule_id parameter",
+			})
+			return
+		}
+		filter.RuleID = uint(ruleID)
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid limit parameter (1-1000)",
+			})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	alerts, err := h.alerts.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve alerts",
+		})
+		return
+	}
+
+	alerts = filterScoped(c, alerts, func(alert models.Alert) (uint, string) {
+		return alert.RouterID, alert.InterfaceName
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"alerts": alerts,
+		"count":  len(alerts),
+		"active": h.alerts.Counts(),
+	})
+}
+
+// GetAlert returns a specific alert
+func (h *Handlers) GetAlert(c *gin.Context) {
+	id, ok := parseIDParam(c, "Invalid alert id")
+	if !ok {
+		return
+	}
+
+	alert, err := h.alerts.Get(id)
+	if err == nil && !requestScope(c).AllowsInterface(alert.RouterID, alert.InterfaceName) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		writeRecordError(c, err, "Alert not found", "Failed to retrieve alert")
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// AcknowledgeAlert marks a pending or firing alert as acknowledged
+// POST /api/v1/alerts/:id/ack
+func (h *Handlers) AcknowledgeAlert(c *gin.Context) {
+	id, ok := parseIDParam(c, "Invalid alert id")
+	if !ok {
+		return
+	}
+
+	if alert, err := h.alerts.Get(id); err == nil && !requestScope(c).AllowsInterface(alert.RouterID, alert.InterfaceName) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Alert not found",
+		})
+		return
+	}
+
+	// The body is optional
+	var req alertAckRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
+
+	alert, err := h.alerts.Acknowledge(id, req.By)
+	if err != nil {
+		if errors.Is(err, service.ErrAlertResolved) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		writeRecordError(c, err, "Alert not found", "Failed to acknowledge alert")
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// GetAlertRules returns all alert rules
+func (h *Handlers) GetAlertRules(c *gin.Context) {
+	rules, err := h.alerts.ListRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve alert rules",
+		})
+		return
+	}
+
+	rules = filterScoped(c, rules, func(rule models.AlertRule) (uint, string) {
+		return rule.RouterID, ""
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules": rules,
+	})
+}
+
+// GetAlertRule returns a specific alert rule
+func (h *Handlers) GetAlertRule(c *gin.Context) {
+	id, ok := parseIDParam(c, "Invalid alert rule id")
+	if !ok {
+		return
+	}
+
+	rule, err := h.alerts.GetRule(id)
+	if err == nil && rule.RouterID != 0 && !requestScope(c).AllowsRouter(rule.RouterID) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		writeRecordError(c, err, "Alert rule not found", "Failed to retrieve alert rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// CreateAlertRule adds an alert rule
+func (h *Handlers) CreateAlertRule(c *gin.Context) {
+	var req alertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	rule := req.toRule()
+	if !writableOrAbort(c, rule.RouterID, "") {
+		return
+	}
+	if err := h.alerts.CreateRule(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateAlertRule replaces an alert rule
+func (h *Handlers) UpdateAlertRule(c *gin.Context) {
+	id, ok := parseIDParam(c, "Invalid alert rule id")
+	if !ok {
+		return
+	}
+
+	var req alertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if !h.alertRuleWritableOrAbort(c, id) || !writableOrAbort(c, req.RouterID, "") {
+		return
+	}
+
+	rule, err := h.alerts.UpdateRule(id, req.toRule())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Alert rule not found",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteAlertRule removes an alert rule and resolves its alerts
+func (h *Handlers) DeleteAlertRule(c *gin.Context) {
+	id, ok := parseIDParam(c, "Invalid alert rule id")
+	if !ok {
+		return
+	}
+
+	if !h.alertRuleWritableOrAbort(c, id) {
+		return
+	}
+
+	if err := h.alerts.DeleteRule(id); err != nil {
+		writeRecordError(c, err, "Alert rule not found", "Failed to delete alert rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Alert rule deleted successfully",
+	})
+}
+
+// alertRuleWritableOrAbort checks that the caller may change an existing alert rule
+func (h *Handlers) alertRuleWritableOrAbort(c *gin.Context, id uint) bool {
+	rule, err := h.alerts.GetRule(id)
+	if err != nil {
+		writeRecordError(c, err, "Alert rule not found", "Failed to retrieve alert rule")
+		return false
+	}
+	return writableOrAbort(c, rule.RouterID, "")
+}
+
+// parseIDParam reads a positive numeric :id path parameter
+func parseIDParam(c *gin.Context, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": message,
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// writeRecordError maps record lookup errors to HTTP responses
+func writeRecordError(c *gin.Context, err error, notFound, message string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": notFound,
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": message,
+	})
+}
@@ -0,0 +1,262 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"monik-enterprise/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/schema"
+)
+
+// Gin context keys handlers set to describe a request in the audit trail
+const (
+	auditActorKey  = "audit_actor"  // Actor of unauthenticated requests, e.g. the username of a login
+	auditActionKey = "audit_action" // Overrides the "METHOD /route" action
+	auditChangeKey = "audit_change" // Resource a request changed, set with setAuditChange
+)
+
+// auditIgnoredFields are bookkeeping fields left out of audited changes
+var auditIgnoredFields = map[string]bool{
+	"id":            true,
+	"created_at":    true,
+	"updated_at":    true,
+	"last_seen":     true,
+	"last_error":    true,
+	"last_login_at": true,
+}
+
+// Audit is the middleware recording every request that changes state, and every
+// rejected one, in the audit trail. Handlers describe the resource they changed with
+// setAuditChange.
+func (h *Handlers) Audit(c *gin.Context) {
+	c.Next()
+
+	if h.logger == nil {
+		return
+	}
+	status := c.Writer.Status()
+	action := c.GetString(auditActionKey)
+	if action == "" {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			if status != http.StatusUnauthorized && status != http.StatusForbidden {
+				return
+			}
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		action = c.Request.Method + " " + route
+	}
+
+	actor := c.GetString(auditActorKey)
+	metadata := map[string]interface{}{
+		service.AuditKeyIP: c.ClientIP(),
+		"status":           status,
+	}
+	if principal := currentPrincipal(c); principal != nil {
+		actor = principal.Username
+		metadata[service.AuditKeyActorID] = principal.UserID
+		metadata["auth_method"] = principal.Method
+		if principal.APIKeyID != 0 {
+			metadata["api_key_id"] = principal.APIKeyID
+		}
+	}
+	if actor == "" {
+		actor = "anonymous"
+	}
+	if routerID := c.Query("router_id"); routerID != "" {
+		metadata["router_id"] = routerID
+	}
+	if change, ok := c.Get(auditChangeKey); ok {
+		metadata["change"] = change
+	}
+
+	h.logger.LogAudit(actor, action, c.Request.URL.Path, status < http.StatusBadRequest, metadata)
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		h.logger.LogSecurity("access_denied", "Request rejected", map[string]interface{}{
+			"user":   actor,
+			"ip":     c.ClientIP(),
+			"action": action,
+			"status": status,
+		})
+	}
+}
+
+// setAuditChange records which resource a request changed and how, for the audit trail.
+// before is nil for a created resource and after nil for a deleted one; an update keeps
+// only the fields that differ. Fields hidden from JSON hold credentials, their values
+// are never recorded and setting them is only named under "secrets_changed".
+func setAuditChange(c *gin.Context, resourceID interface{}, before, after interface{}) {
+	change := gin.H{"resource_id": resourceID}
+	beforeFields, afterFields := auditFields(before), auditFields(after)
+	if beforeFields != nil && afterFields != nil {
+		for field, value := range afterFields {
+			if reflect.DeepEqual(beforeFields[field], value) {
+				delete(beforeFields, field)
+				delete(afterFields, field)
+			}
+		}
+	}
+	if beforeFields != nil {
+		change["before"] = beforeFields
+	}
+	if afterFields != nil {
+		change["after"] = afterFields
+	}
+	if secrets := changedSecrets(before, after); len(secrets) > 0 {
+		change["secrets_changed"] = secrets
+	}
+	c.Set(auditChangeKey, change)
+}
+
+// auditFields returns the JSON fields of a resource without bookkeeping fields, nil
+// for a nil resource
+func auditFields(resource interface{}) map[string]interface{} {
+	if resource == nil || reflect.ValueOf(resource).IsNil() {
+		return nil
+	}
+	encoded, err := json.Marshal(resource)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil
+	}
+	dropAuditIgnored(fields)
+	return fields
+}
+
+// dropAuditIgnored removes bookkeeping fields, including those of nested objects
+func dropAuditIgnored(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for field, nested := range v {
+			if auditIgnoredFields[field] {
+				delete(v, field)
+				continue
+			}
+			dropAuditIgnored(nested)
+		}
+	case []interface{}:
+		for _, nested := range v {
+			dropAuditIgnored(nested)
+		}
+	}
+}
+
+// changedSecrets names the string fields hidden from JSON that a create or update set
+// to a new value. before, when not nil, must point to the same struct type as after.
+func changedSecrets(before, after interface{}) []string {
+	if after == nil || reflect.ValueOf(after).IsNil() {
+		return nil
+	}
+	afterValue := reflect.ValueOf(after).Elem()
+	var beforeValue reflect.Value
+	if before != nil && !reflect.ValueOf(before).IsNil() {
+		beforeValue = reflect.ValueOf(before).Elem()
+	}
+
+	var changed []string
+	naming := schema.NamingStrategy{}
+	typ := afterValue.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Type.Kind() != reflect.String || strings.Split(field.Tag.Get("json"), ",")[0] != "-" {
+			continue
+		}
+		previous := ""
+		if beforeValue.IsValid() {
+			previous = beforeValue.Field(i).String()
+		}
+		if afterValue.Field(i).String() != previous {
+			changed = append(changed, naming.ColumnName("", field.Name))
+		}
+	}
+	return changed
+}
+
+// GetAuditLog returns audit entries, newest first
+// GET /api/v1/audit?actor=admin&action=login&resource=/api/v1/quotas&outcome=failure&from=...&to=...&limit=100&offset=0
+func (h *Handlers) GetAuditLog(c *gin.Context) {
+	filter := service.AuditFilter{
+		Actor:    c.Query("actor"),
+		Action:   c.Query("action"),
+		Resource: c.Query("resource"),
+		Outcome:  c.Query("outcome"),
+		Limit:    100,
+	}
+	switch filter.Outcome {
+	case "", service.AuditOutcomeSuccess, service.AuditOutcomeFailure:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid outcome parameter (success or failure)",
+		})
+		return
+	}
+	if c.Query("from") != "" || c.Query("to") != "" {
+		from, to, ok := parseTimeRange(c, 30*24*time.Hour)
+		if !ok {
+			return
+		}
+		filter.From, filter.To = from, to
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid limit parameter (1-1000)",
+			})
+			return
+		}
+		filter.Limit = limit
+	}
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid offset parameter",
+			})
+			return
+		}
+		filter.Offset = offset
+	}
+
+	entries, total, err := h.audit.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve audit log",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"count":   len(entries),
+		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+	})
+}
+
+// VerifyAuditLog checks the hash chain of the whole audit trail
+// GET /api/v1/audit/verify
+func (h *Handlers) VerifyAuditLog(c *gin.Context) {
+	result, err := h.audit.Verify()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to verify audit log",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
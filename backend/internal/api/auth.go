@@ -0,0 +1,565 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"monik-enterprise/internal/models"
+	"monik-enterprise/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// principalKey is the gin context key holding the authenticated *service.Principal
+const principalKey = "principal"
+
+// loginRequest is the payload accepted by the login endpoint
+type loginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// refreshRequest carries a refresh token for the refresh and logout endpoints
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// passwordRequest is the payload accepted when users change their own password
+type passwordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// scopeRequest is one router, or interfaces of it, a user is limited to
+type scopeRequest struct {
+	RouterID      uint   `json:"router_id" binding:"required"`
+	InterfaceName string `json:"interface_name"` // glob pattern, empty covers the whole router
+}
+
+// userRequest is the payload accepted when creating a user
+type userRequest struct {
+	Username string         `json:"username" binding:"required"`
+	Password string         `json:"password" binding:"required"`
+	Role     string         `json:"role" binding:"required"` // admin, operator, viewer or customer
+	Scopes   []scopeRequest `json:"scopes"`                  // empty is unrestricted, except for customers
+}
+
+// userUpdateRequest is the payload accepted when updating a user, omitted fields are
+// left unchanged
+type userUpdateRequest struct {
+	Password *string         `json:"password"`
+	Disabled *bool           `json:"disabled"`
+	Role     *string         `json:"role"`
+	Scopes   *[]scopeRequest `json:"scopes"` // replaces every scope entry
+}
+
+func toUserScopes(scopes []scopeRequest) []models.UserScope {
+	result := make([]models.UserScope, len(scopes))
+	for i, scope := range scopes {
+		result[i] = models.UserScope{
+			RouterID:      scope.RouterID,
+			InterfaceName: scope.InterfaceName,
+		}
+	}
+	return result
+}
+
+func (req userUpdateRequest) toUpdate() service.UserUpdate {
+	update := service.UserUpdate{
+		Password: req.Password,
+		Disabled: req.Disabled,
+		Role:     req.Role,
+	}
+	if req.Scopes != nil {
+		scopes := toUserScopes(*req.Scopes)
+		update.Scopes = &scopes
+	}
+	return update
+}
+
+// apiKeyRequest is the payload accepted when creating an API key
+type apiKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at"` // nil never expires
+}
+
+// Authenticate is the middleware guarding the API. It accepts an access token or API
+// key as "Authorization: Bearer <credential>" or an API key as "X-API-Key". Browsers
+// cannot set headers on WebSocket upgrades, so those may pass ?token= instead.
+func (h *Handlers) Authenticate(c *gin.Context) {
+	if !h.auth.Enabled() {
+		c.Next()
+		return
+	}
+
+	credential := requestCredential(c)
+	if credential == "" {
+		c.Header("WWW-Authenticate", `Bearer realm="monik"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required",
+		})
+		return
+	}
+
+	principal, err := h.auth.Authenticate(credential)
+	if err != nil {
+		c.Header("WWW-Authenticate", `Bearer realm="monik", error="invalid_token"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Set(principalKey, principal)
+	c.Next()
+}
+
+// Require returns middleware rejecting callers whose role does not grant permission.
+// It lets everything through when authentication is disabled.
+func (h *Handlers) Require(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.auth.Enabled() {
+			c.Next()
+			return
+		}
+		if !currentPrincipal(c).Can(permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "Insufficient permissions",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// requestScope returns the routers and interfaces the caller is limited to, nil when
+// unrestricted
+func requestScope(c *gin.Context) *service.Scope {
+	if principal := currentPrincipal(c); principal != nil {
+		return principal.Scope
+	}
+	return nil
+}
+
+// interfaceInScopeOrAbort writes a 404 response when the interface is outside the
+// caller's scope, so restricted users cannot probe for interfaces
+func interfaceInScopeOrAbort(c *gin.Context, routerID uint, name string) bool {
+	if requestScope(c).AllowsInterface(routerID, name) {
+		return true
+	}
+	c.JSON(http.StatusNotFound, gin.H{
+		"error": "Interface not found",
+	})
+	return false
+}
+
+// writableOrAbort writes a 403 response when the caller may not change settings of the
+// router and interface. Restricted callers cannot change settings covering every router.
+func writableOrAbort(c *gin.Context, routerID uint, name string) bool {
+	scope := requestScope(c)
+	if scope == nil || (routerID != 0 && scope.AllowsInterface(routerID, name)) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": "Router or interface outside your scope",
+	})
+	return false
+}
+
+// filterScoped drops the items outside the caller's scope. key returns the router and
+// interface of an item; router 0 applies to every router and is always kept.
+func filterScoped[T any](c *gin.Context, items []T, key func(T) (uint, string)) []T {
+	scope := requestScope(c)
+	if scope == nil {
+		return items
+	}
+	filtered := make([]T, 0, len(items))
+	for _, item := range items {
+		routerID, name := key(item)
+		if routerID == 0 || scope.AllowsInterface(routerID, name) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// requestCredential returns the token or API key presented with a request
+func requestCredential(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); header != "" {
+		scheme, credential, ok := strings.Cut(header, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(credential)
+		}
+		return ""
+	}
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	if strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+		return c.Query("token")
+	}
+	return ""
+}
+
+// currentPrincipal returns the authenticated caller, nil when authentication is disabled
+func currentPrincipal(c *gin.Context) *service.Principal {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return nil
+	}
+	principal, _ := value.(*service.Principal)
+	return principal
+}
+
+// principalOrAbort returns the authenticated caller or writes a 401 response, for
+// endpoints that act on the caller's own account
+func principalOrAbort(c *gin.Context) (*service.Principal, bool) {
+	principal := currentPrincipal(c)
+	if principal == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Not authenticated",
+		})
+		return nil, false
+	}
+	return principal, true
+}
+
+// Login exchanges a username and password for an access and refresh token
+// POST /api/v1/auth/login
+func (h *Handlers) Login(c *gin.Context) {
+	c.Set(auditActionKey, "login")
+
+	var req loginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Set(auditActorKey, req.Username)
+
+	tokens, err := h.auth.Login(req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to log in",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RefreshToken exchanges a refresh token for a new token pair
+// POST /api/v1/auth/refresh
+func (h *Handlers) RefreshToken(c *gin.Context) {
+	c.Set(auditActionKey, "token_refresh")
+
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	tokens, err := h.auth.Refresh(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to refresh token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout revokes a refresh token
+// POST /api/v1/auth/logout
+func (h *Handlers) Logout(c *gin.Context) {
+	c.Set(auditActionKey, "logout")
+
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.auth.Logout(req.RefreshToken); err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to log out",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logged out successfully",
+	})
+}
+
+// GetCurrentUser returns the authenticated caller
+// GET /api/v1/auth/me
+func (h *Handlers) GetCurrentUser(c *gin.Context) {
+	principal, ok := principalOrAbort(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.auth.GetUser(principal.UserID)
+	if err != nil {
+		writeRecordError(c, err, "User not found", "Failed to retrieve user")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":       user,
+		"method":     principal.Method,
+		"restricted": principal.Scope != nil,
+	})
+}
+
+// ChangePassword changes the password of the authenticated caller
+// PUT /api/v1/auth/password
+func (h *Handlers) ChangePassword(c *gin.Context) {
+	principal, ok := principalOrAbort(c)
+	if !ok {
+		return
+	}
+	var req passwordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.auth.ChangePassword(principal.UserID, req.CurrentPassword, req.NewPassword); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCredentials):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Current password is incorrect",
+			})
+		case errors.Is(err, service.ErrPasswordTooShort):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+		default:
+			writeRecordError(c, err, "User not found", "Failed to change password")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password changed successfully",
+	})
+}
+
+// GetUsers returns all users
+func (h *Handlers) GetUsers(c *gin.Context) {
+	users, err := h.auth.ListUsers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve users",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": users,
+	})
+}
+
+// CreateUser adds a user
+func (h *Handlers) CreateUser(c *gin.Context) {
+	var req userRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	user, err := h.auth.CreateUser(req.Username, req.Password, req.Role, toUserScopes(req.Scopes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	setAuditChange(c, user.ID, nil, user)
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// UpdateUser changes the password, disabled flag, role or scope of a user
+func (h *Handlers) UpdateUser(c *gin.Context) {
+	id, ok := parseIDParam(c, "Invalid user id")
+	if !ok {
+		return
+	}
+	var req userUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if principal := currentPrincipal(c); principal != nil && principal.UserID == id {
+		if req.Disabled != nil && *req.Disabled {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "You cannot disable your own account",
+			})
+			return
+		}
+		if req.Role != nil && *req.Role != service.RoleAdmin {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "You cannot remove your own admin role",
+			})
+			return
+		}
+	}
+
+	before, err := h.auth.GetUser(id)
+	if err != nil {
+		writeRecordError(c, err, "User not found", "Failed to update user")
+		return
+	}
+	user, err := h.auth.UpdateUser(id, req.toUpdate())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	setAuditChange(c, id, before, user)
+
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteUser removes a user with its sessions and API keys
+func (h *Handlers) DeleteUser(c *gin.Context) {
+	id, ok := parseIDParam(c, "Invalid user id")
+	if !ok {
+		return
+	}
+	if principal := currentPrincipal(c); principal != nil && principal.UserID == id {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "You cannot delete your own account",
+		})
+		return
+	}
+
+	before, err := h.auth.GetUser(id)
+	if err != nil {
+		writeRecordError(c, err, "User not found", "Failed to delete user")
+		return
+	}
+	if err := h.auth.DeleteUser(id); err != nil {
+		writeRecordError(c, err, "User not found", "Failed to delete user")
+		return
+	}
+	setAuditChange(c, id, before, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User deleted successfully",
+	})
+}
+
+// GetAPIKeys returns the API keys of the authenticated caller
+func (h *Handlers) GetAPIKeys(c *gin.Context) {
+	principal, ok := principalOrAbort(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.auth.ListAPIKeys(principal.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve API keys",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"api_keys": keys,
+	})
+}
+
+// CreateAPIKey issues an API key for the authenticated caller. The key is only shown
+// in this response.
+func (h *Handlers) CreateAPIKey(c *gin.Context) {
+	principal, ok := principalOrAbort(c)
+	if !ok {
+		return
+	}
+	var req apiKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	key, apiKey, err := h.auth.CreateAPIKey(principal.UserID, req.Name, req.ExpiresAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"key":     key,
+		"api_key": apiKey,
+	})
+}
+
+// DeleteAPIKey revokes an API key of the authenticated caller
+func (h *Handlers) DeleteAPIKey(c *gin.Context) {
+	principal, ok := principalOrAbort(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "Invalid API key id")
+	if !ok {
+		return
+	}
+
+	if err := h.auth.DeleteAPIKey(principal.UserID, id); err != nil {
+		writeRecordError(c, err, "API key not found", "Failed to delete API key")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API key deleted successfully",
+	})
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"monik-enterprise/internal/models"
+	"monik-enterprise/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// billingCycleRequest is the payload accepted when setting the billing cycle of an interface
+type billingCycleRequest struct {
+	StartDay   int    `json:"start_day"`   // 1-31, defaults to 1
+	Timezone   string `json:"timezone"`    // IANA zone, empty uses the server timezone
+	Length     int    `json:"length"`      // defaults to 1
+	Unit       string `json:"unit"`        // month (default) or day
+	AnchorDate string `json:"anchor_date"` // YYYY-MM-DD, required for day cycles
+}
+
+// getCycleUsage serves GetMonthlyUsage per billing cycle
+func (h *Handlers) getCycleUsage(c *gin.Context, routerID uint, ifaceName string) {
+	cycles := 3
+	if cyclesStr := c.Query("cycles"); cyclesStr != "" {
+		parsed, err := strconv.Atoi(cyclesStr)
+		if err != nil || parsed < 1 || parsed > service.MaxUsageCycles {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid cycles parameter (1-" + strconv.Itoa(service.MaxUsageCycles) + ")",
+			})
+			return
+		}
+		cycles = parsed
+	}
+
+	report, err := h.billing.Usage(routerID, ifaceName, cycles, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve usage data",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"router_id":      routerID,
+		"interface_name": ifaceName,
+		"billing_cycle":  report.Cycle,
+		"custom_cycle":   report.Custom,
+		"current":        report.Cycles[0],
+		"cycles":         report.Cycles,
+		"daily_stats":    report.DailyStats,
+	})
+}
+
+// GetBillingCycles returns the interface-specific billing cycles and the default cycle
+// GET /api/v1/billing-cycles?router_id=1
+func (h *Handlers) GetBillingCycles(c *gin.Context) {
+	routerID, err := h.resolveRouterID(c, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	cycles, err := h.billing.ListCycles(routerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve billing cycles",
+		})
+		return
+	}
+
+	cycles = filterScoped(c, cycles, func(cycle models.BillingCycle) (uint, string) {
+		return cycle.RouterID, cycle.InterfaceName
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"billing_cycles": cycles,
+		"default":        h.billing.Defaults(),
+	})
+}
+
+// GetBillingCycle returns the effective billing cycle of an interface and the bounds
+// of its current cycle
+// GET /api/v1/billing-cycles/:interface?router_id=1
+func (h *Handlers) GetBillingCycle(c *gin.Context) {
+	ifaceName := c.Param("interface")
+	routerID, ok := h.routerIDOrAbort(c)
+	if !ok {
+		return
+	}
+	if !interfaceInScopeOrAbort(c, routerID, ifaceName) {
+		return
+	}
+
+	cycle, custom := h.billing.Cycle(routerID, ifaceName)
+	start, end := h.billing.CycleAt(routerID, ifaceName, time.Now())
+	c.JSON(http.StatusOK, gin.H{
+		"router_id":      routerID,
+		"interface_name": ifaceName,
+		"billing_cycle":  cycle,
+		"custom_cycle":   custom,
+		"current_start":  start,
+		"current_end":    end,
+	})
+}
+
+// SetBillingCycle creates or replaces the billing cycle of an interface
+// PUT /api/v1/billing-cycles/:interface?router_id=1
+func (h *Handlers) SetBillingCycle(c *gin.Context) {
+	ifaceName := c.Param("interface")
+	routerID, ok := h.routerIDOrAbort(c)
+	if !ok {
+		return
+	}
+	if !writableOrAbort(c, routerID, ifaceName) {
+		return
+	}
+
+	var req billingCycleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	cycle, err := h.billing.SetCycle(models.BillingCycle{
+		RouterID:      routerID,
+		InterfaceName: ifaceName,
+		StartDay:      req.StartDay,
+		Timezone:      req.Timezone,
+		Length:        req.Length,
+		Unit:          req.Unit,
+		AnchorDate:    req.AnchorDate,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, cycle)
+}
+
+// DeleteBillingCycle removes the billing cycle of an interface so the default applies
+// DELETE /api/v1/billing-cycles/:interface?router_id=1
+func (h *Handlers) DeleteBillingCycle(c *gin.Context) {
+	ifaceName := c.Param("interface")
+	routerID, ok := h.routerIDOrAbort(c)
+	if !ok {
+		return
+	}
+	if !writableOrAbort(c, routerID, ifaceName) {
+		return
+	}
+
+	if err := h.billing.DeleteCycle(routerID, ifaceName); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Billing cycle not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete billing cycle",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Billing cycle deleted successfully",
+	})
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -22,22 +23,54 @@ type Handlers struct {
 	wanService       *service.WANDetectionService
 	workerPool       *service.WorkerPool
 	websocketManager *websocket.WebSocketManager
+	rollups          *service.RollupService
+	billing          *service.BillingService
+	quotas           *service.QuotaService
+	alerts           *service.AlertService
+	notifications    *service.NotificationService
+	maintenance      *service.MaintenanceService
+	outages          *service.OutageService
+	auth             *service.AuthService
+	audit            *service.AuditService
+	logger           *service.LoggerService
+	prometheus       *service.PrometheusExporter
+	metrics          *service.MetricsService
 }
 
 // NewHandlers creates new API handlers
-func NewHandlers(db *gorm.DB, svc *service.MonitoringService, wanSvc *service.WANDetectionService, workerPool *service.WorkerPool, wsManager *websocket.WebSocketManager) *Handlers {
+func NewHandlers(db *gorm.DB, svc *service.MonitoringService, wanSvc *service.WANDetectionService, workerPool *service.WorkerPool, wsManager *websocket.WebSocketManager, rollups *service.RollupService, billing *service.BillingService, quotas *service.QuotaService, alerts *service.AlertService, notifications *service.NotificationService, maintenance *service.MaintenanceService, outages *service.OutageService, auth *service.AuthService, audit *service.AuditService, logger *service.LoggerService, prometheus *service.PrometheusExporter, metrics *service.MetricsService) *Handlers {
 	return &Handlers{
 		db:               db,
 		service:          svc,
 		wanService:       wanSvc,
 		workerPool:       workerPool,
 		websocketManager: wsManager,
+		rollups:          rollups,
+		billing:          billing,
+		quotas:           quotas,
+		alerts:           alerts,
+		notifications:    notifications,
+		maintenance:      maintenance,
+		outages:          outages,
+		auth:             auth,
+		audit:            audit,
+		logger:           logger,
+		prometheus:       prometheus,
+		metrics:          metrics,
 	}
 }
 
-// GetInterfaces returns all interfaces
+// GetInterfaces returns all interfaces, optionally limited to one router
 func (h *Handlers) GetInterfaces(c *gin.Context) {
-	interfaces, err := h.service.GetLatestInterfaces()
+	routerID, err := h.resolveRouterID(c, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	interfaces, err := h.service.GetLatestInterfaces(routerID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to retrieve interfaces",
@@ -45,6 +78,10 @@ func (h *Handlers) GetInterfaces(c *gin.Context) {
 		return
 	}
 
+	interfaces = filterScoped(c, interfaces, func(iface models.Interface) (uint, string) {
+		return iface.RouterID, iface.InterfaceName
+	})
+
 	c.JSON(http.StatusOK, gin.H{
 		"interfaces": interfaces,
 	})
@@ -60,7 +97,15 @@ func (h *Handlers) GetInterface(c *gin.Context) {
 		return
 	}
 
-	iface, err := h.service.GetInterfaceByName(name)
+	routerID, ok := h.routerIDOrAbort(c)
+	if !ok {
+		return
+	}
+	if !interfaceInScopeOrAbort(c, routerID, name) {
+		return
+	}
+
+	iface, err := h.service.GetInterfaceByName(routerID, name)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -77,9 +122,155 @@ func (h *Handlers) GetInterface(c *gin.Context) {
 	c.JSON(http.StatusOK, iface)
 }
 
+// HealthCheck is the liveness probe. It reports service health, and the status is
+// degraded while a router is offline. It is reachable without a token, so routers are
+// only counted; GetHealth has the detail per router.
+func (h *Handlers) HealthCheck(c *gin.Context) {
+	registered, err := h.service.Registry().List()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "error",
+		})
+		return
+	}
+
+	status, summary, _ := routerHealth(registered, h.service.GetRouterStates())
+	c.JSON(http.StatusOK, gin.H{
+		"status":        status,
+		"routers":       summary,
+		"system_health": h.metrics.GetSystemHealth(),
+	})
+}
+
+// GetHealth reports service health together with the connection state of every router
+// in the caller's scope. TLS handshake and certificate verification failures are
+// reported separately from ordinary connectivity problems.
+func (h *Handlers) GetHealth(c *gin.Context) {
+	registered, err := h.service.Registry().List()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "error",
+			"error":  "Failed to retrieve routers",
+		})
+		return
+	}
+	registered = filterScoped(c, registered, func(router models.Router) (uint, string) {
+		return router.ID, ""
+	})
+
+	status, _, routers := routerHealth(registered, h.service.GetRouterStates())
+	c.JSON(http.StatusOK, gin.H{
+		"status":        status,
+		"routers":       routers,
+		"system_health": h.metrics.GetSystemHealth(),
+	})
+}
+
+// routerHealth returns the aggregate status of the routers, how many of them are offline
+// or failing TLS, and the connection state of each of them
+func routerHealth(registered []models.Router, states map[uint]service.RouterPollState) (string, gin.H, []gin.H) {
+	offline, tlsErrors := 0, 0
+	routers := []gin.H{}
+	for _, router := range registered {
+		entry := gin.H{
+			"id":     router.ID,
+			"name":   router.Name,
+			"status": router.Status,
+			"tls":    router.TLSEnabled,
+		}
+		if state, ok := states[router.ID]; ok && !state.Online {
+			offline++
+			entry["error"] = state.LastError
+			entry["error_kind"] = state.ErrorKind
+			if service.IsTLSErrorKind(state.ErrorKind) {
+				tlsErrors++
+				entry["tls_error"] = true
+			}
+		}
+		routers = append(routers, entry)
+	}
+
+	status := "ok"
+	if offline > 0 {
+		status = "degraded"
+	}
+	summary := gin.H{
+		"total":      len(registered),
+		"offline":    offline,
+		"tls_errors": tlsErrors,
+	}
+	return status, summary, routers
+}
+
+// readinessTimeout bounds each readiness check
+const readinessTimeout = 5 * time.Second
+
+// Ready is the readiness probe. The service is ready once the database answers, at
+// least one router was polled successfully and, when WAN detection is enabled, the WAN
+// interface is known. Responds 503 until then.
+func (h *Handlers) Ready(c *gin.Context) {
+	ready := true
+	checks := gin.H{}
+	fail := func(name string, err string) {
+		ready = false
+		checks[name] = gin.H{"ok": false, "error": err}
+	}
+
+	// Database
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+	if sqlDB, err := h.db.DB(); err != nil {
+		fail("database", err.Error())
+	} else if err := sqlDB.PingContext(ctx); err != nil {
+		fail("database", err.Error())
+	} else {
+		checks["database"] = gin.H{"ok": true}
+	}
+
+	// Routers
+	online := 0
+	for _, state := range h.service.GetRouterStates() {
+		if state.Online {
+			online++
+		}
+	}
+	if online == 0 {
+		fail("routers", "No router reachable")
+	} else {
+		checks["routers"] = gin.H{"ok": true, "online": online}
+	}
+
+	// WAN interface, detected now when nothing is cached yet
+	if h.wanService != nil && h.wanService.Enabled() {
+		wan := h.wanService.GetCachedWANInterface()
+		if wan == nil {
+			wan, _ = h.wanService.DetectWANInterface(ctx)
+		}
+		if wan == nil || wan.Name == "none" {
+			fail("wan", "WAN interface not detected")
+		} else {
+			checks["wan"] = gin.H{"ok": true, "interface": wan.Name}
+		}
+	}
+
+	status, code := "ready", http.StatusOK
+	if !ready {
+		status, code = "not_ready", http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{
+		"status": status,
+		"checks": checks,
+	})
+}
+
 // GetSystemInfo returns system information
 func (h *Handlers) GetSystemInfo(c *gin.Context) {
-	info, err := h.service.GetSystemInfo()
+	routerID, ok := h.routerIDOrAbort(c)
+	if !ok {
+		return
+	}
+
+	info, err := h.service.GetSystemInfo(routerID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to retrieve system info",
@@ -90,7 +281,11 @@ func (h *Handlers) GetSystemInfo(c *gin.Context) {
 	c.JSON(http.StatusOK, info)
 }
 
-// GetTrafficHistory returns traffic history for an interface
+// GetTrafficHistory returns traffic history for an interface. Without from/to/step the
+// latest raw snapshots are returned; with a time range the resolution (raw, 1m, 1h, 1d)
+// is picked from the range unless ?resolution= is given. With ?step= the range is split
+// into evenly spaced buckets aggregated with ?agg= (avg, max, sum, p95), and empty
+// buckets the router was unreachable for are returned as gaps.
 func (h *Handlers) GetTrafficHistory(c *gin.Context) {
 	interfaceName := c.Param("interface")
 	if interfaceName == "" {
@@ -100,6 +295,19 @@ func (h *Handlers) GetTrafficHistory(c *gin.Context) {
 		return
 	}
 
+	routerID, ok := h.routerIDOrAbort(c)
+	if !ok {
+		return
+	}
+	if !interfaceInScopeOrAbort(c, routerID, interfaceName) {
+		return
+	}
+
+	if c.Query("from") != "" || c.Query("to") != "" || c.Query("step") != "" {
+		h.getTrafficRange(c, routerID, interfaceName)
+		return
+	}
+
 	// Parse query parameters
 	limitStr := c.DefaultQuery("limit", "100")
 	limit, err := strconv.Atoi(limitStr)
@@ -109,7 +317,7 @@ func (h *Handlers) GetTrafficHistory(c *gin.Context) {
 
 	// Get traffic history from database
 	var snapshots []models.TrafficSnapshot
-	if err := h.db.Where("interface_name = ?", interfaceName).
+	if err := h.db.Where("router_id = ? AND interface_name = ?", routerID, interfaceName).
 		Order("timestamp DESC").
 		Limit(limit).
 		Find(&snapshots).Error; err != nil {
@@ -119,20 +327,35 @@ func (h *Handlers) GetTrafficHistory(c *gin.Context) {
 		return
 	}
 
+	var periods []service.MaintenancePeriod
+	if len(snapshots) > 0 {
+		// Snapshots are newest first
+		from, to := snapshots[len(snapshots)-1].Timestamp, snapshots[0].Timestamp.Add(time.Second)
+		if periods, err = h.maintenance.Periods(routerID, interfaceName, from, to); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to retrieve maintenance periods",
+			})
+			return
+		}
+	}
+
 	// Convert to response format
 	history := make([]gin.H, len(snapshots))
 	for i, snapshot := range snapshots {
 		history[i] = gin.H{
-			"timestamp": snapshot.Timestamp.Format(time.RFC3339),
-			"rx_rate":   snapshot.RxRate,
-			"tx_rate":   snapshot.TxRate,
+			"timestamp":   snapshot.Timestamp.Format(time.RFC3339),
+			"rx_rate":     snapshot.RxRate,
+			"tx_rate":     snapshot.TxRate,
+			"maintenance": inMaintenance(periods, snapshot.Timestamp),
 		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"interface": interfaceName,
-		"history":   history,
-		"limit":     limit,
+		"router_id":   routerID,
+		"interface":   interfaceName,
+		"history":     history,
+		"limit":       limit,
+		"maintenance": periods,
 	})
 }
 
@@ -153,7 +376,12 @@ func (h *Handlers) PopulateTestData(c *gin.Context) {
 // WebSocketHandler handles WebSocket connections for real-time updates
 func (h *Handlers) WebSocketHandler(c *gin.Context) {
 	if h.websocketManager != nil {
-		h.websocketManager.HandleConnection(c.Writer, c.Request)
+		// A nil *service.Scope must not become a non-nil interface value
+		var scope websocket.Scope
+		if requestScope(c) != nil {
+			scope = requestScope(c)
+		}
+		h.websocketManager.HandleConnection(c.Writer, c.Request, scope)
 	} else {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
 			"error": "WebSocket service not available",
@@ -299,8 +527,10 @@ func (h *Handlers) SubmitMonitoringJob(c *gin.Context) {
 	})
 }
 
-// GetMonthlyUsage returns monthly usage data for a specific interface
-// GET /api/v1/usage/:interface?month=12&year=2025
+// GetMonthlyUsage returns usage per billing cycle for a specific interface
+// GET /api/v1/usage/:interface?cycles=3&router_id=1
+// With month and/or year the calendar month report is returned instead:
+// GET /api/v1/usage/:interface?month=12&year=2025&router_id=1
 func (h *Handlers) GetMonthlyUsage(c *gin.Context) {
 	ifaceName := c.Param("interface")
 	if ifaceName == "" {
@@ -310,6 +540,19 @@ func (h *Handlers) GetMonthlyUsage(c *gin.Context) {
 		return
 	}
 
+	routerID, ok := h.routerIDOrAbort(c)
+	if !ok {
+		return
+	}
+	if !interfaceInScopeOrAbort(c, routerID, ifaceName) {
+		return
+	}
+
+	if c.Query("month") == "" && c.Query("year") == "" {
+		h.getCycleUsage(c, routerID, ifaceName)
+		return
+	}
+
 	// Parse query parameters with defaults to current month/year
 	now := time.Now()
 	monthStr := c.DefaultQuery("month", strconv.Itoa(int(now.Month())))
@@ -333,8 +576,8 @@ func (h *Handlers) GetMonthlyUsage(c *gin.Context) {
 
 	// Get monthly usage data from database
 	var stats []models.MonthlyQuota
-	if err := h.db.Where("interface_name = ? AND month = ? AND year = ?",
-		ifaceName, month, year).Order("day ASC").Find(&stats).Error; err != nil {
+	if err := h.db.Where("router_id = ? AND interface_name = ? AND month = ? AND year = ?",
+		routerID, ifaceName, month, year).Order("day ASC").Find(&stats).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to retrieve monthly usage data",
 		})
@@ -350,6 +593,7 @@ func (h *Handlers) GetMonthlyUsage(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
+		"router_id":      routerID,
 		"interface_name": ifaceName,
 		"month":          month,
 		"year":           year,
@@ -362,3 +606,146 @@ func (h *Handlers) GetMonthlyUsage(c *gin.Context) {
 		"days_with_data": len(stats),
 	})
 }
+
+// getTrafficRange serves GetTrafficHistory for a from/to time range
+func (h *Handlers) getTrafficRange(c *gin.Context, routerID uint, interfaceName string) {
+	from, to, ok := parseTimeRange(c, time.Hour)
+	if !ok {
+		return
+	}
+
+	if c.Query("step") != "" {
+		h.getTrafficBuckets(c, routerID, interfaceName, from, to)
+		return
+	}
+
+	resolution := c.Query("resolution")
+	switch resolution {
+	case "", service.ResolutionRaw, service.Resolution1m, service.Resolution1h, service.Resolution1d:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid resolution, expected raw, 1m, 1h or 1d",
+		})
+		return
+	}
+
+	points, resolution, err := h.rollups.History(routerID, interfaceName, from, to, resolution)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve traffic history",
+		})
+		return
+	}
+	periods, err := h.maintenance.Periods(routerID, interfaceName, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve maintenance periods",
+		})
+		return
+	}
+
+	history := make([]gin.H, len(points))
+	for i, point := range points {
+		history[i] = gin.H{
+			"timestamp":   point.Timestamp.Format(time.RFC3339),
+			"samples":     point.Samples,
+			"rx_rate":     point.RxRate,
+			"tx_rate":     point.TxRate,
+			"rx_rate_min": point.RxRateMin,
+			"rx_rate_max": point.RxRateMax,
+			"rx_rate_p95": point.RxRateP95,
+			"tx_rate_min": point.TxRateMin,
+			"tx_rate_max": point.TxRateMax,
+			"tx_rate_p95": point.TxRateP95,
+			"rx_bytes":    point.RxBytes,
+			"tx_bytes":    point.TxBytes,
+			"maintenance": inMaintenance(periods, point.Timestamp),
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"router_id":   routerID,
+		"interface":   interfaceName,
+		"from":        from.Format(time.RFC3339),
+		"to":          to.Format(time.RFC3339),
+		"resolution":  resolution,
+		"history":     history,
+		"maintenance": periods,
+	})
+}
+
+// getTrafficBuckets serves GetTrafficHistory for a time range split into steps
+func (h *Handlers) getTrafficBuckets(c *gin.Context, routerID uint, interfaceName string, from, to time.Time) {
+	step, err := time.ParseDuration(c.Query("step"))
+	if err != nil || step < time.Second {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid step, expected a duration of at least 1s",
+		})
+		return
+	}
+
+	agg := c.DefaultQuery("agg", service.AggAvg)
+	if !service.IsValidAgg(agg) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid agg, expected avg, max, sum or p95",
+		})
+		return
+	}
+
+	buckets, step, resolution, err := h.rollups.Buckets(routerID, interfaceName, from, to, step, agg)
+	if errors.Is(err, service.ErrTooManyBuckets) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve traffic history",
+		})
+		return
+	}
+	periods, err := h.maintenance.Periods(routerID, interfaceName, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve maintenance periods",
+		})
+		return
+	}
+
+	unit := "Mbps"
+	if agg == service.AggSum {
+		unit = "bytes"
+	}
+
+	history := make([]gin.H, len(buckets))
+	for i, bucket := range buckets {
+		point := gin.H{
+			"timestamp":   bucket.Timestamp.Format(time.RFC3339),
+			"samples":     bucket.Samples,
+			"rx":          nil,
+			"tx":          nil,
+			"rx_bytes":    bucket.RxBytes,
+			"tx_bytes":    bucket.TxBytes,
+			"gap":         bucket.Gap,
+			"maintenance": overlapsMaintenance(periods, bucket.Timestamp, bucket.Timestamp.Add(step)),
+		}
+		if !bucket.Gap {
+			point["rx"] = bucket.Rx
+			point["tx"] = bucket.Tx
+		}
+		history[i] = point
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"router_id":   routerID,
+		"interface":   interfaceName,
+		"from":        from.Format(time.RFC3339),
+		"to":          to.Format(time.RFC3339),
+		"step":        step.String(),
+		"agg":         agg,
+		"unit":        unit,
+		"resolution":  resolution,
+		"history":     history,
+		"maintenance": periods,
+	})
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+
+	"monik-enterprise/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// logLevelsRequest is the payload accepted when changing log levels, omitted fields
+// are left unchanged
+type logLevelsRequest struct {
+	Level      *string           `json:"level"`      // Global level
+	Components map[string]string `json:"components"` // component -> level, empty follows the global level again
+}
+
+// GetLogLevels returns the global log level and the components with their own level
+// GET /api/v1/log-levels
+func (h *Handlers) GetLogLevels(c *gin.Context) {
+	c.JSON(http.StatusOK, h.logLevels())
+}
+
+// SetLogLevels changes the global or per-component log levels without a restart
+// PUT /api/v1/log-levels
+func (h *Handlers) SetLogLevels(c *gin.Context) {
+	var req logLevelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Validate everything before applying anything
+	var level service.LogLevel
+	if req.Level != nil {
+		parsed, err := service.ParseLogLevel(*req.Level)
+		if err != nil || *req.Level == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid level parameter (debug, info, warn, error or fatal)",
+			})
+			return
+		}
+		level = parsed
+	}
+	components := make(map[string]service.LogLevel, len(req.Components))
+	for component, name := range req.Components {
+		if component == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Component name is required",
+			})
+			return
+		}
+		if name == "" {
+			continue
+		}
+		parsed, err := service.ParseLogLevel(name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid level for component " + component + " (debug, info, warn, error or fatal)",
+			})
+			return
+		}
+		components[component] = parsed
+	}
+
+	if req.Level != nil {
+		h.logger.SetLogLevel(level)
+	}
+	for component, name := range req.Components {
+		if name == "" {
+			h.logger.ResetComponentLogLevel(component)
+		} else {
+			h.logger.SetComponentLogLevel(component, components[component])
+		}
+	}
+
+	c.JSON(http.StatusOK, h.logLevels())
+}
+
+// logLevels describes the current log levels
+func (h *Handlers) logLevels() gin.H {
+	return gin.H{
+		"level":      h.logger.LogLevel(),
+		"components": h.logger.ComponentLogLevels(),
+		"known_components": []string{
+			service.ComponentApp,
+			service.ComponentMonitoring,
+			service.ComponentCollector,
+			service.ComponentWAN,
+			service.ComponentWorkerPool,
+			service.ComponentWebSocket,
+			service.ComponentAlert,
+			service.ComponentRollup,
+			service.ComponentAuth,
+			service.ComponentQuota,
+			service.ComponentNotification,
+			service.ComponentOutage,
+			service.ComponentBilling,
+			service.ComponentMaintenance,
+			service.ComponentAudit,
+		},
+	}
+}
@@ -0,0 +1,393 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"monik-enterprise/internal/models"
+	"monik-enterprise/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maintenanceWindowRequest is the payload accepted when creating or replacing a
+// maintenance window
+type maintenanceWindowRequest struct {
+	Name          string     `json:"name" binding:"required"`
+	RouterID      uint       `json:"router_id"`      // 0 matches every router
+	InterfaceName string     `json:"interface_name"` // glob pattern, empty covers the whole router
+	StartsAt      time.Time  `json:"starts_at" binding:"required"`
+	Duration      string     `json:"duration" binding:"required"` // Go duration, e.g. "2h"
+	Recurrence    string     `json:"recurrence"`                  // none (default), daily, weekly or monthly
+	RecurUntil    *time.Time `json:"recur_until"`
+	Timezone      string     `json:"timezone"` // IANA zone, empty uses the server timezone
+	Comment       string     `json:"comment"`
+	Enabled       *bool      `json:"enabled"` // defaults to true
+}
+
+// silenceRequest is the payload accepted when creating a silence. The expiry is given
+// either as ends_at or as a duration from starts_at.
+type silenceRequest struct {
+	RouterID      uint       `json:"router_id"`      // 0 matches every router
+	InterfaceName string     `json:"interface_name"` // glob pattern, empty covers the whole router
+	RuleID        uint       `json:"rule_id"`        // 0 matches every alert rule
+	StartsAt      *time.Time `json:"starts_at"`      // defaults to now
+	EndsAt        *time.Time `json:"ends_at"`
+	Duration      string     `json:"duration"` // Go duration, e.g. "30m"
+	CreatedBy     string     `json:"created_by"`
+	Comment       string     `json:"comment"`
+}
+
+func (req maintenanceWindowRequest) toWindow() (models.MaintenanceWindow, error) {
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil {
+		return models.MaintenanceWindow{}, errors.New("invalid duration")
+	}
+	return models.MaintenanceWindow{
+		Name:          req.Name,
+		RouterID:      req.RouterID,
+		InterfaceName: req.InterfaceName,
+		StartsAt:      req.StartsAt,
+		Duration:      duration,
+		Recurrence:    req.Recurrence,
+		RecurUntil:    req.RecurUntil,
+		Timezone:      req.Timezone,
+		Comment:       req.Comment,
+		Enabled:       req.Enabled == nil || *req.Enabled,
+	}, nil
+}
+
+func (req silenceRequest) toSilence(now time.Time) (models.Silence, error) {
+	silence := models.Silence{
+		RouterID:      req.RouterID,
+		InterfaceName: req.InterfaceName,
+		RuleID:        req.RuleID,
+		StartsAt:      now,
+		CreatedBy:     req.CreatedBy,
+		Comment:       req.Comment,
+	}
+	if req.StartsAt != nil {
+		silence.StartsAt = *req.StartsAt
+	}
+	switch {
+	case req.EndsAt != nil && req.Duration != "":
+		return silence, errors.New("use either ends_at or duration")
+	case req.EndsAt != nil:
+		silence.EndsAt = *req.EndsAt
+	case req.Duration != "":
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 {
+			return silence, errors.New("invalid duration")
+		}
+		silence.EndsAt = silence.StartsAt.Add(duration)
+	}
+	return silence, nil
+}
+
+// GetMaintenanceWindows returns all maintenance windows
+func (h *Handlers) GetMaintenanceWindows(c *gin.Context) {
+	windows, err := h.maintenance.ListWindows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve maintenance windows",
+		})
+		return
+	}
+
+	windows = filterScoped(c, windows, func(window models.MaintenanceWindow) (uint, string) {
+		return window.RouterID, ""
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"windows": windows,
+	})
+}
+
+// GetMaintenanceWindow returns a specific maintenance window
+func (h *Handlers) GetMaintenanceWindow(c *gin.Context) {
+	id, ok := parseIDParam(c, "Invalid maintenance window id")
+	if !ok {
+		return
+	}
+
+	window, err := h.maintenance.GetWindow(id)
+	if err == nil && window.RouterID != 0 && !requestScope(c).AllowsRouter(window.RouterID) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		writeRecordError(c, err, "Maintenance window not found", "Failed to retrieve maintenance window")
+		return
+	}
+
+	c.JSON(http.StatusOK, window)
+}
+
+// CreateMaintenanceWindow adds a maintenance window
+func (h *Handlers) CreateMaintenanceWindow(c *gin.Context) {
+	var req maintenanceWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if !writableOrAbort(c, req.RouterID, req.InterfaceName) {
+		return
+	}
+
+	window, err := req.toWindow()
+	if err == nil {
+		err = h.maintenance.CreateWindow(&window)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, window)
+}
+
+// UpdateMaintenanceWindow replaces a maintenance window
+func (h *Handlers) UpdateMaintenanceWindow(c *gin.Context) {
+	id, ok := parseIDParam(c, "Invalid maintenance window id")
+	if !ok {
+		return
+	}
+
+	var req maintenanceWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	window, err := req.toWindow()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if !h.maintenanceWindowWritableOrAbort(c, id) || !writableOrAbort(c, window.RouterID, window.InterfaceName) {
+		return
+	}
+
+	updated, err := h.maintenance.UpdateWindow(id, window)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Maintenance window not found",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteMaintenanceWindow removes a maintenance window
+func (h *Handlers) DeleteMaintenanceWindow(c *gin.Context) {
+	id, ok := parseIDParam(c, "Invalid maintenance window id")
+	if !ok {
+		return
+	}
+
+	if !h.maintenanceWindowWritableOrAbort(c, id) {
+		return
+	}
+
+	if err := h.maintenance.DeleteWindow(id); err != nil {
+		writeRecordError(c, err, "Maintenance window not found", "Failed to delete maintenance window")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Maintenance window deleted successfully",
+	})
+}
+
+// GetSilences returns silences, newest first
+// GET /api/v1/silences?active=true
+func (h *Handlers) GetSilences(c *gin.Context) {
+	silences, err := h.maintenance.ListSilences(c.Query("active") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve silences",
+		})
+		return
+	}
+
+	silences = filterScoped(c, silences, func(silence models.Silence) (uint, string) {
+		return silence.RouterID, ""
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"silences": silences,
+	})
+}
+
+// CreateSilence adds a silence
+func (h *Handlers) CreateSilence(c *gin.Context) {
+	var req silenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if !writableOrAbort(c, req.RouterID, req.InterfaceName) {
+		return
+	}
+
+	silence, err := req.toSilence(time.Now())
+	if err == nil {
+		err = h.maintenance.CreateSilence(&silence)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, silence)
+}
+
+// ExpireSilence ends a silence now
+// DELETE /api/v1/silences/:id
+func (h *Handlers) ExpireSilence(c *gin.Context) {
+	id, ok := parseIDParam(c, "Invalid silence id")
+	if !ok {
+		return
+	}
+
+	var existing models.Silence
+	if err := h.db.First(&existing, id).Error; err != nil {
+		writeRecordError(c, err, "Silence not found", "Failed to expire silence")
+		return
+	}
+	if !writableOrAbort(c, existing.RouterID, existing.InterfaceName) {
+		return
+	}
+
+	silence, err := h.maintenance.ExpireSilence(id)
+	if err != nil {
+		writeRecordError(c, err, "Silence not found", "Failed to expire silence")
+		return
+	}
+
+	c.JSON(http.StatusOK, silence)
+}
+
+// GetMaintenancePeriods returns the maintenance and silence periods of a router or
+// interface within a time range, with the total time SLA reports should exclude
+// GET /api/v1/maintenance-periods?router_id=1&interface=ether1&from=...&to=...
+func (h *Handlers) GetMaintenancePeriods(c *gin.Context) {
+	routerID, ok := h.routerIDOrAbort(c)
+	if !ok {
+		return
+	}
+	from, to, ok := parseTimeRange(c, 30*24*time.Hour)
+	if !ok {
+		return
+	}
+	interfaceName := c.Query("interface")
+	if !interfaceInScopeOrAbort(c, routerID, interfaceName) {
+		return
+	}
+
+	periods, err := h.maintenance.Periods(routerID, interfaceName, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve maintenance periods",
+		})
+		return
+	}
+
+	excluded := service.ExcludedDuration(periods)
+	c.JSON(http.StatusOK, gin.H{
+		"router_id":        routerID,
+		"interface":        interfaceName,
+		"from":             from.Format(time.RFC3339),
+		"to":               to.Format(time.RFC3339),
+		"periods":          periods,
+		"excluded_seconds": int64(excluded / time.Second),
+		"range_seconds":    int64(to.Sub(from) / time.Second),
+	})
+}
+
+// maintenanceWindowWritableOrAbort checks that the caller may change an existing
+// maintenance window
+func (h *Handlers) maintenanceWindowWritableOrAbort(c *gin.Context, id uint) bool {
+	window, err := h.maintenance.GetWindow(id)
+	if err != nil {
+		writeRecordError(c, err, "Maintenance window not found", "Failed to retrieve maintenance window")
+		return false
+	}
+	return writableOrAbort(c, window.RouterID, window.InterfaceName)
+}
+
+// parseTimeRange reads the RFC3339 ?from= and ?to= parameters. to defaults to now and
+// from to span before to. A 400 response is written when they are invalid.
+func parseTimeRange(c *gin.Context, span time.Duration) (time.Time, time.Time, bool) {
+	to := time.Now()
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid to parameter, expected RFC3339",
+			})
+			return time.Time{}, time.Time{}, false
+		}
+		to = parsed
+	}
+	from := to.Add(-span)
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid from parameter, expected RFC3339",
+			})
+			return time.Time{}, time.Time{}, false
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "from must be before to",
+		})
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// inMaintenance reports whether t falls inside one of periods
+func inMaintenance(periods []service.MaintenancePeriod, t time.Time) bool {
+	for _, period := range periods {
+		if period.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlapsMaintenance reports whether [start, end) overlaps one of periods
+func overlapsMaintenance(periods []service.MaintenancePeriod, start, end time.Time) bool {
+	for _, period := range periods {
+		if period.Start.Before(end) && period.End.After(start) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+
+	"monik-enterprise/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics exposes interface traffic and internal metrics for Prometheus. The output
+// covers every router, so callers limited to some routers or interfaces are refused.
+// GET /metrics
+func (h *Handlers) Metrics(c *gin.Context) {
+	if h.prometheus == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Metrics exporter not available",
+		})
+		return
+	}
+	if requestScope(c) != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Metrics are only available to users without a router or interface scope",
+		})
+		return
+	}
+
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", service.PrometheusContentType)
+	if err := h.prometheus.WriteMetrics(c.Writer); err != nil {
+		// Headers are gone once the body started, the scrape fails on the truncated output
+		h.logger.Error(service.ComponentApp, "metrics", "Failed to write metrics", err, nil)
+	}
+}
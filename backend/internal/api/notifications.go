@@ -0,0 +1,375 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"monik-enterprise/internal/models"
+	"monik-enterprise/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// notificationChannelRequest is the payload accepted when creating or replacing a
+// notification channel. Omitted secrets keep their stored value on update.
+type notificationChannelRequest struct {
+	Name    string `json:"name" binding:"required"`
+	Type    string `json:"type" binding:"required"` // smtp, webhook or telegram
+	Enabled *bool  `json:"enabled"`                 // defaults to true
+
+	URL    string  `json:"url"`    // webhook target or Telegram Bot API base URL
+	Secret *string `json:"secret"` // webhook signing key
+
+	TelegramToken  *string `json:"telegram_token"`
+	TelegramChatID string  `json:"telegram_chat_id"`
+
+	SMTPHost     string  `json:"smtp_host"`
+	SMTPPort     int     `json:"smtp_port"`
+	SMTPUsername string  `json:"smtp_username"`
+	SMTPPassword *string `json:"smtp_password"`
+	SMTPFrom     string  `json:"smtp_from"`
+	SMTPTo       string  `json:"smtp_to"`  // comma separated recipients
+	SMTPTLS      string  `json:"smtp_tls"` // starttls (default), tls or none
+
+	SubjectTemplate string `json:"subject_template"`
+	BodyTemplate    string `json:"body_template"`
+}
+
+// notificationRouteRequest is the payload accepted when creating or replacing a
+// notification route
+type notificationRouteRequest struct {
+	Name        string `json:"name" binding:"required"`
+	ChannelID   uint   `json:"channel_id" binding:"required"`
+	Events      string `json:"events"`       // comma separated glob patterns, empty matches every event
+	MinSeverity string `json:"min_severity"` // info (default), warning or critical
+	RouterID    uint   `json:"router_id"`    // 0 matches every router
+	Enabled     *bool  `json:"enabled"`      // defaults to true
+}
+
+// toChannel builds a channel from the request, keeping the secrets of existing when
+// the request omits them
+func (req notificationChannelRequest) toChannel(existing *models.NotificationChannel) models.NotificationChannel {
+	channel := models.NotificationChannel{
+		Name:            req.Name,
+		Type:            req.Type,
+		Enabled:         req.Enabled == nil || *req.Enabled,
+		URL:             req.URL,
+		TelegramChatID:  req.TelegramChatID,
+		SMTPHost:        req.SMTPHost,
+		SMTPPort:        req.SMTPPort,
+		SMTPUsername:    req.SMTPUsername,
+		SMTPFrom:        req.SMTPFrom,
+		SMTPTo:          req.SMTPTo,
+		SMTPTLS:         req.SMTPTLS,
+		SubjectTemplate: req.SubjectTemplate,
+		BodyTemplate:    req.BodyTemplate,
+	}
+	if existing != nil {
+		channel.Secret = existing.Secret
+		channel.TelegramToken = existing.TelegramToken
+		channel.SMTPPassword = existing.SMTPPassword
+	}
+	if req.Secret != nil {
+		channel.Secret = *req.Secret
+	}
+	if req.TelegramToken != nil {
+		channel.TelegramToken = *req.TelegramToken
+	}
+	if req.SMTPPassword != nil {
+		channel.SMTPPassword = *req.SMTPPassword
+	}
+	return channel
+}
+
+func (req notificationRouteRequest) toRoute() models.NotificationRoute {
+	return models.NotificationRoute{
+		Name:        req.Name,
+		ChannelID:   req.ChannelID,
+		Events:      req.Events,
+		MinSeverity: req.MinSeverity,
+		RouterID:    req.RouterID,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+	}
+}
+
+// GetNotificationChannels returns all notification channels
+func (h *Handlers) GetNotificationChannels(c *gin.Context) {
+	channels, err := h.notifications.ListChannels()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve notification channels",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"channels": channels,
+	})
+}
+
+// GetNotificationChannel returns a specific notification channel
+func (h *Handlers) GetNotificationChannel(c *gin.Context) {
+	id, ok := parseIDParam(c, "Invalid channel id")
+	if !ok {
+		return
+	}
+
+	channel, err := h.notifications.GetChannel(id)
+	if err != nil {
+		writeRecordError(c, err, "Notification channel not found", "Failed to retrieve notification channel")
+		return
+	}
+
+	c.JSON(http.StatusOK, channel)
+}
+
+// CreateNotificationChannel adds a notification channel
+func (h *Handlers) CreateNotificationChannel(c *gin.Context) {
+	var req notificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	channel := req.toChannel(nil)
+	if err := h.notifications.CreateChannel(&channel); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	setAuditChange(c, channel.ID, nil, &channel)
+
+	c.JSON(http.StatusCreated, channel)
+}
+
+// UpdateNotificationChannel replaces a notification channel
+func (h *Handlers) UpdateNotificationChannel(c *gin.Context) {
+	id, ok := parseIDParam(c, "Invalid channel id")
+	if !ok {
+		return
+	}
+
+	var req notificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	existing, err := h.notifications.GetChannel(id)
+	if err != nil {
+		writeRecordError(c, err, "Notification channel not found", "Failed to update notification channel")
+		return
+	}
+
+	channel, err := h.notifications.UpdateChannel(id, req.toChannel(existing))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Notification channel not found",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	setAuditChange(c, id, existing, channel)
+
+	c.JSON(http.StatusOK, channel)
+}
+
+// DeleteNotificationChannel removes a notification channel and its routes
+func (h *Handlers) DeleteNotificationChannel(c *gin.Context) {
+	id, ok := parseIDParam(c, "Invalid channel id")
+	if !ok {
+		return
+	}
+
+	before, err := h.notifications.GetChannel(id)
+	if err != nil {
+		writeRecordError(c, err, "Notification channel not found", "Failed to delete notification channel")
+		return
+	}
+	if err := h.notifications.DeleteChannel(id); err != nil {
+		writeRecordError(c, err, "Notification channel not found", "Failed to delete notification channel")
+		return
+	}
+	setAuditChange(c, id, before, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notification channel deleted successfully",
+	})
+}
+
+// TestNotificationChannel sends a test notification through a channel
+// POST /api/v1/notification-channels/:id/test
+func (h *Handlers) TestNotificationChannel(c *gin.Context) {
+	id, ok := parseIDParam(c, "Invalid channel id")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.notifications.TestChannel(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Notification channel not found",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	status := http.StatusOK
+	if delivery.Status != service.DeliveryStatusSent {
+		status = http.StatusBadGateway
+	}
+	c.JSON(status, delivery)
+}
+
+// GetNotificationRoutes returns all notification routes
+func (h *Handlers) GetNotificationRoutes(c *gin.Context) {
+	routes, err := h.notifications.ListRoutes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve notification routes",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"routes": routes,
+	})
+}
+
+// CreateNotificationRoute adds a notification route
+func (h *Handlers) CreateNotificationRoute(c *gin.Context) {
+	var req notificationRouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	route := req.toRoute()
+	if err := h.notifications.CreateRoute(&route); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, route)
+}
+
+// UpdateNotificationRoute replaces a notification route
+func (h *Handlers) UpdateNotificationRoute(c *gin.Context) {
+	id, ok := parseIDParam(c, "Invalid route id")
+	if !ok {
+		return
+	}
+
+	var req notificationRouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	route, err := h.notifications.UpdateRoute(id, req.toRoute())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Notification route not found",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, route)
+}
+
+// DeleteNotificationRoute removes a notification route
+func (h *Handlers) DeleteNotificationRoute(c *gin.Context) {
+	id, ok := parseIDParam(c, "Invalid route id")
+	if !ok {
+		return
+	}
+
+	if err := h.notifications.DeleteRoute(id); err != nil {
+		writeRecordError(c, err, "Notification route not found", "Failed to delete notification route")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notification route deleted successfully",
+	})
+}
+
+// GetNotificationDeliveries returns the delivery log, newest first
+// GET /api/v1/notification-deliveries?channel_id=1&event=quota_threshold&status=failed&limit=100
+func (h *Handlers) GetNotificationDeliveries(c *gin.Context) {
+	filter := service.DeliveryFilter{
+		Event:  c.Query("event"),
+		Status: c.Query("status"),
+		Limit:  100,
+	}
+	switch filter.Status {
+	case "", service.DeliveryStatusSent, service.DeliveryStatusFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid status parameter (sent or failed)",
+		})
+		return
+	}
+	if channelStr := c.Query("channel_id"); channelStr != "" {
+		channelID, err := strconv.ParseUint(channelStr, 10, 64)
+		if err != nil || channelID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid channel_id parameter",
+			})
+			return
+		}
+		filter.ChannelID = uint(channelID)
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid limit parameter (1-1000)",
+			})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	deliveries, err := h.notifications.Deliveries(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve notification deliveries",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"monik-enterprise/internal/models"
+	"monik-enterprise/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// outageResponse adds the duration to an outage, counting an open outage up to now
+func outageResponse(outage models.RouterOutage) gin.H {
+	return gin.H{
+		"id":               outage.ID,
+		"router_id":        outage.RouterID,
+		"started_at":       outage.StartedAt,
+		"ended_at":         outage.EndedAt,
+		"ongoing":          outage.EndedAt == nil,
+		"duration_seconds": int64(service.OutageDuration(outage, time.Time{}, time.Time{}) / time.Second),
+		"failed_polls":     outage.FailedPolls,
+		"error":            outage.Error,
+		"error_kind":       outage.ErrorKind,
+		"maintenance":      outage.Maintenance,
+	}
+}
+
+// GetOutages returns router outages overlapping a time range, newest first, with the
+// total offline time within the range
+// GET /api/v1/outages?router_id=1&from=...&to=...&open=true&limit=100
+func (h *Handlers) GetOutages(c *gin.Context) {
+	routerID, err := h.resolveRouterID(c, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	from, to, ok := parseTimeRange(c, 30*24*time.Hour)
+	if !ok {
+		return
+	}
+
+	filter := service.OutageFilter{
+		RouterID: routerID,
+		From:     from,
+		To:       to,
+		Open:     c.Query("open") == "true",
+		Limit:    100,
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid limit parameter (1-1000)",
+			})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	outages, err := h.outages.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve outages",
+		})
+		return
+	}
+
+	outages = filterScoped(c, outages, func(outage models.RouterOutage) (uint, string) {
+		return outage.RouterID, ""
+	})
+
+	var offline time.Duration
+	result := make([]gin.H, len(outages))
+	for i, outage := range outages {
+		result[i] = outageResponse(outage)
+		offline += service.OutageDuration(outage, from, to)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"router_id":       routerID,
+		"from":            from.Format(time.RFC3339),
+		"to":              to.Format(time.RFC3339),
+		"outages":         result,
+		"count":           len(result),
+		"offline_seconds": int64(offline / time.Second),
+	})
+}
+
+// GetOutage returns a specific outage
+func (h *Handlers) GetOutage(c *gin.Context) {
+	id, ok := parseIDParam(c, "Invalid outage id")
+	if !ok {
+		return
+	}
+
+	outage, err := h.outages.Get(id)
+	if err == nil && !requestScope(c).AllowsRouter(outage.RouterID) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		writeRecordError(c, err, "Outage not found", "Failed to retrieve outage")
+		return
+	}
+
+	c.JSON(http.StatusOK, outageResponse(*outage))
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"monik-enterprise/internal/models"
+	"monik-enterprise/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// quotaRequest is the payload accepted when setting the quota limit of an interface
+type quotaRequest struct {
+	LimitBytes    uint64 `json:"limit_bytes" binding:"required"` // bytes per billing cycle
+	Thresholds    []int  `json:"thresholds"`                     // percentages, empty uses QUOTA_THRESHOLDS
+	Action        string `json:"action"`                         // none (default), queue or disable
+	QueueMaxLimit string `json:"queue_max_limit"`                // max-limit of the queue action, empty uses QUOTA_QUEUE_MAX_LIMIT
+	Enabled       *bool  `json:"enabled"`                        // defaults to true
+}
+
+// GetQuotas returns all quota limits with the usage of their current cycle
+// GET /api/v1/quotas?router_id=1
+func (h *Handlers) GetQuotas(c *gin.Context) {
+	routerID, err := h.resolveRouterID(c, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	quotas, err := h.quotas.ListStatus(routerID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve quotas",
+		})
+		return
+	}
+
+	quotas = filterScoped(c, quotas, func(quota service.QuotaStatus) (uint, string) {
+		return quota.RouterID, quota.InterfaceName
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"quotas": quotas,
+	})
+}
+
+// GetQuota returns the quota limit of an interface with the usage of its current cycle
+// GET /api/v1/quotas/:interface?router_id=1
+func (h *Handlers) GetQuota(c *gin.Context) {
+	ifaceName := c.Param("interface")
+	routerID, ok := h.routerIDOrAbort(c)
+	if !ok {
+		return
+	}
+	if !interfaceInScopeOrAbort(c, routerID, ifaceName) {
+		return
+	}
+
+	status, err := h.quotas.Status(routerID, ifaceName, time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Quota not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve quota",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// SetQuota creates or replaces the quota limit of an interface
+// PUT /api/v1/quotas/:interface?router_id=1
+func (h *Handlers) SetQuota(c *gin.Context) {
+	ifaceName := c.Param("interface")
+	routerID, ok := h.routerIDOrAbort(c)
+	if !ok {
+		return
+	}
+	if !writableOrAbort(c, routerID, ifaceName) {
+		return
+	}
+
+	var req quotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	thresholds := make([]string, len(req.Thresholds))
+	for i, threshold := range req.Thresholds {
+		thresholds[i] = strconv.Itoa(threshold)
+	}
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	before := h.quotas.Limit(routerID, ifaceName)
+	status, err := h.quotas.SetLimit(models.QuotaLimit{
+		RouterID:      routerID,
+		InterfaceName: ifaceName,
+		LimitBytes:    req.LimitBytes,
+		Thresholds:    strings.Join(thresholds, ","),
+		Action:        req.Action,
+		QueueMaxLimit: req.QueueMaxLimit,
+		Enabled:       enabled,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	setAuditChange(c, quotaAuditID(routerID, ifaceName), before, &status.QuotaLimit)
+
+	c.JSON(http.StatusOK, status)
+}
+
+// DeleteQuota removes the quota limit of an interface, lifting any enforcement
+// DELETE /api/v1/quotas/:interface?router_id=1
+func (h *Handlers) DeleteQuota(c *gin.Context) {
+	ifaceName := c.Param("interface")
+	routerID, ok := h.routerIDOrAbort(c)
+	if !ok {
+		return
+	}
+	if !writableOrAbort(c, routerID, ifaceName) {
+		return
+	}
+
+	before := h.quotas.Limit(routerID, ifaceName)
+	if err := h.quotas.DeleteLimit(routerID, ifaceName); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Quota not found",
+			})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	setAuditChange(c, quotaAuditID(routerID, ifaceName), before, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Quota deleted successfully",
+	})
+}
+
+// quotaAuditID identifies the quota limit of an interface in the audit trail
+func quotaAuditID(routerID uint, ifaceName string) string {
+	return fmt.Sprintf("router %d interface %s", routerID, ifaceName)
+}
+
+// GetQuotaEvents returns the quota audit trail, newest first
+// GET /api/v1/quota-events?router_id=1&interface=ether1&limit=100
+func (h *Handlers) GetQuotaEvents(c *gin.Context) {
+	routerID, err := h.resolveRouterID(c, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	limit := 100
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid limit parameter (1-1000)",
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	events, err := h.quotas.Events(routerID, c.Query("interface"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve quota events",
+		})
+		return
+	}
+
+	events = filterScoped(c, events, func(event models.QuotaEvent) (uint, string) {
+		return event.RouterID, event.InterfaceName
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+		"count":  len(events),
+	})
+}
@@ -0,0 +1,412 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"monik-enterprise/internal/models"
+	"monik-enterprise/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// routerRequest is the payload accepted when creating or updating a router
+type routerRequest struct {
+	Name     *string `json:"name"`
+	Host     *string `json:"host"`
+	Port     *int    `json:"port"`
+	Username *string `json:"username"`
+	Password *string `json:"password"`
+	Timeout  *string `json:"timeout"` // Go duration, e.g. "30s"
+	Enabled  *bool   `json:"enabled"`
+	Comment  *string `json:"comment"`
+	Backend  *string `json:"backend"` // api, rest or snmp
+
+	PollInterval *string `json:"poll_interval"` // Go duration, "0s" falls back to POLL_INTERVAL
+
+	TLSEnabled            *bool   `json:"tls_enabled"`
+	TLSCAFile             *string `json:"tls_ca_file"`
+	TLSCertFile           *string `json:"tls_cert_file"`
+	TLSKeyFile            *string `json:"tls_key_file"`
+	TLSServerName         *string `json:"tls_server_name"`
+	TLSInsecureSkipVerify *bool   `json:"tls_insecure_skip_verify"`
+
+	SNMPVersion      *string `json:"snmp_version"`
+	SNMPCommunity    *string `json:"snmp_community"`
+	SNMPUsername     *string `json:"snmp_username"`
+	SNMPAuthProtocol *string `json:"snmp_auth_protocol"`
+	SNMPAuthPassword *string `json:"snmp_auth_password"`
+	SNMPPrivProtocol *string `json:"snmp_priv_protocol"`
+	SNMPPrivPassword *string `json:"snmp_priv_password"`
+}
+
+// resolveRouterID reads the router selector (?router_id= or ?router=<name>) from the request.
+// When no selector is given, fallback is returned. Routers outside the caller's scope are
+// reported as not found; a fallback outside it is replaced by the first router in scope.
+func (h *Handlers) resolveRouterID(c *gin.Context, fallback uint) (uint, error) {
+	scope := requestScope(c)
+	if idStr := c.Query("router_id"); idStr != "" {
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil || id == 0 {
+			return 0, errors.New("invalid router_id parameter")
+		}
+		if _, err := h.service.Registry().Get(uint(id)); err != nil || !scope.AllowsRouter(uint(id)) {
+			return 0, errors.New("router not found")
+		}
+		return uint(id), nil
+	}
+	if name := c.Query("router"); name != "" {
+		router, err := h.service.Registry().GetByName(name)
+		if err != nil || !scope.AllowsRouter(router.ID) {
+			return 0, errors.New("router not found")
+		}
+		return router.ID, nil
+	}
+	if fallback != 0 && !scope.AllowsRouter(fallback) {
+		ids := scope.RouterIDs()
+		if len(ids) == 0 {
+			return 0, errors.New("no router in scope")
+		}
+		return ids[0], nil
+	}
+	return fallback, nil
+}
+
+// routerIDOrAbort resolves the router selector, defaulting to the default router, and
+// writes a 400 response when the selector is invalid
+func (h *Handlers) routerIDOrAbort(c *gin.Context) (uint, bool) {
+	routerID, err := h.resolveRouterID(c, h.service.Registry().DefaultRouterID())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return 0, false
+	}
+	return routerID, true
+}
+
+// GetRouters returns all registered routers with their poll state
+func (h *Handlers) GetRouters(c *gin.Context) {
+	routers, err := h.service.Registry().List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve routers",
+		})
+		return
+	}
+
+	states := h.service.GetRouterStates()
+	scope := requestScope(c)
+	routers = filterScoped(c, routers, func(router models.Router) (uint, string) {
+		return router.ID, ""
+	})
+	for id := range states {
+		if !scope.AllowsRouter(id) {
+			delete(states, id)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"routers":           routers,
+		"default_router_id": h.service.Registry().DefaultRouterID(),
+		"poll_states":       states,
+	})
+}
+
+// GetRouter returns a specific router
+func (h *Handlers) GetRouter(c *gin.Context) {
+	id, ok := parseRouterParam(c)
+	if !ok {
+		return
+	}
+
+	router, err := h.service.Registry().Get(id)
+	if err == nil && !requestScope(c).AllowsRouter(id) {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		writeRouterError(c, err, "Failed to retrieve router")
+		return
+	}
+
+	c.JSON(http.StatusOK, router)
+}
+
+// CreateRouter registers a new router for monitoring
+func (h *Handlers) CreateRouter(c *gin.Context) {
+	var req routerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	router := models.Router{Enabled: true}
+	updates, err := req.toUpdates()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	applyRouterUpdates(&router, updates)
+
+	if err := h.service.Registry().Create(&router); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	setAuditChange(c, router.ID, nil, &router)
+
+	c.JSON(http.StatusCreated, router)
+}
+
+// UpdateRouter changes the settings of a router
+func (h *Handlers) UpdateRouter(c *gin.Context) {
+	id, ok := parseRouterParam(c)
+	if !ok {
+		return
+	}
+
+	var req routerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	updates, err := req.toUpdates()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No fields to update",
+		})
+		return
+	}
+
+	before, err := h.service.Registry().Get(id)
+	if err != nil {
+		writeRouterError(c, err, "Failed to update router")
+		return
+	}
+	router, err := h.service.Registry().Update(id, updates)
+	if err != nil {
+		writeRouterError(c, err, "Failed to update router")
+		return
+	}
+	setAuditChange(c, id, before, router)
+
+	c.JSON(http.StatusOK, router)
+}
+
+// DeleteRouter removes a router from the registry
+func (h *Handlers) DeleteRouter(c *gin.Context) {
+	id, ok := parseRouterParam(c)
+	if !ok {
+		return
+	}
+
+	if id == h.service.Registry().DefaultRouterID() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "The default router cannot be deleted",
+		})
+		return
+	}
+
+	before, err := h.service.Registry().Get(id)
+	if err != nil {
+		writeRouterError(c, err, "Failed to delete router")
+		return
+	}
+	if err := h.service.Registry().Delete(id); err != nil {
+		writeRouterError(c, err, "Failed to delete router")
+		return
+	}
+	setAuditChange(c, id, before, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Router deleted successfully",
+	})
+}
+
+// toUpdates converts the set fields of a router request into column updates
+func (req routerRequest) toUpdates() (map[string]interface{}, error) {
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Host != nil {
+		updates["host"] = *req.Host
+	}
+	if req.Port != nil {
+		if *req.Port <= 0 || *req.Port > 65535 {
+			return nil, errors.New("invalid port")
+		}
+		updates["port"] = *req.Port
+	}
+	if req.Username != nil {
+		updates["username"] = *req.Username
+	}
+	if req.Password != nil {
+		updates["password"] = *req.Password
+	}
+	if req.Timeout != nil {
+		timeout, err := time.ParseDuration(*req.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, errors.New("invalid timeout duration")
+		}
+		updates["timeout"] = timeout
+	}
+	if req.Enabled != nil {
+		updates["enabled"] = *req.Enabled
+	}
+	if req.PollInterval != nil {
+		interval, err := time.ParseDuration(*req.PollInterval)
+		if err != nil || interval < 0 || (interval > 0 && interval < time.Second) {
+			return nil, errors.New("invalid poll_interval duration, expected 0 or at least 1s")
+		}
+		updates["poll_interval"] = interval
+	}
+	if req.Comment != nil {
+		updates["comment"] = *req.Comment
+	}
+	if req.Backend != nil {
+		if !service.IsValidCollectorBackend(*req.Backend) {
+			return nil, errors.New("invalid backend, expected api, rest or snmp")
+		}
+		updates["backend"] = *req.Backend
+	}
+	if req.TLSEnabled != nil {
+		updates["tls_enabled"] = *req.TLSEnabled
+	}
+	if req.TLSCAFile != nil {
+		updates["tls_ca_file"] = *req.TLSCAFile
+	}
+	if req.TLSCertFile != nil {
+		updates["tls_cert_file"] = *req.TLSCertFile
+	}
+	if req.TLSKeyFile != nil {
+		updates["tls_key_file"] = *req.TLSKeyFile
+	}
+	if req.TLSServerName != nil {
+		updates["tls_server_name"] = *req.TLSServerName
+	}
+	if req.TLSInsecureSkipVerify != nil {
+		updates["tls_insecure_skip_verify"] = *req.TLSInsecureSkipVerify
+	}
+	if req.SNMPVersion != nil {
+		if *req.SNMPVersion != "v2c" && *req.SNMPVersion != "v3" {
+			return nil, errors.New("invalid snmp_version, expected v2c or v3")
+		}
+		updates["snmp_version"] = *req.SNMPVersion
+	}
+	if req.SNMPCommunity != nil {
+		updates["snmp_community"] = *req.SNMPCommunity
+	}
+	if req.SNMPUsername != nil {
+		updates["snmp_username"] = *req.SNMPUsername
+	}
+	if req.SNMPAuthProtocol != nil {
+		updates["snmp_auth_protocol"] = *req.SNMPAuthProtocol
+	}
+	if req.SNMPAuthPassword != nil {
+		updates["snmp_auth_password"] = *req.SNMPAuthPassword
+	}
+	if req.SNMPPrivProtocol != nil {
+		updates["snmp_priv_protocol"] = *req.SNMPPrivProtocol
+	}
+	if req.SNMPPrivPassword != nil {
+		updates["snmp_priv_password"] = *req.SNMPPrivPassword
+	}
+	return updates, nil
+}
+
+// applyRouterUpdates copies column updates onto a router that has not been saved yet
+func applyRouterUpdates(router *models.Router, updates map[string]interface{}) {
+	for column, value := range updates {
+		switch column {
+		case "name":
+			router.Name = value.(string)
+		case "host":
+			router.Host = value.(string)
+		case "port":
+			router.Port = value.(int)
+		case "username":
+			router.Username = value.(string)
+		case "password":
+			router.Password = value.(string)
+		case "timeout":
+			router.Timeout = value.(time.Duration)
+		case "enabled":
+			router.Enabled = value.(bool)
+		case "poll_interval":
+			router.PollInterval = value.(time.Duration)
+		case "comment":
+			router.Comment = value.(string)
+		case "backend":
+			router.Backend = value.(string)
+		case "tls_enabled":
+			router.TLSEnabled = value.(bool)
+		case "tls_ca_file":
+			router.TLSCAFile = value.(string)
+		case "tls_cert_file":
+			router.TLSCertFile = value.(string)
+		case "tls_key_file":
+			router.TLSKeyFile = value.(string)
+		case "tls_server_name":
+			router.TLSServerName = value.(string)
+		case "tls_insecure_skip_verify":
+			router.TLSInsecureSkipVerify = value.(bool)
+		case "snmp_version":
+			router.SNMPVersion = value.(string)
+		case "snmp_community":
+			router.SNMPCommunity = value.(string)
+		case "snmp_username":
+			router.SNMPUsername = value.(string)
+		case "snmp_auth_protocol":
+			router.SNMPAuthProtocol = value.(string)
+		case "snmp_auth_password":
+			router.SNMPAuthPassword = value.(string)
+		case "snmp_priv_protocol":
+			router.SNMPPrivProtocol = value.(string)
+		case "snmp_priv_password":
+			router.SNMPPrivPassword = value.(string)
+		}
+	}
+}
+
+// parseRouterParam parses the :id path parameter of router routes
+func parseRouterParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid router id",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// writeRouterError maps registry errors to HTTP responses
+func writeRouterError(c *gin.Context, err error, message string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Router not found",
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": message,
+	})
+}
@@ -1,17 +1,28 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // Config holds all configuration for the application
 type Config struct {
 	Server    ServerConfig       `yaml:"server"`
+	Auth      AuthConfig         `yaml:"auth"`
 	Database  DatabaseConfig     `yaml:"database"`
 	Router    RouterConfig       `yaml:"router"`
+	Polling   PollingConfig      `yaml:"polling"`
+	Snapshot  SnapshotConfig     `yaml:"snapshot"`
+	Retention RetentionConfig    `yaml:"retention"`
+	Billing   BillingConfig      `yaml:"billing"`
+	Quota     QuotaConfig        `yaml:"quota"`
+	Alerts    AlertConfig        `yaml:"alerts"`
+	Notify    NotifyConfig       `yaml:"notify"`
 	Logging   LoggingConfig      `yaml:"logging"`
+	Tracing   TracingConfig      `yaml:"tracing"`
 	WAN       WANDetectionConfig `yaml:"wan"`
 	Worker    WorkerPoolConfig   `yaml:"worker"`
 	WebSocket WebSocketConfig    `yaml:"websocket"`
@@ -21,8 +32,9 @@ type Config struct {
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	Host string `yaml:"host"`
-	Port int    `yaml:"port"`
+	Host        string   `yaml:"host"`
+	Port        int      `yaml:"port"`
+	CORSOrigins []string `yaml:"cors_origins"` // Origins allowed to call the API and open the WebSocket, "*" allows any
 }
 
 // Address returns the full server address
@@ -30,25 +42,179 @@ func (s ServerConfig) Address() string {
 	return s.Host + ":" + strconv.Itoa(s.Port)
 }
 
+// AuthConfig holds API authentication settings
+type AuthConfig struct {
+	Enabled           bool          `yaml:"enabled"`
+	JWTSecret         string        `yaml:"jwt_secret"`  // HMAC key for access and refresh tokens, random per run when empty
+	AccessTTL         time.Duration `yaml:"access_ttl"`  // Lifetime of access tokens
+	RefreshTTL        time.Duration `yaml:"refresh_ttl"` // Lifetime of refresh tokens
+	BcryptCost        int           `yaml:"bcrypt_cost"`
+	AdminUsername     string        `yaml:"admin_username"`      // Account created when there are no users yet
+	AdminPassword     string        `yaml:"admin_password"`      // Random when empty, see AdminPasswordFile
+	AdminPasswordFile string        `yaml:"admin_password_file"` // Receives the generated admin password, mode 0600
+}
+
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
-	Path        string `yaml:"path"`
+	Driver      string `yaml:"driver"` // sqlite, postgres
+	Path        string `yaml:"path"`   // SQLite database file
+	DSN         string `yaml:"dsn"`    // PostgreSQL connection string
 	MaxOpenConn int    `yaml:"max_open_conn"`
 	MaxIdleConn int    `yaml:"max_idle_conn"`
+	AutoMigrate bool   `yaml:"auto_migrate"` // apply pending migrations at startup
+
+	// TimescaleDB turns traffic_snapshots into a hypertable (PostgreSQL only)
+	Timescale          bool          `yaml:"timescale"`
+	TimescaleChunkTime time.Duration `yaml:"timescale_chunk_time"`
 }
 
 // RouterConfig holds MikroTik router configuration
 type RouterConfig struct {
-	IP       string        `yaml:"ip"`
-	Port     int           `yaml:"port"`
-	Username string        `yaml:"username"`
-	Password string        `yaml:"password"`
-	Timeout  time.Duration `yaml:"timeout"`
+	IP       string           `yaml:"ip"`
+	Port     int              `yaml:"port"`
+	Username string           `yaml:"username"`
+	Password string           `yaml:"password"`
+	Timeout  time.Duration    `yaml:"timeout"`
+	Backend  string           `yaml:"backend"` // api (binary API), rest (RouterOS v7 REST) or snmp
+	TLS      RouterTLSConfig  `yaml:"tls"`
+	SNMP     RouterSNMPConfig `yaml:"snmp"`
+}
+
+// RouterSNMPConfig holds SNMP polling settings used by the snmp backend
+type RouterSNMPConfig struct {
+	Version      string `yaml:"version"` // v2c or v3
+	Community    string `yaml:"community"`
+	Username     string `yaml:"username"`
+	AuthProtocol string `yaml:"auth_protocol"` // MD5, SHA, SHA256, SHA512 (v3)
+	AuthPassword string `yaml:"auth_password"`
+	PrivProtocol string `yaml:"priv_protocol"` // DES, AES, AES256 (v3)
+	PrivPassword string `yaml:"priv_password"`
+}
+
+// RouterTLSConfig holds RouterOS API-SSL settings
+type RouterTLSConfig struct {
+	Enabled            bool   `yaml:"enabled"`
+	CAFile             string `yaml:"ca_file"`   // PEM bundle used to verify the router certificate
+	CertFile           string `yaml:"cert_file"` // Client certificate (optional)
+	KeyFile            string `yaml:"key_file"`  // Client private key (optional)
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// Default RouterOS API ports
+const (
+	DefaultRouterAPIPort    = 8728
+	DefaultRouterAPISSLPort = 8729
+	DefaultRouterRESTPort   = 443
+	DefaultRouterSNMPPort   = 161
+)
+
+// DefaultRouterPort returns the default port for a router backend and TLS setting
+func DefaultRouterPort(backend string, tlsEnabled bool) int {
+	switch {
+	case backend == "rest":
+		return DefaultRouterRESTPort
+	case backend == "snmp":
+		return DefaultRouterSNMPPort
+	case tlsEnabled:
+		return DefaultRouterAPISSLPort
+	default:
+		return DefaultRouterAPIPort
+	}
+}
+
+// PollingConfig holds monitoring loop scheduling configuration
+type PollingConfig struct {
+	Mode              string        `yaml:"mode"`        // poll or stream (RouterOS API backend only)
+	Interval          time.Duration `yaml:"interval"`    // Default poll interval, overridable per router
+	Timeout           time.Duration `yaml:"timeout"`     // Deadline of a single router collection, retries included
+	Retries           int           `yaml:"retries"`     // Attempts to reach the router per collection
+	RetryDelay        time.Duration `yaml:"retry_delay"` // Pause between attempts
+	Jitter            float64       `yaml:"jitter"`      // 0.0 to 1.0, random fraction of the interval added per schedule
+	Adaptive          bool          `yaml:"adaptive"`    // Back off while a router is unreachable
+	MaxInterval       time.Duration `yaml:"max_interval"`
+	BackoffMultiplier float64       `yaml:"backoff_multiplier"`
+}
+
+// SnapshotConfig holds the traffic snapshot policy
+type SnapshotConfig struct {
+	Policy     string        `yaml:"policy"`      // interval, delta or both
+	Interval   time.Duration `yaml:"interval"`    // Minimum time between snapshots (interval, both)
+	DeltaBytes uint64        `yaml:"delta_bytes"` // Bytes transferred since the last snapshot (delta, both)
+	Include    []string      `yaml:"include"`     // Interface name patterns to snapshot, empty means all
+	Exclude    []string      `yaml:"exclude"`     // Interface name patterns never snapshotted
+}
+
+// RetentionConfig holds traffic rollup and retention settings. A retention of 0 keeps
+// rows forever.
+type RetentionConfig struct {
+	RollupInterval time.Duration `yaml:"rollup_interval"` // How often the rollup and purge job runs
+	Raw            time.Duration `yaml:"raw"`             // Raw traffic_snapshots
+	Minute         time.Duration `yaml:"minute"`          // traffic_rollups_1m
+	Hour           time.Duration `yaml:"hour"`            // traffic_rollups_1h
+	Day            time.Duration `yaml:"day"`             // traffic_rollups_1d
+	VacuumPages    int           `yaml:"vacuum_pages"`    // Pages freed per incremental VACUUM, 0 frees all
+}
+
+// MinMinuteRetention is the shortest RETENTION_1M. Hourly and daily rollups are built
+// from 1-minute rows, which therefore have to outlive a full day.
+const MinMinuteRetention = 48 * time.Hour
+
+// BillingConfig holds the default billing cycle of interfaces without their own
+// definition
+type BillingConfig struct {
+	StartDay   int    `yaml:"start_day"`   // Day of month a monthly cycle starts
+	Timezone   string `yaml:"timezone"`    // IANA zone, empty uses the server timezone
+	Length     int    `yaml:"length"`      // Cycle length in Unit
+	Unit       string `yaml:"unit"`        // month, day
+	AnchorDate string `yaml:"anchor_date"` // YYYY-MM-DD a cycle started on
+}
+
+// QuotaConfig holds quota limit evaluation and enforcement settings
+type QuotaConfig struct {
+	Thresholds    []string      `yaml:"thresholds"`      // Default notification thresholds in percent
+	CheckInterval time.Duration `yaml:"check_interval"`  // How often every limit is re-evaluated
+	Enforcement   bool          `yaml:"enforcement"`     // Allow limits to act on routers
+	QueueMaxLimit string        `yaml:"queue_max_limit"` // Default max-limit of enforcement queues
+	QueuePrefix   string        `yaml:"queue_prefix"`    // Name prefix of enforcement queues
+}
+
+// AlertConfig holds alert rule evaluation settings
+type AlertConfig struct {
+	EvalInterval time.Duration `yaml:"eval_interval"` // How often rules are re-evaluated between polls
+	ResetWindow  time.Duration `yaml:"reset_window"`  // Window counted by the counter_reset_count metric
+}
+
+// NotifyConfig holds notification delivery settings
+type NotifyConfig struct {
+	Workers       int           `yaml:"workers"`         // Concurrent deliveries
+	QueueSize     int           `yaml:"queue_size"`      // Deliveries buffered before new ones are dropped
+	Timeout       time.Duration `yaml:"timeout"`         // Deadline of a single delivery attempt
+	Retries       int           `yaml:"retries"`         // Attempts after the first one fails
+	RetryDelay    time.Duration `yaml:"retry_delay"`     // Pause before the first retry, doubled per retry
+	MaxRetryDelay time.Duration `yaml:"max_retry_delay"` // Upper bound of the pause between retries
 }
 
 // LoggingConfig holds logging configuration
 type LoggingConfig struct {
-	Level string `yaml:"level"`
+	Level           string        `yaml:"level"`            // debug, info, warn, error
+	Format          string        `yaml:"format"`           // json or logfmt
+	Stdout          bool          `yaml:"stdout"`           // Also write entries to stdout
+	File            string        `yaml:"file"`             // Empty logs to stdout only
+	MaxSizeMB       int           `yaml:"max_size_mb"`      // Rotate the file past this size, 0 disables
+	RotateInterval  time.Duration `yaml:"rotate_interval"`  // Rotate the file this often, 0 disables
+	MaxBackups      int           `yaml:"max_backups"`      // Rotated files kept, 0 keeps all
+	ComponentLevels []string      `yaml:"component_levels"` // component=level overrides, e.g. monitoring=debug
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration
+type TracingConfig struct {
+	Enabled      bool    `yaml:"enabled"`
+	Exporter     string  `yaml:"exporter"`      // otlp or stdout
+	OTLPEndpoint string  `yaml:"otlp_endpoint"` // host:port of the OTLP/HTTP collector
+	OTLPInsecure bool    `yaml:"otlp_insecure"` // Plain HTTP instead of HTTPS
+	SampleRatio  float64 `yaml:"sample_ratio"`  // Share of root traces recorded, 0 to 1
+	ServiceName  string  `yaml:"service_name"`
 }
 
 // WANDetectionConfig holds WAN/ISP detection configuration
@@ -92,6 +258,7 @@ type MetricsConfig struct {
 	EnableHealthCheck   bool          `yaml:"enable_health_check"`
 	HealthCheckInterval time.Duration `yaml:"health_check_interval"`
 	BroadcastMetrics    bool          `yaml:"broadcast_metrics"`
+	PrometheusEnabled   bool          `yaml:"prometheus_enabled"` // Serve /metrics in the Prometheus text format
 }
 
 // DashboardConfig holds dashboard configuration
@@ -104,25 +271,129 @@ type DashboardConfig struct {
 
 // Load loads configuration from environment variables
 func Load() *Config {
+	routerTLS := RouterTLSConfig{
+		Enabled:            getEnvAsBool("ROUTER_TLS_ENABLED", false),
+		CAFile:             getEnv("ROUTER_TLS_CA_FILE", ""),
+		CertFile:           getEnv("ROUTER_TLS_CERT_FILE", ""),
+		KeyFile:            getEnv("ROUTER_TLS_KEY_FILE", ""),
+		ServerName:         getEnv("ROUTER_TLS_SERVER_NAME", ""),
+		InsecureSkipVerify: getEnvAsBool("ROUTER_TLS_INSECURE_SKIP_VERIFY", false),
+	}
+	routerBackend := getEnv("ROUTER_BACKEND", "api")
+
 	return &Config{
 		Server: ServerConfig{
-			Host: getEnv("SERVER_HOST", "0.0.0.0"),
-			Port: getEnvAsInt("SERVER_PORT", 8080),
+			Host:        getEnv("SERVER_HOST", "0.0.0.0"),
+			Port:        getEnvAsInt("SERVER_PORT", 8080),
+			CORSOrigins: getEnvAsSlice("CORS_ALLOWED_ORIGINS", []string{"http://localhost:3000"}),
+		},
+		Auth: AuthConfig{
+			Enabled:           getEnvAsBool("AUTH_ENABLED", true),
+			JWTSecret:         getEnv("AUTH_JWT_SECRET", ""),
+			AccessTTL:         getEnvAsDuration("AUTH_ACCESS_TTL", 15*time.Minute),
+			RefreshTTL:        getEnvAsDuration("AUTH_REFRESH_TTL", 7*24*time.Hour),
+			BcryptCost:        getEnvAsInt("AUTH_BCRYPT_COST", 12),
+			AdminUsername:     getEnv("AUTH_ADMIN_USERNAME", "admin"),
+			AdminPassword:     getEnv("AUTH_ADMIN_PASSWORD", ""),
+			AdminPasswordFile: getEnv("AUTH_ADMIN_PASSWORD_FILE", "data/admin-password"),
 		},
 		Database: DatabaseConfig{
-			Path:        getEnv("DB_PATH", "data/monik.db"),
-			MaxOpenConn: getEnvAsInt("DB_MAX_OPEN_CONN", 25),
-			MaxIdleConn: getEnvAsInt("DB_MAX_IDLE_CONN", 5),
+			Driver:             getEnv("DB_DRIVER", "sqlite"),
+			Path:               getEnv("DB_PATH", "data/monik.db"),
+			DSN:                getEnv("DB_DSN", ""),
+			MaxOpenConn:        getEnvAsInt("DB_MAX_OPEN_CONN", 25),
+			MaxIdleConn:        getEnvAsInt("DB_MAX_IDLE_CONN", 5),
+			AutoMigrate:        getEnvAsBool("DB_AUTO_MIGRATE", true),
+			Timescale:          getEnvAsBool("DB_TIMESCALE", false),
+			TimescaleChunkTime: getEnvAsDuration("DB_TIMESCALE_CHUNK_TIME", 24*time.Hour),
 		},
 		Router: RouterConfig{
 			IP:       getEnv("ROUTER_IP", "192.168.88.1"),
-			Port:     getEnvAsInt("ROUTER_PORT", 8728),
+			Port:     getEnvAsInt("ROUTER_PORT", DefaultRouterPort(routerBackend, routerTLS.Enabled)),
 			Username: getEnv("ROUTER_USERNAME", "admin"),
 			Password: getEnv("ROUTER_PASSWORD", ""),
 			Timeout:  getEnvAsDuration("ROUTER_TIMEOUT", 30*time.Second),
+			Backend:  routerBackend,
+			TLS:      routerTLS,
+			SNMP: RouterSNMPConfig{
+				Version:      getEnv("ROUTER_SNMP_VERSION", "v2c"),
+				Community:    getEnv("ROUTER_SNMP_COMMUNITY", "public"),
+				Username:     getEnv("ROUTER_SNMP_USERNAME", ""),
+				AuthProtocol: getEnv("ROUTER_SNMP_AUTH_PROTOCOL", "SHA"),
+				AuthPassword: getEnv("ROUTER_SNMP_AUTH_PASSWORD", ""),
+				PrivProtocol: getEnv("ROUTER_SNMP_PRIV_PROTOCOL", "AES"),
+				PrivPassword: getEnv("ROUTER_SNMP_PRIV_PASSWORD", ""),
+			},
+		},
+		Polling: PollingConfig{
+			Mode:              getEnv("POLL_MODE", "poll"),
+			Interval:          getEnvAsDuration("POLL_INTERVAL", 10*time.Second),
+			Timeout:           getEnvAsDuration("POLL_TIMEOUT", 15*time.Second),
+			Retries:           getEnvAsInt("POLL_RETRIES", 3),
+			RetryDelay:        getEnvAsDuration("POLL_RETRY_DELAY", 2*time.Second),
+			Jitter:            getEnvAsFloat64("POLL_JITTER", 0.1),
+			Adaptive:          getEnvAsBool("POLL_ADAPTIVE", true),
+			MaxInterval:       getEnvAsDuration("POLL_MAX_INTERVAL", 5*time.Minute),
+			BackoffMultiplier: getEnvAsFloat64("POLL_BACKOFF_MULTIPLIER", 2.0),
+		},
+		Snapshot: SnapshotConfig{
+			Policy:     getEnv("SNAPSHOT_POLICY", "interval"),
+			Interval:   getEnvAsDuration("SNAPSHOT_INTERVAL", time.Minute),
+			DeltaBytes: getEnvAsUint64("SNAPSHOT_DELTA_BYTES", 10*1024*1024*1024), // 10GB
+			Include:    getEnvAsSlice("SNAPSHOT_INCLUDE", nil),
+			Exclude:    getEnvAsSlice("SNAPSHOT_EXCLUDE", nil),
+		},
+		Retention: RetentionConfig{
+			RollupInterval: getEnvAsDuration("ROLLUP_INTERVAL", time.Minute),
+			Raw:            getEnvAsDuration("RETENTION_RAW", 7*24*time.Hour),
+			Minute:         getEnvAsDuration("RETENTION_1M", 30*24*time.Hour),
+			Hour:           getEnvAsDuration("RETENTION_1H", 365*24*time.Hour),
+			Day:            getEnvAsDuration("RETENTION_1D", 0),
+			VacuumPages:    getEnvAsInt("RETENTION_VACUUM_PAGES", 1000),
+		},
+		Billing: BillingConfig{
+			StartDay:   getEnvAsInt("BILLING_CYCLE_START_DAY", 1),
+			Timezone:   getEnv("BILLING_TIMEZONE", ""),
+			Length:     getEnvAsInt("BILLING_CYCLE_LENGTH", 1),
+			Unit:       getEnv("BILLING_CYCLE_UNIT", "month"),
+			AnchorDate: getEnv("BILLING_CYCLE_ANCHOR", ""),
+		},
+		Quota: QuotaConfig{
+			Thresholds:    getEnvAsSlice("QUOTA_THRESHOLDS", []string{"50", "80", "100"}),
+			CheckInterval: getEnvAsDuration("QUOTA_CHECK_INTERVAL", time.Minute),
+			Enforcement:   getEnvAsBool("QUOTA_ENFORCEMENT", false),
+			QueueMaxLimit: getEnv("QUOTA_QUEUE_MAX_LIMIT", "1M/1M"),
+			QueuePrefix:   getEnv("QUOTA_QUEUE_PREFIX", "monik-quota-"),
+		},
+		Alerts: AlertConfig{
+			EvalInterval: getEnvAsDuration("ALERT_EVAL_INTERVAL", 15*time.Second),
+			ResetWindow:  getEnvAsDuration("ALERT_RESET_WINDOW", time.Hour),
+		},
+		Notify: NotifyConfig{
+			Workers:       getEnvAsInt("NOTIFY_WORKERS", 2),
+			QueueSize:     getEnvAsInt("NOTIFY_QUEUE_SIZE", 500),
+			Timeout:       getEnvAsDuration("NOTIFY_TIMEOUT", 10*time.Second),
+			Retries:       getEnvAsInt("NOTIFY_RETRIES", 3),
+			RetryDelay:    getEnvAsDuration("NOTIFY_RETRY_DELAY", 5*time.Second),
+			MaxRetryDelay: getEnvAsDuration("NOTIFY_MAX_RETRY_DELAY", 2*time.Minute),
 		},
 		Logging: LoggingConfig{
-			Level: getEnv("LOG_LEVEL", "info"),
+			Level:           getEnv("LOG_LEVEL", "info"),
+			Format:          getEnv("LOG_FORMAT", "json"),
+			Stdout:          getEnvAsBool("LOG_STDOUT", true),
+			File:            getEnv("LOG_FILE", ""),
+			MaxSizeMB:       getEnvAsInt("LOG_MAX_SIZE_MB", 100),
+			RotateInterval:  getEnvAsDuration("LOG_ROTATE_INTERVAL", 24*time.Hour),
+			MaxBackups:      getEnvAsInt("LOG_MAX_BACKUPS", 7),
+			ComponentLevels: getEnvAsSlice("LOG_COMPONENT_LEVELS", nil),
+		},
+		Tracing: TracingConfig{
+			Enabled:      getEnvAsBool("TRACING_ENABLED", false),
+			Exporter:     getEnv("TRACING_EXPORTER", "otlp"),
+			OTLPEndpoint: getEnv("TRACING_OTLP_ENDPOINT", "localhost:4318"),
+			OTLPInsecure: getEnvAsBool("TRACING_OTLP_INSECURE", true),
+			SampleRatio:  getEnvAsFloat64("TRACING_SAMPLE_RATIO", 1),
+			ServiceName:  getEnv("TRACING_SERVICE_NAME", "monik-enterprise"),
 		},
 		WAN: WANDetectionConfig{
 			Enabled:          getEnvAsBool("WAN_ENABLED", true),
@@ -158,6 +429,7 @@ func Load() *Config {
 			EnableHealthCheck:   getEnvAsBool("METRICS_ENABLE_HEALTH_CHECK", true),
 			HealthCheckInterval: getEnvAsDuration("METRICS_HEALTH_CHECK_INTERVAL", 60*time.Second),
 			BroadcastMetrics:    getEnvAsBool("METRICS_BROADCAST_METRICS", true),
+			PrometheusEnabled:   getEnvAsBool("METRICS_PROMETHEUS_ENABLED", true),
 		},
 		Dashboard: DashboardConfig{
 			Enabled:                getEnvAsBool("DASHBOARD_ENABLED", true),
@@ -168,6 +440,15 @@ func Load() *Config {
 	}
 }
 
+// Validate reports settings that cannot work as configured
+func (c *Config) Validate() error {
+	if c.Retention.Minute > 0 && c.Retention.Minute < MinMinuteRetention {
+		return fmt.Errorf("RETENTION_1M=%s is too short: 1h and 1d rollups are built from 1-minute rows, keep them at least %s or set 0 to keep them forever",
+			c.Retention.Minute, MinMinuteRetention)
+	}
+	return nil
+}
+
 // getEnv gets an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -235,3 +516,18 @@ func getEnvAsInt64(key string, defaultValue int64) int64 {
 	}
 	return defaultValue
 }
+
+// getEnvAsSlice gets a comma separated environment variable as a slice or returns a default value
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
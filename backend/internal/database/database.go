@@ -3,29 +3,52 @@ package database
 import (
 	"fmt"
 	"monik-enterprise/internal/config"
-	"monik-enterprise/internal/models"
 	appLogger "monik-enterprise/pkg/logger"
 	"os"
 	"path/filepath"
 
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	gormLogger "gorm.io/gorm/logger"
 )
 
+// Supported DB_DRIVER values
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
 var db *gorm.DB
 
-// InitDB initializes the database connection
-func InitDB(dbPath string) *gorm.DB {
-	// Ensure the data directory exists
-	dir := filepath.Dir(dbPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		appLogger.Error("Failed to create database directory: %v", err)
-		panic(err)
+// InitDB initializes the database connection for the configured driver
+func InitDB(cfg config.DatabaseConfig) *gorm.DB {
+	var dialector gorm.Dialector
+	var target string
+	switch cfg.Driver {
+	case "", DriverSQLite:
+		// Ensure the data directory exists
+		dir := filepath.Dir(cfg.Path)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			appLogger.Error("Failed to create database directory: %v", err)
+			panic(err)
+		}
+		dialector = sqlite.Open(cfg.Path)
+		target = cfg.Path
+	case DriverPostgres:
+		if cfg.DSN == "" {
+			appLogger.Error("DB_DSN is required for DB_DRIVER=postgres")
+			panic("missing DB_DSN")
+		}
+		dialector = postgres.Open(cfg.DSN)
+		target = "postgres"
+	default:
+		appLogger.Error("Unsupported DB_DRIVER: %s", cfg.Driver)
+		panic(fmt.Sprintf("unsupported DB_DRIVER: %s", cfg.Driver))
 	}
 
 	var err error
-	db, err = gorm.Open(sqlite.Open(dbPath), &gorm.Config{
+	db, err = gorm.Open(dialector, &gorm.Config{
 		Logger: gormLogger.Default.LogMode(gormLogger.Info),
 	})
 	if err != nil {
@@ -33,6 +56,12 @@ func InitDB(dbPath string) *gorm.DB {
 		panic(err)
 	}
 
+	// Trace statements run within a traced operation
+	if err := db.Use(Tracing{}); err != nil {
+		appLogger.Error("Failed to register tracing plugin: %v", err)
+		panic(err)
+	}
+
 	// Configure connection pool
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -40,10 +69,19 @@ func InitDB(dbPath string) *gorm.DB {
 		panic(err)
 	}
 
-	cfg := config.Load()
-	sqlDB.SetMaxOpenConns(cfg.Database.MaxOpenConn)
-	sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConn)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConn)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConn)
+
+	if IsSQLite(db) {
+		configureSQLite(db)
+	}
+
+	appLogger.Info("Database connected successfully: %s", target)
+	return db
+}
 
+// configureSQLite applies the WAL and vacuum pragmas used for the SQLite backend
+func configureSQLite(db *gorm.DB) {
 	// Enable WAL mode and performance optimizations
 	if err := db.Exec("PRAGMA journal_mode=WAL;").Error; err != nil {
 		appLogger.Error("Failed to set journal_mode to WAL: %v", err)
@@ -58,8 +96,17 @@ func InitDB(dbPath string) *gorm.DB {
 		appLogger.Error("Failed to set temp_store to MEMORY: %v", err)
 	}
 
-	appLogger.Info("Database connected successfully: %s", dbPath)
-	return db
+	// Incremental auto-vacuum lets the retention job give space back without a full
+	// VACUUM. An existing database only switches mode after one VACUUM.
+	var autoVacuum int
+	if err := db.Raw("PRAGMA auto_vacuum;").Scan(&autoVacuum).Error; err == nil && autoVacuum != 2 {
+		appLogger.Info("Enabling incremental auto_vacuum (one-time VACUUM)...")
+		if err := db.Exec("PRAGMA auto_vacuum=INCREMENTAL;").Error; err != nil {
+			appLogger.Error("Failed to set auto_vacuum to INCREMENTAL: %v", err)
+		} else if err := db.Exec("VACUUM;").Error; err != nil {
+			appLogger.Error("Failed to VACUUM database: %v", err)
+		}
+	}
 }
 
 // GetDB returns the database instance
@@ -75,24 +122,3 @@ func CloseDB() {
 		appLogger.Info("Database connection closed")
 	}
 }
-
-// RunMigrations runs all database migrations
-func RunMigrations(db *gorm.DB) {
-	appLogger.Info("Running database migrations...")
-
-	// Auto-migrate all models
-	err := db.AutoMigrate(
-		&models.Interface{},
-		&models.TrafficSnapshot{},
-		&models.CounterResetLog{},
-		&models.MonthlyQuota{},
-		&models.SystemInfo{},
-	)
-
-	if err != nil {
-		appLogger.Error("Failed to run migrations: %v", err)
-		panic(fmt.Sprintf("Migration failed: %v", err))
-	}
-
-	appLogger.Info("Database migrations completed successfully")
-}
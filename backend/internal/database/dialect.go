@@ -0,0 +1,76 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	appLogger "monik-enterprise/pkg/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IsSQLite reports whether db talks to SQLite
+func IsSQLite(db *gorm.DB) bool {
+	return db.Dialector.Name() == DriverSQLite
+}
+
+// IsPostgres reports whether db talks to PostgreSQL (including TimescaleDB)
+func IsPostgres(db *gorm.DB) bool {
+	return db.Dialector.Name() == DriverPostgres
+}
+
+// Upsert builds the ON CONFLICT clause that updates the given columns of the row that
+// already holds the conflict key. PostgreSQL only accepts a conflict target that
+// matches a unique index exactly and SQLite needs one for DO UPDATE, so the key is
+// always spelled out. New values are read from the excluded row instead of being
+// bound, which keeps the clause valid for multi-row inserts on both drivers.
+func Upsert(conflict, update []string) clause.OnConflict {
+	columns := make([]clause.Column, len(conflict))
+	for i, name := range conflict {
+		columns[i] = clause.Column{Name: name}
+	}
+	return clause.OnConflict{
+		Columns:   columns,
+		DoUpdates: clause.AssignmentColumns(update),
+	}
+}
+
+// ensureHypertable converts traffic_snapshots into a TimescaleDB hypertable
+// partitioned on timestamp. Existing rows are migrated into chunks once.
+func ensureHypertable(db *gorm.DB, chunkTime time.Duration) error {
+	if !IsPostgres(db) {
+		return errors.New("DB_TIMESCALE requires DB_DRIVER=postgres")
+	}
+	if chunkTime <= 0 {
+		chunkTime = 24 * time.Hour
+	}
+
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS timescaledb").Error; err != nil {
+		return fmt.Errorf("enable timescaledb extension: %w", err)
+	}
+
+	var count int64
+	if err := db.Raw("SELECT count(*) FROM timescaledb_information.hypertables WHERE hypertable_name = ?",
+		"traffic_snapshots").Scan(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	appLogger.Info("Converting traffic_snapshots into a TimescaleDB hypertable...")
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Unique constraints of a hypertable must include the partitioning column
+		if err := tx.Exec("ALTER TABLE traffic_snapshots DROP CONSTRAINT IF EXISTS traffic_snapshots_pkey").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`ALTER TABLE traffic_snapshots ADD PRIMARY KEY (id, "timestamp")`).Error; err != nil {
+			return err
+		}
+		interval := fmt.Sprintf("%d seconds", int64(chunkTime.Seconds()))
+		return tx.Exec("SELECT create_hypertable('traffic_snapshots', 'timestamp', chunk_time_interval => ?::interval, migrate_data => true)",
+			interval).Error
+	})
+}
@@ -0,0 +1,272 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"monik-enterprise/internal/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+// testPostgresDSNEnv names the DSN of a throwaway PostgreSQL (or TimescaleDB) database.
+// The PostgreSQL variants of the driver tests are skipped when it is unset. Every test
+// works in its own schema, which is dropped afterwards.
+const testPostgresDSNEnv = "TEST_POSTGRES_DSN"
+
+// forEachDriver runs fn against a fresh SQLite database and, when configured, a fresh
+// PostgreSQL schema, so both drivers are held to the same assertions
+func forEachDriver(t *testing.T, fn func(t *testing.T, db *gorm.DB)) {
+	t.Run(DriverSQLite, func(t *testing.T) {
+		fn(t, openSQLiteTestDB(t))
+	})
+	t.Run(DriverPostgres, func(t *testing.T) {
+		fn(t, openPostgresTestDB(t))
+	})
+}
+
+func openSQLiteTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "monik.db")), &gorm.Config{
+		Logger: gormLogger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func openPostgresTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv(testPostgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s not set", testPostgresDSNEnv)
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormLogger.Discard})
+	if err != nil {
+		t.Fatalf("open postgres: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// search_path is per connection, so keep a single one
+	sqlDB.SetMaxOpenConns(1)
+
+	schema := fmt.Sprintf("monik_test_%d", time.Now().UnixNano())
+	if err := db.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DROP SCHEMA " + schema + " CASCADE")
+		sqlDB.Close()
+	})
+	if err := db.Exec("SET search_path TO " + schema + ", public").Error; err != nil {
+		t.Fatalf("set search_path: %v", err)
+	}
+	return db
+}
+
+func TestUpsert(t *testing.T) {
+	table := models.TrafficRollup1h{}.TableName()
+	hour := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	rollup := func(offset, samples int, rx uint64) models.TrafficRollup {
+		return models.TrafficRollup{
+			RouterID:      1,
+			InterfaceName: "ether1",
+			BucketStart:   hour.Add(time.Duration(offset) * time.Hour),
+			Samples:       samples,
+			RxRateAvg:     float64(samples),
+			RxBytes:       rx,
+		}
+	}
+	type bucket struct {
+		samples int
+		rxBytes uint64
+	}
+
+	tests := []struct {
+		name    string
+		initial []models.TrafficRollup
+		upsert  []models.TrafficRollup
+		batch   int
+		update  []string
+		want    []bucket // ordered by bucket_start
+	}{
+		{
+			name:   "insert into an empty table",
+			upsert: []models.TrafficRollup{rollup(0, 3, 30), rollup(1, 4, 40)},
+			batch:  10,
+			update: []string{"samples", "rx_bytes"},
+			want:   []bucket{{3, 30}, {4, 40}},
+		},
+		{
+			name:    "update the row holding the key",
+			initial: []models.TrafficRollup{rollup(0, 1, 10)},
+			upsert:  []models.TrafficRollup{rollup(0, 5, 50)},
+			batch:   10,
+			update:  []string{"samples", "rx_bytes"},
+			want:    []bucket{{5, 50}},
+		},
+		{
+			name:    "columns outside the update list are kept",
+			initial: []models.TrafficRollup{rollup(0, 1, 10)},
+			upsert:  []models.TrafficRollup{rollup(0, 5, 50)},
+			batch:   10,
+			update:  []string{"samples"},
+			want:    []bucket{{5, 10}},
+		},
+		{
+			name:    "multi-row insert mixing new and existing keys",
+			initial: []models.TrafficRollup{rollup(1, 1, 10)},
+			upsert:  []models.TrafficRollup{rollup(0, 2, 20), rollup(1, 3, 30), rollup(2, 4, 40)},
+			batch:   10,
+			update:  []string{"samples", "rx_bytes"},
+			want:    []bucket{{2, 20}, {3, 30}, {4, 40}},
+		},
+		{
+			name:   "repeated key across batches keeps the last row",
+			upsert: []models.TrafficRollup{rollup(0, 1, 10), rollup(0, 2, 20), rollup(0, 3, 30)},
+			batch:  1,
+			update: []string{"samples", "rx_bytes"},
+			want:   []bucket{{3, 30}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+				if err := db.AutoMigrate(&models.TrafficRollup1h{}); err != nil {
+					t.Fatal(err)
+				}
+				if len(tt.initial) > 0 {
+					if err := db.Table(table).Create(&tt.initial).Error; err != nil {
+						t.Fatalf("seed: %v", err)
+					}
+				}
+
+				err := db.Table(table).Clauses(Upsert(
+					[]string{"router_id", "interface_name", "bucket_start"}, tt.update,
+				)).CreateInBatches(tt.upsert, tt.batch).Error
+				if err != nil {
+					t.Fatalf("upsert: %v", err)
+				}
+
+				var rows []models.TrafficRollup
+				if err := db.Table(table).Order("bucket_start").Find(&rows).Error; err != nil {
+					t.Fatal(err)
+				}
+				if len(rows) != len(tt.want) {
+					t.Fatalf("got %d rows, want %d", len(rows), len(tt.want))
+				}
+				for i, want := range tt.want {
+					if got := (bucket{rows[i].Samples, rows[i].RxBytes}); got != want {
+						t.Errorf("bucket %s = %+v, want %+v", rows[i].BucketStart.UTC(), got, want)
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestUpsertKeepsRowIdentity(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+		if err := db.AutoMigrate(&models.Interface{}); err != nil {
+			t.Fatal(err)
+		}
+		first := models.Interface{RouterID: 1, InterfaceName: "ether1", RxBytes: 1, Comment: "uplink"}
+		if err := db.Create(&first).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		err := db.Clauses(Upsert(
+			[]string{"router_id", "interface_name"},
+			[]string{"rx_bytes", "updated_at"},
+		)).Create(&models.Interface{RouterID: 1, InterfaceName: "ether1", RxBytes: 99}).Error
+		if err != nil {
+			t.Fatalf("upsert: %v", err)
+		}
+
+		var rows []models.Interface
+		if err := db.Find(&rows).Error; err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 1 {
+			t.Fatalf("got %d rows, want 1", len(rows))
+		}
+		got := rows[0]
+		if got.ID != first.ID || got.RxBytes != 99 || got.Comment != "uplink" {
+			t.Errorf("row = id %d rx %d comment %q, want id %d rx 99 comment uplink",
+				got.ID, got.RxBytes, got.Comment, first.ID)
+		}
+	})
+}
+
+func TestEnsureHypertable(t *testing.T) {
+	t.Run(DriverSQLite, func(t *testing.T) {
+		db := openSQLiteTestDB(t)
+		err := ensureHypertable(db, time.Hour)
+		if err == nil || !strings.Contains(err.Error(), "requires DB_DRIVER=postgres") {
+			t.Fatalf("error = %v, want the postgres requirement", err)
+		}
+	})
+
+	t.Run(DriverPostgres, func(t *testing.T) {
+		db := openPostgresTestDB(t)
+		var available int64
+		if err := db.Raw("SELECT count(*) FROM pg_available_extensions WHERE name = 'timescaledb'").
+			Scan(&available).Error; err != nil {
+			t.Fatal(err)
+		}
+		if available == 0 {
+			t.Skip("timescaledb extension not available")
+		}
+		if err := db.AutoMigrate(&models.TrafficSnapshot{}); err != nil {
+			t.Fatal(err)
+		}
+		before := models.TrafficSnapshot{RouterID: 1, InterfaceName: "ether1", Timestamp: time.Now().Add(-48 * time.Hour)}
+		if err := db.Create(&before).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		// A second call finds the hypertable and leaves it alone
+		for i := 0; i < 2; i++ {
+			if err := ensureHypertable(db, time.Hour); err != nil {
+				t.Fatalf("ensureHypertable call %d: %v", i+1, err)
+			}
+		}
+
+		var hypertables int64
+		if err := db.Raw("SELECT count(*) FROM timescaledb_information.hypertables WHERE hypertable_schema = current_schema() AND hypertable_name = 'traffic_snapshots'").
+			Scan(&hypertables).Error; err != nil {
+			t.Fatal(err)
+		}
+		if hypertables != 1 {
+			t.Fatalf("found %d hypertables, want 1", hypertables)
+		}
+
+		after := models.TrafficSnapshot{RouterID: 1, InterfaceName: "ether1", Timestamp: time.Now()}
+		if err := db.Create(&after).Error; err != nil {
+			t.Fatalf("insert into hypertable: %v", err)
+		}
+		var rows int64
+		if err := db.Model(&models.TrafficSnapshot{}).Count(&rows).Error; err != nil {
+			t.Fatal(err)
+		}
+		if rows != 2 {
+			t.Errorf("found %d snapshots, want the migrated one and the new one", rows)
+		}
+	})
+}
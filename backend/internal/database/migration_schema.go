@@ -0,0 +1,573 @@
+package database
+
+import (
+	"time"
+
+	"monik-enterprise/internal/config"
+
+	"gorm.io/gorm"
+)
+
+// The structs below freeze each table as a migration created or altered it. Migrations
+// use them instead of internal/models, so a later change to a model cannot change what
+// an already released migration does. A migration that alters a table gets a new
+// snapshot named after its version; snapshots used by released migrations are never
+// edited. A snapshot that extends an earlier one holds it in an exported field tagged
+// embedded, as gorm skips unexported embedded structs.
+
+// routerV1 is the routers table of migration 1
+type routerV1 struct {
+	ID                    uint   `gorm:"primaryKey"`
+	Name                  string `gorm:"uniqueIndex;not null"`
+	Host                  string `gorm:"not null"`
+	Port                  int
+	Username              string
+	Password              string
+	Timeout               time.Duration
+	Enabled               bool
+	PollInterval          time.Duration
+	Backend               string
+	TLSEnabled            bool
+	TLSCAFile             string
+	TLSCertFile           string
+	TLSKeyFile            string
+	TLSServerName         string
+	TLSInsecureSkipVerify bool
+	SNMPVersion           string
+	SNMPCommunity         string
+	SNMPUsername          string
+	SNMPAuthProtocol      string
+	SNMPAuthPassword      string
+	SNMPPrivProtocol      string
+	SNMPPrivPassword      string
+	Status                string
+	LastSeen              time.Time
+	LastError             string
+	Comment               string
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	DeletedAt             gorm.DeletedAt `gorm:"index"`
+}
+
+func (routerV1) TableName() string { return "routers" }
+
+// defaultRouterV1 is the router seeded from ROUTER_* settings by migration 3
+func defaultRouterV1(cfg config.RouterConfig) routerV1 {
+	return routerV1{
+		Name:     DefaultRouterName,
+		Host:     cfg.IP,
+		Port:     cfg.Port,
+		Username: cfg.Username,
+		Password: cfg.Password,
+		Timeout:  cfg.Timeout,
+		Enabled:  true,
+		Backend:  cfg.Backend,
+		Status:   "unknown",
+
+		TLSEnabled:            cfg.TLS.Enabled,
+		TLSCAFile:             cfg.TLS.CAFile,
+		TLSCertFile:           cfg.TLS.CertFile,
+		TLSKeyFile:            cfg.TLS.KeyFile,
+		TLSServerName:         cfg.TLS.ServerName,
+		TLSInsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
+
+		SNMPVersion:      cfg.SNMP.Version,
+		SNMPCommunity:    cfg.SNMP.Community,
+		SNMPUsername:     cfg.SNMP.Username,
+		SNMPAuthProtocol: cfg.SNMP.AuthProtocol,
+		SNMPAuthPassword: cfg.SNMP.AuthPassword,
+		SNMPPrivProtocol: cfg.SNMP.PrivProtocol,
+		SNMPPrivPassword: cfg.SNMP.PrivPassword,
+	}
+}
+
+// interfaceV1 is the interfaces table of migration 1
+type interfaceV1 struct {
+	ID                uint   `gorm:"primaryKey"`
+	RouterID          uint   `gorm:"uniqueIndex:idx_router_interface;not null;default:0"`
+	InterfaceName     string `gorm:"uniqueIndex:idx_router_interface;not null"`
+	RxBytes           uint64
+	TxBytes           uint64
+	RxRate            float64
+	TxRate            float64
+	LastSeen          time.Time
+	CounterResetCount int
+	Status            string
+	Comment           string
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	DeletedAt         gorm.DeletedAt `gorm:"index"`
+}
+
+func (interfaceV1) TableName() string { return "interfaces" }
+
+// interfaceV11 adds the counter reset classification columns of migration 11
+type interfaceV11 struct {
+	Base       interfaceV1 `gorm:"embedded"`
+	ObjectID   string
+	CountersAt time.Time
+}
+
+func (interfaceV11) TableName() string { return "interfaces" }
+
+// trafficSnapshotV1 is the traffic_snapshots table of migration 1
+type trafficSnapshotV1 struct {
+	ID            uint      `gorm:"primaryKey"`
+	RouterID      uint      `gorm:"index"`
+	InterfaceName string    `gorm:"index"`
+	Timestamp     time.Time `gorm:"index"`
+	RxBytes       uint64
+	TxBytes       uint64
+	RxRate        float64
+	TxRate        float64
+	TotalBytes    uint64
+	CounterReset  bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+}
+
+func (trafficSnapshotV1) TableName() string { return "traffic_snapshots" }
+
+// trafficSnapshotV11 adds the reset_kind column of migration 11
+type trafficSnapshotV11 struct {
+	Base      trafficSnapshotV1 `gorm:"embedded"`
+	ResetKind string
+}
+
+func (trafficSnapshotV11) TableName() string { return "traffic_snapshots" }
+
+// counterResetLogV1 is the counter_reset_logs table of migration 1
+type counterResetLogV1 struct {
+	ID              uint      `gorm:"primaryKey"`
+	RouterID        uint      `gorm:"index"`
+	InterfaceName   string    `gorm:"index"`
+	ResetTime       time.Time `gorm:"index"`
+	PreviousBytes   uint64
+	NewBytes        uint64
+	DetectionMethod string
+	Notes           string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt `gorm:"index"`
+}
+
+func (counterResetLogV1) TableName() string { return "counter_reset_logs" }
+
+// monthlyQuotaV1 is the monthly_quota table of migration 1
+type monthlyQuotaV1 struct {
+	ID            uint   `gorm:"primaryKey"`
+	RouterID      uint   `gorm:"index"`
+	InterfaceName string `gorm:"index"`
+	Month         int    `gorm:"index"`
+	Year          int    `gorm:"index"`
+	Day           int    `gorm:"index"`
+	RxBytes       uint64
+	TxBytes       uint64
+	TotalBytes    uint64
+	QuotaLimit    uint64
+	TotalRx       uint64
+	TotalTx       uint64
+	LastRxBytes   uint64
+	LastTxBytes   uint64
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+}
+
+func (monthlyQuotaV1) TableName() string { return "monthly_quota" }
+
+// systemInfoV1 is the system_info table of migration 1
+type systemInfoV1 struct {
+	ID          uint `gorm:"primaryKey"`
+	RouterID    uint `gorm:"index"`
+	RouterName  string
+	BoardName   string
+	Version     string
+	Uptime      string
+	CPU         string
+	Memory      string
+	Disk        string
+	Timezone    string
+	Identity    string
+	LastUpdated time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (systemInfoV1) TableName() string { return "system_info" }
+
+// trafficRollupV4 holds the columns shared by the rollup tables of migration 4
+type trafficRollupV4 struct {
+	ID            uint      `gorm:"primaryKey"`
+	RouterID      uint      `gorm:"uniqueIndex:,composite:bucket;not null"`
+	InterfaceName string    `gorm:"uniqueIndex:,composite:bucket;not null"`
+	BucketStart   time.Time `gorm:"uniqueIndex:,composite:bucket;not null;index"`
+	Samples       int
+	RxRateMin     float64
+	RxRateAvg     float64
+	RxRateMax     float64
+	RxRateP95     float64
+	TxRateMin     float64
+	TxRateAvg     float64
+	TxRateMax     float64
+	TxRateP95     float64
+	RxBytes       uint64
+	TxBytes       uint64
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type trafficRollup1mV4 struct {
+	Base trafficRollupV4 `gorm:"embedded"`
+}
+
+func (trafficRollup1mV4) TableName() string { return "traffic_rollups_1m" }
+
+type trafficRollup1hV4 struct {
+	Base trafficRollupV4 `gorm:"embedded"`
+}
+
+func (trafficRollup1hV4) TableName() string { return "traffic_rollups_1h" }
+
+type trafficRollup1dV4 struct {
+	Base trafficRollupV4 `gorm:"embedded"`
+}
+
+func (trafficRollup1dV4) TableName() string { return "traffic_rollups_1d" }
+
+// wanInterfaceLogV5 is the wan_interface_logs table of migration 5
+type wanInterfaceLogV5 struct {
+	ID              uint   `gorm:"primaryKey"`
+	InterfaceName   string `gorm:"index"`
+	DetectionMethod string
+	Confidence      float64
+	Traffic         uint64
+	DetectedAt      time.Time `gorm:"index"`
+	Notes           string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt `gorm:"index"`
+}
+
+func (wanInterfaceLogV5) TableName() string { return "wan_interface_logs" }
+
+// workerMetricsLogV5 is the worker_metrics_logs table of migration 5
+type workerMetricsLogV5 struct {
+	ID             uint `gorm:"primaryKey"`
+	ActiveJobs     int64
+	TotalJobs      int64
+	SuccessJobs    int64
+	FailedJobs     int64
+	AvgResponse    time.Duration
+	WorkerCount    int
+	QueueSize      int
+	LoadPercentage float64
+	LoggedAt       time.Time `gorm:"index"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+}
+
+func (workerMetricsLogV5) TableName() string { return "worker_metrics_logs" }
+
+// webSocketConnectionLogV5 is the web_socket_connection_logs table of migration 5
+type webSocketConnectionLogV5 struct {
+	ID               uint   `gorm:"primaryKey"`
+	ClientID         string `gorm:"index"`
+	InterfaceName    string `gorm:"index"`
+	ConnectedAt      time.Time
+	DisconnectedAt   *time.Time
+	MessageCount     int64
+	BytesTransferred int64
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        gorm.DeletedAt `gorm:"index"`
+}
+
+func (webSocketConnectionLogV5) TableName() string { return "web_socket_connection_logs" }
+
+// billingCycleV6 is the billing_cycles table of migration 6
+type billingCycleV6 struct {
+	ID            uint   `gorm:"primaryKey"`
+	RouterID      uint   `gorm:"uniqueIndex:idx_billing_cycle_interface;not null"`
+	InterfaceName string `gorm:"uniqueIndex:idx_billing_cycle_interface;not null"`
+	StartDay      int
+	Timezone      string
+	Length        int
+	Unit          string
+	AnchorDate    string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (billingCycleV6) TableName() string { return "billing_cycles" }
+
+// quotaLimitV7 is the quota_limits table of migration 7
+type quotaLimitV7 struct {
+	ID                uint   `gorm:"primaryKey"`
+	RouterID          uint   `gorm:"uniqueIndex:idx_quota_limit_interface;not null"`
+	InterfaceName     string `gorm:"uniqueIndex:idx_quota_limit_interface;not null"`
+	LimitBytes        uint64
+	Thresholds        string
+	Action            string
+	QueueMaxLimit     string
+	Enabled           bool
+	CycleStart        time.Time
+	NotifiedThreshold int
+	Enforced          bool
+	EnforcedAction    string
+	EnforcedAt        *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+func (quotaLimitV7) TableName() string { return "quota_limits" }
+
+// quotaEventV7 is the quota_events table of migration 7
+type quotaEventV7 struct {
+	ID            uint   `gorm:"primaryKey"`
+	RouterID      uint   `gorm:"index"`
+	InterfaceName string `gorm:"index"`
+	Type          string
+	CycleStart    time.Time
+	Threshold     int
+	UsedBytes     uint64
+	LimitBytes    uint64
+	Action        string
+	Detail        string
+	CreatedAt     time.Time `gorm:"index"`
+}
+
+func (quotaEventV7) TableName() string { return "quota_events" }
+
+// alertRuleV8 is the alert_rules table of migration 8
+type alertRuleV8 struct {
+	ID            uint   `gorm:"primaryKey"`
+	Name          string `gorm:"not null"`
+	Expression    string `gorm:"not null"`
+	RouterID      uint   `gorm:"index"`
+	InterfaceName string
+	Severity      string
+	Description   string
+	Enabled       bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (alertRuleV8) TableName() string { return "alert_rules" }
+
+// alertV8 is the alerts table of migration 8
+type alertV8 struct {
+	ID             uint `gorm:"primaryKey"`
+	RuleID         uint `gorm:"index"`
+	RuleName       string
+	Expression     string
+	Severity       string
+	RouterID       uint   `gorm:"index"`
+	InterfaceName  string `gorm:"index"`
+	State          string `gorm:"index"`
+	Value          float64
+	Message        string
+	StartsAt       time.Time
+	FiredAt        *time.Time
+	ResolvedAt     *time.Time
+	Acknowledged   bool
+	AcknowledgedBy string
+	AcknowledgedAt *time.Time
+	CreatedAt      time.Time `gorm:"index"`
+	UpdatedAt      time.Time
+}
+
+func (alertV8) TableName() string { return "alerts" }
+
+// alertV10 adds the suppressed column of migration 10
+type alertV10 struct {
+	Base       alertV8 `gorm:"embedded"`
+	Suppressed bool
+}
+
+func (alertV10) TableName() string { return "alerts" }
+
+// notificationChannelV9 is the notification_channels table of migration 9
+type notificationChannelV9 struct {
+	ID              uint   `gorm:"primaryKey"`
+	Name            string `gorm:"uniqueIndex;not null"`
+	Type            string `gorm:"not null"`
+	Enabled         bool
+	URL             string
+	Secret          string
+	TelegramToken   string
+	TelegramChatID  string
+	SMTPHost        string
+	SMTPPort        int
+	SMTPUsername    string
+	SMTPPassword    string
+	SMTPFrom        string
+	SMTPTo          string
+	SMTPTLS         string
+	SubjectTemplate string
+	BodyTemplate    string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (notificationChannelV9) TableName() string { return "notification_channels" }
+
+// notificationRouteV9 is the notification_routes table of migration 9
+type notificationRouteV9 struct {
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"not null"`
+	ChannelID   uint   `gorm:"index;not null"`
+	Events      string
+	MinSeverity string
+	RouterID    uint
+	Enabled     bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (notificationRouteV9) TableName() string { return "notification_routes" }
+
+// notificationDeliveryV9 is the notification_deliveries table of migration 9
+type notificationDeliveryV9 struct {
+	ID          uint `gorm:"primaryKey"`
+	ChannelID   uint `gorm:"index"`
+	ChannelName string
+	Event       string `gorm:"index"`
+	Severity    string
+	Title       string
+	Status      string `gorm:"index"`
+	Attempts    int
+	Error       string
+	SentAt      *time.Time
+	CreatedAt   time.Time `gorm:"index"`
+}
+
+func (notificationDeliveryV9) TableName() string { return "notification_deliveries" }
+
+// maintenanceWindowV10 is the maintenance_windows table of migration 10
+type maintenanceWindowV10 struct {
+	ID            uint   `gorm:"primaryKey"`
+	Name          string `gorm:"not null"`
+	RouterID      uint   `gorm:"index"`
+	InterfaceName string
+	StartsAt      time.Time
+	Duration      time.Duration
+	Recurrence    string
+	RecurUntil    *time.Time
+	Timezone      string
+	Comment       string
+	Enabled       bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (maintenanceWindowV10) TableName() string { return "maintenance_windows" }
+
+// silenceV10 is the silences table of migration 10
+type silenceV10 struct {
+	ID            uint `gorm:"primaryKey"`
+	RouterID      uint `gorm:"index"`
+	InterfaceName string
+	RuleID        uint
+	StartsAt      time.Time
+	EndsAt        time.Time `gorm:"index"`
+	CreatedBy     string
+	Comment       string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (silenceV10) TableName() string { return "silences" }
+
+// routerOutageV12 is the router_outages table of migration 12
+type routerOutageV12 struct {
+	ID          uint       `gorm:"primaryKey"`
+	RouterID    uint       `gorm:"index"`
+	StartedAt   time.Time  `gorm:"index"`
+	EndedAt     *time.Time `gorm:"index"`
+	FailedPolls int
+	Error       string
+	ErrorKind   string
+	Maintenance bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (routerOutageV12) TableName() string { return "router_outages" }
+
+// userV13 is the users table of migration 13
+type userV13 struct {
+	ID           uint   `gorm:"primaryKey"`
+	Username     string `gorm:"uniqueIndex;not null"`
+	PasswordHash string `gorm:"not null"`
+	Disabled     bool
+	LastLoginAt  *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (userV13) TableName() string { return "users" }
+
+// userV14 adds the role column of migration 14
+type userV14 struct {
+	Base userV13 `gorm:"embedded"`
+	Role string  `gorm:"not null;default:viewer"`
+}
+
+func (userV14) TableName() string { return "users" }
+
+// refreshTokenV13 is the refresh_tokens table of migration 13
+type refreshTokenV13 struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"index"`
+	TokenID   string    `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"index"`
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+func (refreshTokenV13) TableName() string { return "refresh_tokens" }
+
+// apiKeyV13 is the api_keys table of migration 13
+type apiKeyV13 struct {
+	ID         uint `gorm:"primaryKey"`
+	UserID     uint `gorm:"index"`
+	Name       string
+	Prefix     string
+	KeyHash    string `gorm:"uniqueIndex;not null"`
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+func (apiKeyV13) TableName() string { return "api_keys" }
+
+// userScopeV14 is the user_scopes table of migration 14
+type userScopeV14 struct {
+	ID            uint `gorm:"primaryKey"`
+	UserID        uint `gorm:"index"`
+	RouterID      uint `gorm:"not null"`
+	InterfaceName string
+}
+
+func (userScopeV14) TableName() string { return "user_scopes" }
+
+// auditEntryV15 is the audit_entries table of migration 15
+type auditEntryV15 struct {
+	ID        uint      `gorm:"primaryKey"`
+	Timestamp time.Time `gorm:"index;not null"`
+	Actor     string    `gorm:"index"`
+	ActorID   uint
+	IP        string
+	Action    string `gorm:"index"`
+	Resource  string
+	Outcome   string `gorm:"index"`
+	Detail    string
+	PrevHash  string
+	Hash      string `gorm:"uniqueIndex;not null"`
+}
+
+func (auditEntryV15) TableName() string { return "audit_entries" }
@@ -0,0 +1,541 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"monik-enterprise/internal/config"
+	"monik-enterprise/internal/models"
+	appLogger "monik-enterprise/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// DefaultRouterName is the registry name of the router seeded from ROUTER_* settings
+const DefaultRouterName = "default"
+
+// Migration is one versioned schema or data change. Up and Down run inside a
+// transaction together with the schema_migrations bookkeeping.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error // nil when the migration cannot be reverted
+}
+
+// MigrationStatus describes whether a migration has been applied
+type MigrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+// errDryRun rolls back the transaction of a dry-run migration
+var errDryRun = errors.New("dry run")
+
+// Migrator applies and reverts the ordered migration list
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+	DryRun     bool // roll all steps back after running them
+}
+
+// NewMigrator creates a migrator for the migrations of this release. The router
+// defaults and the admin username are used by data migrations that seed the default
+// router and assign roles.
+func NewMigrator(db *gorm.DB, cfg *config.Config) *Migrator {
+	list := migrations(cfg.Router, cfg.Auth.AdminUsername)
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return &Migrator{db: db, migrations: list}
+}
+
+// RunMigrations applies all pending migrations and the optional TimescaleDB setup
+func RunMigrations(db *gorm.DB, cfg *config.Config) error {
+	appLogger.Info("Running database migrations...")
+
+	applied, err := NewMigrator(db, cfg).Up(0)
+	if err != nil {
+		appLogger.Error("Failed to run migrations: %v", err)
+		return err
+	}
+	if err := EnsureTimescale(db, cfg.Database); err != nil {
+		appLogger.Error("Failed to set up TimescaleDB hypertable: %v", err)
+		return err
+	}
+
+	appLogger.Info("Database migrations completed successfully (%d applied)", applied)
+	return nil
+}
+
+// EnsureTimescale converts traffic_snapshots into a hypertable when DB_TIMESCALE is set
+func EnsureTimescale(db *gorm.DB, cfg config.DatabaseConfig) error {
+	if !cfg.Timescale {
+		return nil
+	}
+	return ensureHypertable(db, cfg.TimescaleChunkTime)
+}
+
+// Status lists every known migration together with its applied state
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied yet, oldest first
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies pending migrations up to and including target (0 means all) and returns
+// how many were applied. It stops at the first failure; earlier steps stay committed.
+func (m *Migrator) Up(target int64) (int, error) {
+	pending, err := m.Pending()
+	if err != nil {
+		return 0, err
+	}
+	var steps []Migration
+	for _, migration := range pending {
+		if target > 0 && migration.Version > target {
+			break
+		}
+		steps = append(steps, migration)
+	}
+	return m.apply(steps, "up", func(tx *gorm.DB, migration Migration) error {
+		if err := migration.Up(tx); err != nil {
+			return err
+		}
+		return tx.Create(&models.SchemaMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: time.Now(),
+		}).Error
+	})
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns how many
+// were reverted
+func (m *Migrator) Down(steps int) (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	var revert []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(revert) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == nil {
+			return 0, fmt.Errorf("migration %d_%s cannot be reverted", migration.Version, migration.Name)
+		}
+		revert = append(revert, migration)
+	}
+	return m.apply(revert, "down", func(tx *gorm.DB, migration Migration) error {
+		if err := migration.Down(tx); err != nil {
+			return err
+		}
+		return tx.Delete(&models.SchemaMigration{}, migration.Version).Error
+	})
+}
+
+// apply runs each migration step in its own transaction. A dry run wraps all steps in
+// one outer transaction that is rolled back at the end, so later steps see the effect
+// of earlier ones while the database stays unchanged.
+func (m *Migrator) apply(steps []Migration, direction string, step func(tx *gorm.DB, migration Migration) error) (int, error) {
+	mode := ""
+	if m.DryRun {
+		mode = " (dry run)"
+	}
+
+	count := 0
+	runAll := func(db *gorm.DB) error {
+		for _, migration := range steps {
+			appLogger.Info("Migrating %s %d_%s%s", direction, migration.Version, migration.Name, mode)
+			err := db.Transaction(func(tx *gorm.DB) error {
+				return step(tx, migration)
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s %s failed: %w", migration.Version, migration.Name, direction, err)
+			}
+			count++
+		}
+		return nil
+	}
+
+	if !m.DryRun {
+		return count, runAll(m.db)
+	}
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if err := runAll(tx); err != nil {
+			return err
+		}
+		return errDryRun
+	})
+	if errors.Is(err, errDryRun) {
+		err = nil
+	}
+	return count, err
+}
+
+// applied loads the schema_migrations records keyed by version
+func (m *Migrator) applied() (map[int64]models.SchemaMigration, error) {
+	if err := m.db.AutoMigrate(&models.SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	var records []models.SchemaMigration
+	if err := m.db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]models.SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// NewDefaultRouter builds the registry entry of the router configured through ROUTER_*
+func NewDefaultRouter(cfg config.RouterConfig) models.Router {
+	return models.Router{
+		Name:     DefaultRouterName,
+		Host:     cfg.IP,
+		Port:     cfg.Port,
+		Username: cfg.Username,
+		Password: cfg.Password,
+		Timeout:  cfg.Timeout,
+		Enabled:  true,
+		Backend:  cfg.Backend,
+		Status:   "unknown",
+
+		TLSEnabled:            cfg.TLS.Enabled,
+		TLSCAFile:             cfg.TLS.CAFile,
+		TLSCertFile:           cfg.TLS.CertFile,
+		TLSKeyFile:            cfg.TLS.KeyFile,
+		TLSServerName:         cfg.TLS.ServerName,
+		TLSInsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
+
+		SNMPVersion:      cfg.SNMP.Version,
+		SNMPCommunity:    cfg.SNMP.Community,
+		SNMPUsername:     cfg.SNMP.Username,
+		SNMPAuthProtocol: cfg.SNMP.AuthProtocol,
+		SNMPAuthPassword: cfg.SNMP.AuthPassword,
+		SNMPPrivProtocol: cfg.SNMP.PrivProtocol,
+		SNMPPrivPassword: cfg.SNMP.PrivPassword,
+	}
+}
+
+// routerScopedModels are the tables that carried rows before routers were registered
+var routerScopedModels = []interface{}{
+	&interfaceV1{},
+	&trafficSnapshotV1{},
+	&counterResetLogV1{},
+	&monthlyQuotaV1{},
+	&systemInfoV1{},
+}
+
+// migrations returns the ordered migration list. Versions are never reused; append new
+// migrations at the end. Schema steps are written to be safe on databases that were
+// created by the AutoMigrate-only releases, and work on the snapshots in
+// migration_schema.go rather than on internal/models.
+func migrations(routerDefaults config.RouterConfig, adminUsername string) []Migration {
+	return []Migration{
+		{
+			Version: 1,
+			Name:    "initial_schema",
+			Up: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(
+					&routerV1{},
+					&interfaceV1{},
+					&trafficSnapshotV1{},
+					&counterResetLogV1{},
+					&monthlyQuotaV1{},
+					&systemInfoV1{},
+				)
+			},
+			Down: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable(
+					&systemInfoV1{},
+					&monthlyQuotaV1{},
+					&counterResetLogV1{},
+					&trafficSnapshotV1{},
+					&interfaceV1{},
+					&routerV1{},
+				)
+			},
+		},
+		{
+			// Interface names are only unique per router now
+			Version: 2,
+			Name:    "drop_legacy_interface_name_index",
+			Up: func(tx *gorm.DB) error {
+				if tx.Migrator().HasIndex(&interfaceV1{}, "idx_interfaces_interface_name") {
+					return tx.Migrator().DropIndex(&interfaceV1{}, "idx_interfaces_interface_name")
+				}
+				return nil
+			},
+			// The single-column index would reject interfaces shared by several routers,
+			// so it is not restored
+			Down: func(tx *gorm.DB) error { return nil },
+		},
+		{
+			// Rows written before the router registry existed have router_id = 0 and are
+			// adopted by the first router, which is seeded from ROUTER_* when needed
+			Version: 3,
+			Name:    "backfill_router_ids",
+			Up: func(tx *gorm.DB) error {
+				var orphans int64
+				for _, model := range routerScopedModels {
+					var count int64
+					if err := tx.Model(model).Where("router_id = ?", 0).Count(&count).Error; err != nil {
+						return err
+					}
+					orphans += count
+				}
+				if orphans == 0 {
+					return nil
+				}
+
+				var router routerV1
+				err := tx.Order("id ASC").First(&router).Error
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					router = defaultRouterV1(routerDefaults)
+					err = tx.Create(&router).Error
+				}
+				if err != nil {
+					return err
+				}
+
+				for _, model := range routerScopedModels {
+					if err := tx.Model(model).Where("router_id = ?", 0).Update("router_id", router.ID).Error; err != nil {
+						return err
+					}
+				}
+				appLogger.Info("Backfilled %d rows with router id %d", orphans, router.ID)
+				return nil
+			},
+			// Router ownership cannot be forgotten safely; reverting is a no-op
+			Down: func(tx *gorm.DB) error { return nil },
+		},
+		{
+			Version: 4,
+			Name:    "traffic_rollups",
+			Up: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(
+					&trafficRollup1mV4{},
+					&trafficRollup1hV4{},
+					&trafficRollup1dV4{},
+				)
+			},
+			Down: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable(
+					&trafficRollup1mV4{},
+					&trafficRollup1hV4{},
+					&trafficRollup1dV4{},
+				)
+			},
+		},
+		{
+			Version: 5,
+			Name:    "activity_logs",
+			Up: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(
+					&wanInterfaceLogV5{},
+					&workerMetricsLogV5{},
+					&webSocketConnectionLogV5{},
+				)
+			},
+			Down: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable(
+					&wanInterfaceLogV5{},
+					&workerMetricsLogV5{},
+					&webSocketConnectionLogV5{},
+				)
+			},
+		},
+		{
+			Version: 6,
+			Name:    "billing_cycles",
+			Up: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(&billingCycleV6{})
+			},
+			Down: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable(&billingCycleV6{})
+			},
+		},
+		{
+			Version: 7,
+			Name:    "quota_limits",
+			Up: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(&quotaLimitV7{}, &quotaEventV7{})
+			},
+			Down: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable(&quotaEventV7{}, &quotaLimitV7{})
+			},
+		},
+		{
+			Version: 8,
+			Name:    "alerts",
+			Up: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(&alertRuleV8{}, &alertV8{})
+			},
+			Down: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable(&alertV8{}, &alertRuleV8{})
+			},
+		},
+		{
+			Version: 9,
+			Name:    "notifications",
+			Up: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(
+					&notificationChannelV9{},
+					&notificationRouteV9{},
+					&notificationDeliveryV9{},
+				)
+			},
+			Down: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable(
+					&notificationDeliveryV9{},
+					&notificationRouteV9{},
+					&notificationChannelV9{},
+				)
+			},
+		},
+		{
+			Version: 10,
+			Name:    "maintenance_windows",
+			Up: func(tx *gorm.DB) error {
+				// Alert gains the suppressed column
+				return tx.AutoMigrate(&maintenanceWindowV10{}, &silenceV10{}, &alertV10{})
+			},
+			Down: func(tx *gorm.DB) error {
+				if tx.Migrator().HasColumn(&alertV10{}, "suppressed") {
+					if err := tx.Migrator().DropColumn(&alertV10{}, "suppressed"); err != nil {
+						return err
+					}
+				}
+				return tx.Migrator().DropTable(&silenceV10{}, &maintenanceWindowV10{})
+			},
+		},
+		{
+			Version: 11,
+			Name:    "counter_reset_kinds",
+			Up: func(tx *gorm.DB) error {
+				// Interface gains object_id and counters_at, TrafficSnapshot gains reset_kind
+				if err := tx.AutoMigrate(&interfaceV11{}, &trafficSnapshotV11{}); err != nil {
+					return err
+				}
+				// Existing readings were taken no later than they were last seen
+				return tx.Exec("UPDATE interfaces SET counters_at = last_seen WHERE counters_at IS NULL").Error
+			},
+			Down: func(tx *gorm.DB) error {
+				for _, column := range []string{"object_id", "counters_at"} {
+					if tx.Migrator().HasColumn(&interfaceV11{}, column) {
+						if err := tx.Migrator().DropColumn(&interfaceV11{}, column); err != nil {
+							return err
+						}
+					}
+				}
+				if tx.Migrator().HasColumn(&trafficSnapshotV11{}, "reset_kind") {
+					return tx.Migrator().DropColumn(&trafficSnapshotV11{}, "reset_kind")
+				}
+				return nil
+			},
+		},
+		{
+			Version: 12,
+			Name:    "router_outages",
+			Up: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(&routerOutageV12{})
+			},
+			Down: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable(&routerOutageV12{})
+			},
+		},
+		{
+			Version: 13,
+			Name:    "auth",
+			Up: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(&userV13{}, &refreshTokenV13{}, &apiKeyV13{})
+			},
+			Down: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable(&apiKeyV13{}, &refreshTokenV13{}, &userV13{})
+			},
+		},
+		{
+			Version: 14,
+			Name:    "rbac",
+			Up: func(tx *gorm.DB) error {
+				// User gains role
+				if err := tx.AutoMigrate(&userV14{}, &userScopeV14{}); err != nil {
+					return err
+				}
+				// Only the configured admin account keeps the full access every user had
+				// before roles existed. The others get the least-privileged role, customer
+				// without scopes, until an admin assigns them one.
+				if err := tx.Exec("UPDATE users SET role = ?", "customer").Error; err != nil {
+					return err
+				}
+				result := tx.Exec("UPDATE users SET role = ? WHERE username = ?", "admin", adminUsername)
+				if result.Error != nil {
+					return result.Error
+				}
+				if result.RowsAffected == 0 {
+					// The admin account was renamed; the first account is the one created
+					// as admin, so it is kept from locking everyone out
+					result = tx.Exec("UPDATE users SET role = ? WHERE id = (SELECT MIN(id) FROM users)", "admin")
+					if result.Error != nil {
+						return result.Error
+					}
+					if result.RowsAffected > 0 {
+						appLogger.Warn("Admin user %q not found, kept the first user as admin", adminUsername)
+					}
+				}
+				return nil
+			},
+			Down: func(tx *gorm.DB) error {
+				if err := tx.Migrator().DropTable(&userScopeV14{}); err != nil {
+					return err
+				}
+				if tx.Migrator().HasColumn(&userV14{}, "role") {
+					return tx.Migrator().DropColumn(&userV14{}, "role")
+				}
+				return nil
+			},
+		},
+		{
+			Version: 15,
+			Name:    "audit_log",
+			Up: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(&auditEntryV15{})
+			},
+			Down: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable(&auditEntryV15{})
+			},
+		},
+	}
+}
@@ -0,0 +1,188 @@
+package database
+
+import (
+	"testing"
+
+	"monik-enterprise/internal/config"
+	"monik-enterprise/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// schemaModels are the models the application reads and writes. Every column of them
+// must be created by a migration.
+var schemaModels = []interface{}{
+	&models.Router{},
+	&models.Interface{},
+	&models.TrafficSnapshot{},
+	&models.TrafficRollup1m{},
+	&models.TrafficRollup1h{},
+	&models.TrafficRollup1d{},
+	&models.CounterResetLog{},
+	&models.MonthlyQuota{},
+	&models.BillingCycle{},
+	&models.QuotaLimit{},
+	&models.QuotaEvent{},
+	&models.AlertRule{},
+	&models.Alert{},
+	&models.MaintenanceWindow{},
+	&models.Silence{},
+	&models.RouterOutage{},
+	&models.User{},
+	&models.UserScope{},
+	&models.RefreshToken{},
+	&models.APIKey{},
+	&models.AuditEntry{},
+	&models.NotificationChannel{},
+	&models.NotificationRoute{},
+	&models.NotificationDelivery{},
+	&models.SystemInfo{},
+	&models.WANInterfaceLog{},
+	&models.WorkerMetricsLog{},
+	&models.WebSocketConnectionLog{},
+}
+
+func TestMigrationsCreateModelColumns(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+		if _, err := NewMigrator(db, &config.Config{}).Up(0); err != nil {
+			t.Fatalf("Up: %v", err)
+		}
+		assertModelColumns(t, db)
+	})
+}
+
+func TestMigrationsRevertAndReapply(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+		migrator := NewMigrator(db, &config.Config{})
+		applied, err := migrator.Up(0)
+		if err != nil {
+			t.Fatalf("Up: %v", err)
+		}
+
+		reverted, err := migrator.Down(applied)
+		if err != nil {
+			t.Fatalf("Down: %v", err)
+		}
+		if reverted != applied {
+			t.Fatalf("reverted %d of %d migrations", reverted, applied)
+		}
+		for _, model := range schemaModels {
+			if db.Migrator().HasTable(model) {
+				t.Errorf("table of %T left after reverting every migration", model)
+			}
+		}
+
+		if _, err := migrator.Up(0); err != nil {
+			t.Fatalf("Up after Down: %v", err)
+		}
+		assertModelColumns(t, db)
+	})
+}
+
+func TestMigrationBackfillsRouterIDs(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+		migrator := NewMigrator(db, &config.Config{Router: config.RouterConfig{IP: "192.0.2.1", Port: 8728}})
+		if _, err := migrator.Up(2); err != nil {
+			t.Fatalf("Up to 2: %v", err)
+		}
+		// A row written before the router registry existed
+		if err := db.Exec("INSERT INTO interfaces (router_id, interface_name) VALUES (0, 'ether1')").Error; err != nil {
+			t.Fatal(err)
+		}
+		if _, err := migrator.Up(0); err != nil {
+			t.Fatalf("Up: %v", err)
+		}
+
+		var router models.Router
+		if err := db.First(&router).Error; err != nil {
+			t.Fatalf("default router not seeded: %v", err)
+		}
+		if router.Name != DefaultRouterName || router.Host != "192.0.2.1" {
+			t.Errorf("seeded router = %s at %s", router.Name, router.Host)
+		}
+		var iface models.Interface
+		if err := db.First(&iface).Error; err != nil {
+			t.Fatal(err)
+		}
+		if iface.RouterID != router.ID {
+			t.Errorf("interface router id = %d, want %d", iface.RouterID, router.ID)
+		}
+	})
+}
+
+func TestMigrationAssignsRoles(t *testing.T) {
+	tests := []struct {
+		name      string
+		users     []string
+		wantRoles map[string]string
+	}{
+		{
+			name:      "configured admin",
+			users:     []string{"noc", "admin", "billing"},
+			wantRoles: map[string]string{"noc": "customer", "admin": "admin", "billing": "customer"},
+		},
+		{
+			name:      "configured admin renamed",
+			users:     []string{"root", "noc"},
+			wantRoles: map[string]string{"root": "admin", "noc": "customer"},
+		},
+		{name: "no users", wantRoles: map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachDriver(t, func(t *testing.T, db *gorm.DB) {
+				cfg := &config.Config{Auth: config.AuthConfig{AdminUsername: "admin"}}
+				migrator := NewMigrator(db, cfg)
+				if _, err := migrator.Up(13); err != nil {
+					t.Fatalf("Up to 13: %v", err)
+				}
+				for _, username := range tt.users {
+					if err := db.Exec("INSERT INTO users (username, password_hash) VALUES (?, 'x')", username).Error; err != nil {
+						t.Fatal(err)
+					}
+				}
+				if _, err := migrator.Up(0); err != nil {
+					t.Fatalf("Up: %v", err)
+				}
+
+				var users []models.User
+				if err := db.Find(&users).Error; err != nil {
+					t.Fatal(err)
+				}
+				if len(users) != len(tt.wantRoles) {
+					t.Fatalf("found %d users, want %d", len(users), len(tt.wantRoles))
+				}
+				for _, user := range users {
+					if want := tt.wantRoles[user.Username]; user.Role != want {
+						t.Errorf("%s has role %q, want %q", user.Username, user.Role, want)
+					}
+				}
+			})
+		})
+	}
+}
+
+// assertModelColumns fails for every table or column of schemaModels the migrations did
+// not create
+func assertModelColumns(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	for _, model := range schemaModels {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			t.Fatalf("parse %T: %v", model, err)
+		}
+		if !db.Migrator().HasTable(model) {
+			t.Errorf("no migration creates table %s", stmt.Schema.Table)
+			continue
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			if !db.Migrator().HasColumn(model, field.DBName) {
+				t.Errorf("no migration creates column %s.%s", stmt.Schema.Table, field.DBName)
+			}
+		}
+	}
+}
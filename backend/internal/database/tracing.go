@@ -0,0 +1,97 @@
+package database
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// tracingSpanKey stores the span of a statement between the before and after callbacks
+const tracingSpanKey = "monik:tracing_span"
+
+// tracer starts the database spans, it follows the global tracer provider
+var tracer = otel.Tracer("monik-enterprise/internal/database")
+
+// Tracing is a GORM plugin recording a span for every statement run with a context that
+// already carries a span, e.g. db.WithContext(ctx) inside a collection cycle. Statements
+// without one are not traced, so background queries do not start traces of their own.
+type Tracing struct{}
+
+// Name returns the plugin name
+func (Tracing) Name() string {
+	return "monik:tracing"
+}
+
+// Initialize registers the span callbacks around every statement type
+func (Tracing) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	hooks := []struct {
+		operation string
+		before    func(string, func(*gorm.DB)) error
+		after     func(string, func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("gorm:create").Register, callbacks.Create().After("gorm:create").Register},
+		{"query", callbacks.Query().Before("gorm:query").Register, callbacks.Query().After("gorm:query").Register},
+		{"update", callbacks.Update().Before("gorm:update").Register, callbacks.Update().After("gorm:update").Register},
+		{"delete", callbacks.Delete().Before("gorm:delete").Register, callbacks.Delete().After("gorm:delete").Register},
+		{"row", callbacks.Row().Before("gorm:row").Register, callbacks.Row().After("gorm:row").Register},
+		{"raw", callbacks.Raw().Before("gorm:raw").Register, callbacks.Raw().After("gorm:raw").Register},
+	}
+	for _, hook := range hooks {
+		if err := hook.before("monik:tracing_before_"+hook.operation, startStatementSpan(hook.operation)); err != nil {
+			return err
+		}
+		if err := hook.after("monik:tracing_after_"+hook.operation, endStatementSpan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startStatementSpan returns the callback starting the span of a statement
+func startStatementSpan(operation string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		ctx := tx.Statement.Context
+		if ctx == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+			return
+		}
+		name := "db." + operation
+		if tx.Statement.Table != "" {
+			name += " " + tx.Statement.Table
+		}
+		_, span := tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system.name", tx.Dialector.Name()),
+				attribute.String("db.operation.name", operation),
+				attribute.String("db.collection.name", tx.Statement.Table),
+			),
+		)
+		tx.InstanceSet(tracingSpanKey, span)
+	}
+}
+
+// endStatementSpan ends the span of a statement, recording the query and its error
+func endStatementSpan(tx *gorm.DB) {
+	value, ok := tx.InstanceGet(tracingSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	span.SetAttributes(
+		attribute.String("db.query.text", tx.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", tx.Statement.RowsAffected),
+	)
+	if err := tx.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -1,19 +1,57 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// Router represents a monitored MikroTik router in the fleet registry
+type Router struct {
+	ID                    uint           `json:"id" gorm:"primaryKey"`
+	Name                  string         `json:"name" gorm:"uniqueIndex;not null"`
+	Host                  string         `json:"host" gorm:"not null"`
+	Port                  int            `json:"port"`
+	Username              string         `json:"username"`
+	Password              string         `json:"-"`
+	Timeout               time.Duration  `json:"timeout"`
+	Enabled               bool           `json:"enabled"`
+	PollInterval          time.Duration  `json:"poll_interval"` // 0 uses POLL_INTERVAL
+	Backend               string         `json:"backend"`       // api, rest, snmp
+	TLSEnabled            bool           `json:"tls_enabled"`   // RouterOS API-SSL / HTTPS settings
+	TLSCAFile             string         `json:"tls_ca_file"`
+	TLSCertFile           string         `json:"tls_cert_file"`
+	TLSKeyFile            string         `json:"tls_key_file"`
+	TLSServerName         string         `json:"tls_server_name"`
+	TLSInsecureSkipVerify bool           `json:"tls_insecure_skip_verify"`
+	SNMPVersion           string         `json:"snmp_version"` // v2c, v3
+	SNMPCommunity         string         `json:"-"`
+	SNMPUsername          string         `json:"snmp_username"`
+	SNMPAuthProtocol      string         `json:"snmp_auth_protocol"`
+	SNMPAuthPassword      string         `json:"-"`
+	SNMPPrivProtocol      string         `json:"snmp_priv_protocol"`
+	SNMPPrivPassword      string         `json:"-"`
+	Status                string         `json:"status"` // online, offline, unknown
+	LastSeen              time.Time      `json:"last_seen"`
+	LastError             string         `json:"last_error"`
+	Comment               string         `json:"comment"`
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+	DeletedAt             gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
 // Interface represents a network interface
 type Interface struct {
 	ID                uint           `json:"id" gorm:"primaryKey"`
-	InterfaceName     string         `json:"interface_name" gorm:"uniqueIndex;not null"`
+	RouterID          uint           `json:"router_id" gorm:"uniqueIndex:idx_router_interface;not null;default:0"`
+	InterfaceName     string         `json:"interface_name" gorm:"uniqueIndex:idx_router_interface;not null"`
+	ObjectID          string         `json:"object_id"` // RouterOS .id or SNMP ifIndex
 	RxBytes           uint64         `json:"rx_bytes"`
 	TxBytes           uint64         `json:"tx_bytes"`
-	RxRate            float64        `json:"rx_rate"` // Mbps
-	TxRate            float64        `json:"tx_rate"` // Mbps
+	RxRate            float64        `json:"rx_rate"`     // Mbps
+	TxRate            float64        `json:"tx_rate"`     // Mbps
+	CountersAt        time.Time      `json:"counters_at"` // When RxBytes/TxBytes were read, kept while offline
 	LastSeen          time.Time      `json:"last_seen"`
 	CounterResetCount int            `json:"counter_reset_count"`
 	Status            string         `json:"status"` // up, down, unknown
@@ -26,6 +64,7 @@ type Interface struct {
 // TrafficSnapshot stores historical traffic data
 type TrafficSnapshot struct {
 	ID            uint           `json:"id" gorm:"primaryKey"`
+	RouterID      uint           `json:"router_id" gorm:"index"`
 	InterfaceName string         `json:"interface_name" gorm:"index"`
 	Timestamp     time.Time      `json:"timestamp" gorm:"index"`
 	RxBytes       uint64         `json:"rx_bytes"`
@@ -34,19 +73,73 @@ type TrafficSnapshot struct {
 	TxRate        float64        `json:"tx_rate"` // Mbps
 	TotalBytes    uint64         `json:"total_bytes"`
 	CounterReset  bool           `json:"counter_reset"`
+	ResetKind     string         `json:"reset_kind,omitempty"` // Why the counters went backwards, see CounterResetLog
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// TrafficRollup holds aggregated traffic of one interface over one bucket. It is
+// embedded by the per-resolution rollup tables below.
+type TrafficRollup struct {
+	ID            uint      `json:"-" gorm:"primaryKey"`
+	RouterID      uint      `json:"router_id" gorm:"uniqueIndex:,composite:bucket;not null"`
+	InterfaceName string    `json:"interface_name" gorm:"uniqueIndex:,composite:bucket;not null"`
+	BucketStart   time.Time `json:"bucket_start" gorm:"uniqueIndex:,composite:bucket;not null;index"`
+	Samples       int       `json:"samples"`
+	RxRateMin     float64   `json:"rx_rate_min"` // Mbps
+	RxRateAvg     float64   `json:"rx_rate_avg"` // Mbps
+	RxRateMax     float64   `json:"rx_rate_max"` // Mbps
+	RxRateP95     float64   `json:"rx_rate_p95"` // Mbps
+	TxRateMin     float64   `json:"tx_rate_min"` // Mbps
+	TxRateAvg     float64   `json:"tx_rate_avg"` // Mbps
+	TxRateMax     float64   `json:"tx_rate_max"` // Mbps
+	TxRateP95     float64   `json:"tx_rate_p95"` // Mbps
+	RxBytes       uint64    `json:"rx_bytes"`    // Bytes transferred within the bucket
+	TxBytes       uint64    `json:"tx_bytes"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TrafficRollup1m aggregates raw snapshots per minute
+type TrafficRollup1m struct {
+	TrafficRollup
+}
+
+// TableName overrides the table name for TrafficRollup1m
+func (TrafficRollup1m) TableName() string {
+	return "traffic_rollups_1m"
+}
+
+// TrafficRollup1h aggregates 1-minute rollups per hour
+type TrafficRollup1h struct {
+	TrafficRollup
+}
+
+// TableName overrides the table name for TrafficRollup1h
+func (TrafficRollup1h) TableName() string {
+	return "traffic_rollups_1h"
+}
+
+// TrafficRollup1d aggregates 1-minute rollups per day (UTC)
+type TrafficRollup1d struct {
+	TrafficRollup
+}
+
+// TableName overrides the table name for TrafficRollup1d
+func (TrafficRollup1d) TableName() string {
+	return "traffic_rollups_1d"
+}
+
 // CounterResetLog tracks counter reset events
 type CounterResetLog struct {
 	ID              uint           `json:"id" gorm:"primaryKey"`
+	RouterID        uint           `json:"router_id" gorm:"index"`
 	InterfaceName   string         `json:"interface_name" gorm:"index"`
 	ResetTime       time.Time      `json:"reset_time" gorm:"index"`
 	PreviousBytes   uint64         `json:"previous_bytes"`
 	NewBytes        uint64         `json:"new_bytes"`
-	DetectionMethod string         `json:"detection_method"` // sudden_drop, manual_reset, etc.
+	DetectionMethod string         `json:"detection_method"` // reboot, manual_reset, interface_recreated, counter_wrap or sudden_drop (unclassified)
 	Notes           string         `json:"notes"`
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
@@ -56,6 +149,7 @@ type CounterResetLog struct {
 // MonthlyQuota tracks monthly data usage quotas
 type MonthlyQuota struct {
 	ID            uint           `json:"id" gorm:"primaryKey"`
+	RouterID      uint           `json:"router_id" gorm:"index"`
 	InterfaceName string         `json:"interface_name" gorm:"index"`
 	Month         int            `json:"month" gorm:"index"`
 	Year          int            `json:"year" gorm:"index"`
@@ -73,9 +167,279 @@ type MonthlyQuota struct {
 	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// BillingCycle defines the billing period of an interface. Interfaces without a row use
+// the BILLING_* defaults.
+type BillingCycle struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	RouterID      uint      `json:"router_id" gorm:"uniqueIndex:idx_billing_cycle_interface;not null"`
+	InterfaceName string    `json:"interface_name" gorm:"uniqueIndex:idx_billing_cycle_interface;not null"`
+	StartDay      int       `json:"start_day"`   // Day of month a monthly cycle starts, clamped to short months
+	Timezone      string    `json:"timezone"`    // IANA zone the cycle boundaries are evaluated in
+	Length        int       `json:"length"`      // Cycle length in Unit
+	Unit          string    `json:"unit"`        // month, day
+	AnchorDate    string    `json:"anchor_date"` // YYYY-MM-DD a cycle started on
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// QuotaLimit assigns a data limit per billing cycle to an interface together with the
+// evaluation state of the current cycle
+type QuotaLimit struct {
+	ID            uint   `json:"id" gorm:"primaryKey"`
+	RouterID      uint   `json:"router_id" gorm:"uniqueIndex:idx_quota_limit_interface;not null"`
+	InterfaceName string `json:"interface_name" gorm:"uniqueIndex:idx_quota_limit_interface;not null"`
+	LimitBytes    uint64 `json:"limit_bytes"`
+	Thresholds    string `json:"thresholds"`      // Comma separated percentages, empty uses QUOTA_THRESHOLDS
+	Action        string `json:"action"`          // none, queue, disable
+	QueueMaxLimit string `json:"queue_max_limit"` // max-limit of the enforcement queue, empty uses QUOTA_QUEUE_MAX_LIMIT
+	Enabled       bool   `json:"enabled"`
+
+	// State of the current billing cycle
+	CycleStart        time.Time  `json:"cycle_start"`
+	NotifiedThreshold int        `json:"notified_threshold"` // Highest threshold reported this cycle
+	Enforced          bool       `json:"enforced"`
+	EnforcedAction    string     `json:"enforced_action"` // Action applied on the router, lifted with the same action
+	EnforcedAt        *time.Time `json:"enforced_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// QuotaEvent is the audit trail of quota limits
+type QuotaEvent struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	RouterID      uint      `json:"router_id" gorm:"index"`
+	InterfaceName string    `json:"interface_name" gorm:"index"`
+	Type          string    `json:"type"` // limit_set, limit_removed, threshold, enforced, enforcement_failed, lifted, lift_failed
+	CycleStart    time.Time `json:"cycle_start"`
+	Threshold     int       `json:"threshold"`
+	UsedBytes     uint64    `json:"used_bytes"`
+	LimitBytes    uint64    `json:"limit_bytes"`
+	Action        string    `json:"action"`
+	Detail        string    `json:"detail"`
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+}
+
+// AlertRule is a condition evaluated against live interface and router metrics,
+// e.g. "rx_rate > 800 for 5m" or "router offline for 2m"
+type AlertRule struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	Name          string    `json:"name" gorm:"not null"`
+	Expression    string    `json:"expression" gorm:"not null"`
+	RouterID      uint      `json:"router_id" gorm:"index"` // 0 matches every router
+	InterfaceName string    `json:"interface_name"`         // Glob pattern, empty matches every interface
+	Severity      string    `json:"severity"`               // info, warning, critical
+	Description   string    `json:"description"`
+	Enabled       bool      `json:"enabled"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Alert is one occurrence of an alert rule for a router or interface. At most one
+// pending or firing alert exists per rule, router and interface.
+type Alert struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	RuleID         uint       `json:"rule_id" gorm:"index"`
+	RuleName       string     `json:"rule_name"`
+	Expression     string     `json:"expression"`
+	Severity       string     `json:"severity"`
+	RouterID       uint       `json:"router_id" gorm:"index"`
+	InterfaceName  string     `json:"interface_name" gorm:"index"` // Empty for router-level rules
+	State          string     `json:"state" gorm:"index"`          // pending, firing, resolved
+	Value          float64    `json:"value"`                       // Metric value of the last evaluation
+	Message        string     `json:"message"`
+	StartsAt       time.Time  `json:"starts_at"` // Condition first held
+	FiredAt        *time.Time `json:"fired_at"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+	Acknowledged   bool       `json:"acknowledged"`
+	AcknowledgedBy string     `json:"acknowledged_by"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+	Suppressed     bool       `json:"suppressed"` // Notifications held back by a maintenance window or silence
+	CreatedAt      time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// MaintenanceWindow is a planned period, one-off or recurring, during which alerts of a
+// router or interface are suppressed and history is marked as maintenance
+type MaintenanceWindow struct {
+	ID            uint          `json:"id" gorm:"primaryKey"`
+	Name          string        `json:"name" gorm:"not null"`
+	RouterID      uint          `json:"router_id" gorm:"index"` // 0 matches every router
+	InterfaceName string        `json:"interface_name"`         // Glob pattern, empty covers the whole router
+	StartsAt      time.Time     `json:"starts_at"`              // Start of the first occurrence
+	Duration      time.Duration `json:"duration"`
+	Recurrence    string        `json:"recurrence"`  // none, daily, weekly, monthly
+	RecurUntil    *time.Time    `json:"recur_until"` // No occurrences start after this time
+	Timezone      string        `json:"timezone"`    // IANA zone recurrences keep their wall clock time in
+	Comment       string        `json:"comment"`
+	Enabled       bool          `json:"enabled"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// Silence suppresses alerts of a router, interface or rule until it expires
+type Silence struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	RouterID      uint      `json:"router_id" gorm:"index"` // 0 matches every router
+	InterfaceName string    `json:"interface_name"`         // Glob pattern, empty covers the whole router
+	RuleID        uint      `json:"rule_id"`                // 0 matches every alert rule
+	StartsAt      time.Time `json:"starts_at"`
+	EndsAt        time.Time `json:"ends_at" gorm:"index"`
+	CreatedBy     string    `json:"created_by"`
+	Comment       string    `json:"comment"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// RouterOutage is a period in which a router could not be reached. EndedAt is nil while
+// the outage is ongoing.
+type RouterOutage struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	RouterID    uint       `json:"router_id" gorm:"index"`
+	StartedAt   time.Time  `json:"started_at" gorm:"index"` // First failed poll
+	EndedAt     *time.Time `json:"ended_at" gorm:"index"`   // First successful poll afterwards
+	FailedPolls int        `json:"failed_polls"`
+	Error       string     `json:"error"`       // Last error seen during the outage
+	ErrorKind   string     `json:"error_kind"`  // network, auth, tls_* (see service.ConnError*)
+	Maintenance bool       `json:"maintenance"` // Started during a maintenance window
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// User is a local account allowed to use the API
+type User struct {
+	ID           uint        `json:"id" gorm:"primaryKey"`
+	Username     string      `json:"username" gorm:"uniqueIndex;not null"`
+	PasswordHash string      `json:"-" gorm:"not null"`                   // bcrypt
+	Role         string      `json:"role" gorm:"not null;default:viewer"` // admin, operator, viewer, customer
+	Disabled     bool        `json:"disabled"`
+	LastLoginAt  *time.Time  `json:"last_login_at"`
+	Scopes       []UserScope `json:"scopes" gorm:"foreignKey:UserID"` // Routers and interfaces the user is limited to
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+// UserScope grants a user access to a router, or to interfaces of it
+type UserScope struct {
+	ID            uint   `json:"id" gorm:"primaryKey"`
+	UserID        uint   `json:"-" gorm:"index"`
+	RouterID      uint   `json:"router_id" gorm:"not null"`
+	InterfaceName string `json:"interface_name"` // glob pattern, empty covers the whole router
+}
+
+// RefreshToken tracks an issued refresh token so it can be rotated and revoked
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"index"`
+	TokenID   string     `json:"-" gorm:"uniqueIndex;not null"` // jti claim
+	ExpiresAt time.Time  `json:"expires_at" gorm:"index"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// APIKey is a long-lived credential for scripts, acting as the user that created it
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"index"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`                        // First characters of the key, to recognise it
+	KeyHash    string     `json:"-" gorm:"uniqueIndex;not null"` // SHA-256 of the key
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// AuditEntry is one record of the append-only audit trail. Each entry carries the hash
+// of the previous one, so a modified or removed entry breaks the chain.
+type AuditEntry struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Timestamp time.Time `json:"timestamp" gorm:"index;not null"`
+	Actor     string    `json:"actor" gorm:"index"` // Username, "anonymous" when not authenticated
+	ActorID   uint      `json:"actor_id"`
+	IP        string    `json:"ip"`
+	Action    string    `json:"action" gorm:"index"`  // e.g. login, "PUT /api/v1/quotas/:interface"
+	Resource  string    `json:"resource"`             // Request path or other affected object
+	Outcome   string    `json:"outcome" gorm:"index"` // success, failure
+	Detail    string    `json:"detail"`               // JSON metadata
+	PrevHash  string    `json:"prev_hash"`
+	Hash      string    `json:"hash" gorm:"uniqueIndex;not null"`
+}
+
+// ErrAuditAppendOnly is returned when an audit entry is updated or deleted
+var ErrAuditAppendOnly = errors.New("audit entries are append-only")
+
+// BeforeUpdate keeps the audit trail append-only
+func (AuditEntry) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditAppendOnly
+}
+
+// BeforeDelete keeps the audit trail append-only
+func (AuditEntry) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditAppendOnly
+}
+
+// NotificationChannel is a destination notifications are delivered to
+type NotificationChannel struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	Name    string `json:"name" gorm:"uniqueIndex;not null"`
+	Type    string `json:"type" gorm:"not null"` // smtp, webhook, telegram
+	Enabled bool   `json:"enabled"`
+
+	// Webhook target, or Telegram Bot API base URL (empty uses https://api.telegram.org)
+	URL    string `json:"url"`
+	Secret string `json:"-"` // Webhook HMAC-SHA256 signing key
+
+	TelegramToken  string `json:"-"`
+	TelegramChatID string `json:"telegram_chat_id"`
+
+	SMTPHost     string `json:"smtp_host"`
+	SMTPPort     int    `json:"smtp_port"`
+	SMTPUsername string `json:"smtp_username"`
+	SMTPPassword string `json:"-"`
+	SMTPFrom     string `json:"smtp_from"`
+	SMTPTo       string `json:"smtp_to"`  // Comma separated recipients
+	SMTPTLS      string `json:"smtp_tls"` // starttls, tls, none
+
+	// Go text/template rendered with the notification, empty uses the channel default
+	SubjectTemplate string `json:"subject_template"`
+	BodyTemplate    string `json:"body_template"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NotificationRoute sends the events matching its patterns to a channel
+type NotificationRoute struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"not null"`
+	ChannelID   uint      `json:"channel_id" gorm:"index;not null"`
+	Events      string    `json:"events"`       // Comma separated glob patterns, e.g. "wan_*,alert_firing"
+	MinSeverity string    `json:"min_severity"` // info, warning, critical
+	RouterID    uint      `json:"router_id"`    // 0 matches every router
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// NotificationDelivery records the outcome of delivering a notification to a channel
+type NotificationDelivery struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	ChannelID   uint       `json:"channel_id" gorm:"index"`
+	ChannelName string     `json:"channel_name"`
+	Event       string     `json:"event" gorm:"index"`
+	Severity    string     `json:"severity"`
+	Title       string     `json:"title"`
+	Status      string     `json:"status" gorm:"index"` // sent, failed
+	Attempts    int        `json:"attempts"`
+	Error       string     `json:"error"`
+	SentAt      *time.Time `json:"sent_at"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"index"`
+}
+
 // SystemInfo stores system information from the router
 type SystemInfo struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
+	RouterID    uint           `json:"router_id" gorm:"index"`
 	RouterName  string         `json:"router_name"`
 	BoardName   string         `json:"board_name"`
 	Version     string         `json:"version"`
@@ -140,3 +504,15 @@ type WebSocketConnectionLog struct {
 	UpdatedAt        time.Time      `json:"updated_at"`
 	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
 }
+
+// SchemaMigration records a versioned database migration that has been applied
+type SchemaMigration struct {
+	Version   int64     `json:"version" gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `json:"name" gorm:"not null"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// TableName overrides the table name for SchemaMigration
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
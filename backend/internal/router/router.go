@@ -1,59 +1,207 @@
 package router
 
 import (
+	"net/http"
+
 	"monik-enterprise/internal/api"
+	"monik-enterprise/internal/service"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-// SetupRoutes configures all API routes
-func SetupRoutes(handlers *api.Handlers) *gin.Engine {
+// untracedPaths are probe and scrape endpoints left out of traces, they run constantly
+var untracedPaths = map[string]bool{
+	"/health":  true,
+	"/ready":   true,
+	"/metrics": true,
+}
+
+// SetupRoutes configures all API routes. corsOrigins lists the browser origins allowed
+// to call the API, "*" allows any and an empty list disables CORS.
+func SetupRoutes(handlers *api.Handlers, corsOrigins []string) *gin.Engine {
 	r := gin.Default()
 
 	// CORS middleware
-	r.Use(cors.Default())
+	if len(corsOrigins) > 0 {
+		r.Use(cors.New(corsConfig(corsOrigins)))
+	}
+
+	// Trace every request, spans are dropped unless TRACING_ENABLED is set
+	r.Use(otelgin.Middleware("monik-enterprise", otelgin.WithFilter(func(req *http.Request) bool {
+		return !untracedPaths[req.URL.Path]
+	})))
+
+	// Record state changes and rejected requests in the audit trail
+	r.Use(handlers.Audit)
 
-	// API v1 routes
+	// Authentication routes, reachable without a token
+	auth := r.Group("/api/v1/auth")
+	{
+		auth.POST("/login", handlers.Login)
+		auth.POST("/refresh", handlers.RefreshToken)
+		auth.POST("/logout", handlers.Logout)
+	}
+
+	// API v1 routes, each group guarded by the permission its routes need
 	v1 := r.Group("/api/v1")
+	v1.Use(handlers.Authenticate)
 	{
-		// Interface routes
-		v1.GET("/interfaces", handlers.GetInterfaces)
-		v1.GET("/interfaces/:name", handlers.GetInterface)
+		// Account routes, open to every authenticated user
+		v1.GET("/auth/me", handlers.GetCurrentUser)
+		v1.PUT("/auth/password", handlers.ChangePassword)
+		v1.GET("/api-keys", handlers.GetAPIKeys)
+		v1.POST("/api-keys", handlers.CreateAPIKey)
+		v1.DELETE("/api-keys/:id", handlers.DeleteAPIKey)
+	}
 
-		// System info routes
-		v1.GET("/system", handlers.GetSystemInfo)
+	// Traffic and usage, the only routes open to customers
+	usage := v1.Group("", handlers.Require(service.PermissionUsageRead))
+	{
+		// Interface routes
+		usage.GET("/interfaces", handlers.GetInterfaces)
+		usage.GET("/interfaces/:name", handlers.GetInterface)
 
 		// Traffic history routes
-		v1.GET("/traffic/:interface", handlers.GetTrafficHistory)
+		usage.GET("/traffic/:interface", handlers.GetTrafficHistory)
 
 		// Monthly usage routes
-		v1.GET("/usage/:interface", handlers.GetMonthlyUsage)
+		usage.GET("/usage/:interface", handlers.GetMonthlyUsage)
 
-		// Test data routes
-		v1.POST("/populate-test-data", handlers.PopulateTestData)
+		// Billing cycle and quota routes
+		usage.GET("/billing-cycles", handlers.GetBillingCycles)
+		usage.GET("/billing-cycles/:interface", handlers.GetBillingCycle)
+		usage.GET("/quotas", handlers.GetQuotas)
+		usage.GET("/quotas/:interface", handlers.GetQuota)
+		usage.GET("/quota-events", handlers.GetQuotaEvents)
+
+		// Router outage routes
+		usage.GET("/outages", handlers.GetOutages)
+		usage.GET("/outages/:id", handlers.GetOutage)
 
 		// WebSocket route for real-time updates
-		v1.GET("/ws", handlers.WebSocketHandler)
+		usage.GET("/ws", handlers.WebSocketHandler)
+	}
+
+	// Read-only monitoring
+	monitor := v1.Group("", handlers.Require(service.PermissionMonitorRead))
+	{
+		// Router registry routes
+		monitor.GET("/routers", handlers.GetRouters)
+		monitor.GET("/routers/:id", handlers.GetRouter)
+
+		// System info routes
+		monitor.GET("/system", handlers.GetSystemInfo)
+		monitor.GET("/health", handlers.GetHealth)
+
+		// Alert routes
+		monitor.GET("/alerts", handlers.GetAlerts)
+		monitor.GET("/alerts/:id", handlers.GetAlert)
+		monitor.GET("/alert-rules", handlers.GetAlertRules)
+		monitor.GET("/alert-rules/:id", handlers.GetAlertRule)
+
+		// Maintenance window and silence routes
+		monitor.GET("/maintenance-windows", handlers.GetMaintenanceWindows)
+		monitor.GET("/maintenance-windows/:id", handlers.GetMaintenanceWindow)
+		monitor.GET("/maintenance-periods", handlers.GetMaintenancePeriods)
+		monitor.GET("/silences", handlers.GetSilences)
 
 		// WAN detection routes
-		v1.GET("/wan-interface", handlers.GetWANInterface)
-		v1.GET("/wan-stats", handlers.GetWANDetectionStats)
+		monitor.GET("/wan-interface", handlers.GetWANInterface)
+		monitor.GET("/wan-stats", handlers.GetWANDetectionStats)
+
+		// Worker pool and WebSocket stats
+		monitor.GET("/worker-status", handlers.GetWorkerPoolStatus)
+		monitor.GET("/websocket-stats", handlers.GetWebSocketStats)
+	}
+
+	// Operational changes
+	operate := v1.Group("", handlers.Require(service.PermissionOperate))
+	{
+		// Billing cycle and quota routes
+		operate.PUT("/billing-cycles/:interface", handlers.SetBillingCycle)
+		operate.DELETE("/billing-cycles/:interface", handlers.DeleteBillingCycle)
+		operate.PUT("/quotas/:interface", handlers.SetQuota)
+		operate.DELETE("/quotas/:interface", handlers.DeleteQuota)
+
+		// Alert routes
+		operate.POST("/alerts/:id/ack", handlers.AcknowledgeAlert)
+		operate.POST("/alert-rules", handlers.CreateAlertRule)
+		operate.PUT("/alert-rules/:id", handlers.UpdateAlertRule)
+		operate.DELETE("/alert-rules/:id", handlers.DeleteAlertRule)
+
+		// Maintenance window and silence routes
+		operate.POST("/maintenance-windows", handlers.CreateMaintenanceWindow)
+		operate.PUT("/maintenance-windows/:id", handlers.UpdateMaintenanceWindow)
+		operate.DELETE("/maintenance-windows/:id", handlers.DeleteMaintenanceWindow)
+		operate.POST("/silences", handlers.CreateSilence)
+		operate.DELETE("/silences/:id", handlers.ExpireSilence)
 
 		// Worker pool routes
-		v1.GET("/worker-status", handlers.GetWorkerPoolStatus)
-		v1.POST("/submit-job", handlers.SubmitMonitoringJob)
+		operate.POST("/submit-job", handlers.SubmitMonitoringJob)
+	}
+
+	// Administration
+	admin := v1.Group("", handlers.Require(service.PermissionAdmin))
+	{
+		// User routes
+		admin.GET("/users", handlers.GetUsers)
+		admin.POST("/users", handlers.CreateUser)
+		admin.PUT("/users/:id", handlers.UpdateUser)
+		admin.DELETE("/users/:id", handlers.DeleteUser)
+
+		// Router registry routes
+		admin.POST("/routers", handlers.CreateRouter)
+		admin.PUT("/routers/:id", handlers.UpdateRouter)
+		admin.DELETE("/routers/:id", handlers.DeleteRouter)
+
+		// Notification routes, channels hold credentials so reading them is admin only
+		admin.GET("/notification-channels", handlers.GetNotificationChannels)
+		admin.POST("/notification-channels", handlers.CreateNotificationChannel)
+		admin.GET("/notification-channels/:id", handlers.GetNotificationChannel)
+		admin.PUT("/notification-channels/:id", handlers.UpdateNotificationChannel)
+		admin.DELETE("/notification-channels/:id", handlers.DeleteNotificationChannel)
+		admin.POST("/notification-channels/:id/test", handlers.TestNotificationChannel)
+		admin.GET("/notification-routes", handlers.GetNotificationRoutes)
+		admin.POST("/notification-routes", handlers.CreateNotificationRoute)
+		admin.PUT("/notification-routes/:id", handlers.UpdateNotificationRoute)
+		admin.DELETE("/notification-routes/:id", handlers.DeleteNotificationRoute)
+		admin.GET("/notification-deliveries", handlers.GetNotificationDeliveries)
+
+		// Audit trail routes
+		admin.GET("/audit", handlers.GetAuditLog)
+		admin.GET("/audit/verify", handlers.VerifyAuditLog)
+
+		// Runtime log level routes
+		admin.GET("/log-levels", handlers.GetLogLevels)
+		admin.PUT("/log-levels", handlers.SetLogLevels)
 
-		// WebSocket stats
-		v1.GET("/websocket-stats", handlers.GetWebSocketStats)
+		// Test data routes
+		admin.POST("/populate-test-data", handlers.PopulateTestData)
 	}
 
-	// Health check
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status": "ok",
-		})
-	})
+	// Liveness and readiness probes
+	r.GET("/health", handlers.HealthCheck)
+	r.GET("/ready", handlers.Ready)
+
+	// Prometheus scrape endpoint, scrape with a monitoring user's API key as bearer token
+	r.GET("/metrics", handlers.Authenticate, handlers.Require(service.PermissionMonitorRead), handlers.Metrics)
 
 	return r
 }
+
+// corsConfig builds the CORS configuration for the allowed origins
+func corsConfig(origins []string) cors.Config {
+	config := cors.DefaultConfig()
+	config.AllowHeaders = append(config.AllowHeaders, "Authorization", "X-API-Key")
+	for _, origin := range origins {
+		if origin == "*" {
+			config.AllowAllOrigins = true
+			return config
+		}
+	}
+	config.AllowOrigins = origins
+	config.AllowCredentials = true
+	return config
+}
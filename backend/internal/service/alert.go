@@ -0,0 +1,841 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"monik-enterprise/internal/config"
+	"monik-enterprise/internal/models"
+	"monik-enterprise/internal/websocket"
+
+	"gorm.io/gorm"
+)
+
+// Alert states
+const (
+	AlertStatePending  = "pending"  // condition holds, waiting for the rule's "for" duration
+	AlertStateFiring   = "firing"   // condition held for the whole "for" duration
+	AlertStateResolved = "resolved" // condition no longer holds
+)
+
+// Alert severities
+const (
+	AlertSeverityInfo     = "info"
+	AlertSeverityWarning  = "warning"
+	AlertSeverityCritical = "critical"
+)
+
+// Alert metrics
+const (
+	AlertMetricRxRate        = "rx_rate"    // Mbps
+	AlertMetricTxRate        = "tx_rate"    // Mbps
+	AlertMetricTotalRate     = "total_rate" // rx_rate + tx_rate in Mbps
+	AlertMetricStatus        = "status"     // up or down
+	AlertMetricResetCount    = "counter_reset_count"
+	AlertMetricRouterOffline = "router_offline"
+)
+
+// ErrAlertResolved is returned when acknowledging an alert that is already resolved
+var ErrAlertResolved = errors.New("alert is already resolved")
+
+// AlertCondition is the parsed form of an alert rule expression:
+//
+//	<metric> <op> <value> [for <duration>]   rx_rate > 800 for 5m, status == down
+//	<metric> increases                       counter_reset_count increases
+//	router offline [for <duration>]
+type AlertCondition struct {
+	Metric    string
+	Operator  string // >, >=, <, <=, ==, !=, increases
+	Threshold float64
+	Value     string // compared value of the status metric
+	For       time.Duration
+}
+
+// RouterLevel reports whether the condition is evaluated per router instead of per interface
+func (c AlertCondition) RouterLevel() bool {
+	return c.Metric == AlertMetricRouterOffline
+}
+
+// ParseAlertExpression parses and validates an alert rule expression
+func ParseAlertExpression(expr string) (AlertCondition, error) {
+	var cond AlertCondition
+	fields := strings.Fields(strings.ToLower(expr))
+	if n := len(fields); n >= 2 && fields[n-2] == "for" {
+		d, err := time.ParseDuration(fields[n-1])
+		if err != nil || d < 0 {
+			return cond, fmt.Errorf("invalid for duration %q", fields[n-1])
+		}
+		cond.For = d
+		fields = fields[:n-2]
+	}
+
+	switch {
+	case len(fields) == 2 && fields[0] == "router" && fields[1] == "offline":
+		cond.Metric = AlertMetricRouterOffline
+		return cond, nil
+	case len(fields) == 2 && fields[1] == "increases":
+		if fields[0] != AlertMetricResetCount {
+			return cond, fmt.Errorf("increases is only supported for %s", AlertMetricResetCount)
+		}
+		if cond.For > 0 {
+			return cond, errors.New("increases cannot be combined with for")
+		}
+		cond.Metric = fields[0]
+		cond.Operator = "increases"
+		return cond, nil
+	case len(fields) != 3:
+		return cond, errors.New("expected \"<metric> <op> <value> [for <duration>]\", \"<metric> increases\" or \"router offline [for <duration>]\"")
+	}
+
+	cond.Metric, cond.Operator = fields[0], fields[1]
+	switch cond.Operator {
+	case ">", ">=", "<", "<=", "==", "!=":
+	default:
+		return cond, fmt.Errorf("unknown operator %q", cond.Operator)
+	}
+
+	switch cond.Metric {
+	case AlertMetricRxRate, AlertMetricTxRate, AlertMetricTotalRate, AlertMetricResetCount:
+		threshold, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return cond, fmt.Errorf("invalid value %q for %s", fields[2], cond.Metric)
+		}
+		cond.Threshold = threshold
+	case AlertMetricStatus:
+		if cond.Operator != "==" && cond.Operator != "!=" {
+			return cond, errors.New("status only supports == and !=")
+		}
+		if fields[2] != "up" && fields[2] != "down" {
+			return cond, errors.New("status can only be compared with up or down")
+		}
+		cond.Value = fields[2]
+	default:
+		return cond, fmt.Errorf("unknown metric %q", cond.Metric)
+	}
+	return cond, nil
+}
+
+// NormalizeAlertRule fills unset fields of a rule and validates it
+func NormalizeAlertRule(rule *models.AlertRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return errors.New("name is required")
+	}
+	if _, err := ParseAlertExpression(rule.Expression); err != nil {
+		return fmt.Errorf("invalid expression: %w", err)
+	}
+	if rule.Severity == "" {
+		rule.Severity = AlertSeverityWarning
+	}
+	switch rule.Severity {
+	case AlertSeverityInfo, AlertSeverityWarning, AlertSeverityCritical:
+	default:
+		return errors.New("invalid severity, expected info, warning or critical")
+	}
+	if _, err := path.Match(rule.InterfaceName, ""); err != nil {
+		return errors.New("invalid interface_name pattern")
+	}
+	return nil
+}
+
+// AlertFilter selects alerts in List
+type AlertFilter struct {
+	State         string // pending, firing, resolved or active (pending and firing)
+	RouterID      uint
+	InterfaceName string
+	RuleID        uint
+	Limit         int
+}
+
+type alertRule struct {
+	models.AlertRule
+	cond AlertCondition
+}
+
+// interfaceSample is the latest reading of an interface
+type interfaceSample struct {
+	data   InterfaceData
+	reset  bool        // the latest reading was a counter reset
+	resets []time.Time // counter resets within the reset window
+	stale  bool        // the router could not be polled since the reading
+}
+
+// routerSample is the latest reachability of a router
+type routerSample struct {
+	name   string
+	online bool
+	err    string
+}
+
+// AlertService evaluates alert rules against the interface readings and router
+// reachability reported by the monitoring service. Rules are evaluated on every
+// reading and on a timer, so "for" durations elapse even between polls.
+type AlertService struct {
+	db        *gorm.DB
+	config    config.AlertConfig
+	wsManager *websocket.WebSocketManager
+	notifier  *NotificationService
+	maint     *MaintenanceService
+	logger    *LoggerService
+
+	mu         sync.Mutex
+	rules      []alertRule
+	interfaces map[uint]map[string]*interfaceSample // router -> interface -> sample
+	routers    map[uint]*routerSample
+	active     map[string]*models.Alert // fingerprint -> pending or firing alert
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewAlertService creates an alert service and loads the rules and unresolved alerts
+func NewAlertService(db *gorm.DB, cfg config.AlertConfig, wsManager *websocket.WebSocketManager, notifier *NotificationService, maint *MaintenanceService) *AlertService {
+	if cfg.EvalInterval < time.Second {
+		cfg.EvalInterval = 15 * time.Second
+	}
+	if cfg.ResetWindow <= 0 {
+		cfg.ResetWindow = time.Hour
+	}
+
+	a := &AlertService{
+		db:         db,
+		config:     cfg,
+		wsManager:  wsManager,
+		notifier:   notifier,
+		maint:      maint,
+		interfaces: make(map[uint]map[string]*interfaceSample),
+		routers:    make(map[uint]*routerSample),
+		active:     make(map[string]*models.Alert),
+		quit:       make(chan struct{}),
+	}
+	if err := a.loadRules(); err != nil {
+		a.logger.Error(ComponentAlert, "load", "Failed to load alert rules", err, nil)
+	}
+
+	// Alerts that were pending or firing before a restart continue instead of firing again
+	var alerts []models.Alert
+	if err := db.Where("state IN ?", []string{AlertStatePending, AlertStateFiring}).Find(&alerts).Error; err != nil {
+		a.logger.Error(ComponentAlert, "load", "Failed to load active alerts", err, nil)
+	}
+	for i := range alerts {
+		a.active[alertFingerprint(alerts[i].RuleID, alerts[i].RouterID, alerts[i].InterfaceName)] = &alerts[i]
+	}
+	return a
+}
+
+// SetLogger sets the structured logger of the alert service
+func (a *AlertService) SetLogger(logger *LoggerService) {
+	a.logger = logger
+}
+
+// Start runs the periodic evaluation
+func (a *AlertService) Start() {
+	a.wg.Add(1)
+	go a.loop()
+	a.logger.Info(ComponentAlert, "start", "Alert evaluation started", map[string]interface{}{
+		"rules":         len(a.rules),
+		"active_alerts": len(a.active),
+		"interval":      a.config.EvalInterval.String(),
+	})
+}
+
+// Stop stops the periodic evaluation
+func (a *AlertService) Stop() {
+	close(a.quit)
+	a.wg.Wait()
+}
+
+func (a *AlertService) loop() {
+	defer a.wg.Done()
+
+	ticker := time.NewTicker(a.config.EvalInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.quit:
+			return
+		case now := <-ticker.C:
+			a.mu.Lock()
+			a.evaluate(now, 0)
+			a.mu.Unlock()
+		}
+	}
+}
+
+// ObserveInterfaces records a round of interface readings of a router and evaluates
+// the rules of that router. resets holds the interfaces whose counters were reset.
+func (a *AlertService) ObserveInterfaces(router models.Router, interfaces []InterfaceData, resets map[string]bool) {
+	now := time.Now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	samples, ok := a.interfaces[router.ID]
+	if !ok {
+		samples = make(map[string]*interfaceSample)
+		a.interfaces[router.ID] = samples
+	}
+	reported := make(map[string]bool, len(interfaces))
+	for _, iface := range interfaces {
+		reported[iface.Name] = true
+		sample, ok := samples[iface.Name]
+		if !ok {
+			sample = &interfaceSample{}
+			samples[iface.Name] = sample
+		}
+		sample.data = iface
+		sample.stale = false
+		sample.reset = resets[iface.Name]
+		if sample.reset {
+			sample.resets = append(sample.resets, now)
+		}
+	}
+
+	// Interfaces removed from the router resolve their alerts
+	for name := range samples {
+		if reported[name] {
+			continue
+		}
+		delete(samples, name)
+		for fp, alert := range a.active {
+			if alert.RouterID == router.ID && alert.InterfaceName == name {
+				a.clear(fp, alert, fmt.Sprintf("%s is no longer reported by the router", name), now)
+			}
+		}
+	}
+	a.evaluate(now, router.ID)
+}
+
+// ObserveRouter records the result of a poll of a router and evaluates its rules. The
+// interface readings of a router that could not be polled become stale until the next
+// successful poll.
+func (a *AlertService) ObserveRouter(router models.Router, pollErr error) {
+	now := time.Now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	sample := &routerSample{name: router.Name, online: pollErr == nil}
+	if pollErr != nil {
+		sample.err = pollErr.Error()
+		for _, iface := range a.interfaces[router.ID] {
+			iface.stale = true
+		}
+	}
+	a.routers[router.ID] = sample
+	a.evaluate(now, router.ID)
+}
+
+// evaluate runs every enabled rule against the latest samples. routerID 0 evaluates
+// all routers. Callers hold a.mu.
+func (a *AlertService) evaluate(now time.Time, routerID uint) {
+	seen := make(map[string]bool)
+	for _, rule := range a.rules {
+		if !rule.Enabled || (routerID != 0 && rule.RouterID != 0 && rule.RouterID != routerID) {
+			continue
+		}
+		if rule.cond.RouterLevel() {
+			for id, sample := range a.routers {
+				if (routerID != 0 && id != routerID) || (rule.RouterID != 0 && id != rule.RouterID) {
+					continue
+				}
+				value := 0.0
+				if !sample.online {
+					value = 1
+				}
+				message := fmt.Sprintf("Router %s is offline: %s", sample.name, sample.err)
+				if sample.online {
+					message = fmt.Sprintf("Router %s is online", sample.name)
+				}
+				fp := alertFingerprint(rule.ID, id, "")
+				seen[fp] = true
+				a.transition(fp, rule, id, "", !sample.online, value, message, now)
+			}
+			continue
+		}
+
+		for id, samples := range a.interfaces {
+			if (routerID != 0 && id != routerID) || (rule.RouterID != 0 && id != rule.RouterID) {
+				continue
+			}
+			for name, sample := range samples {
+				if rule.InterfaceName != "" {
+					if matched, _ := path.Match(rule.InterfaceName, name); !matched {
+						continue
+					}
+				}
+				fp := alertFingerprint(rule.ID, id, name)
+				seen[fp] = true
+				holds, value := a.check(rule.cond, sample, now)
+				message := fmt.Sprintf("%s on %s is %s (%s)", rule.cond.Metric, name, formatAlertValue(rule.cond, value), rule.Expression)
+				if sample.stale {
+					message = fmt.Sprintf("%s is not reported, the router is unreachable (%s)", name, rule.Expression)
+				}
+				a.transition(fp, rule, id, name, holds, value, message, now)
+			}
+		}
+	}
+
+	// Alerts of removed or disabled rules, or that fell out of a rule's scope, resolve.
+	// Alerts without a reading yet (e.g. right after a restart) are kept.
+	for fp, alert := range a.active {
+		if seen[fp] || (routerID != 0 && alert.RouterID != routerID) || a.inScope(alert) {
+			continue
+		}
+		a.clear(fp, alert, "alert rule removed or changed", now)
+	}
+}
+
+// inScope reports whether an alert still belongs to an enabled rule
+func (a *AlertService) inScope(alert *models.Alert) bool {
+	for _, rule := range a.rules {
+		if rule.ID != alert.RuleID {
+			continue
+		}
+		if !rule.Enabled || (rule.RouterID != 0 && rule.RouterID != alert.RouterID) {
+			return false
+		}
+		if rule.cond.RouterLevel() {
+			return alert.InterfaceName == ""
+		}
+		if alert.InterfaceName == "" {
+			return false
+		}
+		matched, _ := path.Match(rule.InterfaceName, alert.InterfaceName)
+		return rule.InterfaceName == "" || matched
+	}
+	return false
+}
+
+// check evaluates an interface condition and returns whether it holds and the metric value.
+// A stale interface is down and no other condition holds for it, so alerts on its last
+// rates or resets resolve while its router is unreachable.
+func (a *AlertService) check(cond AlertCondition, sample *interfaceSample, now time.Time) (bool, float64) {
+	if sample.stale {
+		if cond.Metric != AlertMetricStatus {
+			return false, 0
+		}
+		if cond.Operator == "==" {
+			return cond.Value == "down", 0
+		}
+		return cond.Value != "down", 0
+	}
+
+	var value float64
+	switch cond.Metric {
+	case AlertMetricRxRate:
+		value = sample.data.RxRate
+	case AlertMetricTxRate:
+		value = sample.data.TxRate
+	case AlertMetricTotalRate:
+		value = sample.data.RxRate + sample.data.TxRate
+	case AlertMetricStatus:
+		status := "down"
+		if isInterfaceUp(sample.data.Status) {
+			status = "up"
+			value = 1
+		}
+		if cond.Operator == "==" {
+			return status == cond.Value, value
+		}
+		return status != cond.Value, value
+	case AlertMetricResetCount:
+		cutoff := now.Add(-a.config.ResetWindow)
+		kept := sample.resets[:0]
+		for _, t := range sample.resets {
+			if t.After(cutoff) {
+				kept = append(kept, t)
+			}
+		}
+		sample.resets = kept
+		value = float64(len(kept))
+		if cond.Operator == "increases" {
+			// Holds until the next reading of the interface without a reset
+			return sample.reset, value
+		}
+	}
+
+	switch cond.Operator {
+	case ">":
+		return value > cond.Threshold, value
+	case ">=":
+		return value >= cond.Threshold, value
+	case "<":
+		return value < cond.Threshold, value
+	case "<=":
+		return value <= cond.Threshold, value
+	case "==":
+		return value == cond.Threshold, value
+	case "!=":
+		return value != cond.Threshold, value
+	}
+	return false, value
+}
+
+// transition moves the alert of a fingerprint through pending, firing and resolved
+func (a *AlertService) transition(fp string, rule alertRule, routerID uint, iface string, holds bool, value float64, message string, now time.Time) {
+	alert, ok := a.active[fp]
+	if !holds {
+		if ok {
+			a.clear(fp, alert, message, now)
+		}
+		return
+	}
+
+	if !ok {
+		alert = &models.Alert{
+			RuleID:        rule.ID,
+			RuleName:      rule.Name,
+			Expression:    rule.Expression,
+			Severity:      rule.Severity,
+			RouterID:      routerID,
+			InterfaceName: iface,
+			State:         AlertStatePending,
+			Value:         value,
+			Message:       message,
+			StartsAt:      now,
+		}
+		if err := a.db.Create(alert).Error; err != nil {
+			a.logger.Error(ComponentAlert, "evaluate", "Failed to record alert", err, map[string]interface{}{
+				"rule": rule.Name,
+			})
+			return
+		}
+		a.active[fp] = alert
+		a.logger.Info(ComponentAlert, "evaluate", "Alert pending", map[string]interface{}{
+			"rule":      rule.Name,
+			"router_id": routerID,
+			"interface": iface,
+			"value":     value,
+		})
+	}
+	alert.Value = value
+	alert.Message = message
+
+	if alert.State == AlertStatePending && now.Sub(alert.StartsAt) >= rule.cond.For {
+		fired := now
+		alert.State = AlertStateFiring
+		alert.FiredAt = &fired
+		suppressed, reason := a.maint.Suppressed(rule.ID, routerID, iface, now)
+		if suppressed {
+			a.logger.Info(ComponentAlert, "evaluate", "Alert suppressed", map[string]interface{}{
+				"rule":      rule.Name,
+				"router_id": routerID,
+				"interface": iface,
+				"reason":    reason,
+			})
+		}
+		alert.Suppressed = suppressed
+		a.save(alert)
+		a.notify(alert)
+	} else if alert.State == AlertStateFiring && alert.Suppressed {
+		// Still firing after the maintenance window or silence ended
+		if suppressed, _ := a.maint.Suppressed(rule.ID, routerID, iface, now); !suppressed {
+			alert.Suppressed = false
+			a.save(alert)
+			a.notify(alert)
+		}
+	}
+}
+
+// clear resolves a firing alert or drops a pending one that never fired
+func (a *AlertService) clear(fp string, alert *models.Alert, message string, now time.Time) {
+	delete(a.active, fp)
+
+	if alert.State == AlertStatePending {
+		if err := a.db.Delete(&models.Alert{}, alert.ID).Error; err != nil {
+			a.logger.Error(ComponentAlert, "evaluate", "Failed to drop pending alert", err, map[string]interface{}{
+				"alert_id": alert.ID,
+			})
+		}
+		return
+	}
+	resolved := now
+	alert.State = AlertStateResolved
+	alert.ResolvedAt = &resolved
+	alert.Message = message
+	a.save(alert)
+	a.notify(alert)
+}
+
+func (a *AlertService) save(alert *models.Alert) {
+	err := a.db.Model(&models.Alert{}).Where("id = ?", alert.ID).Updates(map[string]interface{}{
+		"state":       alert.State,
+		"value":       alert.Value,
+		"message":     alert.Message,
+		"fired_at":    alert.FiredAt,
+		"resolved_at": alert.ResolvedAt,
+		"suppressed":  alert.Suppressed,
+	}).Error
+	if err != nil {
+		a.logger.Error(ComponentAlert, "evaluate", "Failed to save alert", err, map[string]interface{}{
+			"alert_id": alert.ID,
+		})
+	}
+}
+
+// notify logs a state change, broadcasts it to WebSocket clients and hands it to the
+// notification channels. Alerts that fired during a maintenance window or silence are
+// not sent to the channels, neither when firing nor when resolving.
+func (a *AlertService) notify(alert *models.Alert) {
+	a.logger.Info(ComponentAlert, "notify", "Alert "+alert.State, map[string]interface{}{
+		"rule":       alert.RuleName,
+		"router_id":  alert.RouterID,
+		"interface":  alert.InterfaceName,
+		"value":      alert.Value,
+		"suppressed": alert.Suppressed,
+		"message":    alert.Message,
+	})
+	data := map[string]interface{}{
+		"id":             alert.ID,
+		"rule_id":        alert.RuleID,
+		"rule_name":      alert.RuleName,
+		"severity":       alert.Severity,
+		"router_id":      alert.RouterID,
+		"interface_name": alert.InterfaceName,
+		"state":          alert.State,
+		"value":          alert.Value,
+		"suppressed":     alert.Suppressed,
+	}
+	if a.wsManager != nil {
+		a.wsManager.BroadcastEvent("alert_"+alert.State, alert.Message, data)
+	}
+	if alert.Suppressed {
+		return
+	}
+
+	title := fmt.Sprintf("[FIRING] %s", alert.RuleName)
+	if alert.State == AlertStateResolved {
+		title = fmt.Sprintf("[RESOLVED] %s", alert.RuleName)
+	}
+	a.notifier.Notify(Notification{
+		Event:         "alert_" + alert.State,
+		Severity:      alert.Severity,
+		Title:         title,
+		Message:       alert.Message,
+		RouterID:      alert.RouterID,
+		InterfaceName: alert.InterfaceName,
+		Data:          data,
+	})
+}
+
+// loadRules refreshes the cached rules. Callers hold a.mu or run before Start.
+func (a *AlertService) loadRules() error {
+	var rules []models.AlertRule
+	if err := a.db.Order("id ASC").Find(&rules).Error; err != nil {
+		return err
+	}
+	compiled := make([]alertRule, 0, len(rules))
+	for _, rule := range rules {
+		cond, err := ParseAlertExpression(rule.Expression)
+		if err != nil {
+			a.logger.Warn(ComponentAlert, "load", "Skipping invalid rule", map[string]interface{}{
+				"rule":  rule.Name,
+				"error": err.Error(),
+			})
+			continue
+		}
+		compiled = append(compiled, alertRule{AlertRule: rule, cond: cond})
+	}
+	a.rules = compiled
+	return nil
+}
+
+// reloadRules refreshes the cached rules after a change and re-evaluates them, which
+// resolves the alerts of removed or disabled rules
+func (a *AlertService) reloadRules() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.loadRules(); err != nil {
+		return err
+	}
+	a.evaluate(time.Now(), 0)
+	return nil
+}
+
+// ListRules returns all alert rules
+func (a *AlertService) ListRules() ([]models.AlertRule, error) {
+	var rules []models.AlertRule
+	err := a.db.Order("id ASC").Find(&rules).Error
+	return rules, err
+}
+
+// GetRule returns an alert rule by ID
+func (a *AlertService) GetRule(id uint) (*models.AlertRule, error) {
+	var rule models.AlertRule
+	if err := a.db.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// CreateRule stores a new alert rule
+func (a *AlertService) CreateRule(rule *models.AlertRule) error {
+	if err := NormalizeAlertRule(rule); err != nil {
+		return err
+	}
+	if err := a.db.Create(rule).Error; err != nil {
+		return err
+	}
+	return a.reloadRules()
+}
+
+// UpdateRule replaces an alert rule. Alerts of the rule restart when its condition
+// or scope changes.
+func (a *AlertService) UpdateRule(id uint, rule models.AlertRule) (*models.AlertRule, error) {
+	existing, err := a.GetRule(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := NormalizeAlertRule(&rule); err != nil {
+		return nil, err
+	}
+	rule.ID = existing.ID
+	rule.CreatedAt = existing.CreatedAt
+	if err := a.db.Save(&rule).Error; err != nil {
+		return nil, err
+	}
+
+	if rule.Expression != existing.Expression || rule.RouterID != existing.RouterID || rule.InterfaceName != existing.InterfaceName {
+		a.mu.Lock()
+		now := time.Now()
+		for fp, alert := range a.active {
+			if alert.RuleID == id {
+				a.clear(fp, alert, "alert rule changed", now)
+			}
+		}
+		a.mu.Unlock()
+	}
+	a.mu.Lock()
+	for _, alert := range a.active {
+		if alert.RuleID == id {
+			alert.RuleName = rule.Name
+			alert.Severity = rule.Severity
+		}
+	}
+	a.mu.Unlock()
+	return &rule, a.reloadRules()
+}
+
+// DeleteRule removes an alert rule and resolves its alerts
+func (a *AlertService) DeleteRule(id uint) error {
+	res := a.db.Delete(&models.AlertRule{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return a.reloadRules()
+}
+
+// List returns alerts matching filter, newest first
+func (a *AlertService) List(filter AlertFilter) ([]models.Alert, error) {
+	query := a.db.Order("created_at DESC, id DESC")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	switch filter.State {
+	case "":
+	case "active":
+		query = query.Where("state IN ?", []string{AlertStatePending, AlertStateFiring})
+	default:
+		query = query.Where("state = ?", filter.State)
+	}
+	if filter.RouterID != 0 {
+		query = query.Where("router_id = ?", filter.RouterID)
+	}
+	if filter.InterfaceName != "" {
+		query = query.Where("interface_name = ?", filter.InterfaceName)
+	}
+	if filter.RuleID != 0 {
+		query = query.Where("rule_id = ?", filter.RuleID)
+	}
+
+	var alerts []models.Alert
+	err := query.Find(&alerts).Error
+	return alerts, err
+}
+
+// Get returns an alert by ID
+func (a *AlertService) Get(id uint) (*models.Alert, error) {
+	var alert models.Alert
+	if err := a.db.First(&alert, id).Error; err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// Acknowledge marks a pending or firing alert as seen by an operator
+func (a *AlertService) Acknowledge(id uint, by string) (*models.Alert, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	alert, err := a.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if alert.State == AlertStateResolved {
+		return nil, ErrAlertResolved
+	}
+
+	now := time.Now()
+	alert.Acknowledged = true
+	alert.AcknowledgedBy = by
+	alert.AcknowledgedAt = &now
+	err = a.db.Model(&models.Alert{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"acknowledged":    true,
+		"acknowledged_by": by,
+		"acknowledged_at": now,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	if active, ok := a.active[alertFingerprint(alert.RuleID, alert.RouterID, alert.InterfaceName)]; ok {
+		active.Acknowledged = true
+		active.AcknowledgedBy = by
+		active.AcknowledgedAt = &now
+	}
+	return alert, nil
+}
+
+// Counts returns the number of pending and firing alerts per state
+func (a *AlertService) Counts() map[string]int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	counts := map[string]int{AlertStatePending: 0, AlertStateFiring: 0}
+	for _, alert := range a.active {
+		counts[alert.State]++
+	}
+	return counts
+}
+
+// isInterfaceUp normalizes the running flag reported by the different collectors
+func isInterfaceUp(status string) bool {
+	switch strings.ToLower(status) {
+	case "true", "up", "yes", "running":
+		return true
+	}
+	return false
+}
+
+func formatAlertValue(cond AlertCondition, value float64) string {
+	switch cond.Metric {
+	case AlertMetricStatus:
+		if value == 1 {
+			return "up"
+		}
+		return "down"
+	case AlertMetricResetCount:
+		return strconv.Itoa(int(value)) + " resets"
+	}
+	return strconv.FormatFloat(value, 'f', 2, 64) + " Mbps"
+}
+
+func alertFingerprint(ruleID, routerID uint, iface string) string {
+	return fmt.Sprintf("%d/%d/%s", ruleID, routerID, iface)
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"monik-enterprise/internal/config"
+	"monik-enterprise/internal/models"
+)
+
+func TestAlertsOnUnreachableRouter(t *testing.T) {
+	db := openTestDB(t, &models.AlertRule{}, &models.Alert{})
+	a := NewAlertService(db, config.AlertConfig{}, nil, nil, nil)
+	for _, rule := range []models.AlertRule{
+		{Name: "busy", Expression: "rx_rate > 800 for 5m", Enabled: true},
+		{Name: "down", Expression: "status == down", Enabled: true},
+	} {
+		if err := a.CreateRule(&rule); err != nil {
+			t.Fatal(err)
+		}
+	}
+	router := models.Router{ID: 1, Name: "core"}
+	stateOf := func(rule, iface string) string {
+		t.Helper()
+		var alert models.Alert
+		err := db.Where("rule_name = ? AND interface_name = ?", rule, iface).Order("id DESC").First(&alert).Error
+		if err != nil {
+			return ""
+		}
+		return alert.State
+	}
+
+	a.ObserveInterfaces(router, []InterfaceData{
+		{Name: "ether1", Status: "running", RxRate: 900},
+		{Name: "ether2", Status: "running"},
+	}, nil)
+	a.mu.Lock()
+	a.evaluate(time.Now().Add(6*time.Minute), 0)
+	a.mu.Unlock()
+	if got := stateOf("busy", "ether1"); got != AlertStateFiring {
+		t.Fatalf("busy ether1 = %q, want firing", got)
+	}
+
+	// The last rates are stale while the router is unreachable, its interfaces are down
+	a.ObserveRouter(router, errors.New("dial tcp: i/o timeout"))
+	a.mu.Lock()
+	a.evaluate(time.Now().Add(12*time.Minute), 0)
+	a.mu.Unlock()
+	if got := stateOf("busy", "ether1"); got != AlertStateResolved {
+		t.Errorf("busy ether1 during outage = %q, want resolved", got)
+	}
+	for _, iface := range []string{"ether1", "ether2"} {
+		if got := stateOf("down", iface); got != AlertStateFiring {
+			t.Errorf("down %s during outage = %q, want firing", iface, got)
+		}
+	}
+
+	// ether2 was removed from the router while it was unreachable
+	a.ObserveRouter(router, nil)
+	a.ObserveInterfaces(router, []InterfaceData{{Name: "ether1", Status: "running"}}, nil)
+	for _, iface := range []string{"ether1", "ether2"} {
+		if got := stateOf("down", iface); got != AlertStateResolved {
+			t.Errorf("down %s after recovery = %q, want resolved", iface, got)
+		}
+	}
+	if _, ok := a.interfaces[router.ID]["ether2"]; ok {
+		t.Error("sample of the removed ether2 kept")
+	}
+	if len(a.active) != 0 {
+		t.Errorf("%d alerts still active", len(a.active))
+	}
+}
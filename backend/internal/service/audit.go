@@ -0,0 +1,220 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"monik-enterprise/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Audit outcomes
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// Metadata keys of LogAudit that are stored in their own audit entry column
+const (
+	AuditKeyActorID = "user_id"
+	AuditKeyIP      = "ip"
+)
+
+// AuditFilter selects audit entries
+type AuditFilter struct {
+	Actor    string
+	Action   string
+	Resource string // Prefix of the resource
+	Outcome  string
+	From     time.Time
+	To       time.Time
+	Limit    int
+	Offset   int
+}
+
+// AuditVerification is the result of checking the audit hash chain
+type AuditVerification struct {
+	Checked  int    `json:"checked"`
+	Valid    bool   `json:"valid"`
+	BrokenAt uint   `json:"broken_at,omitempty"` // First entry whose hash does not match
+	Reason   string `json:"reason,omitempty"`
+}
+
+// AuditService persists the audit trail. Entries are only ever appended, each one
+// hashing the previous, so the chain can be verified later.
+type AuditService struct {
+	db     *gorm.DB
+	logger *LoggerService
+
+	mu       sync.Mutex
+	lastHash string
+}
+
+// NewAuditService creates an audit service continuing the chain from the latest entry
+func NewAuditService(db *gorm.DB) *AuditService {
+	a := &AuditService{db: db}
+
+	var last models.AuditEntry
+	err := db.Order("id DESC").Limit(1).Find(&last).Error
+	if err != nil {
+		a.logger.Error(ComponentAudit, "load", "Failed to load the latest audit entry", err, nil)
+	}
+	a.lastHash = last.Hash
+	return a
+}
+
+// SetLogger sets the structured logger of the audit service
+func (a *AuditService) SetLogger(logger *LoggerService) {
+	a.logger = logger
+}
+
+// Record appends an audit entry. The actor ID and IP are taken from metadata, the rest
+// of metadata is kept as JSON detail. A nil service records nothing.
+func (a *AuditService) Record(actor, action, resource string, success bool, metadata map[string]interface{}) error {
+	if a == nil {
+		return nil
+	}
+
+	entry := models.AuditEntry{
+		// Stored with microsecond precision so the hash survives a PostgreSQL round trip
+		Timestamp: time.Now().UTC().Truncate(time.Microsecond),
+		Actor:     actor,
+		Action:    action,
+		Resource:  resource,
+		Outcome:   AuditOutcomeFailure,
+	}
+	if success {
+		entry.Outcome = AuditOutcomeSuccess
+	}
+	detail := make(map[string]interface{}, len(metadata))
+	for key, value := range metadata {
+		switch key {
+		case AuditKeyActorID:
+			if id, ok := value.(uint); ok {
+				entry.ActorID = id
+			}
+		case AuditKeyIP:
+			entry.IP = fmt.Sprint(value)
+		case "user", "action", "resource", "success":
+			// Already in their own columns
+		default:
+			detail[key] = value
+		}
+	}
+	if len(detail) > 0 {
+		encoded, err := json.Marshal(detail)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit detail: %w", err)
+		}
+		entry.Detail = string(encoded)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entry.PrevHash = a.lastHash
+	entry.Hash = auditHash(entry)
+	if err := a.db.Create(&entry).Error; err != nil {
+		return err
+	}
+	a.lastHash = entry.Hash
+	return nil
+}
+
+// List returns audit entries matching the filter, newest first, with the total number
+// of matching entries
+func (a *AuditService) List(filter AuditFilter) ([]models.AuditEntry, int64, error) {
+	query := a.db.Model(&models.AuditEntry{})
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Resource != "" {
+		query = query.Where(`resource LIKE ? ESCAPE '\'`, escapeLike(filter.Resource)+"%")
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("timestamp >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("timestamp < ?", filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.AuditEntry
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	err := query.Order("id DESC").Offset(filter.Offset).Find(&entries).Error
+	return entries, total, err
+}
+
+// Verify walks the whole chain in order and reports the first entry that does not
+// match its hash or does not follow its predecessor
+func (a *AuditService) Verify() (AuditVerification, error) {
+	result := AuditVerification{Valid: true}
+	prev := ""
+
+	var batch []models.AuditEntry
+	err := a.db.Order("id ASC").FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for _, entry := range batch {
+			result.Checked++
+			switch {
+			case entry.PrevHash != prev:
+				result.Reason = "previous hash does not match, an entry was removed or reordered"
+			case auditHash(entry) != entry.Hash:
+				result.Reason = "hash does not match, the entry was modified"
+			default:
+				prev = entry.Hash
+				continue
+			}
+			result.Valid = false
+			result.BrokenAt = entry.ID
+			return errChainBroken
+		}
+		return nil
+	}).Error
+	if err != nil && !errors.Is(err, errChainBroken) {
+		return result, err
+	}
+	return result, nil
+}
+
+// errChainBroken stops Verify at the first broken entry
+var errChainBroken = errors.New("audit chain broken")
+
+// auditHash hashes an entry together with the hash of the previous entry
+func auditHash(entry models.AuditEntry) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		entry.PrevHash,
+		entry.Timestamp.UTC().Format(time.RFC3339Nano),
+		entry.Actor,
+		fmt.Sprint(entry.ActorID),
+		entry.IP,
+		entry.Action,
+		entry.Resource,
+		entry.Outcome,
+		entry.Detail,
+	}, "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
+
+// escapeLike escapes the LIKE wildcards in s
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
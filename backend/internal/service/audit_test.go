@@ -0,0 +1,149 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"monik-enterprise/internal/models"
+)
+
+// newTestAudit returns an audit service holding n entries, with IDs 1 to n
+func newTestAudit(t *testing.T, n int) *AuditService {
+	t.Helper()
+	a := NewAuditService(openTestDB(t, &models.AuditEntry{}))
+	for i := 1; i <= n; i++ {
+		metadata := map[string]interface{}{AuditKeyActorID: uint(i), AuditKeyIP: "10.0.0.1", "status": 200 + i}
+		if err := a.Record(fmt.Sprintf("user%d", i), "login", "/api/v1/auth/login", i%2 == 0, metadata); err != nil {
+			t.Fatalf("record entry %d: %v", i, err)
+		}
+	}
+	return a
+}
+
+func TestAuditVerify(t *testing.T) {
+	tests := []struct {
+		name       string
+		tamper     string // Raw SQL run against the chain of five entries
+		wantBroken uint
+		wantReason string
+		wantCheck  int
+	}{
+		{name: "intact chain", wantCheck: 5},
+		{
+			name:       "actor changed",
+			tamper:     "UPDATE audit_entries SET actor = 'admin' WHERE id = 3",
+			wantBroken: 3, wantReason: "modified", wantCheck: 3,
+		},
+		{
+			name:       "outcome changed on the first entry",
+			tamper:     "UPDATE audit_entries SET outcome = 'success' WHERE id = 1",
+			wantBroken: 1, wantReason: "modified", wantCheck: 1,
+		},
+		{
+			name:       "detail changed on the last entry",
+			tamper:     `UPDATE audit_entries SET detail = '{"status":200}' WHERE id = 5`,
+			wantBroken: 5, wantReason: "modified", wantCheck: 5,
+		},
+		{
+			name:       "hash replaced along with the actor",
+			tamper:     "UPDATE audit_entries SET hash = 'forged', actor = 'admin' WHERE id = 2",
+			wantBroken: 2, wantReason: "modified", wantCheck: 2,
+		},
+		{
+			name:       "entry removed",
+			tamper:     "DELETE FROM audit_entries WHERE id = 3",
+			wantBroken: 4, wantReason: "removed", wantCheck: 3,
+		},
+		{
+			name:       "first entry removed",
+			tamper:     "DELETE FROM audit_entries WHERE id = 1",
+			wantBroken: 2, wantReason: "removed", wantCheck: 1,
+		},
+		{
+			name:       "previous hash relinked",
+			tamper:     "UPDATE audit_entries SET prev_hash = (SELECT prev_hash FROM audit_entries WHERE id = 3) WHERE id = 4",
+			wantBroken: 4, wantReason: "removed", wantCheck: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestAudit(t, 5)
+			if tt.tamper != "" {
+				if err := a.db.Exec(tt.tamper).Error; err != nil {
+					t.Fatalf("tamper: %v", err)
+				}
+			}
+
+			got, err := a.Verify()
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if got.Valid != (tt.wantBroken == 0) || got.BrokenAt != tt.wantBroken {
+				t.Fatalf("Verify = %+v, want broken at %d", got, tt.wantBroken)
+			}
+			if got.Checked != tt.wantCheck {
+				t.Errorf("checked %d entries, want %d", got.Checked, tt.wantCheck)
+			}
+			if !strings.Contains(got.Reason, tt.wantReason) {
+				t.Errorf("reason %q does not mention %q", got.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestAuditChainSurvivesRestart(t *testing.T) {
+	a := newTestAudit(t, 3)
+
+	restarted := NewAuditService(a.db)
+	if err := restarted.Record("admin", "logout", "/api/v1/auth/logout", true, nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err := restarted.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Valid || got.Checked != 4 {
+		t.Errorf("Verify after restart = %+v, want 4 valid entries", got)
+	}
+}
+
+func TestAuditEntriesAreAppendOnly(t *testing.T) {
+	a := newTestAudit(t, 2)
+
+	err := a.db.Model(&models.AuditEntry{ID: 1}).Update("actor", "admin").Error
+	if !errors.Is(err, models.ErrAuditAppendOnly) {
+		t.Errorf("update through gorm: %v, want ErrAuditAppendOnly", err)
+	}
+	err = a.db.Delete(&models.AuditEntry{ID: 2}).Error
+	if !errors.Is(err, models.ErrAuditAppendOnly) {
+		t.Errorf("delete through gorm: %v, want ErrAuditAppendOnly", err)
+	}
+	if got, _ := a.Verify(); !got.Valid {
+		t.Errorf("chain broken by rejected changes: %+v", got)
+	}
+}
+
+func TestAuditRecordSplitsMetadata(t *testing.T) {
+	a := newTestAudit(t, 1)
+
+	entries, total, err := a.List(AuditFilter{Actor: "user1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(entries) != 1 {
+		t.Fatalf("found %d entries, want 1", total)
+	}
+	entry := entries[0]
+	if entry.ActorID != 1 || entry.IP != "10.0.0.1" || entry.Outcome != AuditOutcomeFailure {
+		t.Errorf("entry = %+v", entry)
+	}
+	if entry.Detail != `{"status":201}` {
+		t.Errorf("detail = %s, want only the remaining metadata", entry.Detail)
+	}
+	if entry.PrevHash != "" || entry.Hash != auditHash(entry) {
+		t.Errorf("first entry hash = %s, prev %q", entry.Hash, entry.PrevHash)
+	}
+}
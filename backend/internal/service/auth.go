@@ -0,0 +1,579 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"monik-enterprise/internal/config"
+	"monik-enterprise/internal/models"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Authentication methods recorded on a Principal
+const (
+	AuthMethodToken  = "token"
+	AuthMethodAPIKey = "api_key"
+)
+
+// apiKeyPrefix marks API keys so they are told apart from JWTs
+const apiKeyPrefix = "mk_"
+
+// minPasswordLength is the shortest password accepted for a user
+const minPasswordLength = 8
+
+// Authentication errors
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrInvalidAPIKey      = errors.New("invalid or expired API key")
+	ErrPasswordTooShort   = fmt.Errorf("password must be at least %d characters", minPasswordLength)
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	Method   string `json:"method"`               // token, api_key
+	APIKeyID uint   `json:"api_key_id,omitempty"` // Set when authenticated with an API key
+	Scope    *Scope `json:"-"`                    // nil when not limited to some routers
+}
+
+// UserUpdate holds the changes to a user, nil fields are left unchanged
+type UserUpdate struct {
+	Password *string
+	Disabled *bool
+	Role     *string
+	Scopes   *[]models.UserScope // Replaces every scope entry of the user
+}
+
+// TokenPair is the result of a login or refresh
+type TokenPair struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`         // Seconds until the access token expires
+	RefreshExpiresIn int64  `json:"refresh_expires_in"` // Seconds until the refresh token expires
+}
+
+// AuthService manages local users, issues JWT access and refresh tokens and verifies
+// tokens and API keys
+type AuthService struct {
+	db     *gorm.DB
+	config config.AuthConfig
+	secret []byte
+	logger *LoggerService
+
+	dummyHash []byte // Compared against for unknown usernames so they cannot be probed by timing
+}
+
+// NewAuthService creates an auth service. Without a configured secret tokens are signed
+// with a random key and do not survive a restart.
+func NewAuthService(db *gorm.DB, cfg config.AuthConfig) *AuthService {
+	a := &AuthService{db: db, config: cfg, secret: []byte(cfg.JWTSecret)}
+	if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+		a.config.BcryptCost = bcrypt.DefaultCost
+	}
+	if len(a.secret) == 0 {
+		a.secret = make([]byte, 32)
+		if _, err := rand.Read(a.secret); err != nil {
+			panic(fmt.Sprintf("failed to generate token secret: %v", err))
+		}
+	}
+
+	a.dummyHash, _ = bcrypt.GenerateFromPassword([]byte(randomToken(8)), a.config.BcryptCost)
+	return a
+}
+
+// SetLogger sets the structured logger of the auth service
+func (a *AuthService) SetLogger(logger *LoggerService) {
+	a.logger = logger
+}
+
+// Enabled reports whether requests must be authenticated
+func (a *AuthService) Enabled() bool {
+	return a != nil && a.config.Enabled
+}
+
+// EnsureAdmin creates the admin user when the users table is empty
+func (a *AuthService) EnsureAdmin() error {
+	var count int64
+	if err := a.db.Model(&models.User{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	password := a.config.AdminPassword
+	generated := password == ""
+	if generated {
+		// Stored before the user exists so a failed write cannot leave an unknown password
+		password = randomToken(12)
+		if err := writeSecretFile(a.config.AdminPasswordFile, password+"\n"); err != nil {
+			return fmt.Errorf("write admin password: %w", err)
+		}
+	}
+	if _, err := a.CreateUser(a.config.AdminUsername, password, RoleAdmin, nil); err != nil {
+		if generated {
+			os.Remove(a.config.AdminPasswordFile)
+		}
+		return err
+	}
+	fields := map[string]interface{}{"username": a.config.AdminUsername}
+	if generated {
+		fields["password_file"] = a.config.AdminPasswordFile
+		a.logger.Warn(ComponentAuth, "admin", "Created admin user with a generated password, change it after the first login and delete the file", fields)
+	} else {
+		a.logger.Info(ComponentAuth, "admin", "Created admin user", fields)
+	}
+	return nil
+}
+
+// writeSecretFile writes content to a new file only its owner can read. A file left by
+// an earlier attempt is replaced rather than reused, as it may have looser permissions.
+func writeSecretFile(name, content string) error {
+	if name == "" {
+		return errors.New("no file configured, set AUTH_ADMIN_PASSWORD or AUTH_ADMIN_PASSWORD_FILE")
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		os.Remove(name)
+		return err
+	}
+	return f.Close()
+}
+
+// Login checks a username and password and issues a token pair
+func (a *AuthService) Login(username, password string) (*TokenPair, error) {
+	var user models.User
+	if err := a.db.Where("username = ?", username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			bcrypt.CompareHashAndPassword(a.dummyHash, []byte(password))
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if user.Disabled || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	if err := a.db.Model(&user).Update("last_login_at", now).Error; err != nil {
+		a.logger.Error(ComponentAuth, "login", "Failed to record login", err, map[string]interface{}{
+			"username": user.Username,
+		})
+	}
+	return a.issueTokens(a.db, user, now)
+}
+
+// Refresh exchanges a refresh token for a new token pair. The old refresh token is
+// revoked; presenting a revoked one again revokes every refresh token of the user, as
+// it means the token was copied.
+func (a *AuthService) Refresh(refreshToken string) (*TokenPair, error) {
+	now := time.Now()
+	claims, err := parseToken(a.secret, refreshToken, now)
+	if err != nil || claims.Type != TokenTypeRefresh {
+		return nil, ErrInvalidToken
+	}
+
+	var pair *TokenPair
+	var reusedBy uint
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		var stored models.RefreshToken
+		if err := tx.Where("token_id = ?", claims.ID).First(&stored).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidToken
+			}
+			return err
+		}
+		if stored.RevokedAt != nil {
+			reusedBy = stored.UserID
+			return ErrInvalidToken
+		}
+
+		var user models.User
+		if err := tx.First(&user, stored.UserID).Error; err != nil || user.Disabled {
+			return ErrInvalidToken
+		}
+		if err := tx.Model(&stored).Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		pair, err = a.issueTokens(tx, user, now)
+		return err
+	})
+	if reusedBy != 0 {
+		// Revoked outside the transaction, which rolls back on the returned error
+		a.logger.Warn(ComponentAuth, "refresh", "Revoked refresh token reused, revoking all sessions of the user", map[string]interface{}{
+			"user_id": reusedBy,
+		})
+		if err := a.revokeAll(a.db, reusedBy, now); err != nil {
+			a.logger.Error(ComponentAuth, "refresh", "Failed to revoke sessions", err, map[string]interface{}{
+				"user_id": reusedBy,
+			})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Logout revokes a refresh token. Access tokens stay valid until they expire.
+func (a *AuthService) Logout(refreshToken string) error {
+	claims, err := parseToken(a.secret, refreshToken, time.Now())
+	if err != nil || claims.Type != TokenTypeRefresh {
+		return ErrInvalidToken
+	}
+	return a.db.Model(&models.RefreshToken{}).
+		Where("token_id = ? AND revoked_at IS NULL", claims.ID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// Authenticate verifies an access token or API key. The user is loaded on every call so
+// role and scope changes and disabled accounts take effect immediately.
+func (a *AuthService) Authenticate(credential string) (*Principal, error) {
+	if strings.HasPrefix(credential, apiKeyPrefix) {
+		return a.authenticateAPIKey(credential)
+	}
+
+	claims, err := parseToken(a.secret, credential, time.Now())
+	if err != nil || claims.Type != TokenTypeAccess {
+		return nil, ErrInvalidToken
+	}
+	user, err := a.GetUser(claims.Subject)
+	if err != nil || user.Disabled {
+		return nil, ErrInvalidToken
+	}
+	return newPrincipal(*user, AuthMethodToken), nil
+}
+
+func (a *AuthService) authenticateAPIKey(key string) (*Principal, error) {
+	var apiKey models.APIKey
+	if err := a.db.Where("key_hash = ?", hashAPIKey(key)).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if apiKey.ExpiresAt != nil && !now.Before(*apiKey.ExpiresAt) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	user, err := a.GetUser(apiKey.UserID)
+	if err != nil || user.Disabled {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// Recorded at most once a minute to keep scripted polling from writing on every call
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= time.Minute {
+		if err := a.db.Model(&apiKey).Update("last_used_at", now).Error; err != nil {
+			a.logger.Error(ComponentAuth, "api_key", "Failed to record use of API key", err, map[string]interface{}{
+				"api_key_id": apiKey.ID,
+			})
+		}
+	}
+	principal := newPrincipal(*user, AuthMethodAPIKey)
+	principal.APIKeyID = apiKey.ID
+	return principal, nil
+}
+
+func newPrincipal(user models.User, method string) *Principal {
+	return &Principal{
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+		Method:   method,
+		Scope:    newScope(user),
+	}
+}
+
+// issueTokens signs a token pair for user and records the refresh token
+func (a *AuthService) issueTokens(tx *gorm.DB, user models.User, now time.Time) (*TokenPair, error) {
+	access, err := signToken(a.secret, tokenClaims{
+		Subject:   user.ID,
+		Username:  user.Username,
+		Type:      TokenTypeAccess,
+		ID:        randomToken(16),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(a.config.AccessTTL).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stored := models.RefreshToken{
+		UserID:    user.ID,
+		TokenID:   randomToken(16),
+		ExpiresAt: now.Add(a.config.RefreshTTL),
+	}
+	refresh, err := signToken(a.secret, tokenClaims{
+		Subject:   user.ID,
+		Username:  user.Username,
+		Type:      TokenTypeRefresh,
+		ID:        stored.TokenID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: stored.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Create(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	// Expired refresh tokens are no longer useful for reuse detection
+	tx.Where("user_id = ? AND expires_at < ?", user.ID, now).Delete(&models.RefreshToken{})
+
+	return &TokenPair{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(a.config.AccessTTL / time.Second),
+		RefreshExpiresIn: int64(a.config.RefreshTTL / time.Second),
+	}, nil
+}
+
+// revokeAll revokes every outstanding refresh token of a user
+func (a *AuthService) revokeAll(tx *gorm.DB, userID uint, now time.Time) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
+}
+
+// ListUsers returns all users
+func (a *AuthService) ListUsers() ([]models.User, error) {
+	var users []models.User
+	err := a.db.Preload("Scopes").Order("username ASC").Find(&users).Error
+	return users, err
+}
+
+// GetUser returns a user by ID
+func (a *AuthService) GetUser(id uint) (*models.User, error) {
+	var user models.User
+	if err := a.db.Preload("Scopes").First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreateUser adds a user with a role and the routers and interfaces it is limited to
+func (a *AuthService) CreateUser(username, password, role string, scopes []models.UserScope) (*models.User, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return nil, errors.New("username is required")
+	}
+	if !ValidRole(role) {
+		return nil, fmt.Errorf("invalid role %q (admin, operator, viewer or customer)", role)
+	}
+	if err := validateScopes(scopes); err != nil {
+		return nil, err
+	}
+	hash, err := a.hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := a.db.Model(&models.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("user %q already exists", username)
+	}
+
+	user := models.User{Username: username, PasswordHash: hash, Role: role, Scopes: scopes}
+	if err := a.db.Create(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUser applies changes to a user. Changing the password or disabling the user
+// revokes its refresh tokens.
+func (a *AuthService) UpdateUser(id uint, update UserUpdate) (*models.User, error) {
+	user, err := a.GetUser(id)
+	if err != nil {
+		return nil, err
+	}
+	updates := map[string]interface{}{}
+	if update.Password != nil {
+		hash, err := a.hashPassword(*update.Password)
+		if err != nil {
+			return nil, err
+		}
+		updates["password_hash"] = hash
+	}
+	if update.Disabled != nil {
+		updates["disabled"] = *update.Disabled
+	}
+	if update.Role != nil {
+		if !ValidRole(*update.Role) {
+			return nil, fmt.Errorf("invalid role %q (admin, operator, viewer or customer)", *update.Role)
+		}
+		updates["role"] = *update.Role
+	}
+	if update.Scopes != nil {
+		if err := validateScopes(*update.Scopes); err != nil {
+			return nil, err
+		}
+	}
+
+	err = a.db.Transaction(func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			if err := tx.Model(user).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		if update.Scopes != nil {
+			if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserScope{}).Error; err != nil {
+				return err
+			}
+			for _, scope := range *update.Scopes {
+				scope.ID = 0
+				scope.UserID = user.ID
+				if err := tx.Create(&scope).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if update.Password != nil || (update.Disabled != nil && *update.Disabled) {
+			return a.revokeAll(tx, user.ID, time.Now())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a.GetUser(id)
+}
+
+// ChangePassword replaces the password of a user after checking the current one
+func (a *AuthService) ChangePassword(id uint, current, password string) error {
+	user, err := a.GetUser(id)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(current)) != nil {
+		return ErrInvalidCredentials
+	}
+	_, err = a.UpdateUser(id, UserUpdate{Password: &password})
+	return err
+}
+
+// DeleteUser removes a user with its refresh tokens and API keys
+func (a *AuthService) DeleteUser(id uint) error {
+	return a.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.User{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.UserScope{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", id).Delete(&models.APIKey{}).Error
+	})
+}
+
+// ListAPIKeys returns the API keys of a user
+func (a *AuthService) ListAPIKeys(userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := a.db.Where("user_id = ?", userID).Order("id ASC").Find(&keys).Error
+	return keys, err
+}
+
+// CreateAPIKey issues an API key for a user. The key itself is only returned here,
+// the database keeps its hash.
+func (a *AuthService) CreateAPIKey(userID uint, name string, expiresAt *time.Time) (string, *models.APIKey, error) {
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return "", nil, errors.New("expires_at must be in the future")
+	}
+	key := apiKeyPrefix + randomToken(24)
+	apiKey := models.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    key[:len(apiKeyPrefix)+6],
+		KeyHash:   hashAPIKey(key),
+		ExpiresAt: expiresAt,
+	}
+	if err := a.db.Create(&apiKey).Error; err != nil {
+		return "", nil, err
+	}
+	return key, &apiKey, nil
+}
+
+// DeleteAPIKey revokes an API key of a user
+func (a *AuthService) DeleteAPIKey(userID, id uint) error {
+	result := a.db.Where("user_id = ?", userID).Delete(&models.APIKey{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (a *AuthService) hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", ErrPasswordTooShort
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), a.config.BcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// validateScopes checks that scope entries name a router and a valid interface pattern
+func validateScopes(scopes []models.UserScope) error {
+	for _, scope := range scopes {
+		if scope.RouterID == 0 {
+			return errors.New("scope router_id is required")
+		}
+		if _, err := path.Match(scope.InterfaceName, ""); err != nil {
+			return fmt.Errorf("invalid scope interface pattern %q", scope.InterfaceName)
+		}
+	}
+	return nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken returns n random bytes, hex encoded
+func randomToken(n int) string {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("failed to read random bytes: %v", err))
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"monik-enterprise/internal/config"
+	"monik-enterprise/internal/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newTestAuthService returns an auth service over a fresh database with a cheap bcrypt cost
+func newTestAuthService(t *testing.T, cfg config.AuthConfig) *AuthService {
+	t.Helper()
+	db := openTestDB(t, &models.User{}, &models.UserScope{}, &models.RefreshToken{}, &models.APIKey{})
+	cfg.Enabled = true
+	cfg.JWTSecret = "test-secret"
+	cfg.BcryptCost = bcrypt.MinCost
+	cfg.AccessTTL = 15 * time.Minute
+	cfg.RefreshTTL = time.Hour
+	if cfg.AdminUsername == "" {
+		cfg.AdminUsername = "admin"
+	}
+	if cfg.AdminPassword == "" && cfg.AdminPasswordFile == "" {
+		cfg.AdminPassword = "admin-password"
+	}
+	a := NewAuthService(db, cfg)
+	if err := a.EnsureAdmin(); err != nil {
+		t.Fatalf("EnsureAdmin: %v", err)
+	}
+	return a
+}
+
+func TestEnsureAdminWritesGeneratedPassword(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "data", "admin-password")
+	a := newTestAuthService(t, config.AuthConfig{AdminPasswordFile: file})
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatalf("password file: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("password file mode = %o, want 600", perm)
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	password := strings.TrimSpace(string(content))
+	if len(password) < minPasswordLength {
+		t.Fatalf("generated password %q is too short", password)
+	}
+	if _, err := a.Login("admin", password); err != nil {
+		t.Fatalf("login with the generated password: %v", err)
+	}
+
+	// Users exist now, a restart neither creates another admin nor rewrites the file
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.EnsureAdmin(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(file); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("password file written again for an existing admin: %v", err)
+	}
+}
+
+func TestEnsureAdminReplacesLeftoverFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "admin-password")
+	if err := os.WriteFile(file, []byte("stale\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	newTestAuthService(t, config.AuthConfig{AdminPasswordFile: file})
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("password file mode = %o, want 600", perm)
+	}
+	if content, _ := os.ReadFile(file); strings.TrimSpace(string(content)) == "stale" {
+		t.Errorf("leftover password file was kept")
+	}
+}
+
+func TestEnsureAdminConfiguredPassword(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "admin-password")
+	a := newTestAuthService(t, config.AuthConfig{AdminPassword: "configured-secret", AdminPasswordFile: file})
+
+	if _, err := os.Stat(file); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("password file written for a configured password: %v", err)
+	}
+	if _, err := a.Login("admin", "configured-secret"); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+}
+
+func TestEnsureAdminWithoutPasswordFile(t *testing.T) {
+	db := openTestDB(t, &models.User{}, &models.UserScope{})
+	a := &AuthService{db: db, config: config.AuthConfig{AdminUsername: "admin", BcryptCost: bcrypt.MinCost}}
+
+	if err := a.EnsureAdmin(); err == nil {
+		t.Fatal("admin created with a password nobody can read")
+	}
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	if count != 0 {
+		t.Errorf("%d users created", count)
+	}
+}
+
+func TestAuthenticateTokens(t *testing.T) {
+	a := newTestAuthService(t, config.AuthConfig{})
+	pair, err := a.Login("admin", "admin-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	principal, err := a.Authenticate(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("access token: %v", err)
+	}
+	if principal.Username != "admin" || principal.Role != RoleAdmin || principal.Method != AuthMethodToken {
+		t.Errorf("principal = %+v", principal)
+	}
+	if _, err := a.Authenticate(pair.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("refresh token accepted as access token: %v", err)
+	}
+	if _, err := a.Refresh(pair.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("access token accepted as refresh token: %v", err)
+	}
+	if _, err := a.Login("admin", "wrong-password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("wrong password: %v", err)
+	}
+
+	other := &AuthService{db: a.db, config: a.config, secret: []byte("other-secret")}
+	if _, err := other.Authenticate(pair.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token accepted under another secret: %v", err)
+	}
+
+	disabled := true
+	if _, err := a.UpdateUser(principal.UserID, UserUpdate{Disabled: &disabled}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Authenticate(pair.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("access token of a disabled user accepted: %v", err)
+	}
+}
+
+func TestRefreshRotation(t *testing.T) {
+	a := newTestAuthService(t, config.AuthConfig{})
+	first, err := a.Login("admin", "admin-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := a.Refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == first.AccessToken {
+		t.Error("refresh returned the same tokens")
+	}
+	if _, err := a.Authenticate(second.AccessToken); err != nil {
+		t.Errorf("rotated access token: %v", err)
+	}
+
+	third, err := a.Refresh(second.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh of the rotated token: %v", err)
+	}
+	if err := a.Logout(third.RefreshToken); err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+	if _, err := a.Refresh(third.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("refresh after logout: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesAllSessions(t *testing.T) {
+	a := newTestAuthService(t, config.AuthConfig{})
+	stolen, err := a.Login("admin", "admin-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherDevice, err := a.Login("admin", "admin-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := a.Refresh(stolen.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The rotated-away token is presented again: it was copied
+	if _, err := a.Refresh(stolen.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("reused refresh token: %v", err)
+	}
+	for name, token := range map[string]string{"rotated": rotated.RefreshToken, "other device": otherDevice.RefreshToken} {
+		if _, err := a.Refresh(token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s refresh token still valid after reuse: %v", name, err)
+		}
+	}
+
+	var open int64
+	a.db.Model(&models.RefreshToken{}).Where("revoked_at IS NULL").Count(&open)
+	if open != 0 {
+		t.Errorf("%d refresh tokens left unrevoked", open)
+	}
+	if _, err := a.Login("admin", "admin-password"); err != nil {
+		t.Errorf("login after revocation: %v", err)
+	}
+}
+
+func TestRefreshExpired(t *testing.T) {
+	a := newTestAuthService(t, config.AuthConfig{})
+	user, err := a.CreateUser("noc", "noc-password", RoleViewer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, err := a.issueTokens(a.db, *user, time.Now().Add(-2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Refresh(pair.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expired refresh token: %v", err)
+	}
+	if _, err := a.Authenticate(pair.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expired access token: %v", err)
+	}
+}
@@ -0,0 +1,409 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"monik-enterprise/internal/config"
+	"monik-enterprise/internal/database"
+	"monik-enterprise/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Billing cycle units
+const (
+	BillingUnitMonth = "month"
+	BillingUnitDay   = "day"
+)
+
+// BillingDateLayout is the format of BillingCycle.AnchorDate
+const BillingDateLayout = "2006-01-02"
+
+// MaxUsageCycles bounds the number of cycles a usage report covers
+const MaxUsageCycles = 36
+
+// billingEpoch phases monthly cycles when no anchor date is set
+var billingEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// BillingService resolves the billing cycle of each interface and reports usage per
+// cycle from the daily MonthlyQuota rows. Daily rows are bucketed in the timezone of
+// the interface's cycle so cycle boundaries always fall on row boundaries.
+type BillingService struct {
+	db              *gorm.DB
+	defaults        models.BillingCycle
+	defaultLocation *time.Location
+	logger          *LoggerService
+	mu              sync.RWMutex
+	cycles          map[string]resolvedCycle // router/interface -> definition, cached
+}
+
+// resolvedCycle is the effective cycle definition of an interface
+type resolvedCycle struct {
+	cycle    models.BillingCycle
+	location *time.Location
+	custom   bool // false when the BILLING_* defaults apply
+}
+
+// CycleUsage is the traffic accounted within one billing cycle
+type CycleUsage struct {
+	Start         time.Time        `json:"start"`
+	End           time.Time        `json:"end"`
+	RxBytes       uint64           `json:"rx_bytes"`
+	TxBytes       uint64           `json:"tx_bytes"`
+	TotalBytes    uint64           `json:"total_bytes"`
+	DaysWithData  int              `json:"days_with_data"`
+	CoverageStart *time.Time       `json:"coverage_start"` // first day with data
+	InProgress    bool             `json:"in_progress"`
+	Partial       bool             `json:"partial"` // in progress or data does not cover the whole cycle
+	Projection    *UsageProjection `json:"projection,omitempty"`
+}
+
+// UsageProjection extrapolates the cycle-to-date usage to the end of the cycle at the
+// average rate observed since the cycle (or its data) started
+type UsageProjection struct {
+	ElapsedFraction   float64 `json:"elapsed_fraction"`
+	DailyAverageBytes uint64  `json:"daily_average_bytes"`
+	RxBytes           uint64  `json:"projected_rx_bytes"`
+	TxBytes           uint64  `json:"projected_tx_bytes"`
+	TotalBytes        uint64  `json:"projected_total_bytes"`
+}
+
+// UsageReport is the per-cycle usage of one interface, newest cycle first
+type UsageReport struct {
+	Cycle      models.BillingCycle   `json:"billing_cycle"`
+	Custom     bool                  `json:"custom_cycle"`
+	Cycles     []CycleUsage          `json:"cycles"`
+	DailyStats []models.MonthlyQuota `json:"daily_stats"` // rows of the newest cycle
+}
+
+// NewBillingService creates a billing service with the BILLING_* defaults
+func NewBillingService(db *gorm.DB, cfg config.BillingConfig) *BillingService {
+	defaults := models.BillingCycle{
+		StartDay:   cfg.StartDay,
+		Timezone:   cfg.Timezone,
+		Length:     cfg.Length,
+		Unit:       cfg.Unit,
+		AnchorDate: cfg.AnchorDate,
+	}
+	b := &BillingService{db: db, cycles: make(map[string]resolvedCycle)}
+	if err := NormalizeBillingCycle(&defaults); err != nil {
+		b.logger.Error(ComponentBilling, "config", "Invalid BILLING_* settings, using monthly cycles starting on day 1", err, nil)
+		defaults = models.BillingCycle{StartDay: 1, Length: 1, Unit: BillingUnitMonth}
+	}
+	b.defaults = defaults
+	b.defaultLocation, _ = billingLocation(defaults.Timezone)
+	return b
+}
+
+// SetLogger sets the structured logger of the billing service
+func (b *BillingService) SetLogger(logger *LoggerService) {
+	b.logger = logger
+}
+
+// NormalizeBillingCycle fills unset fields of a cycle definition and validates it
+func NormalizeBillingCycle(cycle *models.BillingCycle) error {
+	if cycle.Unit == "" {
+		cycle.Unit = BillingUnitMonth
+	}
+	if cycle.Length == 0 {
+		cycle.Length = 1
+	}
+	if cycle.StartDay == 0 {
+		cycle.StartDay = 1
+	}
+
+	switch cycle.Unit {
+	case BillingUnitMonth:
+		if cycle.Length < 1 || cycle.Length > 12 {
+			return errors.New("invalid length, expected 1-12 months")
+		}
+	case BillingUnitDay:
+		if cycle.Length < 1 || cycle.Length > 366 {
+			return errors.New("invalid length, expected 1-366 days")
+		}
+		if cycle.AnchorDate == "" {
+			return errors.New("anchor_date is required for day cycles")
+		}
+	default:
+		return errors.New("invalid unit, expected month or day")
+	}
+	if cycle.StartDay < 1 || cycle.StartDay > 31 {
+		return errors.New("invalid start_day, expected 1-31")
+	}
+	if _, err := billingLocation(cycle.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone: %s", cycle.Timezone)
+	}
+	if cycle.AnchorDate != "" {
+		if _, err := time.Parse(BillingDateLayout, cycle.AnchorDate); err != nil {
+			return errors.New("invalid anchor_date, expected YYYY-MM-DD")
+		}
+	}
+	return nil
+}
+
+// Defaults returns the cycle definition used by interfaces without their own
+func (b *BillingService) Defaults() models.BillingCycle {
+	return b.defaults
+}
+
+// Cycle returns the effective cycle definition of an interface and whether it is an
+// interface-specific one
+func (b *BillingService) Cycle(routerID uint, interfaceName string) (models.BillingCycle, bool) {
+	resolved := b.resolve(routerID, interfaceName)
+	return resolved.cycle, resolved.custom
+}
+
+// Location returns the timezone the billing days of an interface are counted in
+func (b *BillingService) Location(routerID uint, interfaceName string) *time.Location {
+	return b.resolve(routerID, interfaceName).location
+}
+
+// CycleAt returns the bounds of the billing cycle of an interface that contains t
+func (b *BillingService) CycleAt(routerID uint, interfaceName string, t time.Time) (start, end time.Time) {
+	resolved := b.resolve(routerID, interfaceName)
+	return cycleBounds(resolved.cycle, resolved.location, t)
+}
+
+// ListCycles returns the interface-specific cycle definitions, routerID 0 lists all
+func (b *BillingService) ListCycles(routerID uint) ([]models.BillingCycle, error) {
+	var cycles []models.BillingCycle
+	query := b.db.Order("router_id ASC, interface_name ASC")
+	if routerID != 0 {
+		query = query.Where("router_id = ?", routerID)
+	}
+	err := query.Find(&cycles).Error
+	return cycles, err
+}
+
+// SetCycle creates or replaces the cycle definition of an interface
+func (b *BillingService) SetCycle(cycle models.BillingCycle) (*models.BillingCycle, error) {
+	if err := NormalizeBillingCycle(&cycle); err != nil {
+		return nil, err
+	}
+	cycle.ID = 0
+	err := b.db.Clauses(database.Upsert(
+		[]string{"router_id", "interface_name"},
+		[]string{"start_day", "timezone", "length", "unit", "anchor_date", "updated_at"},
+	)).Create(&cycle).Error
+	if err != nil {
+		return nil, err
+	}
+	b.invalidate(cycle.RouterID, cycle.InterfaceName)
+
+	var saved models.BillingCycle
+	if err := b.db.Where("router_id = ? AND interface_name = ?", cycle.RouterID, cycle.InterfaceName).
+		First(&saved).Error; err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// DeleteCycle removes the cycle definition of an interface so the defaults apply again
+func (b *BillingService) DeleteCycle(routerID uint, interfaceName string) error {
+	res := b.db.Where("router_id = ? AND interface_name = ?", routerID, interfaceName).
+		Delete(&models.BillingCycle{})
+	if res.Error != nil {
+		return res.Error
+	}
+	b.invalidate(routerID, interfaceName)
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Usage reports the usage of the count most recent billing cycles up to now
+func (b *BillingService) Usage(routerID uint, interfaceName string, count int, now time.Time) (*UsageReport, error) {
+	if count < 1 {
+		count = 1
+	} else if count > MaxUsageCycles {
+		count = MaxUsageCycles
+	}
+	resolved := b.resolve(routerID, interfaceName)
+	loc := resolved.location
+
+	report := &UsageReport{Cycle: resolved.cycle, Custom: resolved.custom}
+	at := now
+	for i := 0; i < count; i++ {
+		start, end := cycleBounds(resolved.cycle, loc, at)
+		report.Cycles = append(report.Cycles, CycleUsage{Start: start, End: end})
+		at = start.Add(-time.Nanosecond)
+	}
+	oldest := report.Cycles[len(report.Cycles)-1].Start
+	newest := report.Cycles[0].End
+
+	var rows []models.MonthlyQuota
+	if err := b.db.Where("router_id = ? AND interface_name = ? AND year*10000 + month*100 + day >= ? AND year*10000 + month*100 + day < ?",
+		routerID, interfaceName, dateKey(oldest), dateKey(newest)).
+		Order("year ASC, month ASC, day ASC").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		day := time.Date(row.Year, time.Month(row.Month), row.Day, 0, 0, 0, 0, loc)
+		for i := range report.Cycles {
+			usage := &report.Cycles[i]
+			if day.Before(usage.Start) || !day.Before(usage.End) {
+				continue
+			}
+			usage.RxBytes += row.TotalRx
+			usage.TxBytes += row.TotalTx
+			usage.TotalBytes += row.TotalRx + row.TotalTx
+			usage.DaysWithData++
+			if usage.CoverageStart == nil {
+				coverage := day
+				usage.CoverageStart = &coverage
+			}
+			if i == 0 {
+				report.DailyStats = append(report.DailyStats, row)
+			}
+			break
+		}
+	}
+
+	for i := range report.Cycles {
+		usage := &report.Cycles[i]
+		usage.InProgress = !now.Before(usage.Start) && now.Before(usage.End)
+		usage.Partial = usage.InProgress || usage.CoverageStart == nil || usage.CoverageStart.After(usage.Start)
+		if usage.InProgress {
+			usage.Projection = projectUsage(*usage, now)
+		}
+	}
+	return report, nil
+}
+
+// projectUsage extrapolates a cycle in progress to its end
+func projectUsage(usage CycleUsage, now time.Time) *UsageProjection {
+	from := usage.Start
+	if usage.CoverageStart != nil && usage.CoverageStart.After(from) {
+		from = *usage.CoverageStart
+	}
+	elapsed := now.Sub(from).Seconds()
+	if elapsed <= 0 {
+		return nil
+	}
+	remaining := usage.End.Sub(now).Seconds()
+	project := func(bytes uint64) uint64 {
+		return bytes + uint64(float64(bytes)/elapsed*remaining)
+	}
+	return &UsageProjection{
+		ElapsedFraction:   now.Sub(usage.Start).Seconds() / usage.End.Sub(usage.Start).Seconds(),
+		DailyAverageBytes: uint64(float64(usage.TotalBytes) / elapsed * 86400),
+		RxBytes:           project(usage.RxBytes),
+		TxBytes:           project(usage.TxBytes),
+		TotalBytes:        project(usage.RxBytes) + project(usage.TxBytes),
+	}
+}
+
+// resolve loads the cycle definition of an interface, caching lookups including misses
+func (b *BillingService) resolve(routerID uint, interfaceName string) resolvedCycle {
+	key := fmt.Sprintf("%d/%s", routerID, interfaceName)
+	b.mu.RLock()
+	resolved, ok := b.cycles[key]
+	b.mu.RUnlock()
+	if ok {
+		return resolved
+	}
+
+	resolved = resolvedCycle{cycle: b.defaults, location: b.defaultLocation}
+	var cycle models.BillingCycle
+	err := b.db.Where("router_id = ? AND interface_name = ?", routerID, interfaceName).First(&cycle).Error
+	if err == nil {
+		if loc, locErr := billingLocation(cycle.Timezone); locErr == nil {
+			resolved = resolvedCycle{cycle: cycle, location: loc, custom: true}
+		} else {
+			b.logger.Warn(ComponentBilling, "cycle", "Ignoring cycle with unknown timezone", map[string]interface{}{
+				"router_id": routerID,
+				"interface": interfaceName,
+				"timezone":  cycle.Timezone,
+			})
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		// Do not cache the defaults when the lookup itself failed
+		return resolved
+	}
+
+	b.mu.Lock()
+	b.cycles[key] = resolved
+	b.mu.Unlock()
+	return resolved
+}
+
+func (b *BillingService) invalidate(routerID uint, interfaceName string) {
+	b.mu.Lock()
+	delete(b.cycles, fmt.Sprintf("%d/%s", routerID, interfaceName))
+	b.mu.Unlock()
+}
+
+// billingLocation loads an IANA timezone, an empty name is the server timezone
+func billingLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// cycleBounds returns the cycle of a definition that contains t. Cycles start at
+// midnight in loc.
+func cycleBounds(cycle models.BillingCycle, loc *time.Location, t time.Time) (start, end time.Time) {
+	t = t.In(loc)
+	anchor := time.Date(billingEpoch.Year(), billingEpoch.Month(), billingEpoch.Day(), 0, 0, 0, 0, loc)
+	if cycle.AnchorDate != "" {
+		if parsed, err := time.ParseInLocation(BillingDateLayout, cycle.AnchorDate, loc); err == nil {
+			anchor = parsed
+		}
+	}
+
+	if cycle.Unit == BillingUnitDay {
+		offset := floorDiv(civilDays(anchor, t), cycle.Length) * cycle.Length
+		start = anchor.AddDate(0, 0, offset)
+		return start, start.AddDate(0, 0, cycle.Length)
+	}
+
+	index := t.Year()*12 + int(t.Month()) - 1
+	if monthCycleStart(index, cycle.StartDay, loc).After(t) {
+		index--
+	}
+	anchorIndex := anchor.Year()*12 + int(anchor.Month()) - 1
+	index -= floorMod(index-anchorIndex, cycle.Length)
+	return monthCycleStart(index, cycle.StartDay, loc), monthCycleStart(index+cycle.Length, cycle.StartDay, loc)
+}
+
+// monthCycleStart returns midnight of day in the month with the given index
+// (year*12 + month-1), clamped to the last day of short months
+func monthCycleStart(index, day int, loc *time.Location) time.Time {
+	year, month := index/12, time.Month(index%12+1)
+	if last := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day(); day > last {
+		day = last
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// civilDays counts calendar days from a to b, ignoring DST shifts
+func civilDays(a, b time.Time) int {
+	from := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// dateKey encodes the calendar date of t as YYYYMMDD, matching the day columns of
+// MonthlyQuota
+func dateKey(t time.Time) int {
+	return t.Year()*10000 + int(t.Month())*100 + t.Day()
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+func floorMod(a, b int) int {
+	return a - floorDiv(a, b)*b
+}
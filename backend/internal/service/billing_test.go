@@ -0,0 +1,196 @@
+package service
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // zone rules for the DST cases, independent of the host
+
+	"monik-enterprise/internal/models"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load location %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestCycleBounds(t *testing.T) {
+	utc := time.UTC
+	jakarta := mustLoadLocation(t, "Asia/Jakarta")
+	newYork := mustLoadLocation(t, "America/New_York")
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	monthly := func(startDay int) models.BillingCycle {
+		return models.BillingCycle{StartDay: startDay, Length: 1, Unit: BillingUnitMonth}
+	}
+
+	tests := []struct {
+		name      string
+		cycle     models.BillingCycle
+		loc       *time.Location
+		at        time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			name:      "day 1",
+			cycle:     monthly(1),
+			loc:       utc,
+			at:        time.Date(2026, 6, 15, 12, 0, 0, 0, utc),
+			wantStart: time.Date(2026, 6, 1, 0, 0, 0, 0, utc),
+			wantEnd:   time.Date(2026, 7, 1, 0, 0, 0, 0, utc),
+		},
+		{
+			name:      "day 31 clamps to the end of February",
+			cycle:     monthly(31),
+			loc:       utc,
+			at:        time.Date(2027, 2, 15, 0, 0, 0, 0, utc),
+			wantStart: time.Date(2027, 1, 31, 0, 0, 0, 0, utc),
+			wantEnd:   time.Date(2027, 2, 28, 0, 0, 0, 0, utc),
+		},
+		{
+			name:      "day 31 cycle starting on February 28",
+			cycle:     monthly(31),
+			loc:       utc,
+			at:        time.Date(2027, 2, 28, 10, 0, 0, 0, utc),
+			wantStart: time.Date(2027, 2, 28, 0, 0, 0, 0, utc),
+			wantEnd:   time.Date(2027, 3, 31, 0, 0, 0, 0, utc),
+		},
+		{
+			name:      "day 31 clamps to April 30",
+			cycle:     monthly(31),
+			loc:       utc,
+			at:        time.Date(2026, 4, 29, 23, 59, 59, 0, utc),
+			wantStart: time.Date(2026, 3, 31, 0, 0, 0, 0, utc),
+			wantEnd:   time.Date(2026, 4, 30, 0, 0, 0, 0, utc),
+		},
+		{
+			name:      "day 30 on February 29 of a leap year",
+			cycle:     monthly(30),
+			loc:       utc,
+			at:        time.Date(2028, 2, 29, 8, 0, 0, 0, utc),
+			wantStart: time.Date(2028, 2, 29, 0, 0, 0, 0, utc),
+			wantEnd:   time.Date(2028, 3, 30, 0, 0, 0, 0, utc),
+		},
+		{
+			name:      "day 29 in a non-leap February",
+			cycle:     monthly(29),
+			loc:       utc,
+			at:        time.Date(2027, 3, 1, 0, 0, 0, 0, utc),
+			wantStart: time.Date(2027, 2, 28, 0, 0, 0, 0, utc),
+			wantEnd:   time.Date(2027, 3, 29, 0, 0, 0, 0, utc),
+		},
+		{
+			name:      "boundary instant belongs to the new cycle",
+			cycle:     monthly(29),
+			loc:       utc,
+			at:        time.Date(2027, 3, 29, 0, 0, 0, 0, utc),
+			wantStart: time.Date(2027, 3, 29, 0, 0, 0, 0, utc),
+			wantEnd:   time.Date(2027, 4, 29, 0, 0, 0, 0, utc),
+		},
+		{
+			name:      "cycle crossing the year boundary",
+			cycle:     monthly(15),
+			loc:       utc,
+			at:        time.Date(2027, 1, 10, 0, 0, 0, 0, utc),
+			wantStart: time.Date(2026, 12, 15, 0, 0, 0, 0, utc),
+			wantEnd:   time.Date(2027, 1, 15, 0, 0, 0, 0, utc),
+		},
+		{
+			name:      "quarterly cycle anchored across the year boundary",
+			cycle:     models.BillingCycle{StartDay: 1, Length: 3, Unit: BillingUnitMonth, AnchorDate: "2026-11-01"},
+			loc:       utc,
+			at:        time.Date(2027, 1, 20, 0, 0, 0, 0, utc),
+			wantStart: time.Date(2026, 11, 1, 0, 0, 0, 0, utc),
+			wantEnd:   time.Date(2027, 2, 1, 0, 0, 0, 0, utc),
+		},
+		{
+			name:      "timezone ahead of UTC starts the cycle on the local date",
+			cycle:     monthly(1),
+			loc:       jakarta,
+			at:        time.Date(2026, 5, 31, 18, 0, 0, 0, utc), // June 1, 01:00 WIB
+			wantStart: time.Date(2026, 6, 1, 0, 0, 0, 0, jakarta),
+			wantEnd:   time.Date(2026, 7, 1, 0, 0, 0, 0, jakarta),
+		},
+		{
+			name:      "timezone behind UTC keeps the previous local date",
+			cycle:     monthly(1),
+			loc:       newYork,
+			at:        time.Date(2027, 1, 1, 3, 0, 0, 0, utc), // December 31, 22:00 EST
+			wantStart: time.Date(2026, 12, 1, 0, 0, 0, 0, newYork),
+			wantEnd:   time.Date(2027, 1, 1, 0, 0, 0, 0, newYork),
+		},
+		{
+			name:      "cycle starting on the DST switch day",
+			cycle:     monthly(8),
+			loc:       newYork,
+			at:        time.Date(2026, 3, 10, 12, 0, 0, 0, utc),
+			wantStart: time.Date(2026, 3, 8, 0, 0, 0, 0, newYork), // EST
+			wantEnd:   time.Date(2026, 4, 8, 0, 0, 0, 0, newYork), // EDT
+		},
+		{
+			name:      "day cycle across the year boundary",
+			cycle:     models.BillingCycle{Length: 30, Unit: BillingUnitDay, AnchorDate: "2026-12-20"},
+			loc:       utc,
+			at:        time.Date(2027, 1, 25, 0, 0, 0, 0, utc),
+			wantStart: time.Date(2027, 1, 19, 0, 0, 0, 0, utc),
+			wantEnd:   time.Date(2027, 2, 18, 0, 0, 0, 0, utc),
+		},
+		{
+			name:      "day cycle before its anchor",
+			cycle:     models.BillingCycle{Length: 7, Unit: BillingUnitDay, AnchorDate: "2026-03-26"},
+			loc:       berlin,
+			at:        time.Date(2026, 3, 20, 12, 0, 0, 0, berlin),
+			wantStart: time.Date(2026, 3, 19, 0, 0, 0, 0, berlin),
+			wantEnd:   time.Date(2026, 3, 26, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:      "day cycle spanning the spring DST switch",
+			cycle:     models.BillingCycle{Length: 7, Unit: BillingUnitDay, AnchorDate: "2026-03-26"},
+			loc:       berlin,
+			at:        time.Date(2026, 4, 1, 23, 30, 0, 0, berlin),
+			wantStart: time.Date(2026, 3, 26, 0, 0, 0, 0, berlin), // CET
+			wantEnd:   time.Date(2026, 4, 2, 0, 0, 0, 0, berlin),  // CEST, 167 hours later
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := cycleBounds(tt.cycle, tt.loc, tt.at)
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("cycleBounds(%s) = [%s, %s), want [%s, %s)", tt.at,
+					start.In(tt.loc), end.In(tt.loc), tt.wantStart, tt.wantEnd)
+			}
+			if tt.at.Before(start) || !tt.at.Before(end) {
+				t.Errorf("%s is outside its own cycle [%s, %s)", tt.at, start, end)
+			}
+			if h, m, s := start.In(tt.loc).Clock(); h+m+s != 0 {
+				t.Errorf("cycle starts at %s, want local midnight", start.In(tt.loc))
+			}
+		})
+	}
+}
+
+func TestCycleBoundsAreContiguous(t *testing.T) {
+	// Consecutive cycles must tile the timeline: the end of one is the start of the next
+	cycles := []models.BillingCycle{
+		{StartDay: 31, Length: 1, Unit: BillingUnitMonth},
+		{StartDay: 29, Length: 2, Unit: BillingUnitMonth, AnchorDate: "2026-12-29"},
+		{Length: 10, Unit: BillingUnitDay, AnchorDate: "2026-10-20"},
+	}
+	for _, loc := range []*time.Location{time.UTC, mustLoadLocation(t, "Europe/Berlin")} {
+		for _, cycle := range cycles {
+			start, end := cycleBounds(cycle, loc, time.Date(2026, 1, 5, 0, 0, 0, 0, loc))
+			for i := 0; i < 30; i++ {
+				nextStart, nextEnd := cycleBounds(cycle, loc, end)
+				if !nextStart.Equal(end) {
+					t.Fatalf("%+v in %s: cycle [%s, %s) is followed by one starting %s",
+						cycle, loc, start, end, nextStart)
+				}
+				start, end = nextStart, nextEnd
+			}
+		}
+	}
+}
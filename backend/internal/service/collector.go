@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"monik-enterprise/internal/config"
+)
+
+// Router collector backends selectable per router
+const (
+	CollectorBackendAPI  = "api"  // RouterOS binary API (8728/8729)
+	CollectorBackendREST = "rest" // RouterOS v7 REST JSON API over HTTPS
+	CollectorBackendSNMP = "snmp" // SNMP v2c/v3 IF-MIB polling
+)
+
+// IsValidCollectorBackend reports whether backend names a supported collector
+func IsValidCollectorBackend(backend string) bool {
+	switch backend {
+	case CollectorBackendAPI, CollectorBackendREST, CollectorBackendSNMP:
+		return true
+	}
+	return false
+}
+
+// RouterCollector retrieves monitoring data from a single router. MonitoringService and
+// WorkerPool only depend on this interface so the transport can differ per router.
+type RouterCollector interface {
+	GetInterfaces(ctx context.Context) ([]InterfaceData, error)
+	GetTrafficStats(ctx context.Context, interfaceName string) (*InterfaceData, error)
+	GetSystemInfo(ctx context.Context) (*SystemInfo, error)
+	GetUptime(ctx context.Context) (time.Duration, error)
+	GetLastRebootLog(ctx context.Context) (time.Time, error)
+	Close()
+}
+
+// StreamingCollector is implemented by collectors that can push interface counters over
+// a long-lived subscription instead of being polled. StreamInterfaces blocks until ctx
+// ends or the subscription drops; the caller resubscribes.
+type StreamingCollector interface {
+	StreamInterfaces(ctx context.Context, interval time.Duration, handle func([]InterfaceData)) error
+}
+
+// RouterEnforcer is implemented by collectors that can change router configuration, used
+// to enforce quota limits. AddSimpleQueue replaces a queue of the same name.
+type RouterEnforcer interface {
+	AddSimpleQueue(ctx context.Context, name, target, maxLimit, comment string) error
+	RemoveSimpleQueue(ctx context.Context, name string) error
+	SetInterfaceDisabled(ctx context.Context, name string, disabled bool) error
+}
+
+// NewRouterCollector creates the collector matching the configured backend, logging
+// through logger
+func NewRouterCollector(cfg config.RouterConfig, logger *LoggerService) (RouterCollector, error) {
+	switch cfg.Backend {
+	case "", CollectorBackendAPI:
+		collector := NewMikroTikService(cfg)
+		collector.SetLogger(logger)
+		return collector, nil
+	case CollectorBackendREST:
+		collector := NewRESTCollector(cfg)
+		collector.SetLogger(logger)
+		return collector, nil
+	case CollectorBackendSNMP:
+		collector := NewSNMPCollector(cfg)
+		collector.SetLogger(logger)
+		return collector, nil
+	default:
+		return nil, fmt.Errorf("unknown router backend: %s", cfg.Backend)
+	}
+}
+
+// routerLogFields adds the router address to the metadata of a collector log entry
+func routerLogFields(cfg config.RouterConfig, fields map[string]interface{}) map[string]interface{} {
+	if fields == nil {
+		fields = make(map[string]interface{})
+	}
+	fields["router"] = fmt.Sprintf("%s:%d", cfg.IP, cfg.Port)
+	return fields
+}
+
+// counterSample is the last byte counter reading of an interface
+type counterSample struct {
+	rxBytes uint64
+	txBytes uint64
+	at      time.Time
+}
+
+// counterRates derives Mbps rates from consecutive byte counter readings
+type counterRates struct {
+	samples map[string]counterSample
+}
+
+func newCounterRates() *counterRates {
+	return &counterRates{samples: make(map[string]counterSample)}
+}
+
+// update stores a reading and returns the rates since the previous one. The first
+// reading and a counter that went backwards (reset) yield zero.
+func (r *counterRates) update(name string, rx, tx uint64, now time.Time) (rxRate, txRate float64) {
+	prev, ok := r.samples[name]
+	r.samples[name] = counterSample{rxBytes: rx, txBytes: tx, at: now}
+	if !ok {
+		return 0, 0
+	}
+	elapsed := now.Sub(prev.at).Seconds()
+	if elapsed <= 0 {
+		return 0, 0
+	}
+	if rx >= prev.rxBytes {
+		rxRate = float64(rx-prev.rxBytes) * 8 / elapsed / 1000000
+	}
+	if tx >= prev.txBytes {
+		txRate = float64(tx-prev.txBytes) * 8 / elapsed / 1000000
+	}
+	return rxRate, txRate
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"monik-enterprise/internal/models"
+)
+
+// Counter reset kinds, stored in CounterResetLog.DetectionMethod and TrafficSnapshot.ResetKind
+const (
+	ResetKindReboot    = "reboot"              // Router uptime went backwards, every counter restarted
+	ResetKindManual    = "manual_reset"        // Counters were zeroed while the router kept running
+	ResetKindRecreated = "interface_recreated" // Interface re-created under the same name with a new .id
+	ResetKindWrap      = "counter_wrap"        // Counter wrapped around, at 4 GiB for 32-bit counters
+	ResetKindUnknown   = "sudden_drop"         // Counters dropped and the router uptime was not available
+)
+
+// counterReset describes why the counters of an interface went backwards
+type counterReset struct {
+	Kind  string
+	Notes string
+	Since time.Time // When the counters restarted, zero when unknown
+}
+
+// restarted reports whether the counters started over from zero. A wrap is not a restart.
+func (r *counterReset) restarted() bool {
+	return r != nil && r.Kind != ResetKindWrap
+}
+
+// kind returns the reset kind, empty when there was no reset
+func (r *counterReset) kind() string {
+	if r == nil {
+		return ""
+	}
+	return r.Kind
+}
+
+// resetClassifier classifies counter drops in one round of readings of a router. The
+// router boot time is looked up at most once per round and only when a drop is seen.
+type resetClassifier struct {
+	router   models.Router
+	registry *RouterRegistry
+	timeout  time.Duration
+	logger   *LoggerService
+
+	looked   bool
+	bootTime time.Time
+	bootErr  error
+}
+
+func newResetClassifier(router models.Router, registry *RouterRegistry, timeout time.Duration, logger *LoggerService) *resetClassifier {
+	return &resetClassifier{router: router, registry: registry, timeout: timeout, logger: logger}
+}
+
+// classify compares a reading with the stored one and returns nil when the counters
+// moved forward normally
+func (c *resetClassifier) classify(prev models.Interface, iface InterfaceData) *counterReset {
+	rxDrop := iface.RxBytes < prev.RxBytes
+	txDrop := iface.TxBytes < prev.TxBytes
+	recreated := prev.ObjectID != "" && iface.ObjectID != "" && prev.ObjectID != iface.ObjectID
+	if !rxDrop && !txDrop && !recreated {
+		return nil
+	}
+
+	// A reboot also renumbers dynamic interfaces, so it is checked first
+	bootTime, err := c.routerBootTime()
+	if err == nil && bootTime.After(prev.CountersAt) {
+		return &counterReset{
+			Kind:  ResetKindReboot,
+			Since: bootTime,
+			Notes: fmt.Sprintf("router booted at %s, after the previous reading at %s", bootTime.Format(time.RFC3339), prev.CountersAt.Format(time.RFC3339)),
+		}
+	}
+	if recreated {
+		return &counterReset{
+			Kind:  ResetKindRecreated,
+			Notes: fmt.Sprintf("interface id changed from %s to %s", prev.ObjectID, iface.ObjectID),
+		}
+	}
+	if wrapped(prev.RxBytes, iface.RxBytes, iface.Counter32) && wrapped(prev.TxBytes, iface.TxBytes, iface.Counter32) {
+		notes := "64-bit counter wrapped around"
+		if iface.Counter32 {
+			notes = "32-bit counter wrapped around"
+		}
+		return &counterReset{Kind: ResetKindWrap, Notes: notes}
+	}
+	if err != nil {
+		return &counterReset{
+			Kind:  ResetKindUnknown,
+			Notes: fmt.Sprintf("router uptime not available: %v", err),
+		}
+	}
+	return &counterReset{
+		Kind:  ResetKindManual,
+		Notes: fmt.Sprintf("router up since %s", bootTime.Format(time.RFC3339)),
+	}
+}
+
+// routerBootTime asks the router when it booted, from its uptime or failing that from
+// the last boot message in its log
+func (c *resetClassifier) routerBootTime() (time.Time, error) {
+	if c.looked {
+		return c.bootTime, c.bootErr
+	}
+	c.looked = true
+
+	collector, err := c.registry.Collector(c.router)
+	if err != nil {
+		c.bootErr = err
+		return c.bootTime, c.bootErr
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	uptime, err := collector.GetUptime(ctx)
+	if err == nil {
+		c.bootTime = time.Now().Add(-uptime)
+		return c.bootTime, nil
+	}
+	c.logger.Warn(ComponentMonitoring, "counter_reset", "Router uptime not available, checking the reboot log", map[string]interface{}{
+		"router": c.router.Name,
+		"error":  err.Error(),
+	})
+	c.bootTime, c.bootErr = collector.GetLastRebootLog(ctx)
+	return c.bootTime, c.bootErr
+}
+
+// wrapped reports whether a counter going from prev to curr is explained by a single
+// wraparound. A counter that did not go backwards trivially qualifies; one that dropped
+// from the lower half of its range was reset rather than wrapped.
+func wrapped(prev, curr uint64, counter32 bool) bool {
+	if curr >= prev {
+		return true
+	}
+	limit := uint64(math.MaxUint64)
+	if counter32 {
+		limit = math.MaxUint32
+	}
+	return prev <= limit && prev > limit/2
+}
+
+// counterDelta returns the bytes transferred between two readings of one counter. kind
+// is the reset detected at the second reading, empty when there was none.
+func counterDelta(prev, curr uint64, kind string) uint64 {
+	switch kind {
+	case "":
+		if curr < prev {
+			// Unexplained drop, the new reading is the transfer since it
+			return curr
+		}
+		return curr - prev
+	case ResetKindWrap:
+		if curr < prev {
+			if prev > math.MaxUint32 {
+				// Only a 64-bit counter gets past 4 GiB
+				return math.MaxUint64 - prev + curr + 1
+			}
+			return math.MaxUint32 - prev + curr + 1
+		}
+		return curr - prev
+	default:
+		// Counters restarted from zero
+		return curr
+	}
+}
+
+// snapshotResetKind returns the reset kind of a snapshot. Snapshots written before
+// resets were classified only carry the CounterReset flag.
+func snapshotResetKind(snapshot *models.TrafficSnapshot) string {
+	if snapshot.ResetKind != "" {
+		return snapshot.ResetKind
+	}
+	if snapshot.CounterReset {
+		return ResetKindUnknown
+	}
+	return ""
+}
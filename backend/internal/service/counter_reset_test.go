@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"monik-enterprise/internal/models"
+)
+
+// fakeCollector answers the uptime and reboot log queries of the reset classifier
+type fakeCollector struct {
+	RouterCollector
+	uptime      time.Duration
+	uptimeErr   error
+	rebootAt    time.Time
+	rebootErr   error
+	uptimeCalls int
+}
+
+func (f *fakeCollector) GetUptime(ctx context.Context) (time.Duration, error) {
+	f.uptimeCalls++
+	return f.uptime, f.uptimeErr
+}
+
+func (f *fakeCollector) GetLastRebootLog(ctx context.Context) (time.Time, error) {
+	return f.rebootAt, f.rebootErr
+}
+
+func (f *fakeCollector) Close() {}
+
+// newTestClassifier returns a classifier for router 1 backed by collector
+func newTestClassifier(collector RouterCollector) *resetClassifier {
+	registry := &RouterRegistry{collectors: map[uint]RouterCollector{1: collector}}
+	return newResetClassifier(models.Router{ID: 1, Name: "core"}, registry, time.Second, nil)
+}
+
+func TestCounterDelta(t *testing.T) {
+	tests := []struct {
+		name       string
+		prev, curr uint64
+		kind       string
+		want       uint64
+	}{
+		{name: "forward", prev: 100, curr: 150, want: 50},
+		{name: "unchanged", prev: 100, curr: 100, want: 0},
+		{name: "unexplained drop counts the new reading", prev: 100, curr: 30, want: 30},
+		{name: "32-bit wrap", prev: math.MaxUint32 - 99, curr: 100, kind: ResetKindWrap, want: 200},
+		{name: "32-bit wrap to zero", prev: math.MaxUint32, curr: 0, kind: ResetKindWrap, want: 1},
+		{name: "64-bit wrap", prev: math.MaxUint64 - 9, curr: 10, kind: ResetKindWrap, want: 20},
+		{name: "64-bit wrap to zero", prev: math.MaxUint64, curr: 0, kind: ResetKindWrap, want: 1},
+		{name: "wrap of the other direction", prev: 10, curr: 20, kind: ResetKindWrap, want: 10},
+		{name: "reboot restarts from zero", prev: 5000, curr: 300, kind: ResetKindReboot, want: 300},
+		{name: "reboot with a higher reading", prev: 300, curr: 5000, kind: ResetKindReboot, want: 5000},
+		{name: "manual reset", prev: 5000, curr: 300, kind: ResetKindManual, want: 300},
+		{name: "interface re-created", prev: 5000, curr: 300, kind: ResetKindRecreated, want: 300},
+		{name: "sudden drop", prev: 5000, curr: 300, kind: ResetKindUnknown, want: 300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := counterDelta(tt.prev, tt.curr, tt.kind); got != tt.want {
+				t.Errorf("counterDelta(%d, %d, %q) = %d, want %d", tt.prev, tt.curr, tt.kind, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResetClassifier(t *testing.T) {
+	prevAt := time.Now().Add(-time.Minute)
+	longUp := 30 * 24 * time.Hour           // booted long before the previous reading
+	rebooted := 20 * time.Second            // booted after the previous reading
+	high32 := uint64(math.MaxUint32 - 1000) // upper half of the 32-bit range
+	high64 := uint64(math.MaxUint64 - 1000) // upper half of the 64-bit range
+	reading := func(id string, rx, tx uint64, counter32 bool) InterfaceData {
+		return InterfaceData{Name: "ether1", ObjectID: id, RxBytes: rx, TxBytes: tx, Counter32: counter32}
+	}
+	stored := func(id string, rx, tx uint64) models.Interface {
+		return models.Interface{InterfaceName: "ether1", ObjectID: id, RxBytes: rx, TxBytes: tx, CountersAt: prevAt}
+	}
+	noUptime := errors.New("no uptime")
+
+	tests := []struct {
+		name      string
+		collector *fakeCollector
+		prev      models.Interface
+		iface     InterfaceData
+		wantKind  string // "" for no reset
+		wantSince bool   // the reset carries the boot time
+	}{
+		{
+			name:      "counters moved forward",
+			collector: &fakeCollector{uptime: longUp},
+			prev:      stored("*1", 100, 100),
+			iface:     reading("*1", 200, 300, false),
+		},
+		{
+			name:      "32-bit wrap",
+			collector: &fakeCollector{uptime: longUp},
+			prev:      stored("*1", high32, 100),
+			iface:     reading("*1", 500, 200, true),
+			wantKind:  ResetKindWrap,
+		},
+		{
+			name:      "64-bit wrap",
+			collector: &fakeCollector{uptime: longUp},
+			prev:      stored("*1", 100, high64),
+			iface:     reading("*1", 200, 500, false),
+			wantKind:  ResetKindWrap,
+		},
+		{
+			name:      "32-bit drop from the lower half is a reset",
+			collector: &fakeCollector{uptime: longUp},
+			prev:      stored("*1", 1<<20, 100),
+			iface:     reading("*1", 500, 200, true),
+			wantKind:  ResetKindManual,
+		},
+		{
+			name:      "64-bit counter past 4 GiB dropping is a reset, not a 32-bit wrap",
+			collector: &fakeCollector{uptime: longUp},
+			prev:      stored("*1", high32+(1<<33), 100),
+			iface:     reading("*1", 500, 200, false),
+			wantKind:  ResetKindManual,
+		},
+		{
+			name:      "reboot looks like a 32-bit wrap but uptime says otherwise",
+			collector: &fakeCollector{uptime: rebooted},
+			prev:      stored("*1", high32, 100),
+			iface:     reading("*1", 500, 200, true),
+			wantKind:  ResetKindReboot,
+			wantSince: true,
+		},
+		{
+			name:      "reboot of a 64-bit counter",
+			collector: &fakeCollector{uptime: rebooted},
+			prev:      stored("*1", 1<<40, 1<<40),
+			iface:     reading("*1", 500, 200, false),
+			wantKind:  ResetKindReboot,
+			wantSince: true,
+		},
+		{
+			name:      "reboot found in the log when uptime is not available",
+			collector: &fakeCollector{uptimeErr: noUptime, rebootAt: time.Now().Add(-rebooted)},
+			prev:      stored("*1", 1<<40, 1<<40),
+			iface:     reading("*1", 500, 200, false),
+			wantKind:  ResetKindReboot,
+			wantSince: true,
+		},
+		{
+			name:      "32-bit wrap without uptime",
+			collector: &fakeCollector{uptimeErr: noUptime, rebootErr: noUptime},
+			prev:      stored("*1", high32, 100),
+			iface:     reading("*1", 500, 200, true),
+			wantKind:  ResetKindWrap,
+		},
+		{
+			name:      "drop without uptime",
+			collector: &fakeCollector{uptimeErr: noUptime, rebootErr: noUptime},
+			prev:      stored("*1", 1<<40, 1<<40),
+			iface:     reading("*1", 500, 200, false),
+			wantKind:  ResetKindUnknown,
+		},
+		{
+			name:      "interface re-created",
+			collector: &fakeCollector{uptime: longUp},
+			prev:      stored("*1", 1<<40, 1<<40),
+			iface:     reading("*7", 500, 200, false),
+			wantKind:  ResetKindRecreated,
+		},
+		{
+			name:      "reboot renumbering the interface",
+			collector: &fakeCollector{uptime: rebooted},
+			prev:      stored("*1", 1<<40, 1<<40),
+			iface:     reading("*7", 500, 200, false),
+			wantKind:  ResetKindReboot,
+			wantSince: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reset := newTestClassifier(tt.collector).classify(tt.prev, tt.iface)
+			if got := reset.kind(); got != tt.wantKind {
+				t.Fatalf("kind = %q, want %q (%+v)", got, tt.wantKind, reset)
+			}
+			if reset == nil {
+				return
+			}
+			if hasSince := !reset.Since.IsZero(); hasSince != tt.wantSince {
+				t.Errorf("since = %v, want set: %v", reset.Since, tt.wantSince)
+			}
+			if tt.wantSince && !reset.Since.After(tt.prev.CountersAt) {
+				t.Errorf("since %v is not after the previous reading %v", reset.Since, tt.prev.CountersAt)
+			}
+			if restarted := reset.restarted(); restarted == (tt.wantKind == ResetKindWrap) {
+				t.Errorf("restarted() = %v for kind %s", restarted, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestResetClassifierAsksOncePerRound(t *testing.T) {
+	collector := &fakeCollector{uptime: time.Hour}
+	classifier := newTestClassifier(collector)
+	prev := models.Interface{RxBytes: 1 << 40, TxBytes: 1 << 40, CountersAt: time.Now().Add(-time.Minute)}
+
+	if reset := classifier.classify(prev, InterfaceData{RxBytes: 2 << 40, TxBytes: 2 << 40}); reset != nil {
+		t.Fatalf("forward reading classified as %+v", reset)
+	}
+	if collector.uptimeCalls != 0 {
+		t.Errorf("uptime queried for a forward reading")
+	}
+	for i := 0; i < 3; i++ {
+		classifier.classify(prev, InterfaceData{RxBytes: 10, TxBytes: 10})
+	}
+	if collector.uptimeCalls != 1 {
+		t.Errorf("uptime queried %d times in one round, want 1", collector.uptimeCalls)
+	}
+}
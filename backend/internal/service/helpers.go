@@ -3,10 +3,12 @@ package service
 import (
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // parseUint64 is a helper function to parse string to uint64 safely
-func parseUint64(s string) uint64 {
+func parseUint64(logger *LoggerService, s string) uint64 {
 	if s == "" {
 		return 0
 	}
@@ -14,10 +16,71 @@ func parseUint64(s string) uint64 {
 		return val
 	}
 	// Log error for debugging but return 0 to prevent panic
-	fmt.Printf("Warning: Failed to parse uint64 from string: '%s'\n", s)
+	logger.Warn(ComponentCollector, "parse", "Failed to parse uint64", map[string]interface{}{
+		"value": s,
+	})
 	return 0
 }
 
+// routerOSDurationUnits maps the unit suffixes of RouterOS durations such as the
+// uptime "2w3d04:05:06" or "1d2h3m4s"
+var routerOSDurationUnits = map[byte]time.Duration{
+	'w': 7 * 24 * time.Hour,
+	'd': 24 * time.Hour,
+	'h': time.Hour,
+	'm': time.Minute,
+	's': time.Second,
+}
+
+// parseRouterOSDuration parses a RouterOS duration. Both the unit form "1w2d3h4m5s"
+// and the clock form "1w2d03:04:05" are accepted.
+func parseRouterOSDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	var total time.Duration
+	rest := s
+	for rest != "" {
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		if i == 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		if i == len(rest) || rest[i] == ':' {
+			// Remaining clock part hh:mm:ss
+			clock := strings.Split(rest, ":")
+			if len(clock) != 3 {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			for j, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+				n, err := strconv.Atoi(clock[j])
+				if err != nil {
+					return 0, fmt.Errorf("invalid duration %q", s)
+				}
+				total += time.Duration(n) * unit
+			}
+			return total, nil
+		}
+		n, _ := strconv.Atoi(rest[:i])
+		if strings.HasPrefix(rest[i:], "ms") {
+			total += time.Duration(n) * time.Millisecond
+			rest = rest[i+2:]
+			continue
+		}
+		unit, ok := routerOSDurationUnits[rest[i]]
+		if !ok {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		total += time.Duration(n) * unit
+		rest = rest[i+1:]
+	}
+	return total, nil
+}
+
 // CalculateDelta calculates the delta between current and previous values with protection against false resets
 func CalculateDelta(current, previous uint64, isReset bool) uint64 {
 	if isReset || current < previous {
@@ -14,6 +14,7 @@ type WebSocketManager interface {
 
 // RealTimeData represents real-time monitoring data
 type RealTimeData struct {
+	RouterID      uint      `json:"router_id"`
 	InterfaceName string    `json:"interface_name"`
 	RxRate        float64   `json:"rx_rate"`
 	TxRate        float64   `json:"tx_rate"`
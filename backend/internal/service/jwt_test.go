@@ -0,0 +1,107 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseToken(t *testing.T) {
+	secret := []byte("test-secret")
+	now := time.Date(2026, 7, 14, 12, 0, 0, 0, time.UTC)
+	claims := tokenClaims{
+		Subject:   7,
+		Username:  "noc",
+		Type:      TokenTypeAccess,
+		ID:        "abc",
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(15 * time.Minute).Unix(),
+	}
+	valid := mustSignToken(t, secret, claims)
+	parts := strings.Split(valid, ".")
+
+	encode := func(v string) string { return base64.RawURLEncoding.EncodeToString([]byte(v)) }
+	// signedWith builds a token with a custom header and claims, signed with the HS256 key
+	signedWith := func(header string, c tokenClaims) string {
+		payload, _ := json.Marshal(c)
+		unsigned := encode(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+		return unsigned + "." + tokenSignature(secret, unsigned)
+	}
+	withClaims := func(change func(*tokenClaims)) tokenClaims {
+		c := claims
+		change(&c)
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		now   time.Time
+		ok    bool
+	}{
+		{name: "valid", token: valid, now: now, ok: true},
+		{name: "valid until the last second", token: valid, now: now.Add(15*time.Minute - time.Second), ok: true},
+		{name: "expired at exp", token: valid, now: now.Add(15 * time.Minute)},
+		{name: "expired", token: valid, now: now.Add(time.Hour)},
+		{name: "other secret", token: mustSignToken(t, []byte("other"), claims), now: now},
+		{name: "payload changed", token: parts[0] + "." + encode(`{"sub":1,"typ":"access","exp":9999999999}`) + "." + parts[2], now: now},
+		{name: "signature stripped", token: parts[0] + "." + parts[1] + ".", now: now},
+		{name: "alg none", token: encode(`{"alg":"none","typ":"JWT"}`) + "." + parts[1] + ".", now: now},
+		{name: "alg none keeping the signature", token: encode(`{"alg":"none","typ":"JWT"}`) + "." + parts[1] + "." + parts[2], now: now},
+		{name: "alg HS512", token: signedWith(`{"alg":"HS512","typ":"JWT"}`, claims), now: now},
+		{name: "alg RS256", token: signedWith(`{"alg":"RS256","typ":"JWT"}`, claims), now: now},
+		{name: "header with extra fields", token: signedWith(`{"alg":"HS256","typ":"JWT","kid":"1"}`, claims), now: now},
+		{name: "two parts", token: parts[0] + "." + parts[1], now: now},
+		{name: "four parts", token: valid + ".x", now: now},
+		{name: "empty", token: "", now: now},
+		{name: "payload not base64", token: jwtHeader + ".!!!." + tokenSignature(secret, jwtHeader+".!!!"), now: now},
+		{name: "payload not JSON", token: jwtHeader + "." + encode("nope") + "." + tokenSignature(secret, jwtHeader+"."+encode("nope")), now: now},
+		{name: "no subject", token: mustSignToken(t, secret, withClaims(func(c *tokenClaims) { c.Subject = 0 })), now: now},
+		{name: "no expiry", token: mustSignToken(t, secret, withClaims(func(c *tokenClaims) { c.ExpiresAt = 0 })), now: now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseToken(secret, tt.token, tt.now)
+			if !tt.ok {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("parseToken = %+v, %v, want ErrInvalidToken", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseToken: %v", err)
+			}
+			if *got != claims {
+				t.Errorf("claims = %+v, want %+v", *got, claims)
+			}
+		})
+	}
+}
+
+func TestSignTokenHeader(t *testing.T) {
+	token := mustSignToken(t, []byte("k"), tokenClaims{Subject: 1, ExpiresAt: 1})
+	header, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]string
+	if err := json.Unmarshal(header, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["alg"] != "HS256" || fields["typ"] != "JWT" {
+		t.Errorf("header = %s, want HS256 JWT", header)
+	}
+}
+
+func mustSignToken(t *testing.T, secret []byte, claims tokenClaims) string {
+	t.Helper()
+	token, err := signToken(secret, claims)
+	if err != nil {
+		t.Fatalf("signToken: %v", err)
+	}
+	return token
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// rotatingFile is a log file that is renamed and replaced once it grows past maxSize
+// or has been written to for longer than interval. Rotated files are named
+// <path>.<timestamp> and only the newest maxBackups are kept.
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64         // 0 disables size-based rotation
+	interval   time.Duration // 0 disables time-based rotation
+	maxBackups int           // 0 keeps every rotated file
+	file       *os.File
+	size       int64
+	openedAt   time.Time
+}
+
+// rotatedSuffix is the timestamp layout appended to rotated files, it sorts by time
+const rotatedSuffix = "20060102-150405.000"
+
+// newRotatingFile opens path for appending, rotation continues from its current size
+func newRotatingFile(path string, maxSize int64, interval time.Duration, maxBackups int) (*rotatingFile, error) {
+	rf := &rotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		interval:   interval,
+		maxBackups: maxBackups,
+	}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+// open opens the active file. The caller must hold rf.mu, except in newRotatingFile.
+func (rf *rotatingFile) open() error {
+	if dir := filepath.Dir(rf.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create log directory: %w", err)
+		}
+	}
+	file, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	rf.file = file
+	rf.size = info.Size()
+	rf.openedAt = time.Now()
+	return nil
+}
+
+// Write appends p, rotating first when p would push the file past its limits
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file == nil {
+		return 0, os.ErrClosed
+	}
+	if rf.due(int64(len(p))) {
+		if err := rf.rotate(); err != nil {
+			// Keep logging to the current file rather than losing entries
+			fmt.Fprintf(os.Stderr, "[LOGGER] Failed to rotate %s: %v\n", rf.path, err)
+		}
+	}
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// due reports whether the active file must be rotated before writing n bytes
+func (rf *rotatingFile) due(n int64) bool {
+	if rf.size == 0 {
+		return false
+	}
+	if rf.maxSize > 0 && rf.size+n > rf.maxSize {
+		return true
+	}
+	return rf.interval > 0 && time.Since(rf.openedAt) >= rf.interval
+}
+
+// rotate renames the active file and opens a new one. The caller must hold rf.mu.
+func (rf *rotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return err
+	}
+	rf.file = nil
+	rotated := rf.path + "." + time.Now().Format(rotatedSuffix)
+	if err := os.Rename(rf.path, rotated); err != nil {
+		// Reopen the old file so logging continues
+		if openErr := rf.open(); openErr != nil {
+			return openErr
+		}
+		return err
+	}
+	if err := rf.open(); err != nil {
+		return err
+	}
+	rf.prune()
+	return nil
+}
+
+// prune removes the oldest rotated files beyond maxBackups. The caller must hold rf.mu.
+func (rf *rotatingFile) prune() {
+	if rf.maxBackups <= 0 {
+		return
+	}
+	backups, err := filepath.Glob(rf.path + ".*")
+	if err != nil || len(backups) <= rf.maxBackups {
+		return
+	}
+	sort.Strings(backups)
+	for _, old := range backups[:len(backups)-rf.maxBackups] {
+		if err := os.Remove(old); err != nil {
+			fmt.Fprintf(os.Stderr, "[LOGGER] Failed to remove old log %s: %v\n", old, err)
+		}
+	}
+}
+
+// Close closes the active file
+func (rf *rotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
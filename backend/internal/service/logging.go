@@ -3,10 +3,17 @@ package service
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"monik-enterprise/internal/config"
+	"monik-enterprise/internal/websocket"
 )
 
 // LogLevel represents different logging levels
@@ -20,6 +27,31 @@ const (
 	FatalLevel
 )
 
+// Log output formats
+const (
+	LogFormatJSON   = "json"
+	LogFormatLogfmt = "logfmt"
+)
+
+// Components logging through the LoggerService, each with its own adjustable level
+const (
+	ComponentApp          = "app"
+	ComponentMonitoring   = "monitoring"
+	ComponentCollector    = "collector"
+	ComponentWAN          = "wan"
+	ComponentWorkerPool   = "worker_pool"
+	ComponentWebSocket    = websocket.LogComponent
+	ComponentAlert        = "alert"
+	ComponentRollup       = "rollup"
+	ComponentAuth         = "auth"
+	ComponentQuota        = "quota"
+	ComponentNotification = "notification"
+	ComponentOutage       = "outage"
+	ComponentBilling      = "billing"
+	ComponentMaintenance  = "maintenance"
+	ComponentAudit        = "audit"
+)
+
 // LogEntry represents a structured log entry
 type LogEntry struct {
 	Timestamp time.Time              `json:"timestamp"`
@@ -36,29 +68,74 @@ type LoggerService struct {
 	mu         sync.RWMutex
 	logLevel   LogLevel
 	components map[string]LogLevel
-	file       *os.File
-	stdout     bool
+	format     string
+	writeMu    sync.Mutex
+	file       *rotatingFile
+	stdout     io.Writer     // nil when entries only go to the file
+	audit      *AuditService // Persists LogAudit entries, nil logs them only
 }
 
-// NewLoggerService creates a new logger service
-func NewLoggerService(level string, logFile string, stdout bool) (*LoggerService, error) {
+// fallbackLogger serves services that were never given a logger, so a nil
+// *LoggerService still logs to stdout
+var fallbackLogger = &LoggerService{
+	logLevel:   InfoLevel,
+	components: make(map[string]LogLevel),
+	format:     LogFormatLogfmt,
+	stdout:     os.Stdout,
+}
+
+// NewLoggerService creates a logger writing to stdout, a rotating file or both
+func NewLoggerService(cfg config.LoggingConfig) (*LoggerService, error) {
+	level, err := ParseLogLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
 	logger := &LoggerService{
-		logLevel:   LogLevelFromString(level),
+		logLevel:   level,
 		components: make(map[string]LogLevel),
-		stdout:     stdout,
+		format:     LogFormatJSON,
+	}
+	switch cfg.Format {
+	case "", LogFormatJSON:
+	case LogFormatLogfmt:
+		logger.format = LogFormatLogfmt
+	default:
+		return nil, fmt.Errorf("unknown log format %q (json or logfmt)", cfg.Format)
 	}
 
-	if logFile != "" {
-		file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	for _, override := range cfg.ComponentLevels {
+		component, levelName, ok := strings.Cut(override, "=")
+		if !ok || strings.TrimSpace(component) == "" {
+			return nil, fmt.Errorf("invalid component log level %q (component=level)", override)
+		}
+		componentLevel, err := ParseLogLevel(strings.TrimSpace(levelName))
 		if err != nil {
-			return nil, fmt.Errorf("failed to open log file: %w", err)
+			return nil, err
+		}
+		logger.components[strings.TrimSpace(component)] = componentLevel
+	}
+
+	if cfg.File != "" {
+		file, err := newRotatingFile(cfg.File, int64(cfg.MaxSizeMB)*1024*1024, cfg.RotateInterval, cfg.MaxBackups)
+		if err != nil {
+			return nil, err
 		}
 		logger.file = file
 	}
+	if cfg.Stdout || cfg.File == "" {
+		logger.stdout = os.Stdout
+	}
 
 	return logger, nil
 }
 
+// SetAuditService makes LogAudit persist entries to the audit trail
+func (ls *LoggerService) SetAuditService(audit *AuditService) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.audit = audit
+}
+
 // SetLogLevel sets the global log level
 func (ls *LoggerService) SetLogLevel(level LogLevel) {
 	ls.mu.Lock()
@@ -73,8 +150,36 @@ func (ls *LoggerService) SetComponentLogLevel(component string, level LogLevel)
 	ls.components[component] = level
 }
 
+// ResetComponentLogLevel makes a component follow the global log level again
+func (ls *LoggerService) ResetComponentLogLevel(component string) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	delete(ls.components, component)
+}
+
+// LogLevel returns the global log level
+func (ls *LoggerService) LogLevel() LogLevel {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	return ls.logLevel
+}
+
+// ComponentLogLevels returns the components with their own log level
+func (ls *LoggerService) ComponentLogLevels() map[string]LogLevel {
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	levels := make(map[string]LogLevel, len(ls.components))
+	for component, level := range ls.components {
+		levels[component] = level
+	}
+	return levels
+}
+
 // ShouldLog checks if a log entry should be logged based on level and component
 func (ls *LoggerService) ShouldLog(level LogLevel, component string) bool {
+	if ls == nil {
+		ls = fallbackLogger
+	}
 	ls.mu.RLock()
 	defer ls.mu.RUnlock()
 
@@ -87,6 +192,11 @@ func (ls *LoggerService) ShouldLog(level LogLevel, component string) bool {
 	return level >= ls.logLevel
 }
 
+// Log logs a message at the given level
+func (ls *LoggerService) Log(level LogLevel, component, operation, message string, metadata map[string]interface{}) {
+	ls.log(level, component, operation, message, "", metadata)
+}
+
 // Debug logs a debug message
 func (ls *LoggerService) Debug(component, operation, message string, metadata map[string]interface{}) {
 	ls.log(DebugLevel, component, operation, message, "", metadata)
@@ -123,6 +233,9 @@ func (ls *LoggerService) Fatal(component, operation, message string, err error,
 
 // log is the internal logging method
 func (ls *LoggerService) log(level LogLevel, component, operation, message, errorMsg string, metadata map[string]interface{}) {
+	if ls == nil {
+		ls = fallbackLogger
+	}
 	if !ls.ShouldLog(level, component) {
 		return
 	}
@@ -138,22 +251,95 @@ func (ls *LoggerService) log(level LogLevel, component, operation, message, erro
 	}
 
 	// Create log output
-	logOutput, err := json.Marshal(entry)
-	if err != nil {
-		// Fallback to simple logging if JSON marshaling fails
-		log.Printf("[%s] %s - %s: %s", level.String(), component, operation, message)
-		return
+	var logOutput []byte
+	if ls.format == LogFormatLogfmt {
+		logOutput = entry.logfmt()
+	} else {
+		var err error
+		logOutput, err = json.Marshal(entry)
+		if err != nil {
+			// Fallback to simple logging if JSON marshaling fails
+			log.Printf("[%s] %s - %s: %s", level.String(), component, operation, message)
+			return
+		}
 	}
+	logOutput = append(logOutput, '\n')
+
+	ls.writeMu.Lock()
+	defer ls.writeMu.Unlock()
 
 	// Write to file if configured
 	if ls.file != nil {
-		ls.file.WriteString(string(logOutput) + "\n")
+		ls.file.Write(logOutput)
 	}
 
 	// Write to stdout if configured
-	if ls.stdout {
-		fmt.Println(string(logOutput))
+	if ls.stdout != nil {
+		ls.stdout.Write(logOutput)
+	}
+}
+
+// logfmt encodes the entry as key=value pairs, metadata keys in sorted order
+func (entry LogEntry) logfmt() []byte {
+	var b strings.Builder
+	writePair := func(key string, value interface{}) {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(logfmtValue(value))
+	}
+
+	writePair("time", entry.Timestamp.Format(time.RFC3339Nano))
+	writePair("level", strings.ToLower(entry.Level.String()))
+	writePair("component", entry.Component)
+	if entry.Operation != "" {
+		writePair("operation", entry.Operation)
+	}
+	writePair("msg", entry.Message)
+	if entry.Error != "" {
+		writePair("error", entry.Error)
+	}
+
+	keys := make([]string, 0, len(entry.Metadata))
+	for key := range entry.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writePair(key, entry.Metadata[key])
 	}
+	return []byte(b.String())
+}
+
+// logfmtValue formats a value, quoting it when it is empty or holds spaces, quotes or =
+func logfmtValue(value interface{}) string {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case time.Time:
+		s = v.Format(time.RFC3339Nano)
+	case error:
+		s = v.Error()
+	case fmt.Stringer:
+		s = v.String()
+	case nil:
+		s = ""
+	case bool, int, int64, uint, uint64, float64:
+		s = fmt.Sprint(v)
+	default:
+		if encoded, err := json.Marshal(v); err == nil {
+			s = string(encoded)
+		} else {
+			s = fmt.Sprint(v)
+		}
+	}
+	if s == "" || strings.ContainsAny(s, " =\"\t\n") {
+		return strconv.Quote(s)
+	}
+	return s
 }
 
 // LogSystemEvent logs system-wide events
@@ -180,11 +366,22 @@ func (ls *LoggerService) LogSecurity(eventType, message string, metadata map[str
 	ls.Warn("security", eventType, message, metadata)
 }
 
-// LogAudit logs audit trail events
+// LogAudit logs audit trail events and appends them to the persisted audit trail
 func (ls *LoggerService) LogAudit(user, action, resource string, success bool, metadata map[string]interface{}) {
 	if metadata == nil {
 		metadata = make(map[string]interface{})
 	}
+
+	ls.mu.RLock()
+	audit := ls.audit
+	ls.mu.RUnlock()
+	if err := audit.Record(user, action, resource, success, metadata); err != nil {
+		ls.Error("audit", "persist", "Failed to persist audit entry", err, map[string]interface{}{
+			"user":   user,
+			"action": action,
+		})
+	}
+
 	metadata["user"] = user
 	metadata["action"] = action
 	metadata["resource"] = resource
@@ -200,7 +397,7 @@ func (ls *LoggerService) LogAudit(user, action, resource string, success bool, m
 
 // Close closes the logger
 func (ls *LoggerService) Close() error {
-	if ls.file != nil {
+	if ls != nil && ls.file != nil {
 		return ls.file.Close()
 	}
 	return nil
@@ -224,24 +421,35 @@ func (level LogLevel) String() string {
 	}
 }
 
-// LogLevelFromString converts string to LogLevel
-func LogLevelFromString(level string) LogLevel {
-	switch level {
+// MarshalText encodes the level as its lowercase name
+func (level LogLevel) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(level.String())), nil
+}
+
+// ParseLogLevel converts a level name to LogLevel, rejecting unknown names
+func ParseLogLevel(level string) (LogLevel, error) {
+	switch strings.ToLower(level) {
 	case "debug":
-		return DebugLevel
-	case "info":
-		return InfoLevel
-	case "warn":
-		return WarnLevel
+		return DebugLevel, nil
+	case "info", "":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
 	case "error":
-		return ErrorLevel
+		return ErrorLevel, nil
 	case "fatal":
-		return FatalLevel
+		return FatalLevel, nil
 	default:
-		return InfoLevel
+		return InfoLevel, fmt.Errorf("unknown log level %q (debug, info, warn, error or fatal)", level)
 	}
 }
 
+// LogLevelFromString converts string to LogLevel, unknown names are InfoLevel
+func LogLevelFromString(level string) LogLevel {
+	parsed, _ := ParseLogLevel(level)
+	return parsed
+}
+
 // ErrorHandler provides centralized error handling
 type ErrorHandler struct {
 	logger *LoggerService
@@ -0,0 +1,479 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"monik-enterprise/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Maintenance window recurrences
+const (
+	RecurrenceNone    = "none"
+	RecurrenceDaily   = "daily"
+	RecurrenceWeekly  = "weekly"
+	RecurrenceMonthly = "monthly"
+)
+
+// Maintenance period kinds
+const (
+	PeriodKindMaintenance = "maintenance"
+	PeriodKindSilence     = "silence"
+)
+
+// maxOccurrences bounds the expansion of a recurring window over a queried range
+const maxOccurrences = 10000
+
+// MaintenancePeriod is a concrete occurrence of a maintenance window or silence
+type MaintenancePeriod struct {
+	Kind     string    `json:"kind"` // maintenance, silence
+	SourceID uint      `json:"source_id"`
+	Name     string    `json:"name"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+}
+
+// Contains reports whether t falls inside the period
+func (p MaintenancePeriod) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+type maintenanceWindow struct {
+	models.MaintenanceWindow
+	loc *time.Location
+}
+
+// MaintenanceService keeps maintenance windows and silences and answers whether a
+// router, interface or alert rule is suppressed at a given time
+type MaintenanceService struct {
+	db     *gorm.DB
+	logger *LoggerService
+
+	mu       sync.RWMutex
+	windows  []maintenanceWindow
+	silences []models.Silence
+}
+
+// NewMaintenanceService creates a maintenance service and loads windows and silences
+func NewMaintenanceService(db *gorm.DB) *MaintenanceService {
+	m := &MaintenanceService{db: db}
+	if err := m.reload(); err != nil {
+		m.logger.Error(ComponentMaintenance, "load", "Failed to load maintenance windows", err, nil)
+	}
+	return m
+}
+
+// SetLogger sets the structured logger of the maintenance service
+func (m *MaintenanceService) SetLogger(logger *LoggerService) {
+	m.logger = logger
+}
+
+// reload refreshes the cached windows and the silences that have not expired
+func (m *MaintenanceService) reload() error {
+	var windows []models.MaintenanceWindow
+	if err := m.db.Order("id ASC").Find(&windows).Error; err != nil {
+		return err
+	}
+	var silences []models.Silence
+	if err := m.db.Where("ends_at > ?", time.Now()).Order("id ASC").Find(&silences).Error; err != nil {
+		return err
+	}
+
+	compiled := make([]maintenanceWindow, 0, len(windows))
+	for _, window := range windows {
+		loc, err := billingLocation(window.Timezone)
+		if err != nil {
+			m.logger.Warn(ComponentMaintenance, "load", "Skipping window with invalid timezone", map[string]interface{}{
+				"window":   window.Name,
+				"timezone": window.Timezone,
+			})
+			continue
+		}
+		compiled = append(compiled, maintenanceWindow{MaintenanceWindow: window, loc: loc})
+	}
+
+	m.mu.Lock()
+	m.windows = compiled
+	m.silences = silences
+	m.mu.Unlock()
+	return nil
+}
+
+// Suppressed reports whether alerts of a rule on a router or interface are suppressed
+// at t, with the name of the window or silence responsible. An empty interfaceName is
+// a router-level alert. A nil service suppresses nothing.
+func (m *MaintenanceService) Suppressed(ruleID, routerID uint, interfaceName string, t time.Time) (bool, string) {
+	if m == nil {
+		return false, ""
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, silence := range m.silences {
+		if silence.RuleID != 0 && silence.RuleID != ruleID {
+			continue
+		}
+		if t.Before(silence.StartsAt) || !t.Before(silence.EndsAt) {
+			continue
+		}
+		if scopeMatches(silence.RouterID, silence.InterfaceName, routerID, interfaceName) {
+			return true, fmt.Sprintf("silence %d", silence.ID)
+		}
+	}
+	for _, window := range m.windows {
+		if window.Enabled && scopeMatches(window.RouterID, window.InterfaceName, routerID, interfaceName) &&
+			len(window.occurrences(t, t.Add(time.Nanosecond))) > 0 {
+			return true, "maintenance window " + window.Name
+		}
+	}
+	return false, ""
+}
+
+// InMaintenance reports whether a router or interface is inside a maintenance window at t
+func (m *MaintenanceService) InMaintenance(routerID uint, interfaceName string, t time.Time) bool {
+	if m == nil {
+		return false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, window := range m.windows {
+		if window.Enabled && scopeMatches(window.RouterID, window.InterfaceName, routerID, interfaceName) &&
+			len(window.occurrences(t, t.Add(time.Nanosecond))) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Periods returns the maintenance window occurrences and silences covering a router or
+// interface within [from, to), clipped to the range and ordered by start. Silences
+// limited to one alert rule do not mark history.
+func (m *MaintenanceService) Periods(routerID uint, interfaceName string, from, to time.Time) ([]MaintenancePeriod, error) {
+	var silences []models.Silence
+	err := m.db.Where("rule_id = 0 AND starts_at < ? AND ends_at > ?", to, from).Order("starts_at ASC").Find(&silences).Error
+	if err != nil {
+		return nil, err
+	}
+
+	periods := []MaintenancePeriod{}
+	m.mu.RLock()
+	for _, window := range m.windows {
+		if !window.Enabled || !scopeMatches(window.RouterID, window.InterfaceName, routerID, interfaceName) {
+			continue
+		}
+		for _, start := range window.occurrences(from, to) {
+			periods = append(periods, clipPeriod(MaintenancePeriod{
+				Kind:     PeriodKindMaintenance,
+				SourceID: window.ID,
+				Name:     window.Name,
+				Start:    start,
+				End:      start.Add(window.Duration),
+			}, from, to))
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, silence := range silences {
+		if !scopeMatches(silence.RouterID, silence.InterfaceName, routerID, interfaceName) {
+			continue
+		}
+		name := silence.Comment
+		if name == "" {
+			name = fmt.Sprintf("silence %d", silence.ID)
+		}
+		periods = append(periods, clipPeriod(MaintenancePeriod{
+			Kind:     PeriodKindSilence,
+			SourceID: silence.ID,
+			Name:     name,
+			Start:    silence.StartsAt,
+			End:      silence.EndsAt,
+		}, from, to))
+	}
+
+	sort.Slice(periods, func(i, j int) bool {
+		return periods[i].Start.Before(periods[j].Start)
+	})
+	return periods, nil
+}
+
+// ExcludedDuration returns the time within [from, to) covered by at least one period,
+// counting overlapping periods once
+func ExcludedDuration(periods []MaintenancePeriod) time.Duration {
+	sorted := append([]MaintenancePeriod(nil), periods...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+
+	var total time.Duration
+	var end time.Time
+	for _, period := range sorted {
+		start := period.Start
+		if start.Before(end) {
+			start = end
+		}
+		if period.End.After(start) {
+			total += period.End.Sub(start)
+			end = period.End
+		}
+	}
+	return total
+}
+
+// occurrences returns the start of every occurrence of a window overlapping [from, to)
+func (w maintenanceWindow) occurrences(from, to time.Time) []time.Time {
+	if w.Duration <= 0 {
+		return nil
+	}
+	if w.Recurrence == RecurrenceNone || w.Recurrence == "" {
+		if w.StartsAt.Before(to) && w.StartsAt.Add(w.Duration).After(from) {
+			return []time.Time{w.StartsAt}
+		}
+		return nil
+	}
+
+	// Skip the occurrences that ended before from; the estimate stays on the early side
+	var period time.Duration
+	switch w.Recurrence {
+	case RecurrenceDaily:
+		period = 25 * time.Hour
+	case RecurrenceWeekly:
+		period = 7*24*time.Hour + time.Hour
+	default:
+		period = 31 * 24 * time.Hour
+	}
+	k := 0
+	if gap := from.Sub(w.StartsAt.Add(w.Duration)); gap > 0 {
+		k = int(gap / period)
+	}
+
+	var starts []time.Time
+	for n := 0; n < maxOccurrences; n, k = n+1, k+1 {
+		start := w.occurrence(k)
+		if !start.Before(to) || (w.RecurUntil != nil && start.After(*w.RecurUntil)) {
+			break
+		}
+		if start.Add(w.Duration).After(from) {
+			starts = append(starts, start)
+		}
+	}
+	return starts
+}
+
+// occurrence returns the start of the k-th occurrence, keeping the wall clock time of
+// the first one in the window timezone. Monthly occurrences on a day a month lacks
+// fall on its last day.
+func (w maintenanceWindow) occurrence(k int) time.Time {
+	first := w.StartsAt.In(w.loc)
+	switch w.Recurrence {
+	case RecurrenceDaily:
+		return first.AddDate(0, 0, k)
+	case RecurrenceWeekly:
+		return first.AddDate(0, 0, 7*k)
+	}
+	month := time.Date(first.Year(), first.Month()+time.Month(k), 1, 0, 0, 0, 0, w.loc)
+	day := first.Day()
+	if last := month.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return time.Date(month.Year(), month.Month(), day, first.Hour(), first.Minute(), first.Second(), first.Nanosecond(), w.loc)
+}
+
+// scopeMatches reports whether a router or interface falls in the scope of a window or
+// silence. A scope without interface pattern covers the router and all its interfaces.
+func scopeMatches(scopeRouterID uint, pattern string, routerID uint, interfaceName string) bool {
+	if scopeRouterID != 0 && scopeRouterID != routerID {
+		return false
+	}
+	if pattern == "" {
+		return true
+	}
+	if interfaceName == "" {
+		return false
+	}
+	matched, _ := path.Match(pattern, interfaceName)
+	return matched
+}
+
+func clipPeriod(p MaintenancePeriod, from, to time.Time) MaintenancePeriod {
+	if p.Start.Before(from) {
+		p.Start = from
+	}
+	if p.End.After(to) {
+		p.End = to
+	}
+	return p
+}
+
+// NormalizeMaintenanceWindow fills unset fields of a window and validates it
+func NormalizeMaintenanceWindow(window *models.MaintenanceWindow) error {
+	window.Name = strings.TrimSpace(window.Name)
+	if window.Name == "" {
+		return errors.New("name is required")
+	}
+	if window.StartsAt.IsZero() {
+		return errors.New("starts_at is required")
+	}
+	if window.Duration <= 0 {
+		return errors.New("duration must be greater than 0")
+	}
+	if window.Recurrence == "" {
+		window.Recurrence = RecurrenceNone
+	}
+	var period time.Duration
+	switch window.Recurrence {
+	case RecurrenceNone:
+	case RecurrenceDaily:
+		period = 24 * time.Hour
+	case RecurrenceWeekly:
+		period = 7 * 24 * time.Hour
+	case RecurrenceMonthly:
+		period = 28 * 24 * time.Hour
+	default:
+		return errors.New("invalid recurrence, expected none, daily, weekly or monthly")
+	}
+	if period > 0 && window.Duration > period {
+		return errors.New("duration must not exceed the recurrence period")
+	}
+	if window.RecurUntil != nil && window.RecurUntil.Before(window.StartsAt) {
+		return errors.New("recur_until must not be before starts_at")
+	}
+	if _, err := billingLocation(window.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q", window.Timezone)
+	}
+	if _, err := path.Match(window.InterfaceName, ""); err != nil {
+		return errors.New("invalid interface_name pattern")
+	}
+	return nil
+}
+
+// NormalizeSilence fills unset fields of a silence and validates it
+func NormalizeSilence(silence *models.Silence, now time.Time) error {
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = now
+	}
+	if silence.EndsAt.IsZero() {
+		return errors.New("ends_at or duration is required")
+	}
+	if !silence.EndsAt.After(silence.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	if !silence.EndsAt.After(now) {
+		return errors.New("ends_at must be in the future")
+	}
+	if _, err := path.Match(silence.InterfaceName, ""); err != nil {
+		return errors.New("invalid interface_name pattern")
+	}
+	return nil
+}
+
+// ListWindows returns all maintenance windows
+func (m *MaintenanceService) ListWindows() ([]models.MaintenanceWindow, error) {
+	var windows []models.MaintenanceWindow
+	err := m.db.Order("id ASC").Find(&windows).Error
+	return windows, err
+}
+
+// GetWindow returns a maintenance window by ID
+func (m *MaintenanceService) GetWindow(id uint) (*models.MaintenanceWindow, error) {
+	var window models.MaintenanceWindow
+	if err := m.db.First(&window, id).Error; err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+// CreateWindow stores a new maintenance window
+func (m *MaintenanceService) CreateWindow(window *models.MaintenanceWindow) error {
+	if err := NormalizeMaintenanceWindow(window); err != nil {
+		return err
+	}
+	if err := m.db.Create(window).Error; err != nil {
+		return err
+	}
+	return m.reload()
+}
+
+// UpdateWindow replaces a maintenance window
+func (m *MaintenanceService) UpdateWindow(id uint, window models.MaintenanceWindow) (*models.MaintenanceWindow, error) {
+	existing, err := m.GetWindow(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := NormalizeMaintenanceWindow(&window); err != nil {
+		return nil, err
+	}
+	window.ID = existing.ID
+	window.CreatedAt = existing.CreatedAt
+	if err := m.db.Save(&window).Error; err != nil {
+		return nil, err
+	}
+	return &window, m.reload()
+}
+
+// DeleteWindow removes a maintenance window
+func (m *MaintenanceService) DeleteWindow(id uint) error {
+	res := m.db.Delete(&models.MaintenanceWindow{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return m.reload()
+}
+
+// ListSilences returns silences, newest first. With active only the silences in
+// effect now are returned.
+func (m *MaintenanceService) ListSilences(active bool) ([]models.Silence, error) {
+	query := m.db.Order("starts_at DESC, id DESC")
+	if active {
+		now := time.Now()
+		query = query.Where("starts_at <= ? AND ends_at > ?", now, now)
+	}
+	var silences []models.Silence
+	err := query.Find(&silences).Error
+	return silences, err
+}
+
+// CreateSilence stores a new silence
+func (m *MaintenanceService) CreateSilence(silence *models.Silence) error {
+	if err := NormalizeSilence(silence, time.Now()); err != nil {
+		return err
+	}
+	if err := m.db.Create(silence).Error; err != nil {
+		return err
+	}
+	return m.reload()
+}
+
+// ExpireSilence ends a silence now. The row is kept so the silenced period stays
+// marked in history.
+func (m *MaintenanceService) ExpireSilence(id uint) (*models.Silence, error) {
+	var silence models.Silence
+	if err := m.db.First(&silence, id).Error; err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if silence.EndsAt.After(now) {
+		silence.EndsAt = now
+		if silence.StartsAt.After(now) {
+			silence.StartsAt = now
+		}
+		err := m.db.Model(&models.Silence{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"starts_at": silence.StartsAt,
+			"ends_at":   silence.EndsAt,
+		}).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &silence, m.reload()
+}
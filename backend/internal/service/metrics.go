@@ -4,16 +4,44 @@ import (
 	"sync"
 	"time"
 
+	"monik-enterprise/internal/config"
 	"monik-enterprise/internal/websocket"
 )
 
-// MetricsService collects and aggregates system metrics
+// Health statuses reported by SystemHealth
+const (
+	HealthStatusUnknown  = "unknown"
+	HealthStatusHealthy  = "healthy"
+	HealthStatusDegraded = "degraded"
+	HealthStatusCritical = "critical"
+)
+
+// latencySamples is how many recent samples a latency average covers
+const latencySamples = 100
+
+// queueSaturation is the share of the worker queue in use above which health is degraded
+const queueSaturation = 0.9
+
+// MetricsService collects and aggregates system metrics. Router round trips and
+// database writes are recorded by the monitoring loop; worker pool, WebSocket and WAN
+// detection counters are read from their services on every update.
 type MetricsService struct {
 	mu             sync.RWMutex
+	config         config.MetricsConfig
 	systemMetrics  *SystemMetrics
 	websocketMgr   *websocket.WebSocketManager
+	workerPool     *WorkerPool
+	startedAt      time.Time
 	lastUpdate     time.Time
 	updateInterval time.Duration
+
+	routerLatency  *latencyWindow
+	dbWriteLatency *latencyWindow
+	counters       healthCounters // Totals since start
+	previous       healthCounters // Totals at the previous health update
+
+	quit chan struct{}
+	wg   sync.WaitGroup
 }
 
 // SystemMetrics represents overall system performance metrics
@@ -27,133 +55,213 @@ type SystemMetrics struct {
 
 // SystemHealth represents overall system health status
 type SystemHealth struct {
-	Status        string        `json:"status"` // healthy, degraded, critical
+	Status        string        `json:"status"` // unknown, healthy, degraded, critical
 	LastCheck     time.Time     `json:"last_check"`
+	StartedAt     time.Time     `json:"started_at"`
 	Uptime        time.Duration `json:"uptime"`
-	ErrorRate     float64       `json:"error_rate"`
-	ResponseTime  time.Duration `json:"response_time"`
-	ActiveWorkers int           `json:"active_workers"`
+	ErrorRate     float64       `json:"error_rate"`     // Percentage of failed operations since the previous check
+	ResponseTime  time.Duration `json:"response_time"`  // Average router round trip over recent polls
+	DBWriteTime   time.Duration `json:"db_write_time"`  // Average interface write over recent polls
+	ActiveWorkers int           `json:"active_workers"` // Workers running a job
 	QueueSize     int           `json:"queue_size"`
+	QueueCapacity int           `json:"queue_capacity"`
+}
+
+// healthCounters counts the operations behind the error rate
+type healthCounters struct {
+	routerPolls     int64
+	routerFailures  int64
+	dbWrites        int64
+	dbWriteFailures int64
+	operations      int64 // Recorded through RecordSuccess and RecordError
+	errors          int64
+
+	// Cumulative counters of other services, as read at a health check
+	wsMessages   int64
+	wsDropped    int64
+	wanAttempts  int64
+	wanFailures  int64
+	workerJobs   int64
+	workerFailed int64
 }
 
 // NewMetricsService creates a new metrics service
-func NewMetricsService(websocketMgr *websocket.WebSocketManager) *MetricsService {
+func NewMetricsService(cfg config.MetricsConfig, websocketMgr *websocket.WebSocketManager) *MetricsService {
+	interval := cfg.CollectionInterval
+	if interval < time.Second {
+		interval = 30 * time.Second
+	}
+
+	var wsMetrics *websocket.WebSocketMetrics
+	if websocketMgr != nil {
+		wsMetrics = websocketMgr.GetMetrics()
+	}
+
+	now := time.Now()
 	return &MetricsService{
+		config:         cfg,
 		websocketMgr:   websocketMgr,
-		updateInterval: 30 * time.Second,
+		startedAt:      now,
+		updateInterval: interval,
+		routerLatency:  newLatencyWindow(latencySamples),
+		dbWriteLatency: newLatencyWindow(latencySamples),
+		quit:           make(chan struct{}),
 		systemMetrics: &SystemMetrics{
-			WebSocketMetrics:    websocket.NewWebSocketMetrics(),
+			WebSocketMetrics:    wsMetrics,
 			WANDetectionMetrics: NewWANDetectionMetrics(),
 			WorkerPoolMetrics: &WorkerMetrics{
 				WorkerStats: make(map[int]*WorkerStats),
 			},
 			SystemHealth: SystemHealth{
-				Status:    "unknown",
-				LastCheck: time.Now(),
+				Status:    HealthStatusUnknown,
+				LastCheck: now,
+				StartedAt: now,
 			},
 		},
 	}
 }
 
-// Start starts the metrics collection service
+// Start computes the metrics once and then on every collection interval
 func (ms *MetricsService) Start() {
+	ms.updateSystemMetrics()
+	ms.wg.Add(1)
 	go ms.collectMetrics()
 }
 
+// Stop stops the metrics collection
+func (ms *MetricsService) Stop() {
+	close(ms.quit)
+	ms.wg.Wait()
+}
+
 // collectMetrics collects metrics from all components
 func (ms *MetricsService) collectMetrics() {
+	defer ms.wg.Done()
 	ticker := time.NewTicker(ms.updateInterval)
 	defer ticker.Stop()
 
-	// S1000 Fix: Menggunakan range untuk menyederhanakan loop ticker
-	for range ticker.C {
-		ms.updateSystemMetrics()
+	for {
+		select {
+		case <-ticker.C:
+			ms.updateSystemMetrics()
+		case <-ms.quit:
+			return
+		}
 	}
 }
 
 // updateSystemMetrics updates the overall system metrics
 func (ms *MetricsService) updateSystemMetrics() {
-	ms.mu.Lock()
-	defer ms.mu.Unlock()
-
-	ms.systemMetrics.LastUpdated = time.Now()
+	// Read the worker pool outside ms.mu, it takes its own locks
+	var workerMetrics *WorkerMetrics
+	queueSize, queueCapacity := 0, 0
+	if ms.workerPool != nil {
+		workerMetrics = ms.workerPool.GetMetrics()
+		queueSize = ms.workerPool.GetQueueSize()
+		queueCapacity = ms.workerPool.GetQueueCapacity()
+	}
 
-	// Update system health
-	ms.updateSystemHealth()
+	ms.mu.Lock()
+	ms.lastUpdate = time.Now()
+	ms.systemMetrics.LastUpdated = ms.lastUpdate
+	if workerMetrics != nil {
+		ms.systemMetrics.WorkerPoolMetrics = workerMetrics
+	}
+	if ms.config.EnableHealthCheck {
+		ms.updateSystemHealth(queueSize, queueCapacity)
+	}
+	health := ms.systemMetrics.SystemHealth
+	wsMetrics := ms.systemMetrics.WebSocketMetrics
+	wanMetrics := ms.systemMetrics.WANDetectionMetrics
+	ms.mu.Unlock()
 
 	// Broadcast metrics update via WebSocket
-	if ms.websocketMgr != nil {
-		ms.websocketMgr.BroadcastEvent("metrics_update",
-			"System metrics updated",
-			map[string]interface{}{
-				"timestamp":             ms.systemMetrics.LastUpdated,
-				"system_health":         ms.systemMetrics.SystemHealth,
-				"websocket_metrics":     ms.systemMetrics.WebSocketMetrics.GetStats(),
-				"wan_detection_metrics": ms.systemMetrics.WANDetectionMetrics.GetStats(),
-			})
+	if ms.websocketMgr != nil && ms.config.BroadcastMetrics {
+		data := map[string]interface{}{
+			"timestamp":             ms.lastUpdate,
+			"system_health":         health,
+			"wan_detection_metrics": wanMetrics.GetStats(),
+		}
+		if wsMetrics != nil {
+			data["websocket_metrics"] = wsMetrics.GetStats()
+		}
+		ms.websocketMgr.BroadcastEvent("metrics_update", "System metrics updated", data)
 	}
 }
 
-// updateSystemHealth calculates overall system health
-func (ms *MetricsService) updateSystemHealth() {
+// updateSystemHealth calculates overall system health. The caller must hold ms.mu.
+func (ms *MetricsService) updateSystemHealth(queueSize, queueCapacity int) {
 	health := &ms.systemMetrics.SystemHealth
-	health.LastCheck = time.Now()
-
-	// Calculate error rate from all components
-	var totalErrors, totalRequests float64
-
-	// WebSocket error rate
-	wsStats := ms.systemMetrics.WebSocketMetrics.GetStats()
-	if wsStats != nil {
-		if messagesDropped, ok := wsStats["messages_dropped"].(int64); ok {
-			totalErrors += float64(messagesDropped)
-		}
-		if messagesSent, ok := wsStats["messages_sent"].(int64); ok {
-			totalRequests += float64(messagesSent)
-		}
+	health.LastCheck = ms.lastUpdate
+
+	// Count errors since the previous check so the status recovers once failures stop
+	current := ms.counters
+	delta := healthCounters{
+		routerPolls:     current.routerPolls - ms.previous.routerPolls,
+		routerFailures:  current.routerFailures - ms.previous.routerFailures,
+		dbWrites:        current.dbWrites - ms.previous.dbWrites,
+		dbWriteFailures: current.dbWriteFailures - ms.previous.dbWriteFailures,
+		operations:      current.operations - ms.previous.operations,
+		errors:          current.errors - ms.previous.errors,
 	}
-
-	// WAN detection error rate
-	wanStats := ms.systemMetrics.WANDetectionMetrics.GetStats()
-	if wanStats != nil {
-		if failures, ok := wanStats["detection_failure"].(int64); ok {
-			totalErrors += float64(failures)
-		}
-		if total, ok := wanStats["cache_hits"].(int64); ok {
-			totalRequests += float64(total)
-		}
+	totalErrors := float64(delta.routerFailures + delta.dbWriteFailures + delta.errors)
+	totalRequests := float64(delta.routerPolls + delta.dbWrites + delta.operations)
+
+	// WebSocket, WAN detection and worker pool counters are cumulative, compare them with
+	// the values read at the previous check
+	if wsMetrics := ms.systemMetrics.WebSocketMetrics; wsMetrics != nil {
+		snapshot := wsMetrics.Snapshot()
+		totalErrors += float64(snapshot.MessagesDropped - ms.previous.wsDropped)
+		totalRequests += float64(snapshot.MessagesSent + snapshot.MessagesDropped - ms.previous.wsMessages)
+		current.wsDropped = snapshot.MessagesDropped
+		current.wsMessages = snapshot.MessagesSent + snapshot.MessagesDropped
+	}
+	if wanMetrics := ms.systemMetrics.WANDetectionMetrics; wanMetrics != nil {
+		stats := wanMetrics.Snapshot()
+		attempts := stats.TotalDetections + stats.Failures
+		totalErrors += float64(stats.Failures - ms.previous.wanFailures)
+		totalRequests += float64(attempts - ms.previous.wanAttempts)
+		current.wanFailures = stats.Failures
+		current.wanAttempts = attempts
 	}
-
-	// Worker pool error rate
 	workerStats := ms.systemMetrics.WorkerPoolMetrics
 	if workerStats != nil {
-		totalErrors += float64(workerStats.FailedJobs)
-		totalRequests += float64(workerStats.TotalJobs)
+		totalErrors += float64(workerStats.FailedJobs - ms.previous.workerFailed)
+		totalRequests += float64(workerStats.TotalJobs - ms.previous.workerJobs)
+		current.workerFailed = workerStats.FailedJobs
+		current.workerJobs = workerStats.TotalJobs
 	}
+	ms.previous = current
 
 	// Calculate error rate
+	health.ErrorRate = 0
 	if totalRequests > 0 {
 		health.ErrorRate = (totalErrors / totalRequests) * 100
 	}
 
 	// Determine health status
 	if health.ErrorRate < 1.0 {
-		health.Status = "healthy"
+		health.Status = HealthStatusHealthy
 	} else if health.ErrorRate < 5.0 {
-		health.Status = "degraded"
+		health.Status = HealthStatusDegraded
 	} else {
-		health.Status = "critical"
+		health.Status = HealthStatusCritical
 	}
 
-	// Calculate uptime
-	health.Uptime = time.Since(ms.lastUpdate)
-
-	// Set response time (simplified calculation)
-	health.ResponseTime = 100 * time.Millisecond // This would be calculated from actual response times
+	health.StartedAt = ms.startedAt
+	health.Uptime = time.Since(ms.startedAt)
+	health.ResponseTime = ms.routerLatency.average()
+	health.DBWriteTime = ms.dbWriteLatency.average()
 
 	// Set worker and queue stats
-	health.ActiveWorkers = int(workerStats.ActiveJobs)
-	health.QueueSize = 0 // This would be calculated from actual queue size
+	if workerStats != nil {
+		health.ActiveWorkers = int(workerStats.ActiveJobs)
+	}
+	health.QueueSize = queueSize
+	health.QueueCapacity = queueCapacity
+	if health.Status == HealthStatusHealthy && queueCapacity > 0 && float64(queueSize) >= queueSaturation*float64(queueCapacity) {
+		health.Status = HealthStatusDegraded
+	}
 }
 
 // GetSystemMetrics returns current system metrics
@@ -181,11 +289,16 @@ func (ms *MetricsService) GetSystemMetrics() *SystemMetrics {
 	return metrics
 }
 
-// GetSystemHealth returns current system health
+// GetSystemHealth returns current system health, uptime is current even between checks
 func (ms *MetricsService) GetSystemHealth() SystemHealth {
+	if ms == nil {
+		return SystemHealth{Status: HealthStatusUnknown}
+	}
 	ms.mu.RLock()
 	defer ms.mu.RUnlock()
-	return ms.systemMetrics.SystemHealth
+	health := ms.systemMetrics.SystemHealth
+	health.Uptime = time.Since(ms.startedAt)
+	return health
 }
 
 // SetWANDetectionMetrics sets the WAN detection metrics
@@ -195,30 +308,101 @@ func (ms *MetricsService) SetWANDetectionMetrics(metrics *WANDetectionMetrics) {
 	ms.systemMetrics.WANDetectionMetrics = metrics
 }
 
-// SetWorkerPoolMetrics sets the worker pool metrics
-func (ms *MetricsService) SetWorkerPoolMetrics(metrics *WorkerMetrics) {
+// SetWorkerPool sets the worker pool whose job counters and queue depth are reported
+func (ms *MetricsService) SetWorkerPool(workerPool *WorkerPool) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
-	ms.systemMetrics.WorkerPoolMetrics = metrics
+	ms.workerPool = workerPool
 }
 
-// RecordError records an error in the system metrics
-func (ms *MetricsService) RecordError(component string, errorType string, message string) {
+// RecordRouterPoll records the round trip of a router request, failed requests count
+// towards the error rate but not the latency. A nil service ignores the call.
+func (ms *MetricsService) RecordRouterPoll(latency time.Duration, err error) {
+	if ms == nil {
+		return
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.counters.routerPolls++
+	if err != nil {
+		ms.counters.routerFailures++
+		return
+	}
+	ms.routerLatency.add(latency)
+}
+
+// RecordDBWrite records how long a database write took. A nil service ignores the call.
+func (ms *MetricsService) RecordDBWrite(latency time.Duration, err error) {
+	if ms == nil {
+		return
+	}
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
+	ms.counters.dbWrites++
+	if err != nil {
+		ms.counters.dbWriteFailures++
+		return
+	}
+	ms.dbWriteLatency.add(latency)
+}
 
-	// This would integrate with a logging system
-	// For now, we just update the health status
-	ms.updateSystemHealth()
+// RecordError records a failed operation of any component
+func (ms *MetricsService) RecordError(component string, errorType string, message string) {
+	if ms == nil {
+		return
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.counters.operations++
+	ms.counters.errors++
 }
 
 // RecordSuccess records a successful operation
 func (ms *MetricsService) RecordSuccess(component string, operation string) {
+	if ms == nil {
+		return
+	}
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
+	ms.counters.operations++
+}
+
+// latencyWindow averages the most recent latency samples
+type latencyWindow struct {
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+// newLatencyWindow creates a window over the last size samples
+func newLatencyWindow(size int) *latencyWindow {
+	return &latencyWindow{samples: make([]time.Duration, size)}
+}
 
-	// Update metrics based on successful operation
-	ms.updateSystemHealth()
+// add records a sample, replacing the oldest once the window is full
+func (w *latencyWindow) add(d time.Duration) {
+	w.samples[w.next] = d
+	w.next++
+	if w.next == len(w.samples) {
+		w.next = 0
+		w.full = true
+	}
+}
+
+// average returns the mean of the recorded samples, 0 when there are none
+func (w *latencyWindow) average() time.Duration {
+	count := w.next
+	if w.full {
+		count = len(w.samples)
+	}
+	if count == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, d := range w.samples[:count] {
+		total += d
+	}
+	return total / time.Duration(count)
 }
 
 // WANDetectionMetrics tracks WAN detection performance metrics
@@ -255,6 +439,31 @@ func (m *WANDetectionMetrics) RecordDetectionFailure() {
 	m.Failures++
 }
 
+// WANDetectionStats is a point-in-time copy of the WAN detection counters
+type WANDetectionStats struct {
+	CacheHits       int64
+	TotalDetections int64
+	Failures        int64
+	MethodCounts    map[string]int64
+}
+
+// Snapshot returns a copy of the counters
+func (m *WANDetectionMetrics) Snapshot() WANDetectionStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	methods := make(map[string]int64, len(m.MethodCounts))
+	for method, count := range m.MethodCounts {
+		methods[method] = count
+	}
+	return WANDetectionStats{
+		CacheHits:       m.CacheHits,
+		TotalDetections: m.TotalDetections,
+		Failures:        m.Failures,
+		MethodCounts:    methods,
+	}
+}
+
 // GetStats mengembalikan statistik deteksi WAN dalam format map untuk dikirim ke API
 func (m *WANDetectionMetrics) GetStats() map[string]interface{} {
 	m.mu.RLock()
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -11,18 +12,21 @@ import (
 	"monik-enterprise/internal/config"
 
 	"github.com/go-routeros/routeros/v3"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // MikroTikService handles communication with MikroTik router
 type MikroTikService struct {
 	client *routeros.Client
 	config config.RouterConfig
+	logger *LoggerService
 	mu     sync.Mutex
 }
 
 // InterfaceData represents interface monitoring data
 type InterfaceData struct {
 	Name        string    `json:"name"`
+	ObjectID    string    `json:"object_id"` // RouterOS .id or SNMP ifIndex, changes when the interface is re-created
 	RxBytes     uint64    `json:"rx_bytes"`
 	TxBytes     uint64    `json:"tx_bytes"`
 	RxRate      float64   `json:"rx_rate"` // Mbps
@@ -30,6 +34,7 @@ type InterfaceData struct {
 	Status      string    `json:"status"`
 	Comment     string    `json:"comment"`
 	LastUpdated time.Time `json:"last_updated"`
+	Counter32   bool      `json:"counter32,omitempty"` // Byte counters are 32 bits wide and wrap at 4 GiB
 }
 
 // SystemInfo represents system information
@@ -51,31 +56,88 @@ func NewMikroTikService(cfg config.RouterConfig) *MikroTikService {
 	}
 }
 
-// connect establishes connection to the router
-func (s *MikroTikService) connect(ctx context.Context) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// SetLogger sets the structured logger of the collector
+func (s *MikroTikService) SetLogger(logger *LoggerService) {
+	s.logger = logger
+}
 
+// connect establishes connection to the router. The caller must hold s.mu.
+func (s *MikroTikService) connect(ctx context.Context) error {
 	if s.client != nil {
 		return nil
 	}
 
+	client, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	s.client = client
+	return nil
+}
+
+// dial opens a new API (or API-SSL) session with the router
+func (s *MikroTikService) dial(ctx context.Context) (*routeros.Client, error) {
 	address := fmt.Sprintf("%s:%d", s.config.IP, s.config.Port)
+	ctx, span := startSpan(ctx, "routeros connect",
+		attribute.String("server.address", s.config.IP),
+		attribute.Int("server.port", s.config.Port),
+		attribute.Bool("monik.router.tls", s.config.TLS.Enabled),
+	)
+	var err error
+	defer func() { endSpan(span, err) }()
 
 	// Add explicit dial timeout of 5 seconds
 	dialCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	fmt.Printf("[MIKROTIK] Attempting to connect to %s with 5s timeout...\n", address)
-	client, err := routeros.DialContext(dialCtx, address, s.config.Username, s.config.Password)
+	var client *routeros.Client
+	if s.config.TLS.Enabled {
+		tlsConfig, tlsErr := buildTLSConfig(s.config)
+		if tlsErr != nil {
+			err = tlsErr
+			s.logger.Error(ComponentCollector, "connect", "TLS configuration error", tlsErr, routerLogFields(s.config, nil))
+			return nil, &RouterConnectError{Kind: ConnErrorTLSConfig, Address: address, Err: tlsErr}
+		}
+		client, err = routeros.DialTLSContext(dialCtx, address, s.config.Username, s.config.Password, tlsConfig)
+	} else {
+		client, err = routeros.DialContext(dialCtx, address, s.config.Username, s.config.Password)
+	}
 	if err != nil {
-		fmt.Printf("[MIKROTIK] Connection failed: %v\n", err)
-		return fmt.Errorf("failed to connect to router: %w", err)
+		connErr := classifyConnectError(address, s.config.TLS.Enabled, err)
+		s.logger.Warn(ComponentCollector, "connect", "Connection failed", routerLogFields(s.config, map[string]interface{}{
+			"kind":  connErr.Kind,
+			"tls":   s.config.TLS.Enabled,
+			"error": err.Error(),
+		}))
+		return nil, fmt.Errorf("failed to connect to router: %w", connErr)
 	}
 
-	fmt.Printf("[MIKROTIK] Successfully connected to %s\n", address)
-	s.client = client
-	return nil
+	s.logger.Info(ComponentCollector, "connect", "Connected", routerLogFields(s.config, map[string]interface{}{
+		"tls": s.config.TLS.Enabled,
+	}))
+	return client, nil
+}
+
+// run executes a RouterOS command in a span named after it. The caller must hold s.mu
+// and be connected.
+func (s *MikroTikService) run(ctx context.Context, sentence ...string) (*routeros.Reply, error) {
+	ctx, span := startSpan(ctx, "routeros "+sentence[0],
+		attribute.String("rpc.system", "routeros"),
+		attribute.String("rpc.method", sentence[0]),
+		attribute.String("server.address", s.config.IP),
+		attribute.Int("server.port", s.config.Port),
+	)
+	reply, err := s.client.RunContext(ctx, sentence...)
+	if err == nil {
+		span.SetAttributes(attribute.Int("routeros.replies", len(reply.Re)))
+	}
+	endSpan(span, err)
+	return reply, err
+}
+
+// Config returns the router configuration this service dials with
+func (s *MikroTikService) Config() config.RouterConfig {
+	return s.config
 }
 
 // GetClient returns the router client (for internal use)
@@ -89,50 +151,66 @@ func (s *MikroTikService) GetClient() *routeros.Client {
 func (s *MikroTikService) disconnect() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.disconnectLocked()
+}
 
+// disconnectLocked closes the connection. The caller must hold s.mu.
+func (s *MikroTikService) disconnectLocked() {
 	if s.client != nil {
 		s.client.Close()
 		s.client = nil
 	}
 }
 
+// dropOnTransportError closes the connection after a failed command so the next call
+// reconnects. A !trap reply from the router leaves the session usable and keeps it.
+// The caller must hold s.mu.
+func (s *MikroTikService) dropOnTransportError(err error) {
+	var deviceErr *routeros.DeviceError
+	if errors.As(err, &deviceErr) {
+		return
+	}
+	s.disconnectLocked()
+}
+
 // GetInterfaces retrieves interface information from the router
 func (s *MikroTikService) GetInterfaces(ctx context.Context) ([]InterfaceData, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if err := s.connect(ctx); err != nil {
-		fmt.Printf("[MIKROTIK] GetInterfaces: Connection failed: %v\n", err)
 		return nil, err
 	}
 
-	fmt.Printf("[MIKROTIK] GetInterfaces: Sending /interface/print command with context timeout...\n")
-
 	// Add explicit timeout for the command execution
 	cmdCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	reply, err := s.client.RunContext(cmdCtx, "/interface/print")
+	reply, err := s.run(cmdCtx, "/interface/print")
 	if err != nil {
-		fmt.Printf("[MIKROTIK] GetInterfaces: Command failed with timeout protection: %v\n", err)
-		// Force disconnect on error to trigger reconnect next time
-		s.client = nil
+		s.logger.Warn(ComponentCollector, "get_interfaces", "/interface/print failed", routerLogFields(s.config, map[string]interface{}{
+			"error": err.Error(),
+		}))
+		s.dropOnTransportError(err)
 		return nil, fmt.Errorf("failed to get interfaces: %w", err)
 	}
 
-	fmt.Printf("[MIKROTIK] GetInterfaces: Received %d interfaces\n", len(reply.Re))
+	s.logger.Debug(ComponentCollector, "get_interfaces", "Received interfaces", routerLogFields(s.config, map[string]interface{}{
+		"count": len(reply.Re),
+	}))
 	var interfaces []InterfaceData
 	for _, re := range reply.Re {
 		iface := InterfaceData{
 			Name:        re.Map["name"],
+			ObjectID:    re.Map[".id"],
 			Status:      re.Map["running"],
 			Comment:     re.Map["comment"],
 			LastUpdated: time.Now(),
 		}
 
 		// Parse RX/TX bytes safely
-		iface.RxBytes = parseUint64(re.Map["rx-byte"])
-		iface.TxBytes = parseUint64(re.Map["tx-byte"])
+		iface.RxBytes = parseUint64(s.logger, re.Map["rx-byte"])
+		iface.TxBytes = parseUint64(s.logger, re.Map["tx-byte"])
 
 		interfaces = append(interfaces, iface)
 	}
@@ -146,31 +224,29 @@ func (s *MikroTikService) GetSystemInfo(ctx context.Context) (*SystemInfo, error
 	defer s.mu.Unlock()
 
 	if err := s.connect(ctx); err != nil {
-		fmt.Printf("[MIKROTIK] GetSystemInfo: Connection failed: %v\n", err)
 		return nil, err
 	}
 
 	info := &SystemInfo{}
 
 	// Get identity with timeout protection
-	fmt.Printf("[MIKROTIK] GetSystemInfo: Getting identity with timeout protection...\n")
 	cmdCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	reply, err := s.client.RunContext(cmdCtx, "/system/identity/print")
+	reply, err := s.run(cmdCtx, "/system/identity/print")
 	if err == nil && len(reply.Re) > 0 {
 		info.Identity = reply.Re[0].Map["name"]
-		fmt.Printf("[MIKROTIK] GetSystemInfo: Identity = %s\n", info.Identity)
 	} else if err != nil {
-		fmt.Printf("[MIKROTIK] GetSystemInfo: Failed to get identity with timeout: %v\n", err)
+		s.logger.Warn(ComponentCollector, "get_system_info", "Failed to get identity", routerLogFields(s.config, map[string]interface{}{
+			"error": err.Error(),
+		}))
 	}
 
-	// Get resource info with timeout protection
-	fmt.Printf("[MIKROTIK] GetSystemInfo: Getting resource info with timeout protection...\n")
+	// Get resource and disk info with timeout protection
 	cmdCtx, cancel = context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	reply, err = s.client.RunContext(cmdCtx, "/system/resource/print")
+	reply, err = s.run(cmdCtx, "/system/resource/print")
 	if err == nil && len(reply.Re) > 0 {
 		re := reply.Re[0].Map
 		info.BoardName = re["board-name"]
@@ -178,39 +254,26 @@ func (s *MikroTikService) GetSystemInfo(ctx context.Context) (*SystemInfo, error
 		info.Uptime = re["uptime"]
 		info.CPU = re["cpu-load"] + "%"
 		info.Memory = re["free-memory"] + "/" + re["total-memory"]
-		fmt.Printf("[MIKROTIK] GetSystemInfo: Board=%s, Version=%s, CPU=%s\n",
-			info.BoardName, info.Version, info.CPU)
-	} else if err != nil {
-		fmt.Printf("[MIKROTIK] GetSystemInfo: Failed to get resource info with timeout: %v\n", err)
-	}
-
-	// Get disk info with timeout protection
-	fmt.Printf("[MIKROTIK] GetSystemInfo: Getting disk info with timeout protection...\n")
-	cmdCtx, cancel = context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	reply, err = s.client.RunContext(cmdCtx, "/system/resource/print")
-	if err == nil && len(reply.Re) > 0 {
-		re := reply.Re[0].Map
 		if free, total := re["free-hdd-space"], re["total-hdd-space"]; free != "" && total != "" {
 			info.Disk = free + "/" + total
-			fmt.Printf("[MIKROTIK] GetSystemInfo: Disk = %s\n", info.Disk)
 		}
 	} else if err != nil {
-		fmt.Printf("[MIKROTIK] GetSystemInfo: Failed to get disk info with timeout: %v\n", err)
+		s.logger.Warn(ComponentCollector, "get_system_info", "Failed to get resource info", routerLogFields(s.config, map[string]interface{}{
+			"error": err.Error(),
+		}))
 	}
 
 	// Get timezone with timeout protection
-	fmt.Printf("[MIKROTIK] GetSystemInfo: Getting timezone with timeout protection...\n")
 	cmdCtx, cancel = context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	reply, err = s.client.RunContext(cmdCtx, "/system/clock/print")
+	reply, err = s.run(cmdCtx, "/system/clock/print")
 	if err == nil && len(reply.Re) > 0 {
 		info.Timezone = reply.Re[0].Map["time-zone-name"]
-		fmt.Printf("[MIKROTIK] GetSystemInfo: Timezone = %s\n", info.Timezone)
 	} else if err != nil {
-		fmt.Printf("[MIKROTIK] GetSystemInfo: Failed to get timezone with timeout: %v\n", err)
+		s.logger.Warn(ComponentCollector, "get_system_info", "Failed to get timezone", routerLogFields(s.config, map[string]interface{}{
+			"error": err.Error(),
+		}))
 	}
 
 	return info, nil
@@ -222,28 +285,26 @@ func (s *MikroTikService) GetTrafficStats(ctx context.Context, interfaceName str
 	defer s.mu.Unlock()
 
 	if err := s.connect(ctx); err != nil {
-		fmt.Printf("[MIKROTIK] GetTrafficStats: Connection failed: %v\n", err)
 		return nil, err
 	}
 
-	fmt.Printf("[MIKROTIK] GetTrafficStats: Monitoring traffic for %s with timeout protection...\n", interfaceName)
-
 	// Add explicit timeout for traffic monitoring command
 	cmdCtx, cancel := context.WithTimeout(ctx, 8*time.Second)
 	defer cancel()
 
-	reply, err := s.client.RunContext(cmdCtx, "/interface/monitor-traffic",
+	reply, err := s.run(cmdCtx, "/interface/monitor-traffic",
 		fmt.Sprintf("=interface=%s", interfaceName),
 		"=once=")
 	if err != nil {
-		fmt.Printf("[MIKROTIK] GetTrafficStats: Command failed with timeout protection: %v\n", err)
-		// Force disconnect on error to trigger reconnect next time
-		s.client = nil
+		s.logger.Warn(ComponentCollector, "get_traffic_stats", "/interface/monitor-traffic failed", routerLogFields(s.config, map[string]interface{}{
+			"interface": interfaceName,
+			"error":     err.Error(),
+		}))
+		s.dropOnTransportError(err)
 		return nil, fmt.Errorf("failed to get traffic stats: %w", err)
 	}
 
 	if len(reply.Re) == 0 {
-		fmt.Printf("[MIKROTIK] GetTrafficStats: No data returned for %s\n", interfaceName)
 		return nil, fmt.Errorf("no data returned for interface %s", interfaceName)
 	}
 
@@ -257,49 +318,146 @@ func (s *MikroTikService) GetTrafficStats(ctx context.Context, interfaceName str
 	// Parse rates (bits per second)
 	if rxRate, err := parseRate(re["rx-bits-per-second"]); err == nil {
 		data.RxRate = rxRate
-		fmt.Printf("[MIKROTIK] GetTrafficStats: %s RxRate = %.2f Mbps\n", interfaceName, rxRate)
 	}
 	if txRate, err := parseRate(re["tx-bits-per-second"]); err == nil {
 		data.TxRate = txRate
-		fmt.Printf("[MIKROTIK] GetTrafficStats: %s TxRate = %.2f Mbps\n", interfaceName, txRate)
 	}
 
 	return data, nil
 }
 
+// StreamInterfaces subscribes to /interface/print with =interval= on a dedicated
+// connection, so the polling connection stays free, and calls handle with every round
+// of counters until ctx ends or the connection drops. Rates are derived from
+// consecutive counter readings instead of one monitor-traffic call per interface.
+func (s *MikroTikService) StreamInterfaces(ctx context.Context, interval time.Duration, handle func([]InterfaceData)) error {
+	client, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	seconds := int(interval.Round(time.Second) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	listen, err := client.ListenArgsContext(ctx, []string{
+		"/interface/print",
+		fmt.Sprintf("=interval=%ds", seconds),
+		"=.proplist=.id,name,rx-byte,tx-byte,running,comment",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to interfaces: %w", err)
+	}
+	s.logger.Debug(ComponentCollector, "stream", "Subscribed to /interface/print", routerLogFields(s.config, map[string]interface{}{
+		"interval_seconds": seconds,
+	}))
+
+	rates := newCounterRates()
+	var batch []InterfaceData
+	seen := make(map[string]bool)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		handle(batch)
+		batch = nil
+		seen = make(map[string]bool)
+	}
+
+	// RouterOS sends one !re per interface per round without a round delimiter, so a
+	// round ends when an interface repeats or the stream goes quiet
+	idle := time.NewTimer(time.Hour)
+	idle.Stop()
+	defer idle.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-idle.C:
+			flush()
+		case sen, ok := <-listen.Chan():
+			if !ok {
+				flush()
+				if err := listen.Err(); err != nil {
+					return fmt.Errorf("interface stream failed: %w", err)
+				}
+				return fmt.Errorf("interface stream closed by router")
+			}
+			name := sen.Map["name"]
+			if seen[name] {
+				flush()
+			}
+			iface := InterfaceData{
+				Name:        name,
+				ObjectID:    sen.Map[".id"],
+				RxBytes:     parseUint64(s.logger, sen.Map["rx-byte"]),
+				TxBytes:     parseUint64(s.logger, sen.Map["tx-byte"]),
+				Status:      sen.Map["running"],
+				Comment:     sen.Map["comment"],
+				LastUpdated: time.Now(),
+			}
+			iface.RxRate, iface.TxRate = rates.update(iface.Name, iface.RxBytes, iface.TxBytes, iface.LastUpdated)
+			batch = append(batch, iface)
+			seen[name] = true
+			idle.Reset(time.Duration(seconds) * time.Second / 2)
+		}
+	}
+}
+
+// GetUptime reads the time since the router booted from /system/resource
+func (s *MikroTikService) GetUptime(ctx context.Context) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.connect(ctx); err != nil {
+		return 0, err
+	}
+
+	cmdCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	reply, err := s.run(cmdCtx, "/system/resource/print", "=.proplist=uptime")
+	if err != nil {
+		s.dropOnTransportError(err)
+		return 0, fmt.Errorf("failed to get uptime: %w", err)
+	}
+	if len(reply.Re) == 0 {
+		return 0, fmt.Errorf("uptime not available")
+	}
+	return parseRouterOSDuration(reply.Re[0].Map["uptime"])
+}
+
 // GetLastRebootLog retrieves the timestamp of the last reboot from router logs
 func (s *MikroTikService) GetLastRebootLog(ctx context.Context) (time.Time, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if err := s.connect(ctx); err != nil {
-		fmt.Printf("[MIKROTIK] GetLastRebootLog: Connection failed: %v\n", err)
 		return time.Time{}, err
 	}
 
-	fmt.Printf("[MIKROTIK] GetLastRebootLog: Querying logs for reboot events with timeout protection...\n")
-
 	// Add explicit timeout for log query command
 	cmdCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	// Query logs for reboot events
-	reply, err := s.client.RunContext(cmdCtx, "/log/print",
+	reply, err := s.run(cmdCtx, "/log/print",
 		"where=topics~\"system\"",
 		"?message~\"reboot\"|?message~\"started\"|?message~\"RouterOS\"")
 	if err != nil {
-		fmt.Printf("[MIKROTIK] GetLastRebootLog: Failed to get logs with timeout protection: %v\n", err)
-		// Force disconnect on error to trigger reconnect next time
-		s.client = nil
+		s.logger.Warn(ComponentCollector, "reboot_log", "/log/print failed", routerLogFields(s.config, map[string]interface{}{
+			"error": err.Error(),
+		}))
+		s.dropOnTransportError(err)
 		return time.Time{}, fmt.Errorf("failed to get logs: %w", err)
 	}
 
 	if len(reply.Re) == 0 {
-		fmt.Printf("[MIKROTIK] GetLastRebootLog: No reboot logs found\n")
 		return time.Time{}, fmt.Errorf("no reboot logs found")
 	}
 
-	fmt.Printf("[MIKROTIK] GetLastRebootLog: Found %d log entries\n", len(reply.Re))
 	// Find the most recent reboot log
 	var latestTime time.Time
 	for _, re := range reply.Re {
@@ -320,11 +478,13 @@ func (s *MikroTikService) GetLastRebootLog(ctx context.Context) (time.Time, erro
 	}
 
 	if latestTime.IsZero() {
-		fmt.Printf("[MIKROTIK] GetLastRebootLog: Could not parse any reboot time\n")
 		return time.Time{}, fmt.Errorf("could not parse any reboot time")
 	}
 
-	fmt.Printf("[MIKROTIK] GetLastRebootLog: Latest reboot time = %v\n", latestTime)
+	s.logger.Debug(ComponentCollector, "reboot_log", "Found last reboot", routerLogFields(s.config, map[string]interface{}{
+		"entries":     len(reply.Re),
+		"reboot_time": latestTime,
+	}))
 	return latestTime, nil
 }
 
@@ -367,6 +527,99 @@ func parseRate(rateStr string) (float64, error) {
 	return rate / 1000000, nil
 }
 
+// AddSimpleQueue adds a /queue/simple entry limiting target, replacing an entry with
+// the same name
+func (s *MikroTikService) AddSimpleQueue(ctx context.Context, name, target, maxLimit, comment string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.connect(ctx); err != nil {
+		return err
+	}
+
+	cmdCtx, cancel := context.WithTimeout(ctx, 8*time.Second)
+	defer cancel()
+
+	if err := s.removeSimpleQueue(cmdCtx, name); err != nil {
+		return err
+	}
+	if _, err := s.run(cmdCtx, "/queue/simple/add",
+		"=name="+name,
+		"=target="+target,
+		"=max-limit="+maxLimit,
+		"=comment="+comment); err != nil {
+		s.dropOnTransportError(err)
+		return fmt.Errorf("failed to add simple queue %s: %w", name, err)
+	}
+	s.logger.Info(ComponentCollector, "queue", "Added simple queue", routerLogFields(s.config, map[string]interface{}{
+		"queue":     name,
+		"target":    target,
+		"max_limit": maxLimit,
+	}))
+	return nil
+}
+
+// RemoveSimpleQueue removes the /queue/simple entries with the given name
+func (s *MikroTikService) RemoveSimpleQueue(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.connect(ctx); err != nil {
+		return err
+	}
+
+	cmdCtx, cancel := context.WithTimeout(ctx, 8*time.Second)
+	defer cancel()
+	return s.removeSimpleQueue(cmdCtx, name)
+}
+
+// removeSimpleQueue removes queues by name. The caller must hold s.mu.
+func (s *MikroTikService) removeSimpleQueue(ctx context.Context, name string) error {
+	reply, err := s.run(ctx, "/queue/simple/print", "?name="+name, "=.proplist=.id")
+	if err != nil {
+		s.dropOnTransportError(err)
+		return fmt.Errorf("failed to look up simple queue %s: %w", name, err)
+	}
+	for _, re := range reply.Re {
+		if _, err := s.run(ctx, "/queue/simple/remove", "=.id="+re.Map[".id"]); err != nil {
+			s.dropOnTransportError(err)
+			return fmt.Errorf("failed to remove simple queue %s: %w", name, err)
+		}
+		s.logger.Info(ComponentCollector, "queue", "Removed simple queue", routerLogFields(s.config, map[string]interface{}{
+			"queue": name,
+			"id":    re.Map[".id"],
+		}))
+	}
+	return nil
+}
+
+// SetInterfaceDisabled disables or enables an interface
+func (s *MikroTikService) SetInterfaceDisabled(ctx context.Context, name string, disabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.connect(ctx); err != nil {
+		return err
+	}
+
+	cmdCtx, cancel := context.WithTimeout(ctx, 8*time.Second)
+	defer cancel()
+
+	command := "/interface/enable"
+	if disabled {
+		command = "/interface/disable"
+	}
+	if _, err := s.run(cmdCtx, command, "=numbers="+name); err != nil {
+		s.dropOnTransportError(err)
+		return fmt.Errorf("%s %s failed: %w", command, name, err)
+	}
+	s.logger.Info(ComponentCollector, "interface", "Changed interface state", routerLogFields(s.config, map[string]interface{}{
+		"command":   command,
+		"interface": name,
+	}))
+	return nil
+}
+
 // Close closes the service and cleans up resources
 func (s *MikroTikService) Close() {
 	s.disconnect()
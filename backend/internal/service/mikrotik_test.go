@@ -0,0 +1,314 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"monik-enterprise/internal/config"
+
+	"github.com/go-routeros/routeros/v3"
+	"github.com/go-routeros/routeros/v3/proto"
+)
+
+// apiReplies answers one RouterOS API command with reply sentences, each a list of
+// words. nil drops the connection instead of replying.
+type apiReplies func(command string) [][]string
+
+var (
+	apiDone = []string{"!done"}
+	apiTrap = []string{"!trap", "=message=failure: no such item"}
+)
+
+// newAPITestService returns a collector already connected to an in-process API
+// endpoint. The returned channel is closed once the collector closes its session.
+func newAPITestService(t *testing.T, replies apiReplies) (*MikroTikService, <-chan struct{}) {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	client, err := routeros.NewClient(clientConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		defer serverConn.Close()
+		r, w := bufio.NewReader(serverConn), proto.NewWriter(serverConn)
+		for {
+			command, err := readAPICommand(r)
+			if err != nil {
+				return
+			}
+			reply := replies(command)
+			if reply == nil {
+				return
+			}
+			for _, words := range reply {
+				w.BeginSentence()
+				for _, word := range words {
+					w.WriteWord(word)
+				}
+				if err := w.EndSentence(); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		clientConn.Close()
+		serverConn.Close()
+	})
+
+	s := NewMikroTikService(config.RouterConfig{IP: "192.0.2.1", Port: 8728})
+	s.client = client
+	return s, closed
+}
+
+// readAPICommand reads one sentence and returns its command word. proto.Reader only
+// parses replies and rejects the ?query words of a request. Test words are short, so
+// every length fits in a single byte.
+func readAPICommand(r *bufio.Reader) (string, error) {
+	var command string
+	for {
+		length, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if length == 0 {
+			return command, nil
+		}
+		if length >= 0x80 {
+			return "", fmt.Errorf("word of %#x bytes is too long for the test endpoint", length)
+		}
+		word := make([]byte, length)
+		if _, err := io.ReadFull(r, word); err != nil {
+			return "", err
+		}
+		if command == "" {
+			command = string(word)
+		}
+	}
+}
+
+func TestMikroTikKeepsSessionOnTrap(t *testing.T) {
+	tests := []struct {
+		name     string
+		replies  map[string][][]string // command -> replies, missing commands drop the connection
+		call     func(s *MikroTikService) error
+		wantErr  string
+		wantKept bool
+	}{
+		{
+			name:     "interface disabled",
+			replies:  map[string][][]string{"/interface/disable": {apiDone}},
+			call:     func(s *MikroTikService) error { return s.SetInterfaceDisabled(context.Background(), "ether5", true) },
+			wantKept: true,
+		},
+		{
+			name:     "interface enable trapped",
+			replies:  map[string][][]string{"/interface/enable": {apiTrap, apiDone}},
+			call:     func(s *MikroTikService) error { return s.SetInterfaceDisabled(context.Background(), "ether9", false) },
+			wantErr:  "no such item",
+			wantKept: true,
+		},
+		{
+			name:    "interface disable cut off",
+			replies: map[string][][]string{},
+			call:    func(s *MikroTikService) error { return s.SetInterfaceDisabled(context.Background(), "ether5", true) },
+			wantErr: "/interface/disable ether5 failed",
+		},
+		{
+			name: "queue replaced",
+			replies: map[string][][]string{
+				"/queue/simple/print":  {{"!re", "=.id=*1"}, apiDone},
+				"/queue/simple/remove": {apiDone},
+				"/queue/simple/add":    {apiDone},
+			},
+			call: func(s *MikroTikService) error {
+				return s.AddSimpleQueue(context.Background(), "monik-ether5", "ether5", "1M/1M", "quota")
+			},
+			wantKept: true,
+		},
+		{
+			name: "queue add trapped",
+			replies: map[string][][]string{
+				"/queue/simple/print": {apiDone},
+				"/queue/simple/add":   {{"!trap", "=message=invalid value of max-limit"}, apiDone},
+			},
+			call: func(s *MikroTikService) error {
+				return s.AddSimpleQueue(context.Background(), "monik-ether5", "ether5", "bogus", "quota")
+			},
+			wantErr:  "invalid value of max-limit",
+			wantKept: true,
+		},
+		{
+			name: "queue remove trapped",
+			replies: map[string][][]string{
+				"/queue/simple/print":  {{"!re", "=.id=*1"}, apiDone},
+				"/queue/simple/remove": {apiTrap, apiDone},
+			},
+			call:     func(s *MikroTikService) error { return s.RemoveSimpleQueue(context.Background(), "monik-ether5") },
+			wantErr:  "failed to remove simple queue",
+			wantKept: true,
+		},
+		{
+			name: "queue lookup cut off",
+			replies: map[string][][]string{
+				"/queue/simple/add": {apiDone},
+			},
+			call: func(s *MikroTikService) error {
+				return s.AddSimpleQueue(context.Background(), "monik-ether5", "ether5", "1M/1M", "quota")
+			},
+			wantErr: "failed to look up simple queue",
+		},
+		{
+			name: "queue remove cut off",
+			replies: map[string][][]string{
+				"/queue/simple/print": {{"!re", "=.id=*1"}, apiDone},
+			},
+			call:    func(s *MikroTikService) error { return s.RemoveSimpleQueue(context.Background(), "monik-ether5") },
+			wantErr: "failed to remove simple queue",
+		},
+		{
+			name:    "interfaces trapped",
+			replies: map[string][][]string{"/interface/print": {apiTrap, apiDone}},
+			call: func(s *MikroTikService) error {
+				_, err := s.GetInterfaces(context.Background())
+				return err
+			},
+			wantErr:  "failed to get interfaces",
+			wantKept: true,
+		},
+		{
+			name:    "interfaces cut off",
+			replies: map[string][][]string{},
+			call: func(s *MikroTikService) error {
+				_, err := s.GetInterfaces(context.Background())
+				return err
+			},
+			wantErr: "failed to get interfaces",
+		},
+		{
+			name:    "traffic trapped",
+			replies: map[string][][]string{"/interface/monitor-traffic": {apiTrap, apiDone}},
+			call: func(s *MikroTikService) error {
+				_, err := s.GetTrafficStats(context.Background(), "ether9")
+				return err
+			},
+			wantErr:  "failed to get traffic stats",
+			wantKept: true,
+		},
+		{
+			name:    "traffic cut off",
+			replies: map[string][][]string{},
+			call: func(s *MikroTikService) error {
+				_, err := s.GetTrafficStats(context.Background(), "ether5")
+				return err
+			},
+			wantErr: "failed to get traffic stats",
+		},
+		{
+			name:    "uptime trapped",
+			replies: map[string][][]string{"/system/resource/print": {apiTrap, apiDone}},
+			call: func(s *MikroTikService) error {
+				_, err := s.GetUptime(context.Background())
+				return err
+			},
+			wantErr:  "failed to get uptime",
+			wantKept: true,
+		},
+		{
+			name:    "uptime cut off",
+			replies: map[string][][]string{},
+			call: func(s *MikroTikService) error {
+				_, err := s.GetUptime(context.Background())
+				return err
+			},
+			wantErr: "failed to get uptime",
+		},
+		{
+			name:    "reboot log trapped",
+			replies: map[string][][]string{"/log/print": {apiTrap, apiDone}},
+			call: func(s *MikroTikService) error {
+				_, err := s.GetLastRebootLog(context.Background())
+				return err
+			},
+			wantErr:  "failed to get logs",
+			wantKept: true,
+		},
+		{
+			name:    "reboot log cut off",
+			replies: map[string][][]string{},
+			call: func(s *MikroTikService) error {
+				_, err := s.GetLastRebootLog(context.Background())
+				return err
+			},
+			wantErr: "failed to get logs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, closed := newAPITestService(t, func(command string) [][]string {
+				return tt.replies[command]
+			})
+			client := s.client
+
+			err := tt.call(s)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+
+			if tt.wantKept {
+				if s.client != client {
+					t.Errorf("session dropped after %v", err)
+				}
+				select {
+				case <-closed:
+					t.Errorf("session closed after %v", err)
+				default:
+				}
+				return
+			}
+			if s.client != nil {
+				t.Errorf("broken session kept after %v", err)
+			}
+			<-closed // The old session was closed rather than leaked
+		})
+	}
+}
+
+func TestMikroTikSystemInfoReadsResourcesOnce(t *testing.T) {
+	calls := map[string]int{}
+	s, _ := newAPITestService(t, func(command string) [][]string {
+		calls[command]++
+		switch command {
+		case "/system/identity/print":
+			return [][]string{{"!re", "=name=core"}, apiDone}
+		case "/system/resource/print":
+			return [][]string{{"!re", "=version=7.14", "=cpu-load=3", "=free-memory=10", "=total-memory=20",
+				"=free-hdd-space=5", "=total-hdd-space=16"}, apiDone}
+		case "/system/clock/print":
+			return [][]string{{"!re", "=time-zone-name=UTC"}, apiDone}
+		}
+		return nil
+	})
+
+	info, err := s.GetSystemInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls["/system/resource/print"] != 1 {
+		t.Errorf("resources read %d times, want once", calls["/system/resource/print"])
+	}
+	if info.Identity != "core" || info.Version != "7.14" || info.Memory != "10/20" || info.Disk != "5/16" || info.Timezone != "UTC" {
+		t.Errorf("system info = %+v", info)
+	}
+}
@@ -0,0 +1,525 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"monik-enterprise/internal/config"
+	"monik-enterprise/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Notification delivery states
+const (
+	DeliveryStatusSent   = "sent"
+	DeliveryStatusFailed = "failed"
+)
+
+// Notification events raised outside the alert and quota services
+const (
+	NotifyEventWANFailover = "wan_failover"
+	NotifyEventTest        = "test"
+)
+
+// notifyChannel is a channel together with its compiled notifier
+type notifyChannel struct {
+	models.NotificationChannel
+	notifier Notifier
+}
+
+type notifyJob struct {
+	channel *notifyChannel
+	n       Notification
+}
+
+// NotificationService routes events to notification channels and delivers them from a
+// queue, retrying failed deliveries with exponential backoff. Callers never block on
+// delivery.
+type NotificationService struct {
+	db     *gorm.DB
+	config config.NotifyConfig
+	logger *LoggerService
+
+	mu       sync.RWMutex
+	channels map[uint]*notifyChannel
+	routes   []models.NotificationRoute
+
+	queue chan notifyJob
+	quit  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// DeliveryFilter selects deliveries in Deliveries
+type DeliveryFilter struct {
+	ChannelID uint
+	Event     string
+	Status    string
+	Limit     int
+}
+
+// NewNotificationService creates a notification service and loads channels and routes
+func NewNotificationService(db *gorm.DB, cfg config.NotifyConfig) *NotificationService {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.QueueSize < 1 {
+		cfg.QueueSize = 500
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Retries < 0 {
+		cfg.Retries = 0
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = 5 * time.Second
+	}
+	if cfg.MaxRetryDelay < cfg.RetryDelay {
+		cfg.MaxRetryDelay = cfg.RetryDelay
+	}
+
+	s := &NotificationService{
+		db:       db,
+		config:   cfg,
+		channels: make(map[uint]*notifyChannel),
+		queue:    make(chan notifyJob, cfg.QueueSize),
+		quit:     make(chan struct{}),
+	}
+	if err := s.reload(); err != nil {
+		s.logger.Error(ComponentNotification, "load", "Failed to load notification channels", err, nil)
+	}
+	return s
+}
+
+// SetLogger sets the structured logger of the notification service
+func (s *NotificationService) SetLogger(logger *LoggerService) {
+	s.logger = logger
+}
+
+// Start runs the delivery workers
+func (s *NotificationService) Start() {
+	for i := 0; i < s.config.Workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+	s.mu.RLock()
+	fields := map[string]interface{}{
+		"channels": len(s.channels),
+		"routes":   len(s.routes),
+		"workers":  s.config.Workers,
+	}
+	s.mu.RUnlock()
+	s.logger.Info(ComponentNotification, "start", "Notification delivery started", fields)
+}
+
+// Stop stops the delivery workers; queued deliveries are dropped
+func (s *NotificationService) Stop() {
+	close(s.quit)
+	s.wg.Wait()
+}
+
+func (s *NotificationService) worker() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.quit:
+			return
+		case job := <-s.queue:
+			s.deliver(job)
+		}
+	}
+}
+
+// Notify queues a notification for every channel with a matching route. A nil service
+// drops the notification, so producers work without notifications configured.
+func (s *NotificationService) Notify(n Notification) {
+	if s == nil {
+		return
+	}
+	if n.Time.IsZero() {
+		n.Time = time.Now()
+	}
+	if n.Severity == "" {
+		n.Severity = AlertSeverityInfo
+	}
+
+	for _, channel := range s.match(n) {
+		select {
+		case s.queue <- notifyJob{channel: channel, n: n}:
+		default:
+			s.logger.Warn(ComponentNotification, "notify", "Queue full, dropping notification", map[string]interface{}{
+				"event":   n.Event,
+				"channel": channel.Name,
+			})
+		}
+	}
+}
+
+// match returns the enabled channels routed to a notification, each channel once
+func (s *NotificationService) match(n Notification) []*notifyChannel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matched []*notifyChannel
+	seen := make(map[uint]bool)
+	for _, route := range s.routes {
+		channel, ok := s.channels[route.ChannelID]
+		if !ok || seen[route.ChannelID] || !route.Enabled || !channel.Enabled {
+			continue
+		}
+		if route.RouterID != 0 && route.RouterID != n.RouterID {
+			continue
+		}
+		if severityRank(n.Severity) < severityRank(route.MinSeverity) {
+			continue
+		}
+		if !matchEventPatterns(route.Events, n.Event) {
+			continue
+		}
+		seen[route.ChannelID] = true
+		matched = append(matched, channel)
+	}
+	return matched
+}
+
+// deliver sends a notification with retries and records the outcome
+func (s *NotificationService) deliver(job notifyJob) {
+	delay := s.config.RetryDelay
+	var err error
+	attempts := 0
+	for attempts <= s.config.Retries {
+		if attempts > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-s.quit:
+				timer.Stop()
+				s.record(job, attempts, fmt.Errorf("shutdown before retry: %w", err))
+				return
+			case <-timer.C:
+			}
+			delay *= 2
+			if delay > s.config.MaxRetryDelay {
+				delay = s.config.MaxRetryDelay
+			}
+		}
+		attempts++
+
+		ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+		err = job.channel.notifier.Send(ctx, job.n)
+		cancel()
+		if err == nil || isPermanent(err) {
+			break
+		}
+		s.logger.Warn(ComponentNotification, "deliver", "Delivery failed, retrying", map[string]interface{}{
+			"event":        job.n.Event,
+			"channel":      job.channel.Name,
+			"attempt":      attempts,
+			"max_attempts": s.config.Retries + 1,
+			"error":        err.Error(),
+		})
+	}
+	s.record(job, attempts, err)
+}
+
+// record stores the outcome of a delivery
+func (s *NotificationService) record(job notifyJob, attempts int, err error) *models.NotificationDelivery {
+	delivery := &models.NotificationDelivery{
+		ChannelID:   job.channel.ID,
+		ChannelName: job.channel.Name,
+		Event:       job.n.Event,
+		Severity:    job.n.Severity,
+		Title:       job.n.Title,
+		Status:      DeliveryStatusSent,
+		Attempts:    attempts,
+	}
+	if err != nil {
+		delivery.Status = DeliveryStatusFailed
+		delivery.Error = err.Error()
+		s.logger.Error(ComponentNotification, "deliver", "Gave up delivering notification", err, map[string]interface{}{
+			"event":    job.n.Event,
+			"channel":  job.channel.Name,
+			"attempts": attempts,
+		})
+	} else {
+		now := time.Now()
+		delivery.SentAt = &now
+	}
+	if dbErr := s.db.Create(delivery).Error; dbErr != nil {
+		s.logger.Error(ComponentNotification, "deliver", "Failed to record delivery", dbErr, map[string]interface{}{
+			"channel": job.channel.Name,
+		})
+	}
+	return delivery
+}
+
+// reload refreshes the cached channels and routes
+func (s *NotificationService) reload() error {
+	var channels []models.NotificationChannel
+	if err := s.db.Order("id ASC").Find(&channels).Error; err != nil {
+		return err
+	}
+	var routes []models.NotificationRoute
+	if err := s.db.Order("id ASC").Find(&routes).Error; err != nil {
+		return err
+	}
+
+	compiled := make(map[uint]*notifyChannel, len(channels))
+	for _, channel := range channels {
+		notifier, err := NewNotifier(channel, s.config.Timeout)
+		if err != nil {
+			s.logger.Warn(ComponentNotification, "load", "Skipping invalid channel", map[string]interface{}{
+				"channel": channel.Name,
+				"error":   err.Error(),
+			})
+			continue
+		}
+		compiled[channel.ID] = &notifyChannel{NotificationChannel: channel, notifier: notifier}
+	}
+
+	s.mu.Lock()
+	s.channels = compiled
+	s.routes = routes
+	s.mu.Unlock()
+	return nil
+}
+
+// NormalizeNotificationChannel fills unset fields of a channel and validates it
+func NormalizeNotificationChannel(channel *models.NotificationChannel) error {
+	channel.Name = strings.TrimSpace(channel.Name)
+	if channel.Name == "" {
+		return errors.New("name is required")
+	}
+	channel.Type = strings.ToLower(channel.Type)
+	if channel.Type == ChannelTypeSMTP && channel.SMTPTLS == "" {
+		channel.SMTPTLS = SMTPTLSStartTLS
+	}
+	_, err := NewNotifier(*channel, time.Second)
+	return err
+}
+
+// NormalizeNotificationRoute fills unset fields of a route and validates it
+func NormalizeNotificationRoute(route *models.NotificationRoute) error {
+	route.Name = strings.TrimSpace(route.Name)
+	if route.Name == "" {
+		return errors.New("name is required")
+	}
+	if route.ChannelID == 0 {
+		return errors.New("channel_id is required")
+	}
+	if route.MinSeverity == "" {
+		route.MinSeverity = AlertSeverityInfo
+	}
+	if severityRank(route.MinSeverity) == 0 {
+		return errors.New("invalid min_severity, expected info, warning or critical")
+	}
+	patterns := splitList(route.Events)
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid event pattern %q", pattern)
+		}
+	}
+	route.Events = strings.Join(patterns, ",")
+	return nil
+}
+
+// ListChannels returns all notification channels
+func (s *NotificationService) ListChannels() ([]models.NotificationChannel, error) {
+	var channels []models.NotificationChannel
+	err := s.db.Order("id ASC").Find(&channels).Error
+	return channels, err
+}
+
+// GetChannel returns a notification channel by ID
+func (s *NotificationService) GetChannel(id uint) (*models.NotificationChannel, error) {
+	var channel models.NotificationChannel
+	if err := s.db.First(&channel, id).Error; err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// CreateChannel stores a new notification channel
+func (s *NotificationService) CreateChannel(channel *models.NotificationChannel) error {
+	if err := NormalizeNotificationChannel(channel); err != nil {
+		return err
+	}
+	if err := s.db.Create(channel).Error; err != nil {
+		return err
+	}
+	return s.reload()
+}
+
+// UpdateChannel replaces a notification channel
+func (s *NotificationService) UpdateChannel(id uint, channel models.NotificationChannel) (*models.NotificationChannel, error) {
+	existing, err := s.GetChannel(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := NormalizeNotificationChannel(&channel); err != nil {
+		return nil, err
+	}
+	channel.ID = existing.ID
+	channel.CreatedAt = existing.CreatedAt
+	if err := s.db.Save(&channel).Error; err != nil {
+		return nil, err
+	}
+	return &channel, s.reload()
+}
+
+// DeleteChannel removes a notification channel together with its routes
+func (s *NotificationService) DeleteChannel(id uint) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Delete(&models.NotificationChannel{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("channel_id = ?", id).Delete(&models.NotificationRoute{}).Error
+	})
+	if err != nil {
+		return err
+	}
+	return s.reload()
+}
+
+// TestChannel sends a test notification to a channel right away, without retries
+func (s *NotificationService) TestChannel(id uint) (*models.NotificationDelivery, error) {
+	s.mu.RLock()
+	channel, ok := s.channels[id]
+	s.mu.RUnlock()
+	if !ok {
+		if _, err := s.GetChannel(id); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("channel settings are invalid")
+	}
+
+	n := Notification{
+		Event:    NotifyEventTest,
+		Severity: AlertSeverityInfo,
+		Title:    "MONIK test notification",
+		Message:  fmt.Sprintf("Channel %s is configured correctly.", channel.Name),
+		Time:     time.Now(),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+	defer cancel()
+	err := channel.notifier.Send(ctx, n)
+	return s.record(notifyJob{channel: channel, n: n}, 1, err), nil
+}
+
+// ListRoutes returns all notification routes
+func (s *NotificationService) ListRoutes() ([]models.NotificationRoute, error) {
+	var routes []models.NotificationRoute
+	err := s.db.Order("id ASC").Find(&routes).Error
+	return routes, err
+}
+
+// CreateRoute stores a new notification route
+func (s *NotificationService) CreateRoute(route *models.NotificationRoute) error {
+	if err := s.validateRoute(route); err != nil {
+		return err
+	}
+	if err := s.db.Create(route).Error; err != nil {
+		return err
+	}
+	return s.reload()
+}
+
+// UpdateRoute replaces a notification route
+func (s *NotificationService) UpdateRoute(id uint, route models.NotificationRoute) (*models.NotificationRoute, error) {
+	var existing models.NotificationRoute
+	if err := s.db.First(&existing, id).Error; err != nil {
+		return nil, err
+	}
+	if err := s.validateRoute(&route); err != nil {
+		return nil, err
+	}
+	route.ID = existing.ID
+	route.CreatedAt = existing.CreatedAt
+	if err := s.db.Save(&route).Error; err != nil {
+		return nil, err
+	}
+	return &route, s.reload()
+}
+
+// DeleteRoute removes a notification route
+func (s *NotificationService) DeleteRoute(id uint) error {
+	res := s.db.Delete(&models.NotificationRoute{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return s.reload()
+}
+
+func (s *NotificationService) validateRoute(route *models.NotificationRoute) error {
+	if err := NormalizeNotificationRoute(route); err != nil {
+		return err
+	}
+	if _, err := s.GetChannel(route.ChannelID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("channel not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// Deliveries returns delivery records matching filter, newest first
+func (s *NotificationService) Deliveries(filter DeliveryFilter) ([]models.NotificationDelivery, error) {
+	query := s.db.Order("created_at DESC, id DESC")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.ChannelID != 0 {
+		query = query.Where("channel_id = ?", filter.ChannelID)
+	}
+	if filter.Event != "" {
+		query = query.Where("event = ?", filter.Event)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var deliveries []models.NotificationDelivery
+	err := query.Find(&deliveries).Error
+	return deliveries, err
+}
+
+// matchEventPatterns reports whether an event matches a comma separated list of glob
+// patterns; an empty list matches every event
+func matchEventPatterns(patterns, event string) bool {
+	list := splitList(patterns)
+	if len(list) == 0 {
+		return true
+	}
+	for _, pattern := range list {
+		if matched, _ := path.Match(pattern, event); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// severityRank orders severities, 0 for unknown ones
+func severityRank(severity string) int {
+	switch severity {
+	case AlertSeverityInfo:
+		return 1
+	case AlertSeverityWarning:
+		return 2
+	case AlertSeverityCritical:
+		return 3
+	}
+	return 0
+}
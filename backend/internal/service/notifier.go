@@ -0,0 +1,409 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"monik-enterprise/internal/models"
+)
+
+// Notification channel types
+const (
+	ChannelTypeSMTP     = "smtp"
+	ChannelTypeWebhook  = "webhook"
+	ChannelTypeTelegram = "telegram"
+)
+
+// SMTP connection security
+const (
+	SMTPTLSStartTLS = "starttls"
+	SMTPTLSImplicit = "tls"
+	SMTPTLSNone     = "none"
+)
+
+// DefaultTelegramAPIURL is the Bot API endpoint used when a channel has no URL
+const DefaultTelegramAPIURL = "https://api.telegram.org"
+
+// Webhook request headers
+const (
+	WebhookSignatureHeader = "X-Monik-Signature" // sha256=<hex HMAC of "<timestamp>.<body>">
+	WebhookTimestampHeader = "X-Monik-Timestamp" // Unix seconds
+	WebhookEventHeader     = "X-Monik-Event"
+)
+
+const (
+	defaultSubjectTemplate = "[MONIK] {{.Severity}}: {{.Title}}"
+	defaultBodyTemplate    = `{{.Title}}
+{{.Message}}
+
+Event: {{.Event}}
+Severity: {{.Severity}}
+{{- if .RouterID}}
+Router: {{.RouterID}}{{end}}
+{{- if .InterfaceName}}
+Interface: {{.InterfaceName}}{{end}}
+Time: {{.Time.Format "2006-01-02 15:04:05 MST"}}`
+)
+
+// Notification is an event delivered to notification channels
+type Notification struct {
+	Event         string                 `json:"event"`    // e.g. alert_firing, quota_threshold, wan_failover
+	Severity      string                 `json:"severity"` // info, warning, critical
+	Title         string                 `json:"title"`
+	Message       string                 `json:"message"`
+	RouterID      uint                   `json:"router_id,omitempty"`
+	InterfaceName string                 `json:"interface_name,omitempty"`
+	Data          map[string]interface{} `json:"data,omitempty"`
+	Time          time.Time              `json:"time"`
+}
+
+// Notifier delivers notifications to one channel
+type Notifier interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// permanentError marks a delivery failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// isPermanent reports whether a delivery error should not be retried
+func isPermanent(err error) bool {
+	var perm *permanentError
+	return errors.As(err, &perm)
+}
+
+// NewNotifier builds the notifier of a channel, validating its settings and templates
+func NewNotifier(channel models.NotificationChannel, timeout time.Duration) (Notifier, error) {
+	subject, body, err := parseChannelTemplates(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	switch channel.Type {
+	case ChannelTypeSMTP:
+		if channel.SMTPHost == "" || channel.SMTPFrom == "" || len(splitList(channel.SMTPTo)) == 0 {
+			return nil, errors.New("smtp channels need smtp_host, smtp_from and smtp_to")
+		}
+		switch channel.SMTPTLS {
+		case SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone:
+		default:
+			return nil, errors.New("invalid smtp_tls, expected starttls, tls or none")
+		}
+		return &SMTPNotifier{channel: channel, subject: subject, body: body, timeout: timeout}, nil
+	case ChannelTypeWebhook:
+		if err := validateHTTPURL(channel.URL); err != nil {
+			return nil, fmt.Errorf("invalid webhook url: %w", err)
+		}
+		return &WebhookNotifier{channel: channel, body: body, client: &http.Client{Timeout: timeout}}, nil
+	case ChannelTypeTelegram:
+		if channel.TelegramToken == "" || channel.TelegramChatID == "" {
+			return nil, errors.New("telegram channels need telegram_token and telegram_chat_id")
+		}
+		if err := validateHTTPURL(channel.URL); channel.URL != "" && err != nil {
+			return nil, fmt.Errorf("invalid telegram api url: %w", err)
+		}
+		return &TelegramNotifier{channel: channel, body: body, client: &http.Client{Timeout: timeout}}, nil
+	}
+	return nil, errors.New("invalid type, expected smtp, webhook or telegram")
+}
+
+// parseChannelTemplates compiles the subject and body templates of a channel. A nil
+// webhook body template sends the notification as JSON.
+func parseChannelTemplates(channel models.NotificationChannel) (*template.Template, *template.Template, error) {
+	subjectText := channel.SubjectTemplate
+	if subjectText == "" {
+		subjectText = defaultSubjectTemplate
+	}
+	subject, err := template.New("subject").Parse(subjectText)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid subject_template: %w", err)
+	}
+
+	bodyText := channel.BodyTemplate
+	if bodyText == "" {
+		if channel.Type == ChannelTypeWebhook {
+			return subject, nil, nil
+		}
+		bodyText = defaultBodyTemplate
+	}
+	body, err := template.New("body").Parse(bodyText)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid body_template: %w", err)
+	}
+	return subject, body, nil
+}
+
+// render executes a channel template; failures cannot be fixed by retrying
+func render(tmpl *template.Template, n Notification) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, n); err != nil {
+		return "", &permanentError{fmt.Errorf("failed to render %s template: %w", tmpl.Name(), err)}
+	}
+	return buf.String(), nil
+}
+
+// SMTPNotifier sends notifications as plain text email
+type SMTPNotifier struct {
+	channel models.NotificationChannel
+	subject *template.Template
+	body    *template.Template
+	timeout time.Duration
+}
+
+// Send delivers a notification to every recipient of the channel
+func (s *SMTPNotifier) Send(ctx context.Context, n Notification) error {
+	subject, err := render(s.subject, n)
+	if err != nil {
+		return err
+	}
+	body, err := render(s.body, n)
+	if err != nil {
+		return err
+	}
+	recipients := splitList(s.channel.SMTPTo)
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.channel.SMTPFrom)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(recipients, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", strings.ReplaceAll(subject, "\n", " "))
+	fmt.Fprintf(&msg, "Date: %s\r\n", n.Time.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	port := s.channel.SMTPPort
+	if port == 0 {
+		port = defaultSMTPPort(s.channel.SMTPTLS)
+	}
+	address := net.JoinHostPort(s.channel.SMTPHost, strconv.Itoa(port))
+
+	dialer := &net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	tlsConfig := &tls.Config{ServerName: s.channel.SMTPHost, MinVersion: tls.VersionTLS12}
+	if s.channel.SMTPTLS == SMTPTLSImplicit {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, s.channel.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake with %s failed: %w", address, err)
+	}
+	defer client.Close()
+
+	if s.channel.SMTPTLS == SMTPTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return &permanentError{fmt.Errorf("%s does not support STARTTLS", address)}
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls with %s failed: %w", address, err)
+		}
+	}
+	if s.channel.SMTPUsername != "" {
+		auth := smtp.PlainAuth("", s.channel.SMTPUsername, s.channel.SMTPPassword, s.channel.SMTPHost)
+		if err := client.Auth(auth); err != nil {
+			return &permanentError{fmt.Errorf("smtp authentication failed: %w", err)}
+		}
+	}
+
+	if err := client.Mail(s.channel.SMTPFrom); err != nil {
+		return fmt.Errorf("MAIL FROM rejected: %w", err)
+	}
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("RCPT TO %s rejected: %w", rcpt, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("DATA rejected: %w", err)
+	}
+	if _, err := w.Write(msg.Bytes()); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+	return client.Quit()
+}
+
+// WebhookNotifier posts notifications as JSON, signed with HMAC-SHA256 when the
+// channel has a secret
+type WebhookNotifier struct {
+	channel models.NotificationChannel
+	body    *template.Template // nil posts the notification itself
+	client  *http.Client
+}
+
+// Send posts a notification to the webhook URL
+func (w *WebhookNotifier) Send(ctx context.Context, n Notification) error {
+	var payload []byte
+	if w.body != nil {
+		body, err := render(w.body, n)
+		if err != nil {
+			return err
+		}
+		payload = []byte(body)
+	} else {
+		encoded, err := json.Marshal(n)
+		if err != nil {
+			return &permanentError{fmt.Errorf("failed to encode notification: %w", err)}
+		}
+		payload = encoded
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.channel.URL, bytes.NewReader(payload))
+	if err != nil {
+		return &permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "MONIK-Notifier")
+	req.Header.Set(WebhookEventHeader, n.Event)
+	if w.channel.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(WebhookTimestampHeader, timestamp)
+		req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhook(w.channel.Secret, timestamp, payload))
+	}
+
+	return doNotifyRequest(w.client, req)
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>" receivers compare
+// against the X-Monik-Signature header
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// TelegramNotifier sends notifications through the Telegram Bot API sendMessage method
+type TelegramNotifier struct {
+	channel models.NotificationChannel
+	body    *template.Template
+	client  *http.Client
+}
+
+// Send posts a notification to the chat of the channel
+func (t *TelegramNotifier) Send(ctx context.Context, n Notification) error {
+	text, err := render(t.body, n)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"chat_id":                  t.channel.TelegramChatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return &permanentError{err}
+	}
+
+	base := t.channel.URL
+	if base == "" {
+		base = DefaultTelegramAPIURL
+	}
+	endpoint := strings.TrimRight(base, "/") + "/bot" + t.channel.TelegramToken + "/sendMessage"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		// The URL holds the bot token, keep it out of logs and delivery records
+		return &permanentError{errors.New("invalid telegram api url")}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return doNotifyRequest(t.client, req)
+}
+
+// doNotifyRequest performs a delivery request. Client errors other than 408 and 429
+// are permanent; the Telegram Bot API describes them in a JSON "description".
+func doNotifyRequest(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			// url.Error repeats the request URL, which holds the Telegram bot token
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%s request to %s failed: %w", req.Method, req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 300 {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		return nil
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	detail := strings.TrimSpace(string(data))
+	var apiErr struct {
+		Description string `json:"description"`
+	}
+	if json.Unmarshal(data, &apiErr) == nil && apiErr.Description != "" {
+		detail = apiErr.Description
+	}
+	err = fmt.Errorf("%s responded %s: %s", req.URL.Host, resp.Status, detail)
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return &permanentError{err}
+	}
+	return err
+}
+
+func validateHTTPURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("expected an http or https URL")
+	}
+	return nil
+}
+
+func defaultSMTPPort(mode string) int {
+	switch mode {
+	case SMTPTLSImplicit:
+		return 465
+	case SMTPTLSNone:
+		return 25
+	}
+	return 587
+}
+
+// splitList splits a comma separated list, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"monik-enterprise/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// OutageFilter selects router outages
+type OutageFilter struct {
+	RouterID uint      // 0 selects every router
+	From     time.Time // Outages still running at or ending after From
+	To       time.Time // Outages started before To
+	Open     bool      // Only outages that have not ended
+	Limit    int
+}
+
+// OutageService records the periods in which routers could not be reached. One
+// outage is open per router from its first failed poll until the next successful one.
+type OutageService struct {
+	db     *gorm.DB
+	logger *LoggerService
+
+	mu   sync.Mutex
+	open map[uint]*models.RouterOutage
+}
+
+// NewOutageService creates an outage service and loads the outages left open by a
+// previous run, so they end at the first successful poll instead of being duplicated
+func NewOutageService(db *gorm.DB) *OutageService {
+	o := &OutageService{db: db, open: make(map[uint]*models.RouterOutage)}
+
+	var outages []models.RouterOutage
+	if err := db.Where("ended_at IS NULL").Order("started_at ASC").Find(&outages).Error; err != nil {
+		o.logger.Error(ComponentOutage, "load", "Failed to load open outages", err, nil)
+		return o
+	}
+	for i := range outages {
+		o.open[outages[i].RouterID] = &outages[i]
+	}
+	if len(outages) > 0 {
+		o.logger.Info(ComponentOutage, "load", "Router outages still open", map[string]interface{}{
+			"outages": len(outages),
+		})
+	}
+	return o
+}
+
+// SetLogger sets the structured logger of the outage service
+func (o *OutageService) SetLogger(logger *LoggerService) {
+	o.logger = logger
+}
+
+// RecordFailure opens an outage for the router or extends the open one. at is the
+// start of the failed poll. A nil service records nothing.
+func (o *OutageService) RecordFailure(router models.Router, err error, at time.Time, maintenance bool) {
+	if o == nil || err == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if outage, ok := o.open[router.ID]; ok {
+		outage.FailedPolls++
+		outage.Error = err.Error()
+		outage.ErrorKind = ConnectErrorKind(err)
+		if err := o.db.Model(outage).Updates(map[string]interface{}{
+			"failed_polls": outage.FailedPolls,
+			"error":        outage.Error,
+			"error_kind":   outage.ErrorKind,
+		}).Error; err != nil {
+			o.logger.Error(ComponentOutage, "record", "Failed to update outage", err, map[string]interface{}{
+				"router": router.Name,
+			})
+		}
+		return
+	}
+
+	outage := &models.RouterOutage{
+		RouterID:    router.ID,
+		StartedAt:   at,
+		FailedPolls: 1,
+		Error:       err.Error(),
+		ErrorKind:   ConnectErrorKind(err),
+		Maintenance: maintenance,
+	}
+	if err := o.db.Create(outage).Error; err != nil {
+		o.logger.Error(ComponentOutage, "record", "Failed to record outage", err, map[string]interface{}{
+			"router": router.Name,
+		})
+		return
+	}
+	o.open[router.ID] = outage
+	o.logger.Warn(ComponentOutage, "record", "Router outage started", map[string]interface{}{
+		"router":     router.Name,
+		"started_at": at.Format(time.RFC3339),
+		"error":      outage.Error,
+	})
+}
+
+// RecordSuccess ends the open outage of the router, if any. A nil service records nothing.
+func (o *OutageService) RecordSuccess(router models.Router, at time.Time) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	outage, ok := o.open[router.ID]
+	if !ok {
+		return
+	}
+	delete(o.open, router.ID)
+	outage.EndedAt = &at
+	if err := o.db.Model(outage).Update("ended_at", at).Error; err != nil {
+		o.logger.Error(ComponentOutage, "record", "Failed to close outage", err, map[string]interface{}{
+			"router": router.Name,
+		})
+	}
+	o.logger.Info(ComponentOutage, "record", "Router back online", map[string]interface{}{
+		"router":       router.Name,
+		"offline":      at.Sub(outage.StartedAt).Round(time.Second).String(),
+		"failed_polls": outage.FailedPolls,
+	})
+}
+
+// Current returns the open outage of a router, nil when it is reachable
+func (o *OutageService) Current(routerID uint) *models.RouterOutage {
+	if o == nil {
+		return nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	outage, ok := o.open[routerID]
+	if !ok {
+		return nil
+	}
+	current := *outage
+	return &current
+}
+
+// List returns outages matching the filter, newest first
+func (o *OutageService) List(filter OutageFilter) ([]models.RouterOutage, error) {
+	query := o.db.Order("started_at DESC, id DESC")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.RouterID != 0 {
+		query = query.Where("router_id = ?", filter.RouterID)
+	}
+	if filter.Open {
+		query = query.Where("ended_at IS NULL")
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("(ended_at IS NULL OR ended_at > ?)", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("started_at < ?", filter.To)
+	}
+
+	var outages []models.RouterOutage
+	err := query.Find(&outages).Error
+	return outages, err
+}
+
+// Get returns an outage by ID
+func (o *OutageService) Get(id uint) (*models.RouterOutage, error) {
+	var outage models.RouterOutage
+	if err := o.db.First(&outage, id).Error; err != nil {
+		return nil, err
+	}
+	return &outage, nil
+}
+
+// OutageDuration returns how long an outage lasted within [from, to), counting an open
+// outage up to now
+func OutageDuration(outage models.RouterOutage, from, to time.Time) time.Duration {
+	start, end := outage.StartedAt, time.Now()
+	if outage.EndedAt != nil {
+		end = *outage.EndedAt
+	}
+	if !from.IsZero() && start.Before(from) {
+		start = from
+	}
+	if !to.IsZero() && end.After(to) {
+		end = to
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"monik-enterprise/internal/config"
+	"monik-enterprise/internal/models"
+)
+
+func TestOutageDuration(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 7, d, 0, 0, 0, 0, time.UTC) }
+	at := func(d, h, m int) *time.Time {
+		v := time.Date(2026, 7, d, h, m, 0, 0, time.UTC)
+		return &v
+	}
+	overnight := models.RouterOutage{StartedAt: *at(14, 23, 30), EndedAt: at(15, 0, 45)}
+
+	tests := []struct {
+		name     string
+		outage   models.RouterOutage
+		from, to time.Time
+		want     time.Duration
+	}{
+		{name: "whole outage", outage: overnight, want: 75 * time.Minute},
+		{name: "day before midnight", outage: overnight, from: day(14), to: day(15), want: 30 * time.Minute},
+		{name: "day after midnight", outage: overnight, from: day(15), to: day(16), want: 45 * time.Minute},
+		{name: "day without the outage", outage: overnight, from: day(16), to: day(17), want: 0},
+		{name: "open window start", outage: overnight, to: day(15), want: 30 * time.Minute},
+		{
+			name:   "outage over several days",
+			outage: models.RouterOutage{StartedAt: *at(13, 22, 0), EndedAt: at(15, 1, 0)},
+			from:   day(14), to: day(15),
+			want: 24 * time.Hour,
+		},
+		{
+			name:   "open outage is counted up to now",
+			outage: models.RouterOutage{StartedAt: time.Now().Add(-2 * time.Hour)},
+			from:   time.Now().Add(-time.Hour),
+			want:   time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := OutageDuration(tt.outage, tt.from, tt.to)
+			if diff := got - tt.want; diff < -time.Second || diff > time.Second {
+				t.Errorf("OutageDuration = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutageServiceAcrossMidnight(t *testing.T) {
+	db := openTestDB(t, &models.RouterOutage{})
+	router := models.Router{ID: 1, Name: "core"}
+	start := time.Date(2026, 7, 14, 23, 30, 0, 0, time.UTC)
+	back := time.Date(2026, 7, 15, 0, 45, 0, 0, time.UTC)
+
+	outages := NewOutageService(db)
+	outages.RecordFailure(router, &RouterConnectError{Kind: ConnErrorNetwork, Err: errors.New("i/o timeout")}, start, false)
+	outages.RecordFailure(router, errors.New("connection refused"), start.Add(10*time.Minute), false)
+
+	// A restart picks the open outage up instead of opening a second one
+	outages = NewOutageService(db)
+	if current := outages.Current(router.ID); current == nil || !current.StartedAt.Equal(start) {
+		t.Fatalf("open outage after restart = %+v, want one started at %s", current, start)
+	}
+	outages.RecordSuccess(router, back)
+	if current := outages.Current(router.ID); current != nil {
+		t.Fatalf("outage still open after a successful poll: %+v", current)
+	}
+
+	for _, window := range []struct {
+		from, to time.Time
+		want     time.Duration
+	}{
+		{time.Date(2026, 7, 14, 0, 0, 0, 0, time.UTC), time.Date(2026, 7, 15, 0, 0, 0, 0, time.UTC), 30 * time.Minute},
+		{time.Date(2026, 7, 15, 0, 0, 0, 0, time.UTC), time.Date(2026, 7, 16, 0, 0, 0, 0, time.UTC), 45 * time.Minute},
+	} {
+		list, err := outages.List(OutageFilter{RouterID: router.ID, From: window.from, To: window.to})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 1 {
+			t.Fatalf("%s: found %d outages, want 1", window.from.Format("2006-01-02"), len(list))
+		}
+		if list[0].FailedPolls != 2 || list[0].EndedAt == nil || !list[0].EndedAt.Equal(back) {
+			t.Errorf("outage = %+v, want 2 failed polls ended at %s", list[0], back)
+		}
+		if got := OutageDuration(list[0], window.from, window.to); got != window.want {
+			t.Errorf("%s: offline %v, want %v", window.from.Format("2006-01-02"), got, window.want)
+		}
+	}
+}
+
+func TestUsageAcrossMidnightOutage(t *testing.T) {
+	lastReading := time.Date(2026, 7, 14, 23, 30, 0, 0, time.UTC) // last poll before the outage
+	back := time.Date(2026, 7, 15, 0, 45, 0, 0, time.UTC)         // first poll after it
+	boot := time.Date(2026, 7, 15, 0, 15, 0, 0, time.UTC)         // router rebooted while offline
+	high32 := uint64(math.MaxUint32 - 2999)
+
+	tests := []struct {
+		name      string
+		lastRx    uint64 // counter stored with the last reading
+		iface     InterfaceData
+		kind      string
+		since     time.Time
+		wantDay14 uint64 // rx added to July 14 on top of its 1000 bytes
+		wantDay15 uint64
+	}{
+		{
+			name:      "counters kept running",
+			lastRx:    10000,
+			iface:     InterfaceData{Name: "ether1", RxBytes: 17500},
+			since:     lastReading,
+			wantDay14: 3000, // 30 of 75 minutes
+			wantDay15: 4500,
+		},
+		{
+			name:      "32-bit counter wrapped while offline",
+			lastRx:    high32,
+			iface:     InterfaceData{Name: "ether1", RxBytes: 4500, Counter32: true},
+			kind:      ResetKindWrap,
+			since:     lastReading,
+			wantDay14: 3000,
+			wantDay15: 4500,
+		},
+		{
+			name:      "router rebooted after midnight",
+			lastRx:    10000,
+			iface:     InterfaceData{Name: "ether1", RxBytes: 7500},
+			kind:      ResetKindReboot,
+			since:     boot,
+			wantDay14: 0,
+			wantDay15: 7500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t, &models.MonthlyQuota{}, &models.BillingCycle{})
+			s := &MonitoringService{
+				db:      db,
+				billing: NewBillingService(db, config.BillingConfig{Timezone: "UTC"}),
+				quotas:  &QuotaService{limits: make(map[string]*models.QuotaLimit)},
+			}
+			if err := db.Create(&models.MonthlyQuota{
+				RouterID: 1, InterfaceName: "ether1", Day: 14, Month: 7, Year: 2026,
+				RxBytes: 1000, TotalBytes: 1000, TotalRx: 1000, LastRxBytes: tt.lastRx,
+			}).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			if err := s.updateMonthlyQuota(context.Background(), 1, tt.iface, tt.kind, tt.since, back); err != nil {
+				t.Fatalf("updateMonthlyQuota: %v", err)
+			}
+
+			var rows []models.MonthlyQuota
+			if err := db.Order("day").Find(&rows).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != 2 {
+				t.Fatalf("got %d daily rows, want July 14 and 15", len(rows))
+			}
+			if got := rows[0].RxBytes - 1000; got != tt.wantDay14 {
+				t.Errorf("July 14 gained %d bytes, want %d", got, tt.wantDay14)
+			}
+			if got := rows[1].RxBytes; got != tt.wantDay15 {
+				t.Errorf("July 15 has %d bytes, want %d", got, tt.wantDay15)
+			}
+			// The newest row carries the counter the next reading is compared with
+			if last := rows[len(rows)-1]; last.LastRxBytes != tt.iface.RxBytes {
+				t.Errorf("July %d tracks counter %d, want %d", last.Day, last.LastRxBytes, tt.iface.RxBytes)
+			}
+		})
+	}
+}
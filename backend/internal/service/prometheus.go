@@ -0,0 +1,373 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"monik-enterprise/internal/models"
+	"monik-enterprise/internal/websocket"
+
+	"gorm.io/gorm"
+)
+
+// PrometheusContentType is the content type of the text exposition format
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// PrometheusExporter renders interface traffic, quota usage, router reachability and
+// internal service metrics in the Prometheus text exposition format. Every scrape reads
+// the current state, nothing is buffered between scrapes. Any dependency may be nil,
+// its metrics are then left out.
+type PrometheusExporter struct {
+	db         *gorm.DB
+	registry   *RouterRegistry
+	monitoring *MonitoringService
+	quotas     *QuotaService
+	workerPool *WorkerPool
+	wsManager  *websocket.WebSocketManager
+	wanService *WANDetectionService
+}
+
+// NewPrometheusExporter creates a Prometheus exporter
+func NewPrometheusExporter(db *gorm.DB, registry *RouterRegistry, monitoring *MonitoringService, quotas *QuotaService, workerPool *WorkerPool, wsManager *websocket.WebSocketManager, wanService *WANDetectionService) *PrometheusExporter {
+	return &PrometheusExporter{
+		db:         db,
+		registry:   registry,
+		monitoring: monitoring,
+		quotas:     quotas,
+		workerPool: workerPool,
+		wsManager:  wsManager,
+		wanService: wanService,
+	}
+}
+
+// WriteMetrics writes every metric family to w
+func (e *PrometheusExporter) WriteMetrics(w io.Writer) error {
+	pw := &promWriter{w: bufio.NewWriter(w)}
+
+	routerNames := e.routerNames()
+	if err := e.writeInterfaces(pw, routerNames); err != nil {
+		return err
+	}
+	if err := e.writeQuotas(pw, routerNames); err != nil {
+		return err
+	}
+	e.writeRouters(pw, routerNames)
+	e.writeWorkerPool(pw)
+	e.writeWebSocket(pw)
+	e.writeWAN(pw)
+
+	return pw.w.Flush()
+}
+
+// routerNames maps router IDs to their names for the router label
+func (e *PrometheusExporter) routerNames() map[uint]string {
+	names := make(map[uint]string)
+	if e.registry == nil {
+		return names
+	}
+	routers, err := e.registry.List()
+	if err != nil {
+		return names
+	}
+	for _, router := range routers {
+		names[router.ID] = router.Name
+	}
+	return names
+}
+
+// routerLabels returns the labels identifying a router
+func routerLabels(routerID uint, names map[uint]string) []string {
+	return []string{"router_id", strconv.FormatUint(uint64(routerID), 10), "router", names[routerID]}
+}
+
+// interfaceLabels returns the labels identifying an interface
+func interfaceLabels(routerID uint, name string, names map[uint]string) []string {
+	return append(routerLabels(routerID, names), "interface", name)
+}
+
+// writeInterfaces writes the latest counters, rates and status of every interface
+func (e *PrometheusExporter) writeInterfaces(pw *promWriter, names map[uint]string) error {
+	if e.db == nil {
+		return nil
+	}
+	var interfaces []models.Interface
+	if err := e.db.Order("router_id ASC, interface_name ASC").Find(&interfaces).Error; err != nil {
+		return fmt.Errorf("failed to load interfaces: %w", err)
+	}
+
+	families := []struct {
+		name, kind, help string
+		value            func(models.Interface) float64
+	}{
+		{"monik_interface_receive_bytes_total", "counter", "Bytes received by the interface as reported by the router",
+			func(i models.Interface) float64 { return float64(i.RxBytes) }},
+		{"monik_interface_transmit_bytes_total", "counter", "Bytes transmitted by the interface as reported by the router",
+			func(i models.Interface) float64 { return float64(i.TxBytes) }},
+		{"monik_interface_receive_bits_per_second", "gauge", "Receive rate measured over the last poll",
+			func(i models.Interface) float64 { return i.RxRate * 1e6 }},
+		{"monik_interface_transmit_bits_per_second", "gauge", "Transmit rate measured over the last poll",
+			func(i models.Interface) float64 { return i.TxRate * 1e6 }},
+		{"monik_interface_up", "gauge", "Whether the interface is running (1) or not (0)",
+			func(i models.Interface) float64 { return boolValue(interfaceRunning(i.Status)) }},
+		{"monik_interface_counter_resets_total", "counter", "Times the router's interface counters were seen to reset",
+			func(i models.Interface) float64 { return float64(i.CounterResetCount) }},
+		{"monik_interface_last_seen_timestamp_seconds", "gauge", "Unix time the interface was last read from the router",
+			func(i models.Interface) float64 { return unixSeconds(i.LastSeen) }},
+	}
+	for _, family := range families {
+		pw.family(family.name, family.kind, family.help)
+		for _, iface := range interfaces {
+			pw.sample(family.name, family.value(iface), interfaceLabels(iface.RouterID, iface.InterfaceName, names)...)
+		}
+	}
+	return nil
+}
+
+// interfaceRunning reports whether a stored interface status means the link is up
+func interfaceRunning(status string) bool {
+	switch strings.ToLower(status) {
+	case "true", "up", "running":
+		return true
+	}
+	return false
+}
+
+// writeQuotas writes the usage of every quota limit in its current billing cycle
+func (e *PrometheusExporter) writeQuotas(pw *promWriter, names map[uint]string) error {
+	if e.quotas == nil {
+		return nil
+	}
+	statuses, err := e.quotas.ListStatus(0, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to load quota status: %w", err)
+	}
+
+	families := []struct {
+		name, help string
+		value      func(QuotaStatus) float64
+	}{
+		{"monik_quota_used_bytes", "Bytes used in the current billing cycle",
+			func(s QuotaStatus) float64 { return float64(s.UsedBytes) }},
+		{"monik_quota_limit_bytes", "Quota limit for the billing cycle",
+			func(s QuotaStatus) float64 { return float64(s.LimitBytes) }},
+		{"monik_quota_used_ratio", "Share of the quota used, 1 is the full limit",
+			func(s QuotaStatus) float64 { return s.UsedPct / 100 }},
+		{"monik_quota_exceeded", "Whether the quota is exceeded (1) or not (0)",
+			func(s QuotaStatus) float64 { return boolValue(s.Exceeded) }},
+		{"monik_quota_enforced", "Whether enforcement is applied on the router (1) or not (0)",
+			func(s QuotaStatus) float64 { return boolValue(s.Enforced) }},
+	}
+	for _, family := range families {
+		pw.family(family.name, "gauge", family.help)
+		for _, status := range statuses {
+			pw.sample(family.name, family.value(status), interfaceLabels(status.RouterID, status.InterfaceName, names)...)
+		}
+	}
+	return nil
+}
+
+// writeRouters writes the reachability and polling state of every router
+func (e *PrometheusExporter) writeRouters(pw *promWriter, names map[uint]string) {
+	if e.monitoring == nil {
+		return
+	}
+	states := e.monitoring.GetRouterStates()
+	ids := make([]uint, 0, len(states))
+	for id := range states {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	families := []struct {
+		name, kind, help string
+		value            func(RouterPollState) float64
+	}{
+		{"monik_router_up", "gauge", "Whether the last poll of the router succeeded (1) or not (0)",
+			func(s RouterPollState) float64 { return boolValue(s.Online) }},
+		{"monik_router_consecutive_failures", "gauge", "Polls failed in a row",
+			func(s RouterPollState) float64 { return float64(s.ConsecutiveFailures) }},
+		{"monik_router_last_success_timestamp_seconds", "gauge", "Unix time of the last successful poll",
+			func(s RouterPollState) float64 { return unixSeconds(s.LastSuccess) }},
+		{"monik_router_poll_interval_seconds", "gauge", "Current poll interval, grows while the router is unreachable",
+			func(s RouterPollState) float64 { return s.Interval.Seconds() }},
+		{"monik_router_skipped_polls_total", "counter", "Polls skipped because the previous one was still running",
+			func(s RouterPollState) float64 { return float64(s.SkippedPolls) }},
+		{"monik_router_streaming", "gauge", "Whether the router streams traffic (1) or is polled (0)",
+			func(s RouterPollState) float64 { return boolValue(s.Streaming) }},
+	}
+	for _, family := range families {
+		pw.family(family.name, family.kind, family.help)
+		for _, id := range ids {
+			pw.sample(family.name, family.value(states[id]), routerLabels(id, names)...)
+		}
+	}
+}
+
+// writeWorkerPool writes the worker pool job counters and queue state
+func (e *PrometheusExporter) writeWorkerPool(pw *promWriter) {
+	if e.workerPool == nil {
+		return
+	}
+	metrics := e.workerPool.GetMetrics()
+
+	pw.family("monik_worker_jobs_submitted_total", "counter", "Jobs processed by the worker pool")
+	pw.sample("monik_worker_jobs_submitted_total", float64(metrics.TotalJobs))
+	pw.family("monik_worker_jobs_total", "counter", "Jobs finished by the worker pool by result")
+	pw.sample("monik_worker_jobs_total", float64(metrics.SuccessJobs), "result", "success")
+	pw.sample("monik_worker_jobs_total", float64(metrics.FailedJobs), "result", "failed")
+	pw.family("monik_worker_active_jobs", "gauge", "Jobs currently running")
+	pw.sample("monik_worker_active_jobs", float64(metrics.ActiveJobs))
+	pw.family("monik_worker_average_response_seconds", "gauge", "Average job duration")
+	pw.sample("monik_worker_average_response_seconds", metrics.AvgResponse.Seconds())
+	pw.family("monik_worker_workers", "gauge", "Workers in the pool")
+	pw.sample("monik_worker_workers", float64(e.workerPool.GetWorkerCount()))
+	pw.family("monik_worker_queue_length", "gauge", "Jobs waiting in the queue")
+	pw.sample("monik_worker_queue_length", float64(e.workerPool.GetQueueSize()))
+	pw.family("monik_worker_queue_capacity", "gauge", "Capacity of the job queue")
+	pw.sample("monik_worker_queue_capacity", float64(e.workerPool.GetQueueCapacity()))
+	pw.family("monik_worker_circuit_breaker_open", "gauge", "Whether the circuit breaker rejects jobs (1) or not (0)")
+	pw.sample("monik_worker_circuit_breaker_open", boolValue(e.workerPool.GetCircuitState() == CircuitOpen))
+}
+
+// writeWebSocket writes the WebSocket client count and message counters
+func (e *PrometheusExporter) writeWebSocket(pw *promWriter) {
+	if e.wsManager == nil {
+		return
+	}
+	snapshot := e.wsManager.GetMetrics().Snapshot()
+
+	pw.family("monik_websocket_clients", "gauge", "Connected WebSocket clients")
+	pw.sample("monik_websocket_clients", float64(e.wsManager.GetClientCount()))
+
+	counters := []struct {
+		name, help string
+		value      int64
+	}{
+		{"monik_websocket_connections_total", "WebSocket connections accepted", snapshot.ConnectionsTotal},
+		{"monik_websocket_disconnections_total", "WebSocket connections closed", snapshot.DisconnectionsTotal},
+		{"monik_websocket_messages_sent_total", "Interface updates sent to clients", snapshot.MessagesSent},
+		{"monik_websocket_messages_dropped_total", "Interface updates dropped because a client was too slow", snapshot.MessagesDropped},
+		{"monik_websocket_broadcasts_total", "Broadcast messages sent to clients", snapshot.BroadcastsSent},
+		{"monik_websocket_broadcasts_dropped_total", "Broadcast messages dropped because a client was too slow", snapshot.BroadcastsDropped},
+		{"monik_websocket_events_total", "Events sent to clients", snapshot.EventsSent},
+		{"monik_websocket_events_dropped_total", "Events dropped because a client was too slow", snapshot.EventsDropped},
+	}
+	for _, counter := range counters {
+		pw.family(counter.name, "counter", counter.help)
+		pw.sample(counter.name, float64(counter.value))
+	}
+}
+
+// writeWAN writes the WAN detection counters and the detected interface
+func (e *PrometheusExporter) writeWAN(pw *promWriter) {
+	if e.wanService == nil {
+		return
+	}
+	if metrics := e.wanService.GetDetectionStats(); metrics != nil {
+		stats := metrics.Snapshot()
+		methods := make([]string, 0, len(stats.MethodCounts))
+		for method := range stats.MethodCounts {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+
+		pw.family("monik_wan_detections_total", "counter", "WAN interface detections by the method that found it")
+		for _, method := range methods {
+			pw.sample("monik_wan_detections_total", float64(stats.MethodCounts[method]), "method", method)
+		}
+		pw.family("monik_wan_detection_attempts_total", "counter", "WAN interface detection attempts")
+		pw.sample("monik_wan_detection_attempts_total", float64(stats.TotalDetections))
+		pw.family("monik_wan_detection_failures_total", "counter", "WAN interface detections that found nothing")
+		pw.sample("monik_wan_detection_failures_total", float64(stats.Failures))
+		pw.family("monik_wan_cache_hits_total", "counter", "WAN interface lookups answered from the cache")
+		pw.sample("monik_wan_cache_hits_total", float64(stats.CacheHits))
+	}
+
+	if wan := e.wanService.GetCachedWANInterface(); wan != nil {
+		pw.family("monik_wan_interface_info", "gauge", "The detected WAN interface, always 1")
+		pw.sample("monik_wan_interface_info", 1, "interface", wan.Name, "isp", wan.ISPName, "method", wan.Method)
+		pw.family("monik_wan_detection_confidence", "gauge", "Confidence of the WAN interface detection, 0 to 1")
+		pw.sample("monik_wan_detection_confidence", wan.Confidence, "interface", wan.Name)
+	}
+}
+
+// boolValue converts a flag to a sample value
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// unixSeconds converts a timestamp to a sample value, the zero time is 0
+func unixSeconds(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return float64(t.UnixNano()) / 1e9
+}
+
+// promWriter writes metric families in the text exposition format
+type promWriter struct {
+	w *bufio.Writer
+}
+
+// family writes the HELP and TYPE lines of a metric family
+func (pw *promWriter) family(name, kind, help string) {
+	fmt.Fprintf(pw.w, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, kind)
+}
+
+// sample writes one sample, labels are name/value pairs
+func (pw *promWriter) sample(name string, value float64, labels ...string) {
+	pw.w.WriteString(name)
+	if len(labels) > 0 {
+		pw.w.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				pw.w.WriteByte(',')
+			}
+			pw.w.WriteString(labels[i])
+			pw.w.WriteString(`="`)
+			pw.w.WriteString(escapeLabelValue(labels[i+1]))
+			pw.w.WriteByte('"')
+		}
+		pw.w.WriteByte('}')
+	}
+	pw.w.WriteByte(' ')
+	pw.w.WriteString(formatSampleValue(value))
+	pw.w.WriteByte('\n')
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// escapeHelp escapes backslashes and newlines in HELP text
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+// escapeLabelValue escapes backslashes, newlines and quotes in a label value
+func escapeLabelValue(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+// formatSampleValue formats a value the way Prometheus parses it
+func formatSampleValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,725 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"monik-enterprise/internal/config"
+	"monik-enterprise/internal/models"
+	"monik-enterprise/internal/websocket"
+
+	"gorm.io/gorm"
+)
+
+// Quota enforcement actions
+const (
+	QuotaActionNone    = "none"
+	QuotaActionQueue   = "queue"   // push a RouterOS simple queue limiting the interface
+	QuotaActionDisable = "disable" // disable the interface
+)
+
+// Quota audit event types
+const (
+	QuotaEventLimitSet          = "limit_set"
+	QuotaEventLimitRemoved      = "limit_removed"
+	QuotaEventThreshold         = "threshold"
+	QuotaEventEnforced          = "enforced"
+	QuotaEventEnforcementFailed = "enforcement_failed"
+	QuotaEventLifted            = "lifted"
+	QuotaEventLiftFailed        = "lift_failed"
+)
+
+// quotaActionTimeout bounds a single enforcement or lift on a router
+const quotaActionTimeout = 20 * time.Second
+
+// QuotaService evaluates quota limits against billing cycle usage, reports threshold
+// crossings and applies or lifts enforcement on routers. Polls only queue an
+// evaluation; a single worker does the database and router work.
+type QuotaService struct {
+	db         *gorm.DB
+	config     config.QuotaConfig
+	thresholds []int
+	billing    *BillingService
+	registry   *RouterRegistry
+	wsManager  *websocket.WebSocketManager
+	notifier   *NotificationService
+	logger     *LoggerService
+
+	mu       sync.RWMutex
+	limits   map[string]*models.QuotaLimit // router/interface -> limit
+	pending  map[string]bool               // evaluations queued by polls
+	failures map[string]string             // last failure per interface, avoids repeating audit rows
+	evalMu   sync.Mutex                    // serializes evaluation and limit changes
+
+	wake chan struct{}
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// QuotaStatus is a quota limit together with the usage of its current cycle
+type QuotaStatus struct {
+	models.QuotaLimit
+	CycleEnd   time.Time `json:"cycle_end"`
+	UsedBytes  uint64    `json:"used_bytes"`
+	UsedPct    float64   `json:"used_percent"`
+	Exceeded   bool      `json:"exceeded"`
+	Thresholds []int     `json:"threshold_list"`
+}
+
+// NewQuotaService creates a quota service and loads the configured limits
+func NewQuotaService(db *gorm.DB, cfg config.QuotaConfig, billing *BillingService, registry *RouterRegistry, wsManager *websocket.WebSocketManager, notifier *NotificationService) *QuotaService {
+	if cfg.CheckInterval < time.Second {
+		cfg.CheckInterval = time.Minute
+	}
+	if cfg.QueueMaxLimit == "" {
+		cfg.QueueMaxLimit = "1M/1M"
+	}
+
+	thresholds, err := parseThresholds(strings.Join(cfg.Thresholds, ","))
+	invalidThresholds := err != nil || len(thresholds) == 0
+	if invalidThresholds {
+		thresholds = []int{50, 80, 100}
+	}
+
+	q := &QuotaService{
+		db:         db,
+		config:     cfg,
+		thresholds: thresholds,
+		billing:    billing,
+		registry:   registry,
+		wsManager:  wsManager,
+		notifier:   notifier,
+		limits:     make(map[string]*models.QuotaLimit),
+		pending:    make(map[string]bool),
+		failures:   make(map[string]string),
+		wake:       make(chan struct{}, 1),
+		quit:       make(chan struct{}),
+	}
+
+	// Logged before SetLogger can be called, so these go to the fallback logger
+	if invalidThresholds {
+		q.logger.Warn(ComponentQuota, "config", "Invalid QUOTA_THRESHOLDS, using 50,80,100", map[string]interface{}{
+			"thresholds": strings.Join(cfg.Thresholds, ","),
+		})
+	}
+
+	var limits []models.QuotaLimit
+	if err := db.Find(&limits).Error; err != nil {
+		q.logger.Error(ComponentQuota, "load", "Failed to load quota limits", err, nil)
+	}
+	for i := range limits {
+		q.limits[quotaKey(limits[i].RouterID, limits[i].InterfaceName)] = &limits[i]
+	}
+	return q
+}
+
+// SetLogger sets the structured logger of the quota service
+func (q *QuotaService) SetLogger(logger *LoggerService) {
+	q.logger = logger
+}
+
+// Start runs the evaluation worker
+func (q *QuotaService) Start() {
+	q.wg.Add(1)
+	go q.loop()
+	q.logger.Info(ComponentQuota, "start", "Quota evaluation started", map[string]interface{}{
+		"limits":      len(q.limits),
+		"interval":    q.config.CheckInterval.String(),
+		"enforcement": q.config.Enforcement,
+	})
+}
+
+// Stop stops the evaluation worker
+func (q *QuotaService) Stop() {
+	close(q.quit)
+	q.wg.Wait()
+}
+
+func (q *QuotaService) loop() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(q.config.CheckInterval)
+	defer ticker.Stop()
+
+	// Lift enforcement of cycles that ended while the service was down
+	q.evaluateAll()
+	for {
+		select {
+		case <-q.quit:
+			return
+		case <-q.wake:
+			q.mu.Lock()
+			keys := make([]string, 0, len(q.pending))
+			for key := range q.pending {
+				keys = append(keys, key)
+			}
+			q.pending = make(map[string]bool)
+			q.mu.Unlock()
+			for _, key := range keys {
+				q.evaluate(key, time.Now())
+			}
+		case <-ticker.C:
+			// Catches cycle rollovers of interfaces that are no longer polled and
+			// retries failed enforcement
+			q.evaluateAll()
+		}
+	}
+}
+
+func (q *QuotaService) evaluateAll() {
+	q.mu.RLock()
+	keys := make([]string, 0, len(q.limits))
+	for key := range q.limits {
+		keys = append(keys, key)
+	}
+	q.mu.RUnlock()
+	sort.Strings(keys)
+	for _, key := range keys {
+		q.evaluate(key, time.Now())
+	}
+}
+
+// Evaluate queues an evaluation of the limit of an interface after its usage changed.
+// It never blocks the caller.
+func (q *QuotaService) Evaluate(routerID uint, interfaceName string) {
+	key := quotaKey(routerID, interfaceName)
+	q.mu.Lock()
+	if _, ok := q.limits[key]; !ok {
+		q.mu.Unlock()
+		return
+	}
+	q.pending[key] = true
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// LimitBytes returns the limit of an interface, 0 when it has none
+func (q *QuotaService) LimitBytes(routerID uint, interfaceName string) uint64 {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if limit, ok := q.limits[quotaKey(routerID, interfaceName)]; ok && limit.Enabled {
+		return limit.LimitBytes
+	}
+	return 0
+}
+
+// Limit returns the limit of an interface, nil when it has none
+func (q *QuotaService) Limit(routerID uint, interfaceName string) *models.QuotaLimit {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if limit, ok := q.limits[quotaKey(routerID, interfaceName)]; ok {
+		copied := *limit
+		return &copied
+	}
+	return nil
+}
+
+// NormalizeQuotaLimit fills unset fields of a limit and validates it
+func NormalizeQuotaLimit(limit *models.QuotaLimit) error {
+	if limit.LimitBytes == 0 {
+		return errors.New("limit_bytes must be greater than 0")
+	}
+	if limit.Action == "" {
+		limit.Action = QuotaActionNone
+	}
+	switch limit.Action {
+	case QuotaActionNone, QuotaActionQueue, QuotaActionDisable:
+	default:
+		return errors.New("invalid action, expected none, queue or disable")
+	}
+	if limit.Thresholds != "" {
+		thresholds, err := parseThresholds(limit.Thresholds)
+		if err != nil {
+			return err
+		}
+		limit.Thresholds = formatThresholds(thresholds)
+	}
+	return nil
+}
+
+// SetLimit creates or replaces the limit of an interface and evaluates it right away
+func (q *QuotaService) SetLimit(limit models.QuotaLimit) (*QuotaStatus, error) {
+	if err := NormalizeQuotaLimit(&limit); err != nil {
+		return nil, err
+	}
+
+	q.evalMu.Lock()
+	key := quotaKey(limit.RouterID, limit.InterfaceName)
+	q.mu.RLock()
+	existing, ok := q.limits[key]
+	q.mu.RUnlock()
+
+	saved := limit
+	if ok {
+		// Keep the state of the running cycle; a new limit re-arms the thresholds
+		saved = *existing
+		if saved.LimitBytes != limit.LimitBytes || saved.Thresholds != limit.Thresholds {
+			saved.NotifiedThreshold = 0
+		}
+		saved.LimitBytes = limit.LimitBytes
+		saved.Thresholds = limit.Thresholds
+		saved.Action = limit.Action
+		saved.QueueMaxLimit = limit.QueueMaxLimit
+		saved.Enabled = limit.Enabled
+	}
+	if err := q.db.Save(&saved).Error; err != nil {
+		q.evalMu.Unlock()
+		return nil, err
+	}
+	q.mu.Lock()
+	q.limits[key] = &saved
+	delete(q.failures, key)
+	q.mu.Unlock()
+
+	q.recordEvent(saved, QuotaEventLimitSet, 0, 0, fmt.Sprintf("limit=%d thresholds=%s enabled=%v",
+		saved.LimitBytes, formatThresholds(q.limitThresholds(saved)), saved.Enabled))
+	q.evalMu.Unlock()
+
+	q.evaluate(key, time.Now())
+	return q.Status(limit.RouterID, limit.InterfaceName, time.Now())
+}
+
+// DeleteLimit removes the limit of an interface, lifting its enforcement first
+func (q *QuotaService) DeleteLimit(routerID uint, interfaceName string) error {
+	q.evalMu.Lock()
+	defer q.evalMu.Unlock()
+
+	key := quotaKey(routerID, interfaceName)
+	q.mu.RLock()
+	existing, ok := q.limits[key]
+	q.mu.RUnlock()
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+
+	limit := *existing
+	if limit.Enforced {
+		if err := q.lift(&limit, "limit removed"); err != nil {
+			return fmt.Errorf("failed to lift enforcement: %w", err)
+		}
+	}
+	if err := q.db.Delete(&models.QuotaLimit{}, limit.ID).Error; err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	delete(q.limits, key)
+	delete(q.pending, key)
+	delete(q.failures, key)
+	q.mu.Unlock()
+
+	q.recordEvent(limit, QuotaEventLimitRemoved, 0, 0, "")
+	return nil
+}
+
+// Status returns the limit of an interface with the usage of its current cycle
+func (q *QuotaService) Status(routerID uint, interfaceName string, now time.Time) (*QuotaStatus, error) {
+	q.mu.RLock()
+	existing, ok := q.limits[quotaKey(routerID, interfaceName)]
+	var limit models.QuotaLimit
+	if ok {
+		limit = *existing
+	}
+	q.mu.RUnlock()
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	report, err := q.billing.Usage(routerID, interfaceName, 1, now)
+	if err != nil {
+		return nil, err
+	}
+	current := report.Cycles[0]
+	status := &QuotaStatus{
+		QuotaLimit: limit,
+		CycleEnd:   current.End,
+		UsedBytes:  current.TotalBytes,
+		Exceeded:   current.TotalBytes >= limit.LimitBytes,
+		Thresholds: q.limitThresholds(limit),
+	}
+	if limit.LimitBytes > 0 {
+		status.UsedPct = float64(current.TotalBytes) * 100 / float64(limit.LimitBytes)
+	}
+	return status, nil
+}
+
+// ListStatus returns the status of every limit, routerID 0 lists all routers
+func (q *QuotaService) ListStatus(routerID uint, now time.Time) ([]QuotaStatus, error) {
+	q.mu.RLock()
+	var keys []models.QuotaLimit
+	for _, limit := range q.limits {
+		if routerID == 0 || limit.RouterID == routerID {
+			keys = append(keys, *limit)
+		}
+	}
+	q.mu.RUnlock()
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].RouterID != keys[j].RouterID {
+			return keys[i].RouterID < keys[j].RouterID
+		}
+		return keys[i].InterfaceName < keys[j].InterfaceName
+	})
+
+	statuses := make([]QuotaStatus, 0, len(keys))
+	for _, limit := range keys {
+		status, err := q.Status(limit.RouterID, limit.InterfaceName, now)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, *status)
+	}
+	return statuses, nil
+}
+
+// Events returns the audit trail, newest first. Zero routerID or empty interfaceName
+// do not filter.
+func (q *QuotaService) Events(routerID uint, interfaceName string, limit int) ([]models.QuotaEvent, error) {
+	var events []models.QuotaEvent
+	query := q.db.Order("created_at DESC, id DESC").Limit(limit)
+	if routerID != 0 {
+		query = query.Where("router_id = ?", routerID)
+	}
+	if interfaceName != "" {
+		query = query.Where("interface_name = ?", interfaceName)
+	}
+	err := query.Find(&events).Error
+	return events, err
+}
+
+// evaluate checks one limit against the usage of its current cycle
+func (q *QuotaService) evaluate(key string, now time.Time) {
+	q.evalMu.Lock()
+	defer q.evalMu.Unlock()
+
+	q.mu.RLock()
+	existing, ok := q.limits[key]
+	var limit models.QuotaLimit
+	if ok {
+		limit = *existing
+	}
+	q.mu.RUnlock()
+	if !ok {
+		return
+	}
+	before := limit
+
+	start, _ := q.billing.CycleAt(limit.RouterID, limit.InterfaceName, now)
+	if !limit.CycleStart.Equal(start) {
+		// A new cycle lifts enforcement and re-arms the thresholds
+		if limit.Enforced {
+			if err := q.lift(&limit, "billing cycle ended"); err != nil {
+				q.save(before, limit)
+				return
+			}
+		}
+		limit.CycleStart = start
+		limit.NotifiedThreshold = 0
+	}
+
+	var used uint64
+	if limit.Enabled {
+		report, err := q.billing.Usage(limit.RouterID, limit.InterfaceName, 1, now)
+		if err != nil {
+			q.logger.Error(ComponentQuota, "evaluate", "Failed to read usage", err, map[string]interface{}{
+				"router_id": limit.RouterID,
+				"interface": limit.InterfaceName,
+			})
+			q.save(before, limit)
+			return
+		}
+		used = report.Cycles[0].TotalBytes
+
+		percent := float64(used) * 100 / float64(limit.LimitBytes)
+		for _, threshold := range q.limitThresholds(limit) {
+			if threshold > limit.NotifiedThreshold && percent >= float64(threshold) {
+				limit.NotifiedThreshold = threshold
+				q.recordEvent(limit, QuotaEventThreshold, threshold, used,
+					fmt.Sprintf("%.1f%% of %d bytes used", percent, limit.LimitBytes))
+			}
+		}
+	}
+
+	exceeded := limit.Enabled && used >= limit.LimitBytes
+	wanted := QuotaActionNone
+	if exceeded && q.config.Enforcement {
+		wanted = limit.Action
+	}
+	if limit.Enforced && limit.EnforcedAction != wanted {
+		reason := "usage below limit"
+		switch {
+		case !limit.Enabled:
+			reason = "limit disabled"
+		case !q.config.Enforcement:
+			reason = "enforcement disabled"
+		case exceeded:
+			reason = "action changed to " + limit.Action
+		}
+		if err := q.lift(&limit, reason); err != nil {
+			q.save(before, limit)
+			return
+		}
+	}
+	if !limit.Enforced && wanted != QuotaActionNone {
+		q.enforce(&limit, wanted, used)
+	}
+	q.save(before, limit)
+}
+
+// enforce applies action on the router of a limit
+func (q *QuotaService) enforce(limit *models.QuotaLimit, action string, used uint64) {
+	err := q.apply(*limit, action, true)
+	if err != nil {
+		q.recordFailure(*limit, QuotaEventEnforcementFailed, action, used, err)
+		return
+	}
+	now := time.Now()
+	limit.Enforced = true
+	limit.EnforcedAction = action
+	limit.EnforcedAt = &now
+	q.clearFailure(*limit)
+	q.recordEvent(*limit, QuotaEventEnforced, 0, used, "")
+}
+
+// lift removes the enforcement of a limit from its router
+func (q *QuotaService) lift(limit *models.QuotaLimit, reason string) error {
+	if err := q.apply(*limit, limit.EnforcedAction, false); err != nil {
+		q.recordFailure(*limit, QuotaEventLiftFailed, limit.EnforcedAction, 0, err)
+		return err
+	}
+	lifted := *limit
+	limit.Enforced = false
+	limit.EnforcedAction = ""
+	limit.EnforcedAt = nil
+	q.clearFailure(*limit)
+	q.recordEvent(lifted, QuotaEventLifted, 0, 0, reason)
+	return nil
+}
+
+// apply turns an enforcement action on or off on the router of a limit
+func (q *QuotaService) apply(limit models.QuotaLimit, action string, on bool) error {
+	router, err := q.registry.Get(limit.RouterID)
+	if err != nil {
+		return fmt.Errorf("router %d not found: %w", limit.RouterID, err)
+	}
+	collector, err := q.registry.Collector(*router)
+	if err != nil {
+		return err
+	}
+	enforcer, ok := collector.(RouterEnforcer)
+	if !ok {
+		return fmt.Errorf("backend %s of router %s cannot enforce quotas", router.Backend, router.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), quotaActionTimeout)
+	defer cancel()
+
+	switch action {
+	case QuotaActionQueue:
+		name := q.config.QueuePrefix + limit.InterfaceName
+		if !on {
+			return enforcer.RemoveSimpleQueue(ctx, name)
+		}
+		maxLimit := limit.QueueMaxLimit
+		if maxLimit == "" {
+			maxLimit = q.config.QueueMaxLimit
+		}
+		return enforcer.AddSimpleQueue(ctx, name, limit.InterfaceName, maxLimit, "MONIK quota limit exceeded")
+	case QuotaActionDisable:
+		return enforcer.SetInterfaceDisabled(ctx, limit.InterfaceName, on)
+	}
+	return nil
+}
+
+// save persists the state of a limit when it changed and the limit still exists
+func (q *QuotaService) save(before, after models.QuotaLimit) {
+	if before.CycleStart.Equal(after.CycleStart) &&
+		before.NotifiedThreshold == after.NotifiedThreshold &&
+		before.Enforced == after.Enforced &&
+		before.EnforcedAction == after.EnforcedAction {
+		return
+	}
+
+	err := q.db.Model(&models.QuotaLimit{}).Where("id = ?", after.ID).Updates(map[string]interface{}{
+		"cycle_start":        after.CycleStart,
+		"notified_threshold": after.NotifiedThreshold,
+		"enforced":           after.Enforced,
+		"enforced_action":    after.EnforcedAction,
+		"enforced_at":        after.EnforcedAt,
+	}).Error
+	if err != nil {
+		q.logger.Error(ComponentQuota, "evaluate", "Failed to save quota state", err, map[string]interface{}{
+			"router_id": after.RouterID,
+			"interface": after.InterfaceName,
+		})
+		return
+	}
+
+	q.mu.Lock()
+	key := quotaKey(after.RouterID, after.InterfaceName)
+	if current, ok := q.limits[key]; ok && current.ID == after.ID {
+		q.limits[key] = &after
+	}
+	q.mu.Unlock()
+}
+
+// recordEvent writes an audit row, logs it, broadcasts it to WebSocket clients and
+// hands it to the notification channels
+func (q *QuotaService) recordEvent(limit models.QuotaLimit, eventType string, threshold int, used uint64, detail string) {
+	action := limit.EnforcedAction
+	if action == "" {
+		action = limit.Action
+	}
+	event := models.QuotaEvent{
+		RouterID:      limit.RouterID,
+		InterfaceName: limit.InterfaceName,
+		Type:          eventType,
+		CycleStart:    limit.CycleStart,
+		Threshold:     threshold,
+		UsedBytes:     used,
+		LimitBytes:    limit.LimitBytes,
+		Action:        action,
+		Detail:        detail,
+	}
+	if err := q.db.Create(&event).Error; err != nil {
+		q.logger.Error(ComponentQuota, "event", "Failed to record quota event", err, map[string]interface{}{
+			"event":     eventType,
+			"router_id": limit.RouterID,
+			"interface": limit.InterfaceName,
+		})
+	}
+	q.logger.Info(ComponentQuota, "event", "Quota "+eventType, map[string]interface{}{
+		"router_id": limit.RouterID,
+		"interface": limit.InterfaceName,
+		"threshold": threshold,
+		"used":      used,
+		"limit":     limit.LimitBytes,
+		"detail":    detail,
+	})
+
+	if q.wsManager != nil {
+		q.wsManager.BroadcastEvent("quota_"+eventType, fmt.Sprintf("Quota %s on %s", eventType, limit.InterfaceName), map[string]interface{}{
+			"router_id":   limit.RouterID,
+			"interface":   limit.InterfaceName,
+			"threshold":   threshold,
+			"used_bytes":  used,
+			"limit_bytes": limit.LimitBytes,
+			"action":      action,
+			"detail":      detail,
+		})
+	}
+
+	title := fmt.Sprintf("Quota %s on %s", strings.ReplaceAll(eventType, "_", " "), limit.InterfaceName)
+	if eventType == QuotaEventThreshold {
+		title = fmt.Sprintf("Quota %d%% reached on %s", threshold, limit.InterfaceName)
+	}
+	message := detail
+	if message == "" {
+		message = fmt.Sprintf("%d of %d bytes used", used, limit.LimitBytes)
+	}
+	q.notifier.Notify(Notification{
+		Event:         "quota_" + eventType,
+		Severity:      quotaEventSeverity(eventType, threshold),
+		Title:         title,
+		Message:       message,
+		RouterID:      limit.RouterID,
+		InterfaceName: limit.InterfaceName,
+		Data: map[string]interface{}{
+			"threshold":   threshold,
+			"used_bytes":  used,
+			"limit_bytes": limit.LimitBytes,
+			"action":      action,
+			"cycle_start": limit.CycleStart,
+		},
+	})
+}
+
+// quotaEventSeverity maps quota events to notification severities
+func quotaEventSeverity(eventType string, threshold int) string {
+	switch eventType {
+	case QuotaEventEnforced, QuotaEventEnforcementFailed, QuotaEventLiftFailed:
+		return AlertSeverityCritical
+	case QuotaEventThreshold:
+		if threshold >= 100 {
+			return AlertSeverityCritical
+		}
+		return AlertSeverityWarning
+	}
+	return AlertSeverityInfo
+}
+
+// recordFailure audits a failed enforcement or lift once per distinct error; the
+// worker keeps retrying on every check
+func (q *QuotaService) recordFailure(limit models.QuotaLimit, eventType, action string, used uint64, err error) {
+	key := quotaKey(limit.RouterID, limit.InterfaceName)
+	detail := fmt.Sprintf("%s: %v", action, err)
+	q.mu.Lock()
+	repeated := q.failures[key] == eventType+detail
+	q.failures[key] = eventType + detail
+	q.mu.Unlock()
+	if repeated {
+		return
+	}
+	q.recordEvent(limit, eventType, 0, used, detail)
+}
+
+func (q *QuotaService) clearFailure(limit models.QuotaLimit) {
+	q.mu.Lock()
+	delete(q.failures, quotaKey(limit.RouterID, limit.InterfaceName))
+	q.mu.Unlock()
+}
+
+// limitThresholds returns the thresholds of a limit, falling back to QUOTA_THRESHOLDS
+func (q *QuotaService) limitThresholds(limit models.QuotaLimit) []int {
+	if limit.Thresholds != "" {
+		if thresholds, err := parseThresholds(limit.Thresholds); err == nil {
+			return thresholds
+		}
+	}
+	return q.thresholds
+}
+
+// parseThresholds parses comma separated percentages into a sorted list
+func parseThresholds(value string) ([]int, error) {
+	var thresholds []int
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		threshold, err := strconv.Atoi(part)
+		if err != nil || threshold < 1 || threshold > 1000 {
+			return nil, fmt.Errorf("invalid threshold %q, expected a percentage between 1 and 1000", part)
+		}
+		if !seen[threshold] {
+			seen[threshold] = true
+			thresholds = append(thresholds, threshold)
+		}
+	}
+	sort.Ints(thresholds)
+	return thresholds, nil
+}
+
+func formatThresholds(thresholds []int) string {
+	parts := make([]string, len(thresholds))
+	for i, threshold := range thresholds {
+		parts[i] = strconv.Itoa(threshold)
+	}
+	return strings.Join(parts, ",")
+}
+
+func quotaKey(routerID uint, interfaceName string) string {
+	return fmt.Sprintf("%d/%s", routerID, interfaceName)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"path"
+
+	"monik-enterprise/internal/models"
+)
+
+// User roles
+const (
+	RoleAdmin    = "admin"    // Everything, including users, routers and notification channels
+	RoleOperator = "operator" // Monitoring plus alert, maintenance, billing and quota changes
+	RoleViewer   = "viewer"   // Read-only monitoring
+	RoleCustomer = "customer" // Traffic and usage of the routers and interfaces in scope only
+)
+
+// Permissions, each guarding a group of routes
+const (
+	PermissionUsageRead   = "usage:read"       // Interfaces, traffic, usage, billing cycles, quotas, outages, WebSocket
+	PermissionMonitorRead = "monitoring:read"  // Routers, system info, alerts, maintenance, WAN and worker state
+	PermissionOperate     = "monitoring:write" // Acknowledging alerts, alert rules, maintenance, silences, billing cycles, quotas
+	PermissionAdmin       = "admin"            // Users, routers, notification channels and routes
+)
+
+var rolePermissions = map[string][]string{
+	RoleAdmin:    {PermissionUsageRead, PermissionMonitorRead, PermissionOperate, PermissionAdmin},
+	RoleOperator: {PermissionUsageRead, PermissionMonitorRead, PermissionOperate},
+	RoleViewer:   {PermissionUsageRead, PermissionMonitorRead},
+	RoleCustomer: {PermissionUsageRead},
+}
+
+// ValidRole reports whether role is a known role
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RoleAllows reports whether role grants permission
+func RoleAllows(role, permission string) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Scope limits a user to some routers and interfaces. A nil scope is unrestricted.
+// Customers are always restricted, so a customer without scope entries sees nothing;
+// operators and viewers are only restricted when they have entries, and admins never.
+type Scope struct {
+	entries []models.UserScope
+}
+
+// newScope returns the scope of a user, nil when the user is unrestricted
+func newScope(user models.User) *Scope {
+	if user.Role == RoleAdmin || (user.Role != RoleCustomer && len(user.Scopes) == 0) {
+		return nil
+	}
+	return &Scope{entries: user.Scopes}
+}
+
+// AllowsRouter reports whether anything on the router is in scope
+func (s *Scope) AllowsRouter(routerID uint) bool {
+	if s == nil {
+		return true
+	}
+	for _, entry := range s.entries {
+		if entry.RouterID == routerID {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsInterface reports whether an interface of a router is in scope. An empty
+// name asks about the router itself.
+func (s *Scope) AllowsInterface(routerID uint, name string) bool {
+	if s == nil {
+		return true
+	}
+	if name == "" {
+		return s.AllowsRouter(routerID)
+	}
+	for _, entry := range s.entries {
+		if entry.RouterID != routerID {
+			continue
+		}
+		if entry.InterfaceName == "" {
+			return true
+		}
+		if matched, _ := path.Match(entry.InterfaceName, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// RouterIDs returns the routers in scope, nil when unrestricted
+func (s *Scope) RouterIDs() []uint {
+	if s == nil {
+		return nil
+	}
+	seen := make(map[uint]bool)
+	ids := []uint{}
+	for _, entry := range s.entries {
+		if !seen[entry.RouterID] {
+			seen[entry.RouterID] = true
+			ids = append(ids, entry.RouterID)
+		}
+	}
+	return ids
+}
+
+// Can reports whether the principal's role grants permission
+func (p *Principal) Can(permission string) bool {
+	return p != nil && RoleAllows(p.Role, permission)
+}
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"monik-enterprise/internal/config"
+)
+
+// rebootLogPattern matches the system log messages RouterOS writes while booting
+var rebootLogPattern = regexp.MustCompile(`(?i)(reboot|started|routeros)`)
+
+// RESTCollector talks to the RouterOS v7 REST JSON API (/rest) over HTTPS
+type RESTCollector struct {
+	config  config.RouterConfig
+	baseURL string
+	logger  *LoggerService
+	mu      sync.Mutex
+	client  *http.Client
+}
+
+// NewRESTCollector creates a collector for the RouterOS REST API
+func NewRESTCollector(cfg config.RouterConfig) *RESTCollector {
+	return &RESTCollector{
+		config:  cfg,
+		baseURL: fmt.Sprintf("https://%s:%d/rest", cfg.IP, cfg.Port),
+	}
+}
+
+// SetLogger sets the structured logger of the collector
+func (c *RESTCollector) SetLogger(logger *LoggerService) {
+	c.logger = logger
+}
+
+// httpClient lazily builds the HTTP client so TLS configuration errors surface on poll
+func (c *RESTCollector) httpClient() (*http.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.client != nil {
+		return c.client, nil
+	}
+
+	address := fmt.Sprintf("%s:%d", c.config.IP, c.config.Port)
+	tlsConfig, err := buildTLSConfig(c.config)
+	if err != nil {
+		c.logger.Error(ComponentCollector, "connect", "TLS configuration error", err, routerLogFields(c.config, nil))
+		return nil, &RouterConnectError{Kind: ConnErrorTLSConfig, Address: address, Err: err}
+	}
+
+	c.client = &http.Client{
+		Timeout: c.config.Timeout,
+		Transport: &http.Transport{
+			TLSClientConfig:     tlsConfig,
+			MaxIdleConnsPerHost: 4,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+	return c.client, nil
+}
+
+// do performs a REST request and decodes the JSON response into out
+func (c *RESTCollector) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	client, err := c.httpClient()
+	if err != nil {
+		return err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.config.Username, c.config.Password)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	address := fmt.Sprintf("%s:%d", c.config.IP, c.config.Port)
+	resp, err := client.Do(req)
+	if err != nil {
+		connErr := classifyConnectError(address, true, err)
+		c.logger.Warn(ComponentCollector, "request", "Request failed", routerLogFields(c.config, map[string]interface{}{
+			"method": method,
+			"path":   path,
+			"kind":   connErr.Kind,
+			"error":  err.Error(),
+		}))
+		return fmt.Errorf("failed to connect to router: %w", connErr)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return &RouterConnectError{Kind: ConnErrorAuth, Address: address, Err: fmt.Errorf("%s", resp.Status)}
+	}
+	if resp.StatusCode >= 300 {
+		// RouterOS reports errors as {"error":400,"message":"...","detail":"..."}
+		var apiErr struct {
+			Message string `json:"message"`
+			Detail  string `json:"detail"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("%s %s: %s: %s %s", method, path, resp.Status, apiErr.Message, apiErr.Detail)
+		}
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", path, err)
+	}
+	return nil
+}
+
+// GetInterfaces retrieves interface information via GET /rest/interface
+func (c *RESTCollector) GetInterfaces(ctx context.Context) ([]InterfaceData, error) {
+	var items []map[string]string
+	if err := c.do(ctx, http.MethodGet, "/interface", nil, &items); err != nil {
+		return nil, fmt.Errorf("failed to get interfaces: %w", err)
+	}
+
+	c.logger.Debug(ComponentCollector, "get_interfaces", "Received interfaces", routerLogFields(c.config, map[string]interface{}{
+		"count": len(items),
+	}))
+	interfaces := make([]InterfaceData, 0, len(items))
+	for _, item := range items {
+		interfaces = append(interfaces, InterfaceData{
+			Name:        item["name"],
+			ObjectID:    item[".id"],
+			RxBytes:     parseUint64(c.logger, item["rx-byte"]),
+			TxBytes:     parseUint64(c.logger, item["tx-byte"]),
+			Status:      item["running"],
+			Comment:     item["comment"],
+			LastUpdated: time.Now(),
+		})
+	}
+	return interfaces, nil
+}
+
+// GetTrafficStats gets real-time rates via POST /rest/interface/monitor-traffic
+func (c *RESTCollector) GetTrafficStats(ctx context.Context, interfaceName string) (*InterfaceData, error) {
+	var items []map[string]string
+	body := map[string]interface{}{
+		"interface": interfaceName,
+		"once":      true,
+	}
+	if err := c.do(ctx, http.MethodPost, "/interface/monitor-traffic", body, &items); err != nil {
+		return nil, fmt.Errorf("failed to get traffic stats: %w", err)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no data returned for interface %s", interfaceName)
+	}
+
+	data := &InterfaceData{
+		Name:        interfaceName,
+		Status:      "up", // Assume up if we can monitor
+		LastUpdated: time.Now(),
+	}
+	if rxRate, err := parseRate(items[0]["rx-bits-per-second"]); err == nil {
+		data.RxRate = rxRate
+	}
+	if txRate, err := parseRate(items[0]["tx-bits-per-second"]); err == nil {
+		data.TxRate = txRate
+	}
+	return data, nil
+}
+
+// GetSystemInfo retrieves identity, resource and clock information
+func (c *RESTCollector) GetSystemInfo(ctx context.Context) (*SystemInfo, error) {
+	info := &SystemInfo{}
+
+	var identity map[string]string
+	if err := c.do(ctx, http.MethodGet, "/system/identity", nil, &identity); err != nil {
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+	info.Identity = identity["name"]
+
+	var resource map[string]string
+	if err := c.do(ctx, http.MethodGet, "/system/resource", nil, &resource); err == nil {
+		info.BoardName = resource["board-name"]
+		info.Version = resource["version"]
+		info.Uptime = resource["uptime"]
+		info.CPU = resource["cpu-load"] + "%"
+		info.Memory = resource["free-memory"] + "/" + resource["total-memory"]
+		if free, total := resource["free-hdd-space"], resource["total-hdd-space"]; free != "" && total != "" {
+			info.Disk = free + "/" + total
+		}
+	} else {
+		c.logger.Warn(ComponentCollector, "get_system_info", "Failed to get resource info", routerLogFields(c.config, map[string]interface{}{
+			"error": err.Error(),
+		}))
+	}
+
+	var clock map[string]string
+	if err := c.do(ctx, http.MethodGet, "/system/clock", nil, &clock); err == nil {
+		info.Timezone = clock["time-zone-name"]
+	} else {
+		c.logger.Warn(ComponentCollector, "get_system_info", "Failed to get clock", routerLogFields(c.config, map[string]interface{}{
+			"error": err.Error(),
+		}))
+	}
+
+	return info, nil
+}
+
+// GetUptime reads the time since the router booted via GET /rest/system/resource
+func (c *RESTCollector) GetUptime(ctx context.Context) (time.Duration, error) {
+	var resource map[string]string
+	if err := c.do(ctx, http.MethodGet, "/system/resource", nil, &resource); err != nil {
+		return 0, fmt.Errorf("failed to get uptime: %w", err)
+	}
+	return parseRouterOSDuration(resource["uptime"])
+}
+
+// GetLastRebootLog retrieves the timestamp of the last boot message in the system log
+func (c *RESTCollector) GetLastRebootLog(ctx context.Context) (time.Time, error) {
+	var entries []map[string]string
+	if err := c.do(ctx, http.MethodGet, "/log", nil, &entries); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get logs: %w", err)
+	}
+
+	var latestTime time.Time
+	for _, entry := range entries {
+		if !strings.Contains(entry["topics"], "system") || !rebootLogPattern.MatchString(entry["message"]) {
+			continue
+		}
+		parsedTime, err := parseMikroTikTime(entry["time"])
+		if err != nil {
+			continue
+		}
+		if parsedTime.After(latestTime) {
+			latestTime = parsedTime
+		}
+	}
+
+	if latestTime.IsZero() {
+		return time.Time{}, fmt.Errorf("no reboot logs found")
+	}
+	return latestTime, nil
+}
+
+// AddSimpleQueue adds a simple queue via PUT /rest/queue/simple, replacing a queue with
+// the same name
+func (c *RESTCollector) AddSimpleQueue(ctx context.Context, name, target, maxLimit, comment string) error {
+	if err := c.RemoveSimpleQueue(ctx, name); err != nil {
+		return err
+	}
+	queue := map[string]string{
+		"name":      name,
+		"target":    target,
+		"max-limit": maxLimit,
+		"comment":   comment,
+	}
+	if err := c.do(ctx, http.MethodPut, "/queue/simple", queue, nil); err != nil {
+		return fmt.Errorf("failed to add simple queue %s: %w", name, err)
+	}
+	c.logger.Info(ComponentCollector, "queue", "Added simple queue", routerLogFields(c.config, map[string]interface{}{
+		"queue":     name,
+		"target":    target,
+		"max_limit": maxLimit,
+	}))
+	return nil
+}
+
+// RemoveSimpleQueue removes the simple queues with the given name
+func (c *RESTCollector) RemoveSimpleQueue(ctx context.Context, name string) error {
+	var items []map[string]string
+	if err := c.do(ctx, http.MethodGet, "/queue/simple?name="+url.QueryEscape(name), nil, &items); err != nil {
+		return fmt.Errorf("failed to look up simple queue %s: %w", name, err)
+	}
+	for _, item := range items {
+		if err := c.do(ctx, http.MethodDelete, "/queue/simple/"+url.PathEscape(item[".id"]), nil, nil); err != nil {
+			return fmt.Errorf("failed to remove simple queue %s: %w", name, err)
+		}
+		c.logger.Info(ComponentCollector, "queue", "Removed simple queue", routerLogFields(c.config, map[string]interface{}{
+			"queue": name,
+			"id":    item[".id"],
+		}))
+	}
+	return nil
+}
+
+// SetInterfaceDisabled disables or enables an interface via POST /rest/interface/disable
+// or /rest/interface/enable
+func (c *RESTCollector) SetInterfaceDisabled(ctx context.Context, name string, disabled bool) error {
+	path := "/interface/enable"
+	if disabled {
+		path = "/interface/disable"
+	}
+	if err := c.do(ctx, http.MethodPost, path, map[string]string{"numbers": name}, nil); err != nil {
+		return fmt.Errorf("%s %s failed: %w", path, name, err)
+	}
+	c.logger.Info(ComponentCollector, "interface", "Changed interface state", routerLogFields(c.config, map[string]interface{}{
+		"command":   path,
+		"interface": name,
+	}))
+	return nil
+}
+
+// Close releases idle HTTP connections
+func (c *RESTCollector) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client != nil {
+		c.client.CloseIdleConnections()
+		c.client = nil
+	}
+}
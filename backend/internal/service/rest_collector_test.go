@@ -0,0 +1,378 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"monik-enterprise/internal/config"
+)
+
+const (
+	fakeRouterUser     = "admin"
+	fakeRouterPassword = "secret"
+)
+
+// fakeRouterOS is an httptest server answering like the RouterOS v7 REST API. Paths
+// without a handler answer 404 the way RouterOS does.
+type fakeRouterOS struct {
+	*httptest.Server
+	handlers map[string]http.HandlerFunc // "METHOD /rest/path" -> handler
+}
+
+// newFakeRouterOS starts a TLS server requiring the fake credentials
+func newFakeRouterOS(t *testing.T) *fakeRouterOS {
+	t.Helper()
+	fake := &fakeRouterOS{handlers: make(map[string]http.HandlerFunc)}
+	fake.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != fakeRouterUser || password != fakeRouterPassword {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler, ok := fake.handlers[r.Method+" "+r.URL.Path]
+		if !ok {
+			writeRouterOSError(w, http.StatusNotFound, "Not Found", "no such command or directory")
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(fake.Close)
+	return fake
+}
+
+// handle registers the reply for a method and path below /rest
+func (f *fakeRouterOS) handle(method, path string, handler http.HandlerFunc) {
+	f.handlers[method+" /rest"+path] = handler
+}
+
+// reply registers a JSON reply for a method and path below /rest
+func (f *fakeRouterOS) reply(method, path string, body interface{}) {
+	f.handle(method, path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+	})
+}
+
+// routerConfig returns a router configuration pointing at the fake server
+func (f *fakeRouterOS) routerConfig(t *testing.T) config.RouterConfig {
+	t.Helper()
+	host, port, err := net.SplitHostPort(f.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config.RouterConfig{
+		IP:       host,
+		Port:     portNumber,
+		Username: fakeRouterUser,
+		Password: fakeRouterPassword,
+		Timeout:  2 * time.Second,
+		Backend:  "rest",
+		TLS:      config.RouterTLSConfig{Enabled: true, InsecureSkipVerify: true},
+	}
+}
+
+// caFile writes the certificate of the fake server to a PEM file
+func (f *fakeRouterOS) caFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: f.Certificate().Raw})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeRouterOSError writes an error body the way RouterOS formats them
+func writeRouterOSError(w http.ResponseWriter, status int, message, detail string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":   status,
+		"message": message,
+		"detail":  detail,
+	})
+}
+
+func TestRESTCollectorGetInterfaces(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply []map[string]string
+		want  []InterfaceData
+	}{
+		{
+			name:  "empty",
+			reply: []map[string]string{},
+			want:  []InterfaceData{},
+		},
+		{
+			name: "counters and state",
+			reply: []map[string]string{
+				{".id": "*1", "name": "ether1", "rx-byte": "1024", "tx-byte": "2048", "running": "true", "comment": "uplink"},
+				{".id": "*2", "name": "wlan1", "rx-byte": "0", "tx-byte": "0", "running": "false"},
+			},
+			want: []InterfaceData{
+				{Name: "ether1", ObjectID: "*1", RxBytes: 1024, TxBytes: 2048, Status: "true", Comment: "uplink"},
+				{Name: "wlan1", ObjectID: "*2", Status: "false"},
+			},
+		},
+		{
+			name: "64-bit counters",
+			reply: []map[string]string{
+				{".id": "*3", "name": "sfp1", "rx-byte": "18446744073709551615", "tx-byte": "4294967296", "running": "true"},
+			},
+			want: []InterfaceData{
+				{Name: "sfp1", ObjectID: "*3", RxBytes: 18446744073709551615, TxBytes: 4294967296, Status: "true"},
+			},
+		},
+		{
+			name: "unparsable counters",
+			reply: []map[string]string{
+				{".id": "*4", "name": "bridge", "rx-byte": "n/a", "running": "true"},
+			},
+			want: []InterfaceData{
+				{Name: "bridge", ObjectID: "*4", Status: "true"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeRouterOS(t)
+			fake.reply(http.MethodGet, "/interface", tt.reply)
+			collector := NewRESTCollector(fake.routerConfig(t))
+			defer collector.Close()
+
+			got, err := collector.GetInterfaces(context.Background())
+			if err != nil {
+				t.Fatalf("GetInterfaces: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d interfaces, want %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got[i].LastUpdated = time.Time{}
+				if got[i] != want {
+					t.Errorf("interface %d = %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRESTCollectorGetTrafficStats(t *testing.T) {
+	fake := newFakeRouterOS(t)
+	fake.handle(http.MethodPost, "/interface/monitor-traffic", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["interface"] != "ether1" {
+			writeRouterOSError(w, http.StatusBadRequest, "Bad Request", "unexpected body")
+			return
+		}
+		json.NewEncoder(w).Encode([]map[string]string{
+			{"name": "ether1", "rx-bits-per-second": "12500000", "tx-bits-per-second": "500000"},
+		})
+	})
+	collector := NewRESTCollector(fake.routerConfig(t))
+	defer collector.Close()
+
+	got, err := collector.GetTrafficStats(context.Background(), "ether1")
+	if err != nil {
+		t.Fatalf("GetTrafficStats: %v", err)
+	}
+	if got.RxRate != 12.5 || got.TxRate != 0.5 {
+		t.Errorf("rates = %v/%v Mbps, want 12.5/0.5", got.RxRate, got.TxRate)
+	}
+}
+
+func TestRESTCollectorErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(fake *fakeRouterOS, cfg *config.RouterConfig)
+		wantKind string // expected ConnectErrorKind, "" for a plain error
+		wantText string // substring of the error
+	}{
+		{
+			name: "wrong password",
+			setup: func(fake *fakeRouterOS, cfg *config.RouterConfig) {
+				cfg.Password = "wrong"
+			},
+			wantKind: ConnErrorAuth,
+			wantText: "401",
+		},
+		{
+			name: "forbidden",
+			setup: func(fake *fakeRouterOS, cfg *config.RouterConfig) {
+				fake.handle(http.MethodGet, "/interface", func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusForbidden)
+				})
+			},
+			wantKind: ConnErrorAuth,
+			wantText: "403",
+		},
+		{
+			name: "RouterOS error body",
+			setup: func(fake *fakeRouterOS, cfg *config.RouterConfig) {
+				fake.handle(http.MethodGet, "/interface", func(w http.ResponseWriter, r *http.Request) {
+					writeRouterOSError(w, http.StatusBadRequest, "Bad Request", "unknown parameter")
+				})
+			},
+			wantText: "Bad Request unknown parameter",
+		},
+		{
+			name: "server error without body",
+			setup: func(fake *fakeRouterOS, cfg *config.RouterConfig) {
+				fake.handle(http.MethodGet, "/interface", func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusInternalServerError)
+				})
+			},
+			wantText: "500 Internal Server Error",
+		},
+		{
+			name:     "missing endpoint",
+			setup:    func(fake *fakeRouterOS, cfg *config.RouterConfig) {},
+			wantText: "404",
+		},
+		{
+			name: "invalid JSON",
+			setup: func(fake *fakeRouterOS, cfg *config.RouterConfig) {
+				fake.handle(http.MethodGet, "/interface", func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte("<html>"))
+				})
+			},
+			wantText: "failed to decode",
+		},
+		{
+			name: "request timeout",
+			setup: func(fake *fakeRouterOS, cfg *config.RouterConfig) {
+				cfg.Timeout = 100 * time.Millisecond
+				fake.handle(http.MethodGet, "/interface", func(w http.ResponseWriter, r *http.Request) {
+					select {
+					case <-time.After(2 * time.Second):
+					case <-r.Context().Done():
+					}
+				})
+			},
+			wantKind: ConnErrorNetwork,
+			wantText: "Client.Timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeRouterOS(t)
+			cfg := fake.routerConfig(t)
+			tt.setup(fake, &cfg)
+			collector := NewRESTCollector(cfg)
+			defer collector.Close()
+
+			_, err := collector.GetInterfaces(context.Background())
+			if err == nil {
+				t.Fatal("GetInterfaces succeeded, want an error")
+			}
+			if kind := ConnectErrorKind(err); kind != tt.wantKind {
+				t.Errorf("error kind = %q, want %q (%v)", kind, tt.wantKind, err)
+			}
+			if !strings.Contains(err.Error(), tt.wantText) {
+				t.Errorf("error %q does not mention %q", err, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestRESTCollectorContextDeadline(t *testing.T) {
+	fake := newFakeRouterOS(t)
+	fake.handle(http.MethodGet, "/interface", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	collector := NewRESTCollector(fake.routerConfig(t))
+	defer collector.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	_, err := collector.GetInterfaces(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("request took %v after the deadline", elapsed)
+	}
+}
+
+func TestRESTCollectorTLS(t *testing.T) {
+	tests := []struct {
+		name     string
+		tls      func(fake *fakeRouterOS, t *testing.T) config.RouterTLSConfig
+		wantKind string // "" when the request succeeds
+	}{
+		{
+			name: "insecure skip verify",
+			tls: func(fake *fakeRouterOS, t *testing.T) config.RouterTLSConfig {
+				return config.RouterTLSConfig{Enabled: true, InsecureSkipVerify: true}
+			},
+		},
+		{
+			name: "trusted CA bundle",
+			tls: func(fake *fakeRouterOS, t *testing.T) config.RouterTLSConfig {
+				return config.RouterTLSConfig{Enabled: true, CAFile: fake.caFile(t)}
+			},
+		},
+		{
+			name: "unknown CA",
+			tls: func(fake *fakeRouterOS, t *testing.T) config.RouterTLSConfig {
+				return config.RouterTLSConfig{Enabled: true}
+			},
+			wantKind: ConnErrorTLSVerification,
+		},
+		{
+			name: "server name mismatch",
+			tls: func(fake *fakeRouterOS, t *testing.T) config.RouterTLSConfig {
+				return config.RouterTLSConfig{Enabled: true, CAFile: fake.caFile(t), ServerName: "router.invalid"}
+			},
+			wantKind: ConnErrorTLSVerification,
+		},
+		{
+			name: "missing CA bundle",
+			tls: func(fake *fakeRouterOS, t *testing.T) config.RouterTLSConfig {
+				return config.RouterTLSConfig{Enabled: true, CAFile: filepath.Join(t.TempDir(), "missing.pem")}
+			},
+			wantKind: ConnErrorTLSConfig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeRouterOS(t)
+			fake.reply(http.MethodGet, "/interface", []map[string]string{{"name": "ether1", "running": "true"}})
+			cfg := fake.routerConfig(t)
+			cfg.TLS = tt.tls(fake, t)
+			collector := NewRESTCollector(cfg)
+			defer collector.Close()
+
+			_, err := collector.GetInterfaces(context.Background())
+			if tt.wantKind == "" {
+				if err != nil {
+					t.Fatalf("GetInterfaces: %v", err)
+				}
+				return
+			}
+			if kind := ConnectErrorKind(err); kind != tt.wantKind {
+				t.Errorf("error kind = %q, want %q (%v)", kind, tt.wantKind, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,827 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"monik-enterprise/internal/config"
+	"monik-enterprise/internal/database"
+	"monik-enterprise/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Traffic history resolutions
+const (
+	ResolutionRaw = "raw"
+	Resolution1m  = "1m"
+	Resolution1h  = "1h"
+	Resolution1d  = "1d"
+)
+
+const (
+	// rollupLookback is how far before a window raw snapshots are read, so the first
+	// bucket gets the counter delta from the snapshot preceding it
+	rollupLookback = time.Hour
+	// rollupChunk bounds the time window aggregated per query
+	rollupChunk = 6 * time.Hour
+	// rollupBatchSize is the number of rollup rows written per INSERT
+	rollupBatchSize = 200
+)
+
+var (
+	rollupTable1m = models.TrafficRollup1m{}.TableName()
+	rollupTable1h = models.TrafficRollup1h{}.TableName()
+	rollupTable1d = models.TrafficRollup1d{}.TableName()
+)
+
+// RollupService aggregates raw traffic snapshots into 1-minute, 1-hour and 1-day
+// rollups and purges rows past their retention
+type RollupService struct {
+	db         *gorm.DB
+	config     config.RetentionConfig
+	rawSpacing time.Duration // Expected time between raw snapshots of an interface
+	logger     *LoggerService
+	mu         sync.Mutex // Serializes runs
+	quit       chan struct{}
+	wg         sync.WaitGroup
+}
+
+// TrafficPoint is one entry of an interface traffic history at any resolution. Rates
+// are in Mbps; RxRate/TxRate hold the average for rollups.
+type TrafficPoint struct {
+	Timestamp time.Time
+	Samples   int
+	RxRate    float64
+	TxRate    float64
+	RxRateMin float64
+	RxRateMax float64
+	RxRateP95 float64
+	TxRateMin float64
+	TxRateMax float64
+	TxRateP95 float64
+	RxBytes   uint64
+	TxBytes   uint64
+}
+
+// NewRollupService creates the rollup and retention job. The snapshot policy tells how
+// densely raw snapshots are written.
+func NewRollupService(db *gorm.DB, cfg config.RetentionConfig, snapshotCfg config.SnapshotConfig) *RollupService {
+	if cfg.RollupInterval <= 0 {
+		cfg.RollupInterval = time.Minute
+	}
+	// Delta-only snapshots have no regular spacing; bucket raw data per minute at least
+	rawSpacing := time.Minute
+	if snapshotCfg.Policy != SnapshotPolicyDelta && snapshotCfg.Interval > 0 {
+		rawSpacing = snapshotCfg.Interval
+	}
+
+	return &RollupService{
+		db:         db,
+		config:     cfg,
+		rawSpacing: rawSpacing,
+		quit:       make(chan struct{}),
+	}
+}
+
+// SetLogger sets the structured logger of the rollup job
+func (r *RollupService) SetLogger(logger *LoggerService) {
+	r.logger = logger
+}
+
+// Start runs the rollup job every RollupInterval
+func (r *RollupService) Start() {
+	r.wg.Add(1)
+	go r.loop()
+}
+
+// Stop stops the rollup job
+func (r *RollupService) Stop() {
+	close(r.quit)
+	r.wg.Wait()
+}
+
+func (r *RollupService) loop() {
+	defer r.wg.Done()
+	r.logger.Info(ComponentRollup, "start", "Rollup job started", map[string]interface{}{
+		"interval": r.config.RollupInterval.String(),
+	})
+
+	r.Run()
+	ticker := time.NewTicker(r.config.RollupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.quit:
+			return
+		case <-ticker.C:
+			r.Run()
+		}
+	}
+}
+
+// Run aggregates every complete bucket that has not been rolled up yet and applies
+// the retention settings
+func (r *RollupService) Run() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	if err := r.rollupMinutes(now); err != nil {
+		r.logger.Error(ComponentRollup, "rollup", "1m rollup failed", err, nil)
+		return
+	}
+	if err := r.rollupFromMinutes(rollupTable1h, time.Hour, now); err != nil {
+		r.logger.Error(ComponentRollup, "rollup", "1h rollup failed", err, nil)
+	}
+	if err := r.rollupFromMinutes(rollupTable1d, 24*time.Hour, now); err != nil {
+		r.logger.Error(ComponentRollup, "rollup", "1d rollup failed", err, nil)
+	}
+	if err := r.purge(now); err != nil {
+		r.logger.Error(ComponentRollup, "purge", "Retention purge failed", err, nil)
+	}
+}
+
+// nextBucket returns the first bucket of table that still has to be built, or
+// ok=false when the table is empty
+func (r *RollupService) nextBucket(table string, bucket time.Duration) (time.Time, bool, error) {
+	var rows []models.TrafficRollup
+	if err := r.db.Table(table).Select("bucket_start").Order("bucket_start DESC").Limit(1).Find(&rows).Error; err != nil {
+		return time.Time{}, false, err
+	}
+	if len(rows) == 0 {
+		return time.Time{}, false, nil
+	}
+	return rows[0].BucketStart.UTC().Add(bucket), true, nil
+}
+
+// rollupMinutes aggregates raw snapshots into 1-minute buckets
+func (r *RollupService) rollupMinutes(now time.Time) error {
+	until := now.Truncate(time.Minute)
+	since, ok, err := r.nextBucket(rollupTable1m, time.Minute)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		var first models.TrafficSnapshot
+		err := r.db.Order("timestamp ASC").First(&first).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		since = first.Timestamp.UTC().Truncate(time.Minute)
+	}
+
+	for since.Before(until) {
+		end := since.Add(rollupChunk)
+		if end.After(until) {
+			end = until
+		}
+
+		var snapshots []models.TrafficSnapshot
+		if err := r.db.Where("timestamp >= ? AND timestamp < ?", since.Add(-rollupLookback), end).
+			Order("router_id, interface_name, timestamp").
+			Find(&snapshots).Error; err != nil {
+			return err
+		}
+
+		rollups := aggregateSnapshots(snapshots, since, time.Minute)
+		if err := r.upsertRollups(rollupTable1m, rollups); err != nil {
+			return err
+		}
+		if len(rollups) > 0 {
+			r.logger.Debug(ComponentRollup, "rollup", "1m buckets written", map[string]interface{}{
+				"buckets": len(rollups),
+				"from":    since.Format(time.RFC3339),
+				"to":      end.Format(time.RFC3339),
+			})
+		}
+		since = end
+	}
+	return nil
+}
+
+// rollupFromMinutes aggregates 1-minute rollups into the buckets of a coarser table
+func (r *RollupService) rollupFromMinutes(table string, bucket time.Duration, now time.Time) error {
+	until := now.Truncate(bucket)
+	since, ok, err := r.nextBucket(table, bucket)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		var first []models.TrafficRollup
+		if err := r.db.Table(rollupTable1m).Order("bucket_start ASC").Limit(1).Find(&first).Error; err != nil {
+			return err
+		}
+		if len(first) == 0 {
+			return nil
+		}
+		since = first[0].BucketStart.UTC().Truncate(bucket)
+	}
+
+	for since.Before(until) {
+		end := since.Add(rollupChunk)
+		if end.Before(since.Add(bucket)) {
+			end = since.Add(bucket)
+		}
+		if end.After(until) {
+			end = until
+		}
+
+		var minutes []models.TrafficRollup
+		if err := r.db.Table(rollupTable1m).Where("bucket_start >= ? AND bucket_start < ?", since, end).
+			Order("router_id, interface_name, bucket_start").
+			Find(&minutes).Error; err != nil {
+			return err
+		}
+
+		rollups := aggregateRollups(minutes, bucket)
+		if err := r.upsertRollups(table, rollups); err != nil {
+			return err
+		}
+		since = end
+	}
+	return nil
+}
+
+// upsertRollups writes rollup rows, replacing buckets that already exist
+func (r *RollupService) upsertRollups(table string, rollups []models.TrafficRollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+	return r.db.Table(table).Clauses(database.Upsert(
+		[]string{"router_id", "interface_name", "bucket_start"},
+		[]string{
+			"samples", "rx_rate_min", "rx_rate_avg", "rx_rate_max", "rx_rate_p95",
+			"tx_rate_min", "tx_rate_avg", "tx_rate_max", "tx_rate_p95",
+			"rx_bytes", "tx_bytes", "updated_at",
+		},
+	)).CreateInBatches(rollups, rollupBatchSize).Error
+}
+
+// purge deletes rows past their retention and hands the freed pages back to the
+// filesystem. Rows that have not been rolled up into the next resolution are kept.
+func (r *RollupService) purge(now time.Time) error {
+	var deleted int64
+
+	if r.config.Raw > 0 {
+		cutoff := now.Add(-r.config.Raw)
+		if next, ok, err := r.nextBucket(rollupTable1m, time.Minute); err != nil {
+			return err
+		} else if !ok {
+			cutoff = time.Time{}
+		} else if next.Before(cutoff) {
+			cutoff = next
+		}
+		if !cutoff.IsZero() {
+			res := r.db.Unscoped().Where("timestamp < ?", cutoff).Delete(&models.TrafficSnapshot{})
+			if res.Error != nil {
+				return res.Error
+			}
+			deleted += res.RowsAffected
+		}
+	}
+
+	if r.config.Minute > 0 {
+		cutoff := now.Add(-r.config.Minute)
+		for _, coarse := range []struct {
+			table  string
+			bucket time.Duration
+		}{{rollupTable1h, time.Hour}, {rollupTable1d, 24 * time.Hour}} {
+			next, ok, err := r.nextBucket(coarse.table, coarse.bucket)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				cutoff = time.Time{}
+				break
+			}
+			if next.Before(cutoff) {
+				cutoff = next
+			}
+		}
+		if !cutoff.IsZero() {
+			n, err := r.deleteRollupsBefore(rollupTable1m, cutoff)
+			if err != nil {
+				return err
+			}
+			deleted += n
+		}
+	}
+
+	for _, retention := range []struct {
+		table string
+		keep  time.Duration
+	}{{rollupTable1h, r.config.Hour}, {rollupTable1d, r.config.Day}} {
+		if retention.keep <= 0 {
+			continue
+		}
+		n, err := r.deleteRollupsBefore(retention.table, now.Add(-retention.keep))
+		if err != nil {
+			return err
+		}
+		deleted += n
+	}
+
+	if deleted == 0 {
+		return nil
+	}
+	r.logger.Info(ComponentRollup, "purge", "Retention purged rows", map[string]interface{}{
+		"rows": deleted,
+	})
+
+	// PostgreSQL reclaims dead rows through autovacuum
+	if !database.IsSQLite(r.db) {
+		return nil
+	}
+	vacuum := "PRAGMA incremental_vacuum;"
+	if r.config.VacuumPages > 0 {
+		vacuum = fmt.Sprintf("PRAGMA incremental_vacuum(%d);", r.config.VacuumPages)
+	}
+	return r.db.Exec(vacuum).Error
+}
+
+func (r *RollupService) deleteRollupsBefore(table string, cutoff time.Time) (int64, error) {
+	res := r.db.Table(table).Where("bucket_start < ?", cutoff).Delete(&models.TrafficRollup{})
+	return res.RowsAffected, res.Error
+}
+
+// SelectResolution picks the finest resolution that keeps a range readable and is still
+// retained for its start. When none is, the resolution kept longest is used.
+func (r *RollupService) SelectResolution(from, to, now time.Time) string {
+	span := to.Sub(from)
+	resolutions := []struct {
+		name    string
+		maxSpan time.Duration // 0 reads any span
+		keep    time.Duration
+	}{
+		{ResolutionRaw, 2 * time.Hour, r.config.Raw},
+		{Resolution1m, 2 * 24 * time.Hour, r.config.Minute},
+		{Resolution1h, 90 * 24 * time.Hour, r.config.Hour},
+		{Resolution1d, 0, r.config.Day},
+	}
+	for _, res := range resolutions {
+		if res.maxSpan > 0 && span > res.maxSpan {
+			continue
+		}
+		if res.keep > 0 && from.Before(now.Add(-res.keep)) {
+			continue
+		}
+		return res.name
+	}
+	return r.longestRetained()
+}
+
+// longestRetained returns the resolution whose rows are kept longest, the coarsest one
+// on a tie
+func (r *RollupService) longestRetained() string {
+	retention := []struct {
+		name string
+		keep time.Duration
+	}{
+		{ResolutionRaw, r.config.Raw},
+		{Resolution1m, r.config.Minute},
+		{Resolution1h, r.config.Hour},
+		{Resolution1d, r.config.Day},
+	}
+	forever := func(keep time.Duration) time.Duration {
+		if keep <= 0 {
+			return math.MaxInt64
+		}
+		return keep
+	}
+	longest := retention[0]
+	for _, res := range retention[1:] {
+		if forever(res.keep) >= forever(longest.keep) {
+			longest = res
+		}
+	}
+	return longest.name
+}
+
+// History returns the traffic of an interface between from and to, oldest first. An
+// empty resolution is chosen with SelectResolution.
+func (r *RollupService) History(routerID uint, interfaceName string, from, to time.Time, resolution string) ([]TrafficPoint, string, error) {
+	if resolution == "" {
+		resolution = r.SelectResolution(from, to, time.Now())
+	}
+
+	var table string
+	switch resolution {
+	case ResolutionRaw:
+		points, err := r.rawHistory(routerID, interfaceName, from, to)
+		return points, resolution, err
+	case Resolution1m:
+		table = rollupTable1m
+	case Resolution1h:
+		table = rollupTable1h
+	case Resolution1d:
+		table = rollupTable1d
+	default:
+		return nil, resolution, fmt.Errorf("unknown resolution: %s", resolution)
+	}
+
+	var rollups []models.TrafficRollup
+	if err := r.db.Table(table).
+		Where("router_id = ? AND interface_name = ? AND bucket_start >= ? AND bucket_start <= ?", routerID, interfaceName, from, to).
+		Order("bucket_start ASC").
+		Find(&rollups).Error; err != nil {
+		return nil, resolution, err
+	}
+
+	points := make([]TrafficPoint, len(rollups))
+	for i, rollup := range rollups {
+		points[i] = TrafficPoint{
+			Timestamp: rollup.BucketStart,
+			Samples:   rollup.Samples,
+			RxRate:    rollup.RxRateAvg,
+			TxRate:    rollup.TxRateAvg,
+			RxRateMin: rollup.RxRateMin,
+			RxRateMax: rollup.RxRateMax,
+			RxRateP95: rollup.RxRateP95,
+			TxRateMin: rollup.TxRateMin,
+			TxRateMax: rollup.TxRateMax,
+			TxRateP95: rollup.TxRateP95,
+			RxBytes:   rollup.RxBytes,
+			TxBytes:   rollup.TxBytes,
+		}
+	}
+	return points, resolution, nil
+}
+
+// rawHistory returns raw snapshots as single-sample points
+func (r *RollupService) rawHistory(routerID uint, interfaceName string, from, to time.Time) ([]TrafficPoint, error) {
+	var snapshots []models.TrafficSnapshot
+	if err := r.db.Where("router_id = ? AND interface_name = ? AND timestamp >= ? AND timestamp <= ?", routerID, interfaceName, from, to).
+		Order("timestamp ASC").
+		Find(&snapshots).Error; err != nil {
+		return nil, err
+	}
+
+	// The snapshot before the range gives the byte delta of the first point
+	var prev *models.TrafficSnapshot
+	var before models.TrafficSnapshot
+	if err := r.db.Where("router_id = ? AND interface_name = ? AND timestamp < ?", routerID, interfaceName, from).
+		Order("timestamp DESC").First(&before).Error; err == nil {
+		prev = &before
+	}
+
+	points := make([]TrafficPoint, len(snapshots))
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		point := TrafficPoint{
+			Timestamp: snapshot.Timestamp,
+			Samples:   1,
+			RxRate:    snapshot.RxRate,
+			TxRate:    snapshot.TxRate,
+			RxRateMin: snapshot.RxRate,
+			RxRateMax: snapshot.RxRate,
+			RxRateP95: snapshot.RxRate,
+			TxRateMin: snapshot.TxRate,
+			TxRateMax: snapshot.TxRate,
+			TxRateP95: snapshot.TxRate,
+		}
+		if prev != nil {
+			kind := snapshotResetKind(snapshot)
+			point.RxBytes = counterDelta(prev.RxBytes, snapshot.RxBytes, kind)
+			point.TxBytes = counterDelta(prev.TxBytes, snapshot.TxBytes, kind)
+		}
+		points[i] = point
+		prev = snapshot
+	}
+	return points, nil
+}
+
+// rollupKey identifies a bucket of one router interface
+type rollupKey struct {
+	routerID      uint
+	interfaceName string
+	bucketStart   time.Time
+}
+
+// rollupAccumulator collects the samples of one bucket
+type rollupAccumulator struct {
+	samples          int
+	rxRates, txRates []float64
+	rxMin, rxMax     float64
+	txMin, txMax     float64
+	rxSum, txSum     float64 // Sample weighted
+	rxBytes, txBytes uint64
+}
+
+func (a *rollupAccumulator) add(samples int, rxAvg, txAvg, rxMin, rxMax, txMin, txMax float64) {
+	if a.samples == 0 {
+		a.rxMin, a.rxMax, a.txMin, a.txMax = rxMin, rxMax, txMin, txMax
+	} else {
+		a.rxMin, a.rxMax = math.Min(a.rxMin, rxMin), math.Max(a.rxMax, rxMax)
+		a.txMin, a.txMax = math.Min(a.txMin, txMin), math.Max(a.txMax, txMax)
+	}
+	a.samples += samples
+	a.rxSum += rxAvg * float64(samples)
+	a.txSum += txAvg * float64(samples)
+	a.rxRates = append(a.rxRates, rxAvg)
+	a.txRates = append(a.txRates, txAvg)
+}
+
+// collectRollups turns accumulators into rows ordered by router, interface and bucket
+func collectRollups(accs map[rollupKey]*rollupAccumulator) []models.TrafficRollup {
+	rollups := make([]models.TrafficRollup, 0, len(accs))
+	for key, acc := range accs {
+		if acc.samples == 0 {
+			continue
+		}
+		rollups = append(rollups, models.TrafficRollup{
+			RouterID:      key.routerID,
+			InterfaceName: key.interfaceName,
+			BucketStart:   key.bucketStart,
+			Samples:       acc.samples,
+			RxRateMin:     acc.rxMin,
+			RxRateAvg:     acc.rxSum / float64(acc.samples),
+			RxRateMax:     acc.rxMax,
+			RxRateP95:     percentile(acc.rxRates, 0.95),
+			TxRateMin:     acc.txMin,
+			TxRateAvg:     acc.txSum / float64(acc.samples),
+			TxRateMax:     acc.txMax,
+			TxRateP95:     percentile(acc.txRates, 0.95),
+			RxBytes:       acc.rxBytes,
+			TxBytes:       acc.txBytes,
+		})
+	}
+	sort.Slice(rollups, func(i, j int) bool {
+		a, b := rollups[i], rollups[j]
+		if a.RouterID != b.RouterID {
+			return a.RouterID < b.RouterID
+		}
+		if a.InterfaceName != b.InterfaceName {
+			return a.InterfaceName < b.InterfaceName
+		}
+		return a.BucketStart.Before(b.BucketStart)
+	})
+	return rollups
+}
+
+// aggregateSnapshots builds buckets from raw snapshots ordered by router, interface and
+// timestamp. Snapshots before since only provide the counter baseline. The byte delta
+// between two snapshots is attributed to the bucket of the later one.
+func aggregateSnapshots(snapshots []models.TrafficSnapshot, since time.Time, bucket time.Duration) []models.TrafficRollup {
+	accs := make(map[rollupKey]*rollupAccumulator)
+	var prev *models.TrafficSnapshot
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		if prev != nil && (prev.RouterID != snapshot.RouterID || prev.InterfaceName != snapshot.InterfaceName) {
+			prev = nil
+		}
+		if snapshot.Timestamp.Before(since) {
+			prev = snapshot
+			continue
+		}
+
+		key := rollupKey{snapshot.RouterID, snapshot.InterfaceName, snapshot.Timestamp.UTC().Truncate(bucket)}
+		acc, ok := accs[key]
+		if !ok {
+			acc = &rollupAccumulator{}
+			accs[key] = acc
+		}
+		acc.add(1, snapshot.RxRate, snapshot.TxRate, snapshot.RxRate, snapshot.RxRate, snapshot.TxRate, snapshot.TxRate)
+		if prev != nil {
+			kind := snapshotResetKind(snapshot)
+			acc.rxBytes += counterDelta(prev.RxBytes, snapshot.RxBytes, kind)
+			acc.txBytes += counterDelta(prev.TxBytes, snapshot.TxBytes, kind)
+		}
+		prev = snapshot
+	}
+	return collectRollups(accs)
+}
+
+// aggregateRollups merges finer rollups into coarser buckets. The p95 of a coarse
+// bucket is taken over the averages of its finer buckets, like 95th percentile billing.
+func aggregateRollups(rows []models.TrafficRollup, bucket time.Duration) []models.TrafficRollup {
+	accs := make(map[rollupKey]*rollupAccumulator)
+	for _, row := range rows {
+		key := rollupKey{row.RouterID, row.InterfaceName, row.BucketStart.UTC().Truncate(bucket)}
+		acc, ok := accs[key]
+		if !ok {
+			acc = &rollupAccumulator{}
+			accs[key] = acc
+		}
+		acc.add(row.Samples, row.RxRateAvg, row.TxRateAvg, row.RxRateMin, row.RxRateMax, row.TxRateMin, row.TxRateMax)
+		acc.rxBytes += row.RxBytes
+		acc.txBytes += row.TxBytes
+	}
+	return collectRollups(accs)
+}
+
+// percentile returns the nearest-rank percentile p (0..1) of values
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// Aggregations of a bucketed traffic history
+const (
+	AggAvg = "avg" // Sample weighted average rate
+	AggMax = "max" // Peak rate
+	AggP95 = "p95" // 95th percentile of the source point rates
+	AggSum = "sum" // Bytes transferred
+)
+
+// maxHistoryBuckets bounds the number of points of a bucketed history
+const maxHistoryBuckets = 10000
+
+// ErrTooManyBuckets is returned when a range would be split into more than
+// maxHistoryBuckets buckets
+var ErrTooManyBuckets = errors.New("too many buckets")
+
+// TrafficBucket is one evenly spaced point of a bucketed traffic history. Gap marks a
+// bucket without any sample, e.g. while the router was offline, so charts can break the
+// line instead of interpolating across it.
+type TrafficBucket struct {
+	Timestamp time.Time
+	Samples   int
+	Rx        float64 // Mbps, or bytes for AggSum
+	Tx        float64
+	RxBytes   uint64
+	TxBytes   uint64
+	Gap       bool // No samples while the router was unreachable, or not collected yet
+}
+
+// IsValidAgg reports whether agg names a supported aggregation
+func IsValidAgg(agg string) bool {
+	switch agg {
+	case AggAvg, AggMax, AggP95, AggSum:
+		return true
+	}
+	return false
+}
+
+// resolutionForStep picks the coarsest resolution still finer than step whose retention
+// covers from, and the step raised to that resolution's sample spacing
+func (r *RollupService) resolutionForStep(from, now time.Time, step time.Duration) (string, time.Duration) {
+	candidates := []struct {
+		name    string
+		spacing time.Duration
+		keep    time.Duration
+	}{
+		{ResolutionRaw, r.rawSpacing, r.config.Raw},
+		{Resolution1m, time.Minute, r.config.Minute},
+		{Resolution1h, time.Hour, r.config.Hour},
+		{Resolution1d, 24 * time.Hour, r.config.Day},
+	}
+
+	chosen := -1
+	for i, candidate := range candidates {
+		if candidate.keep > 0 && from.Before(now.Add(-candidate.keep)) {
+			continue
+		}
+		if chosen >= 0 && candidate.spacing > step {
+			break
+		}
+		chosen = i
+	}
+	if chosen < 0 {
+		longest := r.longestRetained()
+		for i, candidate := range candidates {
+			if candidate.name == longest {
+				chosen = i
+			}
+		}
+	}
+
+	if spacing := candidates[chosen].spacing; step < spacing {
+		step = spacing
+	}
+	return candidates[chosen].name, step
+}
+
+// Buckets returns the traffic of an interface between from and to in buckets of step
+// aligned to step, aggregated with agg. The step is raised when the source resolution
+// samples less often. An empty bucket is only a gap when the router was unreachable
+// during it or it lies in the future; otherwise the interface was idle, which delta
+// snapshots do not record, and it reads as zero.
+func (r *RollupService) Buckets(routerID uint, interfaceName string, from, to time.Time, step time.Duration, agg string) ([]TrafficBucket, time.Duration, string, error) {
+	now := time.Now()
+	resolution, step := r.resolutionForStep(from, now, step)
+
+	// The last bucket is the one holding the instant before to, a range ending on a step
+	// boundary gets no bucket starting at to
+	start := from.UTC().Truncate(step)
+	count := 0
+	if to.After(start) {
+		count = int((to.Sub(start) + step - 1) / step)
+	}
+	if count > maxHistoryBuckets {
+		return nil, step, resolution, fmt.Errorf("%w: range needs %d buckets of %s, maximum is %d", ErrTooManyBuckets, count, step, maxHistoryBuckets)
+	}
+
+	points, _, err := r.History(routerID, interfaceName, start, to, resolution)
+	if err != nil {
+		return nil, step, resolution, err
+	}
+
+	// Rollups lag behind by up to one bucket, the raw snapshots after the last rollup
+	// fill the tail of the range
+	if resolution != ResolutionRaw {
+		table := map[string]string{Resolution1m: rollupTable1m, Resolution1h: rollupTable1h, Resolution1d: rollupTable1d}[resolution]
+		spacing := map[string]time.Duration{Resolution1m: time.Minute, Resolution1h: time.Hour, Resolution1d: 24 * time.Hour}[resolution]
+		next, ok, err := r.nextBucket(table, spacing)
+		if err != nil {
+			return nil, step, resolution, err
+		}
+		if !ok || next.Before(start) {
+			next = start
+		}
+		if next.Before(to) {
+			tail, err := r.rawHistory(routerID, interfaceName, next, to)
+			if err != nil {
+				return nil, step, resolution, err
+			}
+			points = append(points, tail...)
+		}
+	}
+
+	type accumulator struct {
+		samples          int
+		rxSum, txSum     float64
+		rxMax, txMax     float64
+		rxRates, txRates []float64
+		rxBytes, txBytes uint64
+	}
+	accs := make([]accumulator, count)
+	for _, point := range points {
+		idx := int(point.Timestamp.Sub(start) / step)
+		if idx < 0 || idx >= count {
+			continue
+		}
+		acc := &accs[idx]
+		acc.samples += point.Samples
+		acc.rxSum += point.RxRate * float64(point.Samples)
+		acc.txSum += point.TxRate * float64(point.Samples)
+		acc.rxMax = math.Max(acc.rxMax, point.RxRateMax)
+		acc.txMax = math.Max(acc.txMax, point.TxRateMax)
+		acc.rxRates = append(acc.rxRates, point.RxRate)
+		acc.txRates = append(acc.txRates, point.TxRate)
+		acc.rxBytes += point.RxBytes
+		acc.txBytes += point.TxBytes
+	}
+
+	var outages []models.RouterOutage
+	if err := r.db.Where("router_id = ? AND started_at < ? AND (ended_at IS NULL OR ended_at > ?)", routerID, to, start).
+		Find(&outages).Error; err != nil {
+		return nil, step, resolution, err
+	}
+
+	buckets := make([]TrafficBucket, count)
+	for i, acc := range accs {
+		bucketStart := start.Add(time.Duration(i) * step)
+		bucket := TrafficBucket{
+			Timestamp: bucketStart,
+			Samples:   acc.samples,
+			RxBytes:   acc.rxBytes,
+			TxBytes:   acc.txBytes,
+			Gap:       acc.samples == 0 && (bucketStart.After(now) || overlapsOutage(outages, bucketStart, bucketStart.Add(step), now)),
+		}
+		if acc.samples > 0 {
+			switch agg {
+			case AggMax:
+				bucket.Rx, bucket.Tx = acc.rxMax, acc.txMax
+			case AggP95:
+				bucket.Rx, bucket.Tx = percentile(acc.rxRates, 0.95), percentile(acc.txRates, 0.95)
+			case AggSum:
+				bucket.Rx, bucket.Tx = float64(acc.rxBytes), float64(acc.txBytes)
+			default:
+				bucket.Rx = acc.rxSum / float64(acc.samples)
+				bucket.Tx = acc.txSum / float64(acc.samples)
+			}
+		}
+		buckets[i] = bucket
+	}
+	return buckets, step, resolution, nil
+}
+
+// overlapsOutage reports whether the router was unreachable at some point between start
+// and end. Ongoing outages last until now.
+func overlapsOutage(outages []models.RouterOutage, start, end, now time.Time) bool {
+	for _, outage := range outages {
+		ended := now
+		if outage.EndedAt != nil {
+			ended = *outage.EndedAt
+		}
+		if outage.StartedAt.Before(end) && ended.After(start) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"monik-enterprise/internal/config"
+	"monik-enterprise/internal/models"
+)
+
+func TestSelectResolution(t *testing.T) {
+	const day = 24 * time.Hour
+	now := time.Date(2026, 7, 14, 12, 0, 0, 0, time.UTC)
+	defaults := config.RetentionConfig{Raw: 7 * day, Minute: 30 * day, Hour: 365 * day}
+
+	tests := []struct {
+		name      string
+		retention config.RetentionConfig
+		from, to  time.Time
+		want      string
+	}{
+		{name: "last hour", retention: defaults, from: now.Add(-time.Hour), to: now, want: ResolutionRaw},
+		{name: "last day", retention: defaults, from: now.Add(-day), to: now, want: Resolution1m},
+		{name: "an hour two weeks ago", retention: defaults, from: now.Add(-14 * day), to: now.Add(-14*day + time.Hour), want: Resolution1m},
+		{name: "last month", retention: defaults, from: now.Add(-30 * day), to: now, want: Resolution1h},
+		{name: "last two years", retention: defaults, from: now.Add(-730 * day), to: now, want: Resolution1d},
+		{
+			name:      "daily rollups purged before the start",
+			retention: config.RetentionConfig{Raw: 7 * day, Minute: 30 * day, Hour: 365 * day, Day: 180 * day},
+			from:      now.Add(-200 * day),
+			to:        now,
+			want:      Resolution1h,
+		},
+		{
+			name:      "nothing retained for the start",
+			retention: config.RetentionConfig{Raw: 7 * day, Minute: 30 * day, Hour: 90 * day, Day: 180 * day},
+			from:      now.Add(-400 * day),
+			to:        now,
+			want:      Resolution1d,
+		},
+		{
+			name:      "hourly rollups kept forever",
+			retention: config.RetentionConfig{Raw: 7 * day, Minute: 30 * day, Day: 180 * day},
+			from:      now.Add(-400 * day),
+			to:        now,
+			want:      Resolution1h,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRollupService(nil, tt.retention, config.SnapshotConfig{})
+			if got := r.SelectResolution(tt.from, tt.to, now); got != tt.want {
+				t.Errorf("SelectResolution = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolutionForStepRetention(t *testing.T) {
+	const day = 24 * time.Hour
+	now := time.Date(2026, 7, 14, 12, 0, 0, 0, time.UTC)
+	r := NewRollupService(nil, config.RetentionConfig{Raw: 7 * day, Minute: 30 * day, Hour: 365 * day, Day: 180 * day}, config.SnapshotConfig{})
+
+	resolution, step := r.resolutionForStep(now.Add(-200*day), now, 24*time.Hour)
+	if resolution != Resolution1h || step != 24*time.Hour {
+		t.Errorf("resolutionForStep = %s, %s, want 1h rollups in steps of 24h", resolution, step)
+	}
+	resolution, step = r.resolutionForStep(now.Add(-400*day), now, time.Minute)
+	if resolution != Resolution1h || step != time.Hour {
+		t.Errorf("resolutionForStep = %s, %s, want the longest kept 1h rollups in steps of 1h", resolution, step)
+	}
+}
+
+func TestBucketsGapsFromOutages(t *testing.T) {
+	db := openTestDB(t, &models.TrafficSnapshot{}, &models.TrafficRollup1m{}, &models.TrafficRollup1h{},
+		&models.TrafficRollup1d{}, &models.RouterOutage{})
+	r := NewRollupService(db, config.RetentionConfig{}, config.SnapshotConfig{Policy: SnapshotPolicyDelta})
+
+	const step = 5 * time.Minute
+	from := time.Now().UTC().Truncate(step).Add(-40 * time.Minute)
+	to := from.Add(50 * time.Minute)
+	// Samples in the first 10 minutes, an outage in the next 10 and an idle interface
+	// afterwards
+	for minute := 0; minute < 10; minute++ {
+		snapshot := models.TrafficSnapshot{
+			RouterID:      1,
+			InterfaceName: "ether1",
+			Timestamp:     from.Add(time.Duration(minute) * time.Minute),
+			RxBytes:       uint64(minute) * 1000,
+			RxRate:        8,
+		}
+		if err := db.Create(&snapshot).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	ended := from.Add(20 * time.Minute)
+	outages := []models.RouterOutage{
+		{RouterID: 1, StartedAt: from.Add(10 * time.Minute), EndedAt: &ended},
+		{RouterID: 2, StartedAt: from.Add(20 * time.Minute)}, // Another router
+	}
+	if err := db.Create(&outages).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	buckets, gotStep, _, err := r.Buckets(1, "ether1", from, to, step, AggAvg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The range ends on a step boundary, so no bucket starts at to
+	if gotStep != step || len(buckets) != 10 {
+		t.Fatalf("got %d buckets of %s, want 10 of %s", len(buckets), gotStep, step)
+	}
+	// Bucket 8 holds now, 9 has not been collected yet
+	wantGaps := []bool{false, false, true, true, false, false, false, false, false, true}
+	for i, bucket := range buckets {
+		if bucket.Gap != wantGaps[i] {
+			t.Errorf("bucket %d at %s: gap = %v, want %v", i, bucket.Timestamp.Format("15:04"), bucket.Gap, wantGaps[i])
+		}
+	}
+	if buckets[0].Rx != 8 || buckets[0].Samples != 5 {
+		t.Errorf("first bucket = %+v, want 5 samples at 8 Mbps", buckets[0])
+	}
+	if idle := buckets[5]; idle.Rx != 0 || idle.Samples != 0 {
+		t.Errorf("idle bucket = %+v, want zero traffic", idle)
+	}
+}
+
+func TestBucketsCount(t *testing.T) {
+	db := openTestDB(t, &models.TrafficSnapshot{}, &models.TrafficRollup1m{}, &models.TrafficRollup1h{},
+		&models.TrafficRollup1d{}, &models.RouterOutage{})
+	r := NewRollupService(db, config.RetentionConfig{}, config.SnapshotConfig{})
+
+	const step = 5 * time.Minute
+	from := time.Now().UTC().Truncate(step).Add(-time.Hour)
+	tests := []struct {
+		name string
+		to   time.Time
+		want int
+	}{
+		{name: "ends on a boundary", to: from.Add(time.Hour), want: 12},
+		{name: "ends inside a bucket", to: from.Add(time.Hour + time.Minute), want: 13},
+		{name: "empty range", to: from, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buckets, _, _, err := r.Buckets(1, "ether1", from, tt.to, step, AggAvg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(buckets) != tt.want {
+				t.Fatalf("got %d buckets, want %d", len(buckets), tt.want)
+			}
+			if n := len(buckets); n > 0 && !buckets[n-1].Timestamp.Before(tt.to) {
+				t.Errorf("last bucket starts at %s, not before the end of the range %s", buckets[n-1].Timestamp, tt.to)
+			}
+		})
+	}
+}
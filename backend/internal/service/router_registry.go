@@ -0,0 +1,256 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"monik-enterprise/internal/config"
+	"monik-enterprise/internal/database"
+	"monik-enterprise/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Router status values stored in models.Router.Status
+const (
+	RouterStatusOnline  = "online"
+	RouterStatusOffline = "offline"
+	RouterStatusUnknown = "unknown"
+)
+
+// DefaultRouterName is the registry name of the router seeded from ROUTER_* settings
+const DefaultRouterName = database.DefaultRouterName
+
+// RouterRegistry keeps the fleet of monitored routers and one collector per router
+type RouterRegistry struct {
+	db         *gorm.DB
+	defaults   config.RouterConfig
+	mu         sync.RWMutex
+	collectors map[uint]RouterCollector
+	defaultID  uint
+	logger     *LoggerService // Handed to every collector the registry creates
+}
+
+// NewRouterRegistry creates a new router registry backed by the routers table
+func NewRouterRegistry(db *gorm.DB, defaults config.RouterConfig) *RouterRegistry {
+	return &RouterRegistry{
+		db:         db,
+		defaults:   defaults,
+		collectors: make(map[uint]RouterCollector),
+	}
+}
+
+// SetLogger sets the structured logger of the registry and the collectors it creates
+// from now on
+func (r *RouterRegistry) SetLogger(logger *LoggerService) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logger = logger
+}
+
+// EnsureDefaultRouter seeds the registry from the single-router configuration when it is
+// empty. Rows written before routers were registered are adopted by a data migration.
+func (r *RouterRegistry) EnsureDefaultRouter() (*models.Router, error) {
+	var router models.Router
+	err := r.db.Order("id ASC").First(&router).Error
+	if err == gorm.ErrRecordNotFound {
+		router = database.NewDefaultRouter(r.defaults)
+		if err := r.db.Create(&router).Error; err != nil {
+			return nil, fmt.Errorf("failed to seed default router: %w", err)
+		}
+		r.logger.Info(ComponentMonitoring, "registry", "Seeded default router", map[string]interface{}{
+			"router": router.Name,
+			"host":   router.Host,
+			"port":   router.Port,
+		})
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load routers: %w", err)
+	}
+
+	r.mu.Lock()
+	r.defaultID = router.ID
+	r.mu.Unlock()
+	return &router, nil
+}
+
+// DefaultRouterID returns the ID of the router used when a request does not select one
+func (r *RouterRegistry) DefaultRouterID() uint {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.defaultID
+}
+
+// List returns all registered routers
+func (r *RouterRegistry) List() ([]models.Router, error) {
+	var routers []models.Router
+	err := r.db.Order("id ASC").Find(&routers).Error
+	return routers, err
+}
+
+// ListEnabled returns the routers that should be polled
+func (r *RouterRegistry) ListEnabled() ([]models.Router, error) {
+	var routers []models.Router
+	err := r.db.Where("enabled = ?", true).Order("id ASC").Find(&routers).Error
+	return routers, err
+}
+
+// Get returns a router by ID
+func (r *RouterRegistry) Get(id uint) (*models.Router, error) {
+	var router models.Router
+	if err := r.db.First(&router, id).Error; err != nil {
+		return nil, err
+	}
+	return &router, nil
+}
+
+// GetByName returns a router by its registry name
+func (r *RouterRegistry) GetByName(name string) (*models.Router, error) {
+	var router models.Router
+	if err := r.db.Where("name = ?", name).First(&router).Error; err != nil {
+		return nil, err
+	}
+	return &router, nil
+}
+
+// Create registers a new router, filling unset connection settings from the defaults
+func (r *RouterRegistry) Create(router *models.Router) error {
+	if router.Name == "" || router.Host == "" {
+		return fmt.Errorf("router name and host are required")
+	}
+	if router.Backend == "" {
+		router.Backend = CollectorBackendAPI
+	}
+	if !IsValidCollectorBackend(router.Backend) {
+		return fmt.Errorf("unknown router backend: %s", router.Backend)
+	}
+	if router.Port == 0 {
+		router.Port = config.DefaultRouterPort(router.Backend, router.TLSEnabled)
+	}
+	if router.Timeout == 0 {
+		router.Timeout = r.defaults.Timeout
+	}
+	if router.Backend == CollectorBackendSNMP && router.SNMPVersion == "" {
+		router.SNMPVersion = "v2c"
+	}
+	router.Status = RouterStatusUnknown
+	return r.db.Create(router).Error
+}
+
+// Update applies changes to a router and drops its cached connection so the next
+// poll dials with the new settings
+func (r *RouterRegistry) Update(id uint, updates map[string]interface{}) (*models.Router, error) {
+	router, err := r.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(router).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	r.closeCollector(id)
+	return r.Get(id)
+}
+
+// Delete removes a router from the registry and closes its connection
+func (r *RouterRegistry) Delete(id uint) error {
+	if id == r.DefaultRouterID() {
+		return fmt.Errorf("the default router cannot be deleted")
+	}
+	res := r.db.Delete(&models.Router{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	r.closeCollector(id)
+	return nil
+}
+
+// Collector returns the collector dedicated to a router, creating it on first use
+func (r *RouterRegistry) Collector(router models.Router) (RouterCollector, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if collector, ok := r.collectors[router.ID]; ok {
+		return collector, nil
+	}
+	collector, err := NewRouterCollector(r.routerConfig(router), r.logger)
+	if err != nil {
+		return nil, err
+	}
+	r.collectors[router.ID] = collector
+	return collector, nil
+}
+
+// RecordStatus stores the reachability of a router after a poll
+func (r *RouterRegistry) RecordStatus(id uint, pollErr error) {
+	updates := map[string]interface{}{
+		"status":     RouterStatusOnline,
+		"last_error": "",
+	}
+	if pollErr != nil {
+		updates["status"] = RouterStatusOffline
+		updates["last_error"] = pollErr.Error()
+	} else {
+		updates["last_seen"] = time.Now()
+	}
+	if err := r.db.Model(&models.Router{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		r.logger.Error(ComponentMonitoring, "registry", "Failed to record router status", err, map[string]interface{}{
+			"router_id": id,
+		})
+	}
+}
+
+// Close closes every router connection held by the registry
+func (r *RouterRegistry) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, collector := range r.collectors {
+		collector.Close()
+		delete(r.collectors, id)
+	}
+}
+
+// closeCollector closes and forgets the cached collector of a router
+func (r *RouterRegistry) closeCollector(id uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if collector, ok := r.collectors[id]; ok {
+		collector.Close()
+		delete(r.collectors, id)
+	}
+}
+
+// routerConfig converts a registry entry into the connection settings of its collector
+func (r *RouterRegistry) routerConfig(router models.Router) config.RouterConfig {
+	cfg := r.defaults
+	cfg.IP = router.Host
+	if router.Port != 0 {
+		cfg.Port = router.Port
+	}
+	cfg.Username = router.Username
+	cfg.Password = router.Password
+	if router.Timeout != 0 {
+		cfg.Timeout = router.Timeout
+	}
+	cfg.Backend = router.Backend
+	cfg.TLS = config.RouterTLSConfig{
+		Enabled:            router.TLSEnabled,
+		CAFile:             router.TLSCAFile,
+		CertFile:           router.TLSCertFile,
+		KeyFile:            router.TLSKeyFile,
+		ServerName:         router.TLSServerName,
+		InsecureSkipVerify: router.TLSInsecureSkipVerify,
+	}
+	cfg.SNMP = config.RouterSNMPConfig{
+		Version:      router.SNMPVersion,
+		Community:    router.SNMPCommunity,
+		Username:     router.SNMPUsername,
+		AuthProtocol: router.SNMPAuthProtocol,
+		AuthPassword: router.SNMPAuthPassword,
+		PrivProtocol: router.SNMPPrivProtocol,
+		PrivPassword: router.SNMPPrivPassword,
+	}
+	return cfg
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"monik-enterprise/internal/config"
+
+	"github.com/go-routeros/routeros/v3"
+)
+
+// Connection error kinds reported in logs, poll state and /api/v1/health
+const (
+	ConnErrorTLSConfig       = "tls_config"       // CA bundle or client certificate could not be loaded
+	ConnErrorTLSVerification = "tls_verification" // router certificate rejected (unknown CA, hostname, expiry)
+	ConnErrorTLSHandshake    = "tls_handshake"    // handshake failed, e.g. plaintext port or protocol mismatch
+	ConnErrorAuth            = "auth"             // router rejected the credentials
+	ConnErrorNetwork         = "network"          // dial failed or connection dropped
+)
+
+// RouterConnectError describes why a connection to a router could not be established
+type RouterConnectError struct {
+	Kind    string
+	Address string
+	Err     error
+}
+
+func (e *RouterConnectError) Error() string {
+	return fmt.Sprintf("%s error connecting to %s: %v", e.Kind, e.Address, e.Err)
+}
+
+func (e *RouterConnectError) Unwrap() error {
+	return e.Err
+}
+
+// ConnectErrorKind returns the connection error kind carried by err, or "" if it has none
+func ConnectErrorKind(err error) string {
+	var connErr *RouterConnectError
+	if errors.As(err, &connErr) {
+		return connErr.Kind
+	}
+	return ""
+}
+
+// IsTLSErrorKind reports whether a connection error kind is TLS related
+func IsTLSErrorKind(kind string) bool {
+	return kind == ConnErrorTLSConfig || kind == ConnErrorTLSVerification || kind == ConnErrorTLSHandshake
+}
+
+// buildTLSConfig builds the crypto/tls configuration for a RouterOS API-SSL connection
+func buildTLSConfig(cfg config.RouterConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.TLS.ServerName,
+		InsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
+	}
+	if tlsConfig.ServerName == "" && net.ParseIP(cfg.IP) == nil {
+		tlsConfig.ServerName = cfg.IP
+	}
+
+	if cfg.TLS.CAFile != "" {
+		pem, err := os.ReadFile(cfg.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle %s: %w", cfg.TLS.CAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", cfg.TLS.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.TLS.CertFile != "" || cfg.TLS.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// classifyConnectError wraps a dial/login error with the kind of failure it represents
+func classifyConnectError(address string, useTLS bool, err error) *RouterConnectError {
+	kind := ConnErrorNetwork
+
+	var verifyErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var deviceErr *routeros.DeviceError
+
+	switch {
+	case errors.As(err, &verifyErr), errors.As(err, &unknownAuthority),
+		errors.As(err, &hostnameErr), errors.As(err, &invalidCert):
+		kind = ConnErrorTLSVerification
+	case errors.As(err, &recordErr), errors.As(err, &alertErr):
+		kind = ConnErrorTLSHandshake
+	case errors.As(err, &deviceErr):
+		kind = ConnErrorAuth
+	case useTLS && (errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)):
+		// The peer hung up during the handshake, typically a plaintext API port
+		kind = ConnErrorTLSHandshake
+	}
+
+	return &RouterConnectError{Kind: kind, Address: address, Err: err}
+}
@@ -3,108 +3,347 @@ package service
 import (
 	"context"
 	"fmt"
+	"math/rand"
+	"monik-enterprise/internal/config"
+	"monik-enterprise/internal/database"
 	"monik-enterprise/internal/models"
 	"monik-enterprise/internal/websocket"
 	"regexp"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 // --- MONITORING SERVICE SECTION ---
 
-var dbMutex sync.Mutex
+// schedulerResolution is how often the monitoring loop checks for routers that are due
+const schedulerResolution = time.Second
 
 type MonitoringService struct {
 	db               *gorm.DB
-	routerSvc        *MikroTikService
+	pollConfig       config.PollingConfig
+	snapshots        *snapshotPolicy
+	registry         *RouterRegistry
+	billing          *BillingService
+	quotas           *QuotaService
+	alerts           *AlertService
+	maint            *MaintenanceService
+	outages          *OutageService
 	wanService       *WANDetectionService
 	websocketManager *websocket.WebSocketManager
+	logger           *LoggerService
+	metrics          *MetricsService
 	isRunning        bool
 	stopChan         chan struct{}
 	wg               sync.WaitGroup
+	stateMu          sync.RWMutex
+	routerStates     map[uint]*RouterPollState
+	streamMu         sync.Mutex
+	streams          map[uint]*routerStream
+	interfaceLocks   interfaceLocks
 }
 
-func NewMonitoringService(db *gorm.DB, routerSvc *MikroTikService, wanService *WANDetectionService, wsManager *websocket.WebSocketManager) *MonitoringService {
+// interfaceLocks serializes the reads and writes of the stored state of each router
+// interface. Readings of different interfaces and routers are stored concurrently.
+type interfaceLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex // snapshotKey -> lock
+}
+
+// lock locks one router interface and returns the function unlocking it
+func (l *interfaceLocks) lock(routerID uint, name string) func() {
+	key := snapshotKey(routerID, name)
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*sync.Mutex)
+	}
+	m, ok := l.locks[key]
+	if !ok {
+		m = &sync.Mutex{}
+		l.locks[key] = m
+	}
+	l.mu.Unlock()
+
+	m.Lock()
+	return m.Unlock
+}
+
+// RouterPollState tracks the connection and retry state of a single router
+type RouterPollState struct {
+	RouterID            uint      `json:"router_id"`
+	Online              bool      `json:"online"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastAttempt         time.Time `json:"last_attempt"`
+	LastSuccess         time.Time `json:"last_success"`
+	LastError           string    `json:"last_error,omitempty"`
+	ErrorKind           string    `json:"error_kind,omitempty"` // see ConnError* constants
+	TLS                 bool      `json:"tls"`
+
+	// Scheduling
+	Interval     time.Duration `json:"interval"` // Current interval, grows while the router is unreachable
+	NextPoll     time.Time     `json:"next_poll"`
+	InFlight     bool          `json:"in_flight"`
+	SkippedPolls int64         `json:"skipped_polls"` // Polls skipped because the previous one was still running
+	Streaming    bool          `json:"streaming"`     // Counters arrive over a /interface/print subscription
+}
+
+func NewMonitoringService(db *gorm.DB, pollConfig config.PollingConfig, snapshotConfig config.SnapshotConfig, registry *RouterRegistry, billing *BillingService, quotas *QuotaService, alerts *AlertService, maint *MaintenanceService, outages *OutageService, wanService *WANDetectionService, wsManager *websocket.WebSocketManager) *MonitoringService {
+	// Guard against settings that would stall or spin the scheduler
+	if pollConfig.Interval < schedulerResolution {
+		pollConfig.Interval = schedulerResolution
+	}
+	if pollConfig.Timeout <= 0 {
+		pollConfig.Timeout = 15 * time.Second
+	}
+	if pollConfig.Retries < 1 {
+		pollConfig.Retries = 1
+	}
+	if pollConfig.Jitter < 0 {
+		pollConfig.Jitter = 0
+	} else if pollConfig.Jitter > 1 {
+		pollConfig.Jitter = 1
+	}
+	if pollConfig.MaxInterval < pollConfig.Interval {
+		pollConfig.MaxInterval = pollConfig.Interval
+	}
+	if pollConfig.BackoffMultiplier < 1 {
+		pollConfig.BackoffMultiplier = 1
+	}
+	if pollConfig.Mode != PollModeStream {
+		pollConfig.Mode = PollModePoll
+	}
+
 	return &MonitoringService{
 		db:               db,
-		routerSvc:        routerSvc,
+		pollConfig:       pollConfig,
+		snapshots:        newSnapshotPolicy(snapshotConfig),
+		registry:         registry,
+		billing:          billing,
+		quotas:           quotas,
+		alerts:           alerts,
+		maint:            maint,
+		outages:          outages,
 		wanService:       wanService,
 		websocketManager: wsManager,
 		stopChan:         make(chan struct{}),
+		routerStates:     make(map[uint]*RouterPollState),
+		streams:          make(map[uint]*routerStream),
 	}
 }
 
+// SetLogger sets the structured logger of the monitoring loop
+func (s *MonitoringService) SetLogger(logger *LoggerService) {
+	s.logger = logger
+}
+
+// SetMetrics sets the metrics service fed with router round trips and database write
+// latencies
+func (s *MonitoringService) SetMetrics(metrics *MetricsService) {
+	s.metrics = metrics
+}
+
+// Registry returns the router registry polled by this service
+func (s *MonitoringService) Registry() *RouterRegistry {
+	return s.registry
+}
+
 func (s *MonitoringService) Start() {
 	if s.isRunning {
-		fmt.Printf("[MONITORING] Service already running\n")
+		s.logger.Warn(ComponentMonitoring, "start", "Monitoring service already running", nil)
 		return
 	}
-	fmt.Printf("[MONITORING] Starting monitoring service...\n")
 	s.isRunning = true
 	s.wg.Add(1)
 	go s.monitoringLoop()
-	fmt.Printf("[MONITORING] Monitoring service started successfully\n")
+	s.logger.Info(ComponentMonitoring, "start", "Monitoring service started", nil)
 }
 
+// monitoringLoop checks every schedulerResolution which routers are due and starts their
+// collection. Each router runs on its own schedule so a slow router never delays the others.
 func (s *MonitoringService) monitoringLoop() {
 	defer s.wg.Done()
-	fmt.Printf("[MONITORING] Monitoring loop started - collecting data every 10 seconds\n")
-	ticker := time.NewTicker(10 * time.Second)
+	s.logger.Info(ComponentMonitoring, "loop", "Monitoring loop started", map[string]interface{}{
+		"mode":     s.pollConfig.Mode,
+		"interval": s.pollConfig.Interval.String(),
+		"timeout":  s.pollConfig.Timeout.String(),
+		"jitter":   s.pollConfig.Jitter,
+		"adaptive": s.pollConfig.Adaptive,
+	})
+	if s.snapshots.unknownPolicy != "" {
+		s.logger.Warn(ComponentMonitoring, "loop", "Unknown snapshot policy, using "+SnapshotPolicyInterval, map[string]interface{}{
+			"policy": s.snapshots.unknownPolicy,
+		})
+	}
+	ticker := time.NewTicker(schedulerResolution)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-s.stopChan:
-			fmt.Printf("[MONITORING] Stop signal received, exiting loop\n")
+			s.logger.Info(ComponentMonitoring, "loop", "Stop signal received, exiting loop", nil)
+			s.stopStreams(nil)
 			return
-		case <-ticker.C:
-			fmt.Printf("[MONITORING] ===== TICK RECEIVED at %s =====\n", time.Now().Format("15:04:05"))
-			s.collectData()
-			fmt.Printf("[MONITORING] ===== DATA COLLECTION COMPLETE =====\n")
+		case now := <-ticker.C:
+			s.collectDue(now)
+		}
+	}
+}
+
+// collectDue starts a collection for every enabled router whose next poll time has passed
+func (s *MonitoringService) collectDue(now time.Time) {
+	routers, err := s.registry.ListEnabled()
+	if err != nil {
+		s.logger.Error(ComponentMonitoring, "schedule", "Failed to load router registry", err, nil)
+		return
+	}
+
+	streamed := make(map[uint]bool)
+	for _, router := range routers {
+		if s.pollConfig.Mode == PollModeStream && s.ensureStream(router) {
+			streamed[router.ID] = true
+			continue
+		}
+		if !s.claimPoll(router, now) {
+			continue
 		}
+		router := router
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.collectRouter(router)
+		}()
 	}
+	// Disabled or deleted routers lose their subscription
+	s.stopStreams(streamed)
 }
 
-func (s *MonitoringService) collectData() {
-	fmt.Printf("[DEBUG] === COLLECT DATA STARTED at %s ===\n", time.Now().Format("15:04:05"))
+// baseInterval returns the configured poll interval of a router
+func (s *MonitoringService) baseInterval(router models.Router) time.Duration {
+	if router.PollInterval > 0 {
+		return router.PollInterval
+	}
+	return s.pollConfig.Interval
+}
+
+// jitter returns a random delay of up to Jitter * interval, spreading polls of many
+// routers so they do not all hit the database at the same moment
+func (s *MonitoringService) jitter(interval time.Duration) time.Duration {
+	max := int64(float64(interval) * s.pollConfig.Jitter)
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(max))
+}
+
+// claimPoll reports whether a router is due and marks it in flight. A router whose
+// previous collection is still running is skipped so collections never overlap.
+func (s *MonitoringService) claimPoll(router models.Router, now time.Time) bool {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	state, ok := s.routerStates[router.ID]
+	if !ok {
+		// First sighting: spread the initial polls over the jitter window
+		interval := s.baseInterval(router)
+		state = &RouterPollState{
+			RouterID: router.ID,
+			Interval: interval,
+			NextPoll: now.Add(s.jitter(interval)),
+		}
+		s.routerStates[router.ID] = state
+	}
+
+	if now.Before(state.NextPoll) {
+		return false
+	}
+	if state.InFlight {
+		state.SkippedPolls++
+		state.NextPoll = now.Add(state.Interval)
+		s.logger.Warn(ComponentMonitoring, "schedule", "Previous poll still running, skipping", map[string]interface{}{
+			"router":  router.Name,
+			"skipped": state.SkippedPolls,
+		})
+		return false
+	}
+	state.InFlight = true
+	state.LastAttempt = now
+	// Provisional schedule; a poll still running by then counts as skipped
+	state.NextPoll = now.Add(state.Interval)
+	return true
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+// collectRouter collects interface data from a single router
+func (s *MonitoringService) collectRouter(router models.Router) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.pollConfig.Timeout)
 	defer cancel()
+	ctx, span := startSpan(ctx, "monitoring.collect_router", routerSpanAttributes(router)...)
+	var err error
+	defer func() { endSpan(span, err) }()
+	started := time.Now()
+	logFields := func(fields map[string]interface{}) map[string]interface{} {
+		fields["router"] = router.Name
+		fields["router_id"] = router.ID
+		return fields
+	}
+	s.logger.Debug(ComponentMonitoring, "poll", "Poll started", logFields(map[string]interface{}{}))
+
+	routerSvc, err := s.registry.Collector(router)
+	if err != nil {
+		s.logger.Error(ComponentMonitoring, "poll", "Invalid collector configuration", err, logFields(map[string]interface{}{}))
+		s.recordPollResult(router, err)
+		s.registry.RecordStatus(router.ID, err)
+		return
+	}
 
 	// Priority 2 Fix: Implement Retry Logic & Anti-Early-Return
 	var interfaces []InterfaceData
-	var err error
 
-	fmt.Printf("[DEBUG] Attempting to get interfaces from router...\n")
-
-	// Retry up to 3 times when router is unreachable
-	for attempt := 1; attempt <= 3; attempt++ {
-		fmt.Printf("[DEBUG] Attempt %d: Getting interfaces from router\n", attempt)
-		interfaces, err = s.routerSvc.GetInterfaces(ctx)
+	// Retry when router is unreachable, bounded by the poll timeout
+	for attempt := 1; attempt <= s.pollConfig.Retries; attempt++ {
+		requestStarted := time.Now()
+		interfaces, err = routerSvc.GetInterfaces(ctx)
+		s.metrics.RecordRouterPoll(time.Since(requestStarted), err)
 		if err == nil {
-			fmt.Printf("[INFO] Router GMG-SITE connected successfully (attempt %d) - got %d interfaces\n", attempt, len(interfaces))
+			s.logger.Debug(ComponentMonitoring, "poll", "Router connected", logFields(map[string]interface{}{
+				"attempt":    attempt,
+				"interfaces": len(interfaces),
+			}))
+			break
+		}
+		if attempt == s.pollConfig.Retries {
+			break
+		}
+		s.logger.Warn(ComponentMonitoring, "poll", "Router unreachable, retrying", logFields(map[string]interface{}{
+			"attempt":     attempt,
+			"retry_delay": s.pollConfig.RetryDelay.String(),
+			"error":       err.Error(),
+		}))
+		if !s.sleepContext(ctx, s.pollConfig.RetryDelay) {
 			break
 		}
-		fmt.Printf("[RETRY %d] Router unreachable, waiting 2s... Error: %v\n", attempt, err)
-		time.Sleep(2 * time.Second)
 	}
 
 	// Critical Fix: JANGAN RETURN! Continue flow even when router is offline
+	s.recordPollResult(router, err)
+	s.registry.RecordStatus(router.ID, err)
 	if err != nil {
-		fmt.Printf("[CRITICAL] Router GMG-SITE is OFFLINE after retries: %v\n", err)
-		fmt.Printf("[INFO] Recording offline status in database...\n")
+		if s.maint.InMaintenance(router.ID, "", time.Now()) {
+			s.logger.Info(ComponentMonitoring, "poll", "Router offline during a maintenance window", logFields(map[string]interface{}{
+				"error": err.Error(),
+			}))
+		} else {
+			s.logger.Error(ComponentMonitoring, "poll", "Router offline after retries", err, logFields(map[string]interface{}{
+				"attempts": s.pollConfig.Retries,
+			}))
+		}
 
 		// Update all known interfaces as offline in database
-		s.RecordOfflineStatus()
-		fmt.Printf("[DEBUG] === COLLECT DATA COMPLETE (OFFLINE PATH) ===\n")
+		s.RecordOfflineStatus(router.ID)
 		return
 	}
 
-	fmt.Printf("[DEBUG] Router connected successfully, processing %d interfaces\n", len(interfaces))
-
 	trafficMap := make(map[string]*InterfaceData)
 	var mu sync.Mutex
 	g, gctx := errgroup.WithContext(ctx)
@@ -112,127 +351,374 @@ func (s *MonitoringService) collectData() {
 	for _, iface := range interfaces {
 		iface := iface
 		g.Go(func() error {
-			traffic, err := s.routerSvc.GetTrafficStats(gctx, iface.Name)
+			traffic, err := routerSvc.GetTrafficStats(gctx, iface.Name)
 			if err == nil {
 				mu.Lock()
 				trafficMap[iface.Name] = traffic
 				mu.Unlock()
-				fmt.Printf("[DEBUG] Got traffic stats for %s: RxRate=%.2f, TxRate=%.2f\n", iface.Name, traffic.RxRate, traffic.TxRate)
 			} else {
-				fmt.Printf("[WARN] Failed to get traffic stats for %s: %v\n", iface.Name, err)
+				s.logger.Warn(ComponentMonitoring, "traffic", "Failed to get traffic stats", logFields(map[string]interface{}{
+					"interface": iface.Name,
+					"error":     err.Error(),
+				}))
 			}
 			return nil
 		})
 	}
 	g.Wait()
 
-	fmt.Printf("[DEBUG] Saving interface data for %d interfaces\n", len(interfaces))
-	for _, iface := range interfaces {
+	for i, iface := range interfaces {
 		if t, ok := trafficMap[iface.Name]; ok {
 			iface.RxRate = t.RxRate
 			iface.TxRate = t.TxRate
-			fmt.Printf("[DEBUG] Updated rates for %s: RxRate=%.2f, TxRate=%.2f\n", iface.Name, iface.RxRate, iface.TxRate)
 		} else {
 			// Set rates to 0 if traffic stats failed
 			iface.RxRate = 0
 			iface.TxRate = 0
-			fmt.Printf("[DEBUG] No traffic stats for %s, setting rates to 0\n", iface.Name)
 		}
-		s.saveInterfaceData(iface)
+		interfaces[i] = iface
+	}
+	s.processInterfaces(ctx, router, interfaces)
+	s.logger.LogPerformance(ComponentMonitoring, "poll", time.Since(started), logFields(map[string]interface{}{
+		"interfaces": len(interfaces),
+		"rates":      len(trafficMap),
+	}))
+}
+
+// processInterfaces stores and broadcasts a round of interface readings, whether they
+// were polled or streamed. ctx only carries the trace, storing is not cut short when the
+// poll deadline passes.
+func (s *MonitoringService) processInterfaces(ctx context.Context, router models.Router, interfaces []InterfaceData) {
+	ctx, span := startSpan(context.WithoutCancel(ctx), "monitoring.process_interfaces",
+		append(routerSpanAttributes(router), attribute.Int("monik.interfaces", len(interfaces)))...)
+	defer span.End()
+
+	classifier := newResetClassifier(router, s.registry, s.pollConfig.Timeout, s.logger)
+	resets := make(map[string]bool)
+	rebooted := false
+	for _, iface := range interfaces {
+		eventType := websocket.EventTypeTraffic
+		if reset := s.saveInterfaceData(ctx, router.ID, iface, classifier); reset.restarted() {
+			resets[iface.Name] = true
+			eventType = websocket.EventTypeReset
+			rebooted = rebooted || reset.Kind == ResetKindReboot
+		}
+		_, broadcastSpan := startSpan(ctx, "websocket.broadcast", attribute.String("monik.interface", iface.Name))
+		s.broadcastInterface(router.ID, iface, eventType)
+		broadcastSpan.End()
 	}
-	fmt.Printf("[DEBUG] === COLLECT DATA COMPLETE (ONLINE PATH) ===\n")
+	if rebooted && s.websocketManager != nil {
+		s.websocketManager.BroadcastEvent(websocket.EventTypeReboot, fmt.Sprintf("Router %s rebooted", router.Name), map[string]interface{}{
+			"router_id": router.ID,
+		})
+	}
+
+	_, alertSpan := startSpan(ctx, "alerts.observe_interfaces")
+	s.alerts.ObserveInterfaces(router, interfaces, resets)
+	alertSpan.End()
 }
 
-func (s *MonitoringService) saveInterfaceData(iface InterfaceData) {
-	dbMutex.Lock()
-	defer dbMutex.Unlock()
+// sleepContext waits for d and returns false when the context ends or the service stops first
+func (s *MonitoringService) sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-s.stopChan:
+		return false
+	}
+}
 
-	fmt.Printf("[DEBUG] saveInterfaceData called for %s: Rx=%d, Tx=%d\n", iface.Name, iface.RxBytes, iface.TxBytes)
+// recordPollResult updates the retry state kept for a router, releases its in-flight
+// mark and schedules the next poll. With adaptive polling the interval is multiplied
+// on every failure up to MaxInterval and drops back to the base interval on recovery.
+func (s *MonitoringService) recordPollResult(router models.Router, err error) {
+	s.alerts.ObserveRouter(router, err)
+	s.recordOutage(router, err)
 
-	var existing models.Interface
-	res := s.db.Where("interface_name = ?", iface.Name).First(&existing)
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
 
-	isReset := false
-	if res.Error == nil && (iface.RxBytes < existing.RxBytes || iface.TxBytes < existing.TxBytes) {
-		isReset = true
-		fmt.Printf("[WARN] Reset detected on %s at %s\n", iface.Name, time.Now().Format("15:04:05"))
-		fmt.Printf("[DEBUG] Reset Details: New Rx=%d < Old Rx=%d OR New Tx=%d < Old Tx=%d\n", iface.RxBytes, existing.RxBytes, iface.TxBytes, existing.TxBytes)
+	state, ok := s.routerStates[router.ID]
+	if !ok {
+		state = &RouterPollState{RouterID: router.ID}
+		s.routerStates[router.ID] = state
+	}
+	base := s.baseInterval(router)
+	if state.Interval == 0 {
+		state.Interval = base
+	}
+	finished := time.Now()
+	if !state.InFlight {
+		// Not started by claimPoll (streamed or invalid configuration)
+		state.LastAttempt = finished
+	}
+	state.TLS = router.TLSEnabled
+	state.InFlight = false
+
+	if err != nil {
+		state.Online = false
+		state.ConsecutiveFailures++
+		state.LastError = err.Error()
+		state.ErrorKind = ConnectErrorKind(err)
+		if s.pollConfig.Adaptive && state.ConsecutiveFailures > 1 {
+			next := time.Duration(float64(state.Interval) * s.pollConfig.BackoffMultiplier)
+			if next > s.pollConfig.MaxInterval {
+				next = s.pollConfig.MaxInterval
+			}
+			if next > state.Interval {
+				s.logger.Warn(ComponentMonitoring, "schedule", "Router unreachable, slowing polling", map[string]interface{}{
+					"router":   router.Name,
+					"failures": state.ConsecutiveFailures,
+					"interval": next.String(),
+				})
+				state.Interval = next
+			}
+		}
 	} else {
-		fmt.Printf("[DEBUG] No reset detected for %s. isReset=%v\n", iface.Name, isReset)
-		if res.Error == nil {
-			fmt.Printf("[DEBUG] Comparison: New Rx=%d vs Old Rx=%d, New Tx=%d vs Old Tx=%d\n", iface.RxBytes, existing.RxBytes, iface.TxBytes, existing.TxBytes)
-		} else {
-			fmt.Printf("[DEBUG] No existing record found for %s\n", iface.Name)
+		if state.Interval != base {
+			s.logger.Info(ComponentMonitoring, "schedule", "Router recovered, restoring poll interval", map[string]interface{}{
+				"router":   router.Name,
+				"interval": base.String(),
+			})
 		}
+		state.Online = true
+		state.ConsecutiveFailures = 0
+		state.LastSuccess = finished
+		state.LastError = ""
+		state.ErrorKind = ""
+		state.Interval = base
 	}
 
-	s.db.Clauses(clause.OnConflict{
-		Columns: []clause.Column{{Name: "interface_name"}},
-		DoUpdates: clause.Assignments(map[string]interface{}{
-			"rx_bytes": iface.RxBytes, "tx_bytes": iface.TxBytes,
-			"rx_rate": iface.RxRate, "tx_rate": iface.TxRate,
-			"last_seen": time.Now(),
-		}),
-	}).Create(&models.Interface{
+	// Keep the cadence anchored to the start of the poll, unless the poll overran it
+	next := state.LastAttempt.Add(state.Interval + s.jitter(state.Interval))
+	if next.Before(finished) {
+		next = finished.Add(s.jitter(state.Interval))
+	}
+	state.NextPoll = next
+}
+
+// recordOutage opens or extends the outage of a router after a failed poll and ends it
+// after a successful one
+func (s *MonitoringService) recordOutage(router models.Router, err error) {
+	now := time.Now()
+	if err == nil {
+		s.outages.RecordSuccess(router, now)
+		return
+	}
+
+	// The outage starts with the failed poll, not when its retries ran out
+	started := now
+	s.stateMu.RLock()
+	if state, ok := s.routerStates[router.ID]; ok && state.InFlight {
+		started = state.LastAttempt
+	}
+	s.stateMu.RUnlock()
+	s.outages.RecordFailure(router, err, started, s.maint.InMaintenance(router.ID, "", started))
+}
+
+// GetRouterStates returns a copy of the poll state of every router
+func (s *MonitoringService) GetRouterStates() map[uint]RouterPollState {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+
+	states := make(map[uint]RouterPollState, len(s.routerStates))
+	for id, state := range s.routerStates {
+		states[id] = *state
+	}
+	return states
+}
+
+// broadcastInterface pushes the latest interface reading to WebSocket subscribers
+func (s *MonitoringService) broadcastInterface(routerID uint, iface InterfaceData, eventType string) {
+	if s.websocketManager == nil {
+		return
+	}
+	s.websocketManager.BroadcastData(websocket.RealTimeData{
+		RouterID:      routerID,
 		InterfaceName: iface.Name,
+		RxRate:        iface.RxRate,
+		TxRate:        iface.TxRate,
+		RxBytes:       iface.RxBytes,
+		TxBytes:       iface.TxBytes,
+		Status:        iface.Status,
+		Comment:       iface.Comment,
+		Timestamp:     time.Now(),
+		EventType:     eventType,
+	})
+}
+
+// saveInterfaceData stores a reading of an interface and returns the counter reset it
+// detected, nil when the counters moved forward normally
+func (s *MonitoringService) saveInterfaceData(ctx context.Context, routerID uint, iface InterfaceData, classifier *resetClassifier) *counterReset {
+	ctx, span := startSpan(ctx, "monitoring.save_interface", attribute.String("monik.interface", iface.Name))
+	defer span.End()
+	db := s.db.WithContext(ctx)
+
+	// Held from reading the previous counters until the usage they add up to is stored.
+	// Classifying may ask the router for its uptime, which only delays this interface.
+	unlock := s.interfaceLocks.lock(routerID, iface.Name)
+
+	var existing models.Interface
+	res := db.Where("router_id = ? AND interface_name = ?", routerID, iface.Name).First(&existing)
+	var reset *counterReset
+	if res.Error == nil {
+		reset = classifier.classify(existing, iface)
+	}
+
+	if reset != nil {
+		s.logger.Warn(ComponentMonitoring, "counter_reset", "Counter reset detected", map[string]interface{}{
+			"router_id": routerID,
+			"interface": iface.Name,
+			"kind":      reset.Kind,
+			"notes":     reset.Notes,
+			"rx":        iface.RxBytes,
+			"tx":        iface.TxBytes,
+			"prev_rx":   existing.RxBytes,
+			"prev_tx":   existing.TxBytes,
+		})
+	} else if res.Error != nil {
+		s.logger.Debug(ComponentMonitoring, "save", "First reading of interface", map[string]interface{}{
+			"router_id": routerID,
+			"interface": iface.Name,
+		})
+	}
+
+	now := time.Now()
+	err := db.Clauses(database.Upsert(
+		[]string{"router_id", "interface_name"},
+		[]string{"object_id", "rx_bytes", "tx_bytes", "rx_rate", "tx_rate", "counters_at", "last_seen", "updated_at"},
+	)).Create(&models.Interface{
+		RouterID:      routerID,
+		InterfaceName: iface.Name,
+		ObjectID:      iface.ObjectID,
 		RxBytes:       iface.RxBytes, TxBytes: iface.TxBytes,
 		RxRate: iface.RxRate, TxRate: iface.TxRate,
-		LastSeen: time.Now(),
-	})
+		CountersAt: now,
+		LastSeen:   now,
+	}).Error
+	s.metrics.RecordDBWrite(time.Since(now), err)
+	if err != nil {
+		s.logger.Error(ComponentMonitoring, "save", "Failed to store interface reading", err, map[string]interface{}{
+			"router_id": routerID,
+			"interface": iface.Name,
+		})
+	}
 
-	if isReset {
-		s.db.Create(&models.CounterResetLog{
+	if reset != nil {
+		if reset.restarted() {
+			db.Model(&models.Interface{}).
+				Where("router_id = ? AND interface_name = ?", routerID, iface.Name).
+				UpdateColumn("counter_reset_count", gorm.Expr("counter_reset_count + 1"))
+		}
+		db.Create(&models.CounterResetLog{
+			RouterID:        routerID,
 			InterfaceName:   iface.Name,
-			ResetTime:       time.Now(),
+			ResetTime:       now,
 			PreviousBytes:   existing.RxBytes + existing.TxBytes,
 			NewBytes:        iface.RxBytes + iface.TxBytes,
-			DetectionMethod: "sudden_drop",
+			DetectionMethod: reset.Kind,
+			Notes:           reset.Notes,
 		})
 	}
 
-	if iface.Name == "xether2" {
-		s.handleSnapshot(iface, isReset)
-	}
+	s.handleSnapshot(ctx, routerID, iface, reset)
 
-	// Update MonthlyQuota untuk semua interface
-	now := time.Now()
-	if err := s.updateMonthlyQuota(iface, isReset, now); err != nil {
-		fmt.Printf("[ERROR] Gagal update MonthlyQuota untuk %s: %v\n", iface.Name, err)
+	// Update MonthlyQuota untuk semua interface. Traffic dihitung sejak pembacaan counter
+	// sebelumnya, atau sejak router boot jika counter dimulai ulang karena reboot.
+	since := existing.CountersAt
+	if reset != nil && !reset.Since.IsZero() {
+		since = reset.Since
+	}
+	if err := s.updateMonthlyQuota(ctx, routerID, iface, reset.kind(), since, now); err != nil {
+		s.logger.Error(ComponentMonitoring, "usage", "Failed to update MonthlyQuota", err, map[string]interface{}{
+			"router_id": routerID,
+			"interface": iface.Name,
+		})
 	}
+	unlock()
+
+	s.quotas.Evaluate(routerID, iface.Name)
+	return reset
 }
 
-func (s *MonitoringService) handleSnapshot(iface InterfaceData, isReset bool) {
-	var last models.TrafficSnapshot
+// handleSnapshot writes a traffic snapshot when the snapshot policy says one is due.
+// A counter reset or wrap is always recorded.
+func (s *MonitoringService) handleSnapshot(ctx context.Context, routerID uint, iface InterfaceData, reset *counterReset) {
+	if !s.snapshots.matches(iface.Name) {
+		return
+	}
+
+	now := time.Now()
 	curr := iface.RxBytes + iface.TxBytes
-	err := s.db.Where("interface_name = ?", iface.Name).Order("timestamp DESC").First(&last).Error
-
-	if err == gorm.ErrRecordNotFound || isReset || (curr-last.TotalBytes) > (10*1024*1024*1024) {
-		s.db.Create(&models.TrafficSnapshot{
-			InterfaceName: iface.Name,
-			Timestamp:     time.Now(),
-			RxBytes:       iface.RxBytes,
-			TxBytes:       iface.TxBytes,
-			TotalBytes:    curr,
-			CounterReset:  isReset,
+	last, ok := s.snapshots.mark(routerID, iface.Name)
+	if !ok {
+		// First reading since startup, continue from the stored history
+		var snapshot models.TrafficSnapshot
+		err := s.db.WithContext(ctx).Where("router_id = ? AND interface_name = ?", routerID, iface.Name).Order("timestamp DESC").First(&snapshot).Error
+		if err == nil {
+			last = snapshotMark{at: snapshot.Timestamp, totalBytes: snapshot.TotalBytes}
+			ok = true
+			s.snapshots.remember(routerID, iface.Name, last)
+		}
+	}
+	if ok && reset == nil && !s.snapshots.due(last, now, curr) {
+		return
+	}
+
+	if err := s.db.WithContext(ctx).Create(&models.TrafficSnapshot{
+		RouterID:      routerID,
+		InterfaceName: iface.Name,
+		Timestamp:     now,
+		RxBytes:       iface.RxBytes,
+		TxBytes:       iface.TxBytes,
+		RxRate:        iface.RxRate,
+		TxRate:        iface.TxRate,
+		TotalBytes:    curr,
+		CounterReset:  reset.restarted(),
+		ResetKind:     reset.kind(),
+	}).Error; err != nil {
+		s.logger.Error(ComponentMonitoring, "snapshot", "Failed to save snapshot", err, map[string]interface{}{
+			"router_id": routerID,
+			"interface": iface.Name,
 		})
-		fmt.Printf("[INFO] Snapshot saved for xether2 | Total: %d bytes\n", curr)
+		return
 	}
+	s.snapshots.remember(routerID, iface.Name, snapshotMark{at: now, totalBytes: curr})
+	s.logger.Debug(ComponentMonitoring, "snapshot", "Snapshot saved", map[string]interface{}{
+		"router_id":   routerID,
+		"interface":   iface.Name,
+		"total_bytes": curr,
+	})
 }
 
-// RecordOfflineStatus updates all interfaces with offline status when router is unreachable
-func (s *MonitoringService) RecordOfflineStatus() {
-	fmt.Printf("[SELF-HEALING] Starting recordOfflineStatus() method\n")
+// RecordOfflineStatus updates all interfaces of a router with offline status when it is
+// unreachable. Per-interface logging is skipped while the router is in maintenance.
+func (s *MonitoringService) RecordOfflineStatus(routerID uint) {
+	quiet := s.maint.InMaintenance(routerID, "", time.Now())
 
-	// Get all known interfaces from database
+	// Get all known interfaces of this router from database
 	var knownInterfaces []models.Interface
-	err := s.db.Find(&knownInterfaces).Error
+	err := s.db.Where("router_id = ?", routerID).Find(&knownInterfaces).Error
 	if err != nil {
-		fmt.Printf("[ERROR] Failed to fetch known interfaces: %v\n", err)
+		s.logger.Error(ComponentMonitoring, "offline", "Failed to fetch known interfaces", err, map[string]interface{}{
+			"router_id": routerID,
+		})
 		return
 	}
 
-	fmt.Printf("[SELF-HEALING] Found %d known interfaces to record offline status\n", len(knownInterfaces))
+	if !quiet {
+		s.logger.Info(ComponentMonitoring, "offline", "Recording offline status", map[string]interface{}{
+			"router_id":  routerID,
+			"interfaces": len(knownInterfaces),
+		})
+	}
 
+	// Counters and MonthlyQuota are left alone; the traffic of the outage is attributed
+	// to the days it spanned at the first successful poll afterwards
 	now := time.Now()
 	for _, iface := range knownInterfaces {
 		// Update interface with offline status
@@ -241,8 +727,14 @@ func (s *MonitoringService) RecordOfflineStatus() {
 		iface.RxRate = 0
 		iface.TxRate = 0
 
-		fmt.Printf("[SELF-HEALING] Recording offline status for %s (Rx: %d, Tx: %d)\n",
-			iface.InterfaceName, iface.RxBytes, iface.TxBytes)
+		if !quiet {
+			s.logger.Debug(ComponentMonitoring, "offline", "Recording offline status of interface", map[string]interface{}{
+				"router_id": routerID,
+				"interface": iface.InterfaceName,
+				"rx":        iface.RxBytes,
+				"tx":        iface.TxBytes,
+			})
+		}
 
 		// Save to database
 		updateErr := s.db.Model(&iface).Updates(map[string]interface{}{
@@ -251,120 +743,165 @@ func (s *MonitoringService) RecordOfflineStatus() {
 			"tx_rate":   0,
 		}).Error
 		if updateErr != nil {
-			fmt.Printf("[ERROR] Failed to update interface %s: %v\n", iface.InterfaceName, updateErr)
-		}
-
-		// Still call updateMonthlyQuota even when offline to maintain data consistency
-		interfaceData := InterfaceData{
-			Name:    iface.InterfaceName,
-			RxBytes: iface.RxBytes,
-			TxBytes: iface.TxBytes,
-			RxRate:  0,
-			TxRate:  0,
-		}
-
-		fmt.Printf("[SELF-HEALING] Calling updateMonthlyQuota for %s\n", iface.InterfaceName)
-		if err := s.updateMonthlyQuota(interfaceData, false, now); err != nil {
-			fmt.Printf("[ERROR] updateMonthlyQuota failed for %s: %v\n", iface.InterfaceName, err)
+			s.logger.Error(ComponentMonitoring, "offline", "Failed to update interface", updateErr, map[string]interface{}{
+				"router_id": routerID,
+				"interface": iface.InterfaceName,
+			})
 		}
+		s.quotas.Evaluate(routerID, iface.InterfaceName)
 	}
 }
 
-// updateMonthlyQuota mengupdate atau membuat record MonthlyQuota berdasarkan data interface
-func (s *MonitoringService) updateMonthlyQuota(iface InterfaceData, isReset bool, now time.Time) error {
-	fmt.Printf("[DEBUG-QUOTA] Processing %s | Rx: %d | Reset: %v\n", iface.Name, iface.RxBytes, isReset)
+// updateMonthlyQuota menambahkan traffic sejak pembacaan counter sebelumnya ke record
+// MonthlyQuota. resetKind adalah jenis reset counter yang terdeteksi (kosong jika tidak ada).
+// Traffic dibagi secara proporsional ke setiap hari antara since dan now, sehingga hari yang
+// terlewati saat router offline tetap mendapat bagiannya. Pemanggil harus memegang kunci
+// interface (interfaceLocks).
+func (s *MonitoringService) updateMonthlyQuota(ctx context.Context, routerID uint, iface InterfaceData, resetKind string, since, now time.Time) (err error) {
+	ctx, span := startSpan(ctx, "monitoring.update_monthly_quota", attribute.String("monik.interface", iface.Name))
+	defer func() { endSpan(span, err) }()
+
+	// Hari dihitung di zona waktu siklus tagihan interface
+	loc := s.billing.Location(routerID, iface.Name)
+
+	// Record terakhir menyimpan tracker counter terakhir, meskipun dari hari sebelumnya
+	var last models.MonthlyQuota
+	err = s.db.WithContext(ctx).Where("router_id = ? AND interface_name = ?", routerID, iface.Name).
+		Order("year DESC, month DESC, day DESC").First(&last).Error
+	if err == gorm.ErrRecordNotFound {
+		// Pembacaan pertama: belum ada baseline, mulai dari nol
+		s.logger.Debug(ComponentMonitoring, "usage", "No usage record yet, starting baseline", map[string]interface{}{
+			"router_id": routerID,
+			"interface": iface.Name,
+			"rx":        iface.RxBytes,
+			"tx":        iface.TxBytes,
+		})
+		return s.addDailyUsage(ctx, routerID, iface, []dayShare{dayShareOf(now.In(loc), 0, 0)})
+	} else if err != nil {
+		return err
+	}
 
-	dbMutex.Lock()
-	defer dbMutex.Unlock()
+	// Hitung Delta per arah berdasarkan nilai counter terakhir yang tercatat di tabel Quota.
+	// Reboot, reset manual dan interface yang dibuat ulang memulai counter dari nol sehingga
+	// nilai baru seutuhnya menjadi delta; wraparound menambahkan sisa sampai 2^32 (2^64 untuk counter 64-bit).
+	deltaRx := counterDelta(last.LastRxBytes, iface.RxBytes, resetKind)
+	deltaTx := counterDelta(last.LastTxBytes, iface.TxBytes, resetKind)
+	if resetKind == "" && (iface.RxBytes < last.LastRxBytes || iface.TxBytes < last.LastTxBytes) {
+		// Additional protection: values unexpectedly lower without a detected reset
+		s.logger.Warn(ComponentMonitoring, "usage", "Counters went backwards without a detected reset", map[string]interface{}{
+			"router_id": routerID,
+			"interface": iface.Name,
+			"rx":        iface.RxBytes,
+			"tx":        iface.TxBytes,
+			"last_rx":   last.LastRxBytes,
+			"last_tx":   last.LastTxBytes,
+		})
+	}
+	s.logger.Debug(ComponentMonitoring, "usage", "Counter delta", map[string]interface{}{
+		"router_id":  routerID,
+		"interface":  iface.Name,
+		"reset_kind": resetKind,
+		"delta_rx":   deltaRx,
+		"delta_tx":   deltaTx,
+	})
 
-	fmt.Printf("[DEBUG] updateMonthlyQuota called for %s: isReset=%v, Rx=%d, Tx=%d\n", iface.Name, isReset, iface.RxBytes, iface.TxBytes)
+	shares := splitByDay(since, now, loc, deltaRx, deltaTx)
+	if len(shares) > 1 {
+		s.logger.Info(ComponentMonitoring, "usage", "Attributing traffic across several days", map[string]interface{}{
+			"router_id": routerID,
+			"interface": iface.Name,
+			"bytes":     deltaRx + deltaTx,
+			"days":      len(shares),
+			"since":     since.Format(time.RFC3339),
+		})
+	}
+	return s.addDailyUsage(ctx, routerID, iface, shares)
+}
 
-	// Ekstrak informasi tanggal dari waktu saat ini
-	day := now.Day()
-	month := int(now.Month())
-	year := now.Year()
+// dayShare is the part of a counter delta attributed to one local day
+type dayShare struct {
+	day, month, year int
+	rx, tx           uint64
+}
 
-	fmt.Printf("[DEBUG] Current date context: Day=%d, Month=%d, Year=%d\n", day, month, year)
+func dayShareOf(t time.Time, rx, tx uint64) dayShare {
+	return dayShare{day: t.Day(), month: int(t.Month()), year: t.Year(), rx: rx, tx: tx}
+}
 
-	// Cari record MonthlyQuota berdasarkan interface_name, day, month, year
-	var quota models.MonthlyQuota
-	err := s.db.Where("interface_name = ? AND day = ? AND month = ? AND year = ?",
-		iface.Name, day, month, year).First(&quota).Error
+// splitByDay divides rx and tx over the local days between since and now in proportion
+// to the time spent in each day. The last day takes the rounding remainder so the shares
+// add up exactly. A zero or later since attributes everything to the day of now.
+func splitByDay(since, now time.Time, loc *time.Location, rx, tx uint64) []dayShare {
+	end := now.In(loc)
+	if since.IsZero() || !since.Before(now) {
+		return []dayShare{dayShareOf(end, rx, tx)}
+	}
 
-	if err == gorm.ErrRecordNotFound {
-		fmt.Printf("[DEBUG] No existing quota record found for %s on %d/%d/%d\n", iface.Name, day, month, year)
-		// Inisialisasi record hari baru
-		newQuota := models.MonthlyQuota{
-			InterfaceName: iface.Name,
-			Day:           day,
-			Month:         month,
-			Year:          year,
-			RxBytes:       0,
-			TxBytes:       0,
-			TotalBytes:    0,
-			TotalRx:       0,
-			TotalTx:       0,
-			LastRxBytes:   iface.RxBytes,
-			LastTxBytes:   iface.TxBytes,
-		}
-		fmt.Printf("[DEBUG] Creating new quota record with LastRxBytes=%d, LastTxBytes=%d\n", iface.RxBytes, iface.TxBytes)
-		err := s.db.Create(&newQuota).Error
-		if err != nil {
-			fmt.Printf("[ERROR] Failed to create new quota record: %v\n", err)
-			return err
+	total := float64(now.Sub(since))
+	var shares []dayShare
+	var givenRx, givenTx uint64
+	for cursor := since.In(loc); ; {
+		y, m, d := cursor.Date()
+		next := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+		if !next.Before(end) {
+			return append(shares, dayShareOf(cursor, rx-givenRx, tx-givenTx))
 		}
-		fmt.Printf("[SUCCESS] Successfully created new quota record for %s\n", iface.Name)
-		return nil
-	} else if err != nil {
-		fmt.Printf("[ERROR] Database error when querying quota: %v\n", err)
-		return err
+		fraction := float64(next.Sub(cursor)) / total
+		share := dayShareOf(cursor, uint64(float64(rx)*fraction), uint64(float64(tx)*fraction))
+		givenRx += share.rx
+		givenTx += share.tx
+		shares = append(shares, share)
+		cursor = next
 	}
+}
 
-	fmt.Printf("[DEBUG] Found existing quota record for %s\n", iface.Name)
-
-	var deltaRx, deltaTx uint64
-
-	// Hitung Delta berdasarkan nilai counter terakhir yang tercatat di tabel Quota
-	if isReset || iface.RxBytes < quota.LastRxBytes {
-		// Skenario Reset: Ambil nilai baru seutuhnya sebagai delta
-		deltaRx = iface.RxBytes
-		deltaTx = iface.TxBytes
-		fmt.Printf("[DEBUG] RESET SCENARIO: Taking full values as delta - deltaRx=%d, deltaTx=%d\n", deltaRx, deltaTx)
-	} else {
-		// Skenario Normal: Selisih antara counter sekarang dengan counter terakhir yang dicatat
-		// Additional validation to prevent false reset detection
-		if iface.RxBytes >= quota.LastRxBytes && iface.TxBytes >= quota.LastTxBytes {
-			deltaRx = iface.RxBytes - quota.LastRxBytes
-			deltaTx = iface.TxBytes - quota.LastTxBytes
-			fmt.Printf("[DEBUG] NORMAL SCENARIO: Calculating difference - deltaRx=%d (%d - %d), deltaTx=%d (%d - %d)\n",
-				deltaRx, iface.RxBytes, quota.LastRxBytes, deltaTx, iface.TxBytes, quota.LastTxBytes)
-		} else {
-			// Additional protection: if values are unexpectedly lower, treat as reset
-			fmt.Printf("[WARN] Unexpected lower values detected: Rx=%d < LastRx=%d OR Tx=%d < LastTx=%d\n",
-				iface.RxBytes, quota.LastRxBytes, iface.TxBytes, quota.LastTxBytes)
-			deltaRx = iface.RxBytes
-			deltaTx = iface.TxBytes
-			fmt.Printf("[DEBUG] PROTECTION SCENARIO: Using full values as delta - deltaRx=%d, deltaTx=%d\n", deltaRx, deltaTx)
+// addDailyUsage menambahkan bagian traffic ke record MonthlyQuota tiap hari (dibuat jika
+// belum ada) dan menyimpan nilai counter terakhir. Pemanggil harus memegang kunci interface.
+func (s *MonitoringService) addDailyUsage(ctx context.Context, routerID uint, iface InterfaceData, shares []dayShare) error {
+	db := s.db.WithContext(ctx)
+	for _, share := range shares {
+		var quota models.MonthlyQuota
+		err := db.Where("router_id = ? AND interface_name = ? AND day = ? AND month = ? AND year = ?",
+			routerID, iface.Name, share.day, share.month, share.year).First(&quota).Error
+		if err == gorm.ErrRecordNotFound {
+			// Inisialisasi record hari baru
+			quota = models.MonthlyQuota{
+				RouterID:      routerID,
+				InterfaceName: iface.Name,
+				Day:           share.day,
+				Month:         share.month,
+				Year:          share.year,
+				RxBytes:       share.rx,
+				TxBytes:       share.tx,
+				TotalBytes:    share.rx + share.tx,
+				TotalRx:       share.rx,
+				TotalTx:       share.tx,
+				LastRxBytes:   iface.RxBytes,
+				LastTxBytes:   iface.TxBytes,
+				QuotaLimit:    s.quotas.LimitBytes(routerID, iface.Name),
+			}
+			if err := db.Create(&quota).Error; err != nil {
+				return fmt.Errorf("failed to create usage record: %w", err)
+			}
+			continue
+		} else if err != nil {
+			return err
 		}
-	}
 
-	// Update akumulasi harian dan perbarui tracker counter terakhir
-	fmt.Printf("[DEBUG] Updating quota: Current RxBytes=%d, TxBytes=%d, adding deltaRx=%d, deltaTx=%d\n",
-		quota.RxBytes, quota.TxBytes, deltaRx, deltaTx)
-	err = s.db.Model(&quota).Updates(map[string]interface{}{
-		"rx_bytes":      quota.RxBytes + deltaRx,
-		"tx_bytes":      quota.TxBytes + deltaTx,
-		"total_bytes":   (quota.RxBytes + deltaRx) + (quota.TxBytes + deltaTx),
-		"total_rx":      quota.TotalRx + deltaRx,
-		"total_tx":      quota.TotalTx + deltaTx,
-		"last_rx_bytes": iface.RxBytes,
-		"last_tx_bytes": iface.TxBytes,
-	}).Error
-	if err != nil {
-		fmt.Printf("[ERROR] Failed to update quota record: %v\n", err)
-		return err
+		// Update akumulasi harian dan perbarui tracker counter terakhir
+		err = db.Model(&quota).Updates(map[string]interface{}{
+			"rx_bytes":      quota.RxBytes + share.rx,
+			"tx_bytes":      quota.TxBytes + share.tx,
+			"total_bytes":   (quota.RxBytes + share.rx) + (quota.TxBytes + share.tx),
+			"total_rx":      quota.TotalRx + share.rx,
+			"total_tx":      quota.TotalTx + share.tx,
+			"last_rx_bytes": iface.RxBytes,
+			"last_tx_bytes": iface.TxBytes,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update usage record: %w", err)
+		}
 	}
-	fmt.Printf("[SUCCESS] Successfully updated quota record for %s\n", iface.Name)
 	return nil
 }
 
@@ -375,18 +912,23 @@ func filterComment(c string) string {
 
 // --- GETTER METHODS FOR API HANDLERS ---
 
-// GetLatestInterfaces mengambil semua data interface terbaru dari database
-func (s *MonitoringService) GetLatestInterfaces() ([]models.Interface, error) {
+// GetLatestInterfaces mengambil semua data interface terbaru dari database.
+// routerID 0 mengembalikan interface dari semua router.
+func (s *MonitoringService) GetLatestInterfaces(routerID uint) ([]models.Interface, error) {
 	var interfaces []models.Interface
+	query := s.db
+	if routerID != 0 {
+		query = query.Where("router_id = ?", routerID)
+	}
 	// Mengurutkan berdasarkan nama agar konsisten di UI
-	err := s.db.Order("interface_name ASC").Find(&interfaces).Error
+	err := query.Order("router_id ASC, interface_name ASC").Find(&interfaces).Error
 	return interfaces, err
 }
 
-// GetInterfaceByName mengambil satu data interface berdasarkan nama
-func (s *MonitoringService) GetInterfaceByName(name string) (*models.Interface, error) {
+// GetInterfaceByName mengambil satu data interface berdasarkan router dan nama
+func (s *MonitoringService) GetInterfaceByName(routerID uint, name string) (*models.Interface, error) {
 	var iface models.Interface
-	err := s.db.Where("interface_name = ?", name).First(&iface).Error
+	err := s.db.Where("router_id = ? AND interface_name = ?", routerID, name).First(&iface).Error
 	if err != nil {
 		return nil, err
 	}
@@ -394,10 +936,10 @@ func (s *MonitoringService) GetInterfaceByName(name string) (*models.Interface,
 }
 
 // GetSystemInfo mengambil informasi sistem MikroTik terbaru dari DB
-func (s *MonitoringService) GetSystemInfo() (*models.SystemInfo, error) {
+func (s *MonitoringService) GetSystemInfo(routerID uint) (*models.SystemInfo, error) {
 	var info models.SystemInfo
 	// Mengambil data terakhir yang diupdate
-	err := s.db.Order("last_updated DESC").First(&info).Error
+	err := s.db.Where("router_id = ?", routerID).Order("last_updated DESC").First(&info).Error
 	if err != nil {
 		return nil, err
 	}
@@ -405,21 +947,21 @@ func (s *MonitoringService) GetSystemInfo() (*models.SystemInfo, error) {
 }
 
 // GetMonthlyQuota mengambil data MonthlyQuota berdasarkan interface, bulan, dan tahun
-func (s *MonitoringService) GetMonthlyQuota(interfaceName string, month, year int) ([]models.MonthlyQuota, error) {
+func (s *MonitoringService) GetMonthlyQuota(routerID uint, interfaceName string, month, year int) ([]models.MonthlyQuota, error) {
 	var quotas []models.MonthlyQuota
-	err := s.db.Where("interface_name = ? AND month = ? AND year = ?",
-		interfaceName, month, year).
+	err := s.db.Where("router_id = ? AND interface_name = ? AND month = ? AND year = ?",
+		routerID, interfaceName, month, year).
 		Order("day ASC").
-		Find("quotas").Error
+		Find(&quotas).Error
 	return quotas, err
 }
 
 // GetMonthlyQuotaByDay mengambil data MonthlyQuota untuk hari tertentu
-func (s *MonitoringService) GetMonthlyQuotaByDay(interfaceName string, day, month, year int) (*models.MonthlyQuota, error) {
+func (s *MonitoringService) GetMonthlyQuotaByDay(routerID uint, interfaceName string, day, month, year int) (*models.MonthlyQuota, error) {
 	var quota models.MonthlyQuota
-	err := s.db.Where("interface_name = ? AND day = ? AND month = ? AND year = ?",
-		interfaceName, day, month, year).
-		First("quota").Error
+	err := s.db.Where("router_id = ? AND interface_name = ? AND day = ? AND month = ? AND year = ?",
+		routerID, interfaceName, day, month, year).
+		First(&quota).Error
 	if err != nil {
 		return nil, err
 	}
@@ -430,6 +972,7 @@ func (s *MonitoringService) GetMonthlyQuotaByDay(interfaceName string, day, mont
 func (s *MonitoringService) PopulateTestCounterResetLogs() error {
 	testLogs := []models.CounterResetLog{
 		{
+			RouterID:        s.registry.DefaultRouterID(),
 			InterfaceName:   "xether2",
 			ResetTime:       time.Now().Add(-1 * time.Hour),
 			PreviousBytes:   5000000,
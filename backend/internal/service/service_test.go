@@ -0,0 +1,204 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+// openTestDB opens a fresh SQLite database holding the tables of the given models
+func openTestDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "monik.db")), &gorm.Config{
+		Logger: gormLogger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestSplitByDay(t *testing.T) {
+	utc := time.UTC
+	jakarta := mustLoadLocation(t, "Asia/Jakarta")
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+
+	tests := []struct {
+		name   string
+		since  time.Time
+		now    time.Time
+		loc    *time.Location
+		rx, tx uint64
+		want   []dayShare
+	}{
+		{
+			name: "no previous reading",
+			now:  time.Date(2026, 5, 3, 10, 0, 0, 0, utc),
+			loc:  utc,
+			rx:   100, tx: 50,
+			want: []dayShare{{day: 3, month: 5, year: 2026, rx: 100, tx: 50}},
+		},
+		{
+			name:  "within one day",
+			since: time.Date(2026, 5, 3, 10, 0, 0, 0, utc),
+			now:   time.Date(2026, 5, 3, 10, 5, 0, 0, utc),
+			loc:   utc,
+			rx:    100, tx: 50,
+			want: []dayShare{{day: 3, month: 5, year: 2026, rx: 100, tx: 50}},
+		},
+		{
+			name:  "clock went backwards",
+			since: time.Date(2026, 5, 3, 10, 5, 0, 0, utc),
+			now:   time.Date(2026, 5, 3, 10, 0, 0, 0, utc),
+			loc:   utc,
+			rx:    100, tx: 50,
+			want: []dayShare{{day: 3, month: 5, year: 2026, rx: 100, tx: 50}},
+		},
+		{
+			name:  "across midnight",
+			since: time.Date(2026, 5, 3, 23, 0, 0, 0, utc),
+			now:   time.Date(2026, 5, 4, 1, 0, 0, 0, utc),
+			loc:   utc,
+			rx:    200, tx: 100,
+			want: []dayShare{
+				{day: 3, month: 5, year: 2026, rx: 100, tx: 50},
+				{day: 4, month: 5, year: 2026, rx: 100, tx: 50},
+			},
+		},
+		{
+			name:  "UTC midnight is not a local day boundary",
+			since: time.Date(2026, 5, 3, 23, 0, 0, 0, utc), // 06:00 WIB
+			now:   time.Date(2026, 5, 4, 1, 0, 0, 0, utc),  // 08:00 WIB
+			loc:   jakarta,
+			rx:    200, tx: 100,
+			want: []dayShare{{day: 4, month: 5, year: 2026, rx: 200, tx: 100}},
+		},
+		{
+			name:  "local midnight splits a UTC day",
+			since: time.Date(2026, 5, 3, 16, 0, 0, 0, utc), // 23:00 WIB
+			now:   time.Date(2026, 5, 3, 18, 0, 0, 0, utc), // 01:00 WIB
+			loc:   jakarta,
+			rx:    200, tx: 100,
+			want: []dayShare{
+				{day: 3, month: 5, year: 2026, rx: 100, tx: 50},
+				{day: 4, month: 5, year: 2026, rx: 100, tx: 50},
+			},
+		},
+		{
+			name:  "23-hour day at the spring DST switch",
+			since: time.Date(2026, 3, 28, 23, 0, 0, 0, berlin),
+			now:   time.Date(2026, 3, 30, 0, 0, 0, 0, berlin),
+			loc:   berlin,
+			rx:    2400, tx: 240,
+			want: []dayShare{
+				{day: 28, month: 3, year: 2026, rx: 100, tx: 10},
+				{day: 29, month: 3, year: 2026, rx: 2300, tx: 230},
+			},
+		},
+		{
+			name:  "25-hour day at the autumn DST switch",
+			since: time.Date(2026, 10, 25, 0, 0, 0, 0, berlin),
+			now:   time.Date(2026, 10, 26, 1, 0, 0, 0, berlin),
+			loc:   berlin,
+			rx:    2600, tx: 260,
+			want: []dayShare{
+				{day: 25, month: 10, year: 2026, rx: 2500, tx: 250},
+				{day: 26, month: 10, year: 2026, rx: 100, tx: 10},
+			},
+		},
+		{
+			name:  "across the year boundary",
+			since: time.Date(2026, 12, 31, 12, 0, 0, 0, utc),
+			now:   time.Date(2027, 1, 1, 12, 0, 0, 0, utc),
+			loc:   utc,
+			rx:    100, tx: 10,
+			want: []dayShare{
+				{day: 31, month: 12, year: 2026, rx: 50, tx: 5},
+				{day: 1, month: 1, year: 2027, rx: 50, tx: 5},
+			},
+		},
+		{
+			name:  "last day takes the rounding remainder",
+			since: time.Date(2026, 1, 1, 0, 0, 0, 0, utc),
+			now:   time.Date(2026, 1, 4, 0, 0, 0, 0, utc),
+			loc:   utc,
+			rx:    10, tx: 1,
+			want: []dayShare{
+				{day: 1, month: 1, year: 2026, rx: 3, tx: 0},
+				{day: 2, month: 1, year: 2026, rx: 3, tx: 0},
+				{day: 3, month: 1, year: 2026, rx: 4, tx: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitByDay(tt.since, tt.now, tt.loc, tt.rx, tt.tx)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d shares %+v, want %+v", len(got), got, tt.want)
+			}
+			var rx, tx uint64
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("share %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+				rx += got[i].rx
+				tx += got[i].tx
+			}
+			if rx != tt.rx || tx != tt.tx {
+				t.Errorf("shares add up to %d/%d, want %d/%d", rx, tx, tt.rx, tt.tx)
+			}
+		})
+	}
+}
+
+func TestInterfaceLocks(t *testing.T) {
+	var locks interfaceLocks
+	unlock := locks.lock(1, "ether1")
+
+	// Other interfaces, and the same name on another router, are not held up
+	for _, other := range []struct {
+		routerID uint
+		name     string
+	}{{1, "ether2"}, {2, "ether1"}} {
+		done := make(chan struct{})
+		go func() {
+			locks.lock(other.routerID, other.name)()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("router %d %s waited for router 1 ether1", other.routerID, other.name)
+		}
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		locks.lock(1, "ether1")()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("router 1 ether1 locked twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("router 1 ether1 not released by unlock")
+	}
+}
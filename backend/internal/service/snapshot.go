@@ -0,0 +1,113 @@
+package service
+
+import (
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"monik-enterprise/internal/config"
+)
+
+// Snapshot policies deciding when an interface reading is written to traffic_snapshots
+const (
+	SnapshotPolicyInterval = "interval" // Every SNAPSHOT_INTERVAL
+	SnapshotPolicyDelta    = "delta"    // Every SNAPSHOT_DELTA_BYTES transferred
+	SnapshotPolicyBoth     = "both"     // Whichever comes first
+)
+
+// snapshotMark is the last snapshot written for an interface
+type snapshotMark struct {
+	at         time.Time
+	totalBytes uint64
+}
+
+// snapshotPolicy applies the configured snapshot policy and remembers the last snapshot
+// per router interface so the decision does not need a query on every poll
+type snapshotPolicy struct {
+	config config.SnapshotConfig
+	mu     sync.Mutex
+	marks  map[string]snapshotMark // "<router id>/<interface>" -> last snapshot
+
+	unknownPolicy string // Configured policy replaced by the interval policy, logged at start
+}
+
+func newSnapshotPolicy(cfg config.SnapshotConfig) *snapshotPolicy {
+	unknown := ""
+	switch cfg.Policy {
+	case SnapshotPolicyInterval, SnapshotPolicyDelta, SnapshotPolicyBoth:
+	default:
+		unknown = cfg.Policy
+		cfg.Policy = SnapshotPolicyInterval
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	if cfg.DeltaBytes == 0 {
+		cfg.DeltaBytes = 10 * 1024 * 1024 * 1024
+	}
+	return &snapshotPolicy{
+		config:        cfg,
+		marks:         make(map[string]snapshotMark),
+		unknownPolicy: unknown,
+	}
+}
+
+// matches reports whether an interface is covered by the include/exclude lists.
+// Patterns use shell glob syntax, e.g. "vlan*".
+func (p *snapshotPolicy) matches(name string) bool {
+	for _, pattern := range p.config.Exclude {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+	if len(p.config.Include) == 0 {
+		return true
+	}
+	for _, pattern := range p.config.Include {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// mark returns the last remembered snapshot of a router interface
+func (p *snapshotPolicy) mark(routerID uint, name string) (snapshotMark, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	mark, ok := p.marks[snapshotKey(routerID, name)]
+	return mark, ok
+}
+
+// remember stores the last snapshot of a router interface
+func (p *snapshotPolicy) remember(routerID uint, name string, mark snapshotMark) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.marks[snapshotKey(routerID, name)] = mark
+}
+
+// snapshotKey identifies an interface across routers
+func snapshotKey(routerID uint, name string) string {
+	return fmt.Sprintf("%d/%s", routerID, name)
+}
+
+// due reports whether a new snapshot is needed since last
+func (p *snapshotPolicy) due(last snapshotMark, now time.Time, totalBytes uint64) bool {
+	intervalDue := now.Sub(last.at) >= p.config.Interval
+
+	delta := totalBytes
+	if totalBytes >= last.totalBytes {
+		delta = totalBytes - last.totalBytes
+	}
+	deltaDue := delta >= p.config.DeltaBytes
+
+	switch p.config.Policy {
+	case SnapshotPolicyDelta:
+		return deltaDue
+	case SnapshotPolicyBoth:
+		return intervalDue || deltaDue
+	default:
+		return intervalDue
+	}
+}
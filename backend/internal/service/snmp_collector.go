@@ -0,0 +1,411 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"monik-enterprise/internal/config"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// IF-MIB and SNMPv2-MIB objects read by the SNMP collector
+const (
+	oidSysDescr      = ".1.3.6.1.2.1.1.1.0"
+	oidSysUpTime     = ".1.3.6.1.2.1.1.3.0"
+	oidSysName       = ".1.3.6.1.2.1.1.5.0"
+	oidIfOperStatus  = ".1.3.6.1.2.1.2.2.1.8"
+	oidIfInOctets    = ".1.3.6.1.2.1.2.2.1.10"
+	oidIfOutOctets   = ".1.3.6.1.2.1.2.2.1.16"
+	oidIfName        = ".1.3.6.1.2.1.31.1.1.1.1"
+	oidIfHCInOctets  = ".1.3.6.1.2.1.31.1.1.1.6"
+	oidIfHCOutOctets = ".1.3.6.1.2.1.31.1.1.1.10"
+	oidIfAlias       = ".1.3.6.1.2.1.31.1.1.1.18"
+)
+
+// ifOperStatusUp is the IF-MIB ifOperStatus value for an operational interface
+const ifOperStatusUp = 1
+
+// snmpSample is the last counter reading of an interface, used to derive rates
+type snmpSample struct {
+	index     string
+	counter32 bool // read from ifInOctets/ifOutOctets because the agent has no HC counters
+	rxBytes   uint64
+	txBytes   uint64
+	rxRate    float64 // Mbps
+	txRate    float64 // Mbps
+	at        time.Time
+}
+
+// SNMPCollector polls interface counters through IF-MIB using SNMP v2c or v3
+type SNMPCollector struct {
+	config  config.RouterConfig
+	logger  *LoggerService
+	mu      sync.Mutex
+	client  *gosnmp.GoSNMP
+	samples map[string]*snmpSample // interface name -> last sample
+	rates   *counterRates          // keyed by ifIndex so a re-created interface starts over
+}
+
+// NewSNMPCollector creates a collector for SNMP polling
+func NewSNMPCollector(cfg config.RouterConfig) *SNMPCollector {
+	return &SNMPCollector{
+		config:  cfg,
+		samples: make(map[string]*snmpSample),
+		rates:   newCounterRates(),
+	}
+}
+
+// SetLogger sets the structured logger of the collector
+func (c *SNMPCollector) SetLogger(logger *LoggerService) {
+	c.logger = logger
+}
+
+// connect creates the SNMP session. The caller must hold c.mu.
+func (c *SNMPCollector) connect(ctx context.Context) error {
+	if c.client != nil {
+		return nil
+	}
+
+	client := &gosnmp.GoSNMP{
+		Context:        ctx,
+		Target:         c.config.IP,
+		Port:           uint16(c.config.Port),
+		Timeout:        5 * time.Second,
+		Retries:        1,
+		MaxOids:        gosnmp.MaxOids,
+		MaxRepetitions: 25,
+	}
+
+	switch c.config.SNMP.Version {
+	case "", "v2c", "2c":
+		client.Version = gosnmp.Version2c
+		client.Community = c.config.SNMP.Community
+	case "v3", "3":
+		params, msgFlags, err := snmpV3SecurityParameters(c.config.SNMP)
+		if err != nil {
+			return err
+		}
+		client.Version = gosnmp.Version3
+		client.SecurityModel = gosnmp.UserSecurityModel
+		client.MsgFlags = msgFlags
+		client.SecurityParameters = params
+	default:
+		return fmt.Errorf("unsupported SNMP version: %s", c.config.SNMP.Version)
+	}
+
+	address := fmt.Sprintf("%s:%d", c.config.IP, c.config.Port)
+	if err := client.Connect(); err != nil {
+		c.logger.Warn(ComponentCollector, "connect", "Connection failed", routerLogFields(c.config, map[string]interface{}{
+			"error": err.Error(),
+		}))
+		return &RouterConnectError{Kind: ConnErrorNetwork, Address: address, Err: err}
+	}
+	c.client = client
+	return nil
+}
+
+// snmpV3SecurityParameters builds USM parameters from the configured protocols
+func snmpV3SecurityParameters(cfg config.RouterSNMPConfig) (*gosnmp.UsmSecurityParameters, gosnmp.SnmpV3MsgFlags, error) {
+	params := &gosnmp.UsmSecurityParameters{
+		UserName:                 cfg.Username,
+		AuthenticationProtocol:   gosnmp.NoAuth,
+		PrivacyProtocol:          gosnmp.NoPriv,
+		AuthenticationPassphrase: cfg.AuthPassword,
+		PrivacyPassphrase:        cfg.PrivPassword,
+	}
+	if cfg.Username == "" {
+		return nil, 0, fmt.Errorf("SNMP v3 requires a username")
+	}
+
+	msgFlags := gosnmp.NoAuthNoPriv
+	if cfg.AuthPassword != "" {
+		switch strings.ToUpper(cfg.AuthProtocol) {
+		case "MD5":
+			params.AuthenticationProtocol = gosnmp.MD5
+		case "", "SHA", "SHA1":
+			params.AuthenticationProtocol = gosnmp.SHA
+		case "SHA256":
+			params.AuthenticationProtocol = gosnmp.SHA256
+		case "SHA512":
+			params.AuthenticationProtocol = gosnmp.SHA512
+		default:
+			return nil, 0, fmt.Errorf("unsupported SNMP auth protocol: %s", cfg.AuthProtocol)
+		}
+		msgFlags = gosnmp.AuthNoPriv
+	}
+	if cfg.PrivPassword != "" {
+		if msgFlags == gosnmp.NoAuthNoPriv {
+			return nil, 0, fmt.Errorf("SNMP v3 privacy requires authentication")
+		}
+		switch strings.ToUpper(cfg.PrivProtocol) {
+		case "DES":
+			params.PrivacyProtocol = gosnmp.DES
+		case "", "AES", "AES128":
+			params.PrivacyProtocol = gosnmp.AES
+		case "AES256":
+			params.PrivacyProtocol = gosnmp.AES256
+		default:
+			return nil, 0, fmt.Errorf("unsupported SNMP privacy protocol: %s", cfg.PrivProtocol)
+		}
+		msgFlags = gosnmp.AuthPriv
+	}
+	return params, msgFlags, nil
+}
+
+// resetConnection drops the SNMP session so the next call reconnects. The caller must hold c.mu.
+func (c *SNMPCollector) resetConnection() {
+	if c.client != nil && c.client.Conn != nil {
+		c.client.Conn.Close()
+	}
+	c.client = nil
+}
+
+// walk runs a bulk walk and returns the PDUs keyed by the ifIndex suffix. The caller must hold c.mu.
+func (c *SNMPCollector) walk(rootOid string) (map[string]gosnmp.SnmpPDU, error) {
+	pdus, err := c.client.BulkWalkAll(rootOid)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]gosnmp.SnmpPDU, len(pdus))
+	for _, pdu := range pdus {
+		result[strings.TrimPrefix(pdu.Name, rootOid+".")] = pdu
+	}
+	return result, nil
+}
+
+// GetInterfaces walks IF-MIB and maps every interface into InterfaceData
+func (c *SNMPCollector) GetInterfaces(ctx context.Context) ([]InterfaceData, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.connect(ctx); err != nil {
+		return nil, err
+	}
+	c.client.Context = ctx
+
+	names, err := c.walk(oidIfName)
+	if err != nil {
+		c.resetConnection()
+		return nil, fmt.Errorf("failed to get interfaces: %w", err)
+	}
+	inOid, outOid, counter32 := oidIfHCInOctets, oidIfHCOutOctets, false
+	inOctets, err := c.walk(inOid)
+	if err == nil && len(inOctets) == 0 && len(names) > 0 {
+		// SNMPv1-era agents only expose the 32-bit counters, which wrap at 4 GiB
+		c.logger.Info(ComponentCollector, "get_interfaces", "No ifHCInOctets, falling back to 32-bit ifInOctets", routerLogFields(c.config, nil))
+		inOid, outOid, counter32 = oidIfInOctets, oidIfOutOctets, true
+		inOctets, err = c.walk(inOid)
+	}
+	if err != nil {
+		c.resetConnection()
+		return nil, fmt.Errorf("failed to get %s: %w", inOid, err)
+	}
+	outOctets, err := c.walk(outOid)
+	if err != nil {
+		c.resetConnection()
+		return nil, fmt.Errorf("failed to get %s: %w", outOid, err)
+	}
+	operStatus, err := c.walk(oidIfOperStatus)
+	if err != nil {
+		c.resetConnection()
+		return nil, fmt.Errorf("failed to get ifOperStatus: %w", err)
+	}
+	aliases, err := c.walk(oidIfAlias)
+	if err != nil {
+		// ifAlias is optional; some agents do not expose it
+		c.logger.Debug(ComponentCollector, "get_interfaces", "ifAlias not available", routerLogFields(c.config, map[string]interface{}{
+			"error": err.Error(),
+		}))
+		aliases = map[string]gosnmp.SnmpPDU{}
+	}
+
+	now := time.Now()
+	interfaces := make([]InterfaceData, 0, len(names))
+	for index, namePDU := range names {
+		iface := InterfaceData{
+			Name:        snmpString(namePDU),
+			ObjectID:    index,
+			RxBytes:     snmpUint64(inOctets[index]),
+			TxBytes:     snmpUint64(outOctets[index]),
+			Status:      "false",
+			Comment:     snmpString(aliases[index]),
+			LastUpdated: now,
+			Counter32:   counter32,
+		}
+		if snmpUint64(operStatus[index]) == ifOperStatusUp {
+			// Same "running" representation as the RouterOS API
+			iface.Status = "true"
+		}
+		sample := c.updateSample(iface.Name, index, counter32, iface.RxBytes, iface.TxBytes, now)
+		iface.RxRate = sample.rxRate
+		iface.TxRate = sample.txRate
+		interfaces = append(interfaces, iface)
+	}
+
+	c.logger.Debug(ComponentCollector, "get_interfaces", "Received interfaces", routerLogFields(c.config, map[string]interface{}{
+		"count": len(interfaces),
+	}))
+	return interfaces, nil
+}
+
+// updateSample stores a counter reading and derives rates from the previous one.
+// The caller must hold c.mu.
+func (c *SNMPCollector) updateSample(name, index string, counter32 bool, rx, tx uint64, now time.Time) *snmpSample {
+	sample := &snmpSample{index: index, counter32: counter32, rxBytes: rx, txBytes: tx, at: now}
+	sample.rxRate, sample.txRate = c.rates.update(index, rx, tx, now)
+	c.samples[name] = sample
+	return sample
+}
+
+// GetTrafficStats returns rates derived from consecutive counter readings. Readings taken
+// by a GetInterfaces call in the last couple of seconds are reused.
+func (c *SNMPCollector) GetTrafficStats(ctx context.Context, interfaceName string) (*InterfaceData, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev, ok := c.samples[interfaceName]
+	if !ok {
+		return nil, fmt.Errorf("unknown interface %s, run interface discovery first", interfaceName)
+	}
+
+	sample := prev
+	if time.Since(prev.at) > 2*time.Second {
+		if err := c.connect(ctx); err != nil {
+			return nil, err
+		}
+		c.client.Context = ctx
+
+		inOid, outOid := oidIfHCInOctets, oidIfHCOutOctets
+		if prev.counter32 {
+			inOid, outOid = oidIfInOctets, oidIfOutOctets
+		}
+		result, err := c.client.Get([]string{
+			inOid + "." + prev.index,
+			outOid + "." + prev.index,
+		})
+		if err != nil {
+			c.resetConnection()
+			return nil, fmt.Errorf("failed to get traffic stats: %w", err)
+		}
+		if len(result.Variables) < 2 {
+			return nil, fmt.Errorf("no data returned for interface %s", interfaceName)
+		}
+		sample = c.updateSample(interfaceName, prev.index, prev.counter32,
+			snmpUint64(result.Variables[0]), snmpUint64(result.Variables[1]), time.Now())
+	}
+
+	return &InterfaceData{
+		Name:        interfaceName,
+		ObjectID:    sample.index,
+		RxBytes:     sample.rxBytes,
+		TxBytes:     sample.txBytes,
+		RxRate:      sample.rxRate,
+		TxRate:      sample.txRate,
+		Status:      "up", // Assume up if we can monitor
+		LastUpdated: sample.at,
+		Counter32:   sample.counter32,
+	}, nil
+}
+
+// GetSystemInfo reads sysName, sysDescr and sysUpTime
+func (c *SNMPCollector) GetSystemInfo(ctx context.Context) (*SystemInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.connect(ctx); err != nil {
+		return nil, err
+	}
+	c.client.Context = ctx
+
+	result, err := c.client.Get([]string{oidSysName, oidSysDescr, oidSysUpTime})
+	if err != nil {
+		c.resetConnection()
+		return nil, fmt.Errorf("failed to get system info: %w", err)
+	}
+
+	info := &SystemInfo{}
+	for _, pdu := range result.Variables {
+		switch pdu.Name {
+		case oidSysName:
+			info.Identity = snmpString(pdu)
+		case oidSysDescr:
+			// MikroTik reports "RouterOS <board-name>"
+			descr := snmpString(pdu)
+			info.BoardName = strings.TrimSpace(strings.TrimPrefix(descr, "RouterOS"))
+		case oidSysUpTime:
+			info.Uptime = snmpUptime(pdu).String()
+		}
+	}
+	return info, nil
+}
+
+// GetUptime reads sysUpTime
+func (c *SNMPCollector) GetUptime(ctx context.Context) (time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.connect(ctx); err != nil {
+		return 0, err
+	}
+	c.client.Context = ctx
+
+	result, err := c.client.Get([]string{oidSysUpTime})
+	if err != nil {
+		c.resetConnection()
+		return 0, fmt.Errorf("failed to get sysUpTime: %w", err)
+	}
+	if len(result.Variables) == 0 {
+		return 0, fmt.Errorf("sysUpTime not available")
+	}
+	return snmpUptime(result.Variables[0]), nil
+}
+
+// GetLastRebootLog derives the boot time from sysUpTime, SNMP has no access to the log
+func (c *SNMPCollector) GetLastRebootLog(ctx context.Context) (time.Time, error) {
+	uptime, err := c.GetUptime(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().Add(-uptime), nil
+}
+
+// Close closes the SNMP session
+func (c *SNMPCollector) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resetConnection()
+}
+
+// snmpString converts an OctetString PDU value to string
+func snmpString(pdu gosnmp.SnmpPDU) string {
+	switch v := pdu.Value.(type) {
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// snmpUint64 converts a numeric PDU value (Counter64, Integer, ...) to uint64
+func snmpUint64(pdu gosnmp.SnmpPDU) uint64 {
+	if pdu.Value == nil {
+		return 0
+	}
+	if n := gosnmp.ToBigInt(pdu.Value); n != nil && n.Sign() >= 0 {
+		return n.Uint64()
+	}
+	return 0
+}
+
+// snmpUptime converts a TimeTicks PDU value (hundredths of a second) to a duration
+func snmpUptime(pdu gosnmp.SnmpPDU) time.Duration {
+	return time.Duration(snmpUint64(pdu)) * 10 * time.Millisecond
+}
@@ -0,0 +1,605 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"monik-enterprise/internal/config"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// USM report OIDs (RFC 3414) sent by the agent stub
+const (
+	oidUsmStatsUnknownEngineIDs = ".1.3.6.1.6.3.15.1.1.4.0"
+	oidUsmStatsWrongDigests     = ".1.3.6.1.6.3.15.1.1.5.0"
+)
+
+// snmpAgentEngineID is the authoritative engine ID of the agent stub
+const snmpAgentEngineID = "\x80\x00\x3a\x8c\x04monik-test"
+
+// snmpAgentStub is an in-process SNMP agent answering Get, GetNext and GetBulk requests
+// from a static MIB. It speaks v2c or v3 USM, depending on the credentials it was
+// started with, and answers v3 engine discovery and authentication failures with the
+// matching USM reports.
+type snmpAgentStub struct {
+	conn    *net.UDPConn
+	decoder *gosnmp.GoSNMP
+	cfg     config.RouterSNMPConfig
+
+	mu     sync.Mutex
+	values map[string]gosnmp.SnmpPDU
+	oids   []string // sorted in lexicographic OID order
+	silent bool     // drop every request, like an unreachable or firewalled agent
+}
+
+// newSNMPAgentStub starts an agent on a random local UDP port
+func newSNMPAgentStub(t *testing.T, cfg config.RouterSNMPConfig) *snmpAgentStub {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent := &snmpAgentStub{
+		conn:    conn,
+		decoder: &gosnmp.GoSNMP{Version: gosnmp.Version2c},
+		cfg:     cfg,
+		values:  make(map[string]gosnmp.SnmpPDU),
+	}
+	if cfg.Version == "v3" {
+		params, msgFlags, err := snmpV3SecurityParameters(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		params.AuthoritativeEngineID = snmpAgentEngineID
+		params.AuthoritativeEngineBoots = 1
+		params.AuthoritativeEngineTime = 1
+		agent.decoder = &gosnmp.GoSNMP{
+			Version:            gosnmp.Version3,
+			SecurityModel:      gosnmp.UserSecurityModel,
+			MsgFlags:           msgFlags,
+			SecurityParameters: params,
+		}
+	}
+	go agent.serve()
+	t.Cleanup(func() { conn.Close() })
+	return agent
+}
+
+// routerConfig returns a router configuration polling the agent with the given credentials
+func (a *snmpAgentStub) routerConfig(snmp config.RouterSNMPConfig) config.RouterConfig {
+	addr := a.conn.LocalAddr().(*net.UDPAddr)
+	return config.RouterConfig{
+		IP:      addr.IP.String(),
+		Port:    addr.Port,
+		Backend: "snmp",
+		SNMP:    snmp,
+	}
+}
+
+// set stores a MIB value
+func (a *snmpAgentStub) set(oid string, asnType gosnmp.Asn1BER, value interface{}) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.values[oid]; !ok {
+		a.oids = append(a.oids, oid)
+		sort.Slice(a.oids, func(i, j int) bool { return compareOIDs(a.oids[i], a.oids[j]) < 0 })
+	}
+	a.values[oid] = gosnmp.SnmpPDU{Name: oid, Type: asnType, Value: value}
+}
+
+// setSilent makes the agent drop or answer requests
+func (a *snmpAgentStub) setSilent(silent bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.silent = silent
+}
+
+// snmpStubInterface is an ifTable/ifXTable row of the agent stub
+type snmpStubInterface struct {
+	index       int
+	name        string
+	alias       string
+	up          bool
+	in32, out32 uint32
+	hcIn, hcOut uint64
+	noHC        bool // leave the ifXTable HC counters out, like an SNMPv1-era agent
+}
+
+// addInterface stores the ifTable and ifXTable columns of an interface
+func (a *snmpAgentStub) addInterface(iface snmpStubInterface) {
+	suffix := "." + strconv.Itoa(iface.index)
+	operStatus := 2
+	if iface.up {
+		operStatus = ifOperStatusUp
+	}
+	a.set(oidIfName+suffix, gosnmp.OctetString, iface.name)
+	a.set(oidIfOperStatus+suffix, gosnmp.Integer, operStatus)
+	a.set(oidIfInOctets+suffix, gosnmp.Counter32, iface.in32)
+	a.set(oidIfOutOctets+suffix, gosnmp.Counter32, iface.out32)
+	if iface.alias != "" {
+		a.set(oidIfAlias+suffix, gosnmp.OctetString, iface.alias)
+	}
+	if !iface.noHC {
+		a.set(oidIfHCInOctets+suffix, gosnmp.Counter64, iface.hcIn)
+		a.set(oidIfHCOutOctets+suffix, gosnmp.Counter64, iface.hcOut)
+	}
+}
+
+func (a *snmpAgentStub) serve() {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := a.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		request := make([]byte, n)
+		copy(request, buf[:n])
+		if reply := a.handle(request); reply != nil {
+			a.conn.WriteToUDP(reply, addr)
+		}
+	}
+}
+
+// handle decodes a request and returns the encoded reply, nil to drop the request
+func (a *snmpAgentStub) handle(request []byte) []byte {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.silent {
+		return nil
+	}
+
+	// UnmarshalTrap is the gosnmp decoder that also verifies the USM digest
+	packet, err := a.decoder.UnmarshalTrap(request, true)
+	if err != nil {
+		if a.decoder.Version == gosnmp.Version3 && strings.Contains(err.Error(), "not authentic") {
+			return a.report(0, 0, oidUsmStatsWrongDigests)
+		}
+		return nil
+	}
+
+	if packet.Version == gosnmp.Version3 {
+		usm := packet.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+		if usm.AuthoritativeEngineID == "" {
+			return a.report(packet.MsgID, packet.RequestID, oidUsmStatsUnknownEngineIDs)
+		}
+	} else if packet.Community != a.cfg.Community {
+		// Agents silently drop requests with an unknown community
+		return nil
+	}
+
+	var variables []gosnmp.SnmpPDU
+	switch packet.PDUType {
+	case gosnmp.GetRequest:
+		for _, v := range packet.Variables {
+			variables = append(variables, a.get(v.Name))
+		}
+	case gosnmp.GetNextRequest:
+		for _, v := range packet.Variables {
+			variables = append(variables, a.next(v.Name))
+		}
+	case gosnmp.GetBulkRequest:
+		cursors := make([]string, len(packet.Variables))
+		for i, v := range packet.Variables {
+			cursors[i] = v.Name
+		}
+		for r := uint32(0); r < packet.MaxRepetitions; r++ {
+			for i := range cursors {
+				pdu := a.next(cursors[i])
+				cursors[i] = pdu.Name
+				variables = append(variables, pdu)
+			}
+		}
+	default:
+		return nil
+	}
+
+	reply := &gosnmp.SnmpPacket{
+		Version:            packet.Version,
+		Community:          packet.Community,
+		MsgFlags:           packet.MsgFlags &^ gosnmp.Reportable,
+		SecurityModel:      packet.SecurityModel,
+		SecurityParameters: packet.SecurityParameters,
+		MsgID:              packet.MsgID,
+		ContextEngineID:    packet.ContextEngineID,
+		ContextName:        packet.ContextName,
+		PDUType:            gosnmp.GetResponse,
+		RequestID:          packet.RequestID,
+		Variables:          variables,
+	}
+	encoded, err := reply.MarshalMsg()
+	if err != nil {
+		return nil
+	}
+	return encoded
+}
+
+// report encodes an unauthenticated v3 Report carrying a single USM counter
+func (a *snmpAgentStub) report(msgID, requestID uint32, oid string) []byte {
+	reply := &gosnmp.SnmpPacket{
+		Version:       gosnmp.Version3,
+		MsgFlags:      gosnmp.NoAuthNoPriv,
+		SecurityModel: gosnmp.UserSecurityModel,
+		SecurityParameters: &gosnmp.UsmSecurityParameters{
+			AuthoritativeEngineID:    snmpAgentEngineID,
+			AuthoritativeEngineBoots: 1,
+			AuthoritativeEngineTime:  1,
+		},
+		MsgID:           msgID,
+		ContextEngineID: snmpAgentEngineID,
+		PDUType:         gosnmp.Report,
+		RequestID:       requestID,
+		Variables:       []gosnmp.SnmpPDU{{Name: oid, Type: gosnmp.Counter32, Value: uint32(1)}},
+	}
+	encoded, err := reply.MarshalMsg()
+	if err != nil {
+		return nil
+	}
+	return encoded
+}
+
+// get returns the value of an OID or noSuchObject. The caller must hold a.mu.
+func (a *snmpAgentStub) get(oid string) gosnmp.SnmpPDU {
+	if pdu, ok := a.values[oid]; ok {
+		return pdu
+	}
+	return gosnmp.SnmpPDU{Name: oid, Type: gosnmp.NoSuchObject}
+}
+
+// next returns the first value after an OID or endOfMibView. The caller must hold a.mu.
+func (a *snmpAgentStub) next(oid string) gosnmp.SnmpPDU {
+	i := sort.Search(len(a.oids), func(i int) bool { return compareOIDs(a.oids[i], oid) > 0 })
+	if i == len(a.oids) {
+		return gosnmp.SnmpPDU{Name: oid, Type: gosnmp.EndOfMibView}
+	}
+	return a.values[a.oids[i]]
+}
+
+// compareOIDs orders two dotted OIDs sub-identifier by sub-identifier
+func compareOIDs(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "."), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "."), ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, _ := strconv.Atoi(as[i])
+		y, _ := strconv.Atoi(bs[i])
+		if x != y {
+			return x - y
+		}
+	}
+	return len(as) - len(bs)
+}
+
+var snmpV2cCredentials = config.RouterSNMPConfig{Version: "v2c", Community: "monik"}
+
+func TestSNMPCollectorGetInterfaces(t *testing.T) {
+	tests := []struct {
+		name       string
+		interfaces []snmpStubInterface
+		want       map[string]InterfaceData // by name, LastUpdated and rates ignored
+	}{
+		{
+			name: "64-bit counters from ifXTable",
+			interfaces: []snmpStubInterface{
+				{index: 1, name: "ether1", alias: "uplink", up: true, in32: 10, out32: 20, hcIn: 1 << 40, hcOut: 5<<32 + 7},
+				{index: 2, name: "ether2", in32: 1, out32: 2, hcIn: 3, hcOut: 4},
+			},
+			want: map[string]InterfaceData{
+				"ether1": {Name: "ether1", ObjectID: "1", RxBytes: 1 << 40, TxBytes: 5<<32 + 7, Status: "true", Comment: "uplink"},
+				"ether2": {Name: "ether2", ObjectID: "2", RxBytes: 3, TxBytes: 4, Status: "false"},
+			},
+		},
+		{
+			name: "32-bit fallback without HC counters",
+			interfaces: []snmpStubInterface{
+				{index: 3, name: "ether1", up: true, in32: 4294967295, out32: 17, noHC: true},
+				{index: 12, name: "wlan1", up: true, in32: 5, out32: 6, noHC: true},
+			},
+			want: map[string]InterfaceData{
+				"ether1": {Name: "ether1", ObjectID: "3", RxBytes: 4294967295, TxBytes: 17, Status: "true", Counter32: true},
+				"wlan1":  {Name: "wlan1", ObjectID: "12", RxBytes: 5, TxBytes: 6, Status: "true", Counter32: true},
+			},
+		},
+		{
+			name: "more rows than one bulk reply",
+			interfaces: func() []snmpStubInterface {
+				var rows []snmpStubInterface
+				for i := 1; i <= 40; i++ {
+					rows = append(rows, snmpStubInterface{index: i, name: "vlan" + strconv.Itoa(i), hcIn: uint64(i), hcOut: uint64(2 * i)})
+				}
+				return rows
+			}(),
+			want: map[string]InterfaceData{
+				"vlan1":  {Name: "vlan1", ObjectID: "1", RxBytes: 1, TxBytes: 2, Status: "false"},
+				"vlan40": {Name: "vlan40", ObjectID: "40", RxBytes: 40, TxBytes: 80, Status: "false"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := newSNMPAgentStub(t, snmpV2cCredentials)
+			for _, iface := range tt.interfaces {
+				agent.addInterface(iface)
+			}
+			collector := NewSNMPCollector(agent.routerConfig(snmpV2cCredentials))
+			defer collector.Close()
+
+			got, err := collector.GetInterfaces(context.Background())
+			if err != nil {
+				t.Fatalf("GetInterfaces: %v", err)
+			}
+			if len(got) != len(tt.interfaces) {
+				t.Fatalf("got %d interfaces, want %d", len(got), len(tt.interfaces))
+			}
+			for _, iface := range got {
+				want, ok := tt.want[iface.Name]
+				if !ok {
+					continue
+				}
+				iface.LastUpdated, iface.RxRate, iface.TxRate = time.Time{}, 0, 0
+				if iface != want {
+					t.Errorf("interface %s = %+v, want %+v", iface.Name, iface, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSNMPCollectorCounterWidthFollowsDiscovery(t *testing.T) {
+	agent := newSNMPAgentStub(t, snmpV2cCredentials)
+	agent.addInterface(snmpStubInterface{index: 1, name: "ether1", up: true, in32: 100, out32: 200, noHC: true})
+	collector := NewSNMPCollector(agent.routerConfig(snmpV2cCredentials))
+	defer collector.Close()
+
+	if _, err := collector.GetInterfaces(context.Background()); err != nil {
+		t.Fatalf("GetInterfaces: %v", err)
+	}
+	// An older reading forces GetTrafficStats to query the agent again
+	collector.samples["ether1"].at = time.Now().Add(-time.Minute)
+	agent.set(oidIfInOctets+".1", gosnmp.Counter32, uint32(300))
+
+	stats, err := collector.GetTrafficStats(context.Background(), "ether1")
+	if err != nil {
+		t.Fatalf("GetTrafficStats: %v", err)
+	}
+	if !stats.Counter32 || stats.RxBytes != 300 || stats.TxBytes != 200 {
+		t.Errorf("stats = %+v, want the 32-bit counters 300/200", stats)
+	}
+}
+
+func TestSNMPCollectorCredentials(t *testing.T) {
+	v3 := func(auth, authPassword, priv, privPassword string) config.RouterSNMPConfig {
+		return config.RouterSNMPConfig{
+			Version:      "v3",
+			Username:     "monitor",
+			AuthProtocol: auth,
+			AuthPassword: authPassword,
+			PrivProtocol: priv,
+			PrivPassword: privPassword,
+		}
+	}
+	tests := []struct {
+		name    string
+		agent   config.RouterSNMPConfig
+		client  config.RouterSNMPConfig
+		wantErr error // nil for success, context.DeadlineExceeded for a dropped request
+	}{
+		{
+			name:   "v2c community",
+			agent:  snmpV2cCredentials,
+			client: snmpV2cCredentials,
+		},
+		{
+			name:    "v2c wrong community",
+			agent:   snmpV2cCredentials,
+			client:  config.RouterSNMPConfig{Version: "2c", Community: "public"},
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name:   "v3 noAuthNoPriv",
+			agent:  v3("", "", "", ""),
+			client: v3("", "", "", ""),
+		},
+		{
+			name:   "v3 authNoPriv MD5",
+			agent:  v3("MD5", "auth-secret", "", ""),
+			client: v3("MD5", "auth-secret", "", ""),
+		},
+		{
+			name:   "v3 authPriv SHA/AES",
+			agent:  v3("SHA", "auth-secret", "AES", "priv-secret"),
+			client: v3("SHA", "auth-secret", "AES", "priv-secret"),
+		},
+		{
+			name:   "v3 authPriv SHA256/AES256",
+			agent:  v3("SHA256", "auth-secret", "AES256", "priv-secret"),
+			client: v3("SHA256", "auth-secret", "AES256", "priv-secret"),
+		},
+		{
+			name:   "v3 authPriv SHA/DES",
+			agent:  v3("SHA", "auth-secret", "DES", "priv-secret"),
+			client: v3("SHA", "auth-secret", "DES", "priv-secret"),
+		},
+		{
+			name:    "v3 wrong auth password",
+			agent:   v3("SHA", "auth-secret", "AES", "priv-secret"),
+			client:  v3("SHA", "not-the-secret", "AES", "priv-secret"),
+			wantErr: gosnmp.ErrWrongDigest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := newSNMPAgentStub(t, tt.agent)
+			agent.addInterface(snmpStubInterface{index: 1, name: "ether1", up: true, hcIn: 42, hcOut: 43})
+			collector := NewSNMPCollector(agent.routerConfig(tt.client))
+			defer collector.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			got, err := collector.GetInterfaces(ctx)
+			if tt.wantErr != nil {
+				if err == nil || !(errors.Is(err, tt.wantErr) || strings.Contains(err.Error(), tt.wantErr.Error())) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetInterfaces: %v", err)
+			}
+			if len(got) != 1 || got[0].RxBytes != 42 || got[0].TxBytes != 43 {
+				t.Errorf("interfaces = %+v, want ether1 with 42/43 bytes", got)
+			}
+		})
+	}
+}
+
+func TestSNMPV3SecurityParameters(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       config.RouterSNMPConfig
+		wantFlags gosnmp.SnmpV3MsgFlags
+		wantAuth  gosnmp.SnmpV3AuthProtocol
+		wantPriv  gosnmp.SnmpV3PrivProtocol
+		wantErr   string
+	}{
+		{
+			name:      "user only",
+			cfg:       config.RouterSNMPConfig{Username: "monitor"},
+			wantFlags: gosnmp.NoAuthNoPriv, wantAuth: gosnmp.NoAuth, wantPriv: gosnmp.NoPriv,
+		},
+		{
+			name:      "auth defaults to SHA",
+			cfg:       config.RouterSNMPConfig{Username: "monitor", AuthPassword: "auth-secret"},
+			wantFlags: gosnmp.AuthNoPriv, wantAuth: gosnmp.SHA, wantPriv: gosnmp.NoPriv,
+		},
+		{
+			name:      "privacy defaults to AES",
+			cfg:       config.RouterSNMPConfig{Username: "monitor", AuthProtocol: "sha512", AuthPassword: "a", PrivPassword: "p"},
+			wantFlags: gosnmp.AuthPriv, wantAuth: gosnmp.SHA512, wantPriv: gosnmp.AES,
+		},
+		{
+			name:    "missing username",
+			cfg:     config.RouterSNMPConfig{AuthPassword: "auth-secret"},
+			wantErr: "requires a username",
+		},
+		{
+			name:    "privacy without authentication",
+			cfg:     config.RouterSNMPConfig{Username: "monitor", PrivPassword: "priv-secret"},
+			wantErr: "privacy requires authentication",
+		},
+		{
+			name:    "unsupported auth protocol",
+			cfg:     config.RouterSNMPConfig{Username: "monitor", AuthProtocol: "SHA3", AuthPassword: "a"},
+			wantErr: "unsupported SNMP auth protocol",
+		},
+		{
+			name:    "unsupported privacy protocol",
+			cfg:     config.RouterSNMPConfig{Username: "monitor", AuthPassword: "a", PrivProtocol: "3DES", PrivPassword: "p"},
+			wantErr: "unsupported SNMP privacy protocol",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, flags, err := snmpV3SecurityParameters(tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if flags != tt.wantFlags || params.AuthenticationProtocol != tt.wantAuth || params.PrivacyProtocol != tt.wantPriv {
+				t.Errorf("got %v/%v/%v, want %v/%v/%v", flags, params.AuthenticationProtocol, params.PrivacyProtocol,
+					tt.wantFlags, tt.wantAuth, tt.wantPriv)
+			}
+		})
+	}
+}
+
+func TestSNMPCollectorTimeout(t *testing.T) {
+	agent := newSNMPAgentStub(t, snmpV2cCredentials)
+	agent.addInterface(snmpStubInterface{index: 1, name: "ether1", up: true, hcIn: 1, hcOut: 2})
+	agent.set(oidSysUpTime, gosnmp.TimeTicks, uint32(360000))
+	collector := NewSNMPCollector(agent.routerConfig(snmpV2cCredentials))
+	defer collector.Close()
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context) error
+	}{
+		{
+			name: "walk",
+			call: func(ctx context.Context) error {
+				_, err := collector.GetInterfaces(ctx)
+				return err
+			},
+		},
+		{
+			name: "get",
+			call: func(ctx context.Context) error {
+				_, err := collector.GetUptime(ctx)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent.setSilent(true)
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			started := time.Now()
+			err := tt.call(ctx)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("error = %v, want context.DeadlineExceeded", err)
+			}
+			if elapsed := time.Since(started); elapsed > 2*time.Second {
+				t.Errorf("request took %v with a 200ms deadline", elapsed)
+			}
+			if collector.client != nil {
+				t.Error("session kept after a timeout")
+			}
+
+			// The next poll reconnects once the agent answers again
+			agent.setSilent(false)
+			if err := tt.call(context.Background()); err != nil {
+				t.Fatalf("call after recovery: %v", err)
+			}
+		})
+	}
+}
+
+func TestSNMPCollectorGetSystemInfo(t *testing.T) {
+	agent := newSNMPAgentStub(t, snmpV2cCredentials)
+	agent.set(oidSysName, gosnmp.OctetString, "core-router")
+	agent.set(oidSysDescr, gosnmp.OctetString, "RouterOS CCR2004-16G-2S+")
+	agent.set(oidSysUpTime, gosnmp.TimeTicks, uint32(9000)) // 90 seconds
+	collector := NewSNMPCollector(agent.routerConfig(snmpV2cCredentials))
+	defer collector.Close()
+
+	info, err := collector.GetSystemInfo(context.Background())
+	if err != nil {
+		t.Fatalf("GetSystemInfo: %v", err)
+	}
+	if info.Identity != "core-router" || info.BoardName != "CCR2004-16G-2S+" || info.Uptime != "1m30s" {
+		t.Errorf("info = %+v", info)
+	}
+	uptime, err := collector.GetUptime(context.Background())
+	if err != nil {
+		t.Fatalf("GetUptime: %v", err)
+	}
+	if uptime != 90*time.Second {
+		t.Errorf("uptime = %v, want 1m30s", uptime)
+	}
+}
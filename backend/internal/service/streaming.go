@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"monik-enterprise/internal/config"
+	"monik-enterprise/internal/models"
+)
+
+// Collection modes of the monitoring loop
+const (
+	PollModePoll   = "poll"   // GetInterfaces plus one monitor-traffic call per interface
+	PollModeStream = "stream" // Long-lived /interface/print subscription where supported
+)
+
+// routerStream is a running interface subscription of one router
+type routerStream struct {
+	cancel   context.CancelFunc
+	config   config.RouterConfig // Settings the subscription was opened with
+	interval time.Duration
+}
+
+// ensureStream makes sure a router with a streaming-capable collector has a running
+// subscription and reports whether the router is streamed. Routers whose collector
+// cannot stream keep being polled.
+func (s *MonitoringService) ensureStream(router models.Router) bool {
+	collector, err := s.registry.Collector(router)
+	if err != nil {
+		return false
+	}
+	streamer, ok := collector.(StreamingCollector)
+	if !ok {
+		return false
+	}
+
+	cfg := s.registry.routerConfig(router)
+	interval := s.baseInterval(router)
+
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
+
+	if stream, ok := s.streams[router.ID]; ok {
+		if stream.config == cfg && stream.interval == interval {
+			return true
+		}
+		s.logger.Info(ComponentMonitoring, "stream", "Router settings changed, resubscribing", map[string]interface{}{
+			"router": router.Name,
+		})
+		stream.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.streams[router.ID] = &routerStream{cancel: cancel, config: cfg, interval: interval}
+	s.setStreaming(router.ID, true)
+	s.wg.Add(1)
+	go s.streamRouter(ctx, router, streamer, interval)
+	return true
+}
+
+// stopStreams cancels every subscription whose router is not in keep
+func (s *MonitoringService) stopStreams(keep map[uint]bool) {
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
+
+	for id, stream := range s.streams {
+		if keep[id] {
+			continue
+		}
+		s.logger.Info(ComponentMonitoring, "stream", "Stopping subscription", map[string]interface{}{
+			"router_id": id,
+		})
+		stream.cancel()
+		delete(s.streams, id)
+		s.setStreaming(id, false)
+	}
+}
+
+// setStreaming flags the poll state of a router as streamed
+func (s *MonitoringService) setStreaming(routerID uint, streaming bool) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	state, ok := s.routerStates[routerID]
+	if !ok {
+		state = &RouterPollState{RouterID: routerID}
+		s.routerStates[routerID] = state
+	}
+	state.Streaming = streaming
+}
+
+// streamRouter keeps a router subscribed until ctx is cancelled. When the subscription
+// drops the router is marked offline and resubscribed after RetryDelay, growing up to
+// MaxInterval in adaptive mode while the router stays unreachable.
+func (s *MonitoringService) streamRouter(ctx context.Context, router models.Router, streamer StreamingCollector, interval time.Duration) {
+	defer s.wg.Done()
+
+	delay := s.pollConfig.RetryDelay
+	for {
+		s.logger.Info(ComponentMonitoring, "stream", "Subscribing to interfaces", map[string]interface{}{
+			"router":   router.Name,
+			"interval": interval.String(),
+		})
+		received := false
+		err := streamer.StreamInterfaces(ctx, interval, func(interfaces []InterfaceData) {
+			received = true
+			s.recordPollResult(router, nil)
+			s.registry.RecordStatus(router.ID, nil)
+			s.processInterfaces(ctx, router, interfaces)
+		})
+		if ctx.Err() != nil {
+			s.logger.Info(ComponentMonitoring, "stream", "Subscription closed", map[string]interface{}{
+				"router": router.Name,
+			})
+			return
+		}
+
+		if received {
+			delay = s.pollConfig.RetryDelay
+		}
+		s.logger.Warn(ComponentMonitoring, "stream", "Subscription dropped, resubscribing", map[string]interface{}{
+			"router": router.Name,
+			"delay":  delay.String(),
+			"error":  fmt.Sprint(err),
+		})
+		s.recordPollResult(router, err)
+		s.registry.RecordStatus(router.ID, err)
+		s.RecordOfflineStatus(router.ID)
+
+		if !s.sleepContext(ctx, delay) {
+			return
+		}
+		if s.pollConfig.Adaptive {
+			delay = time.Duration(float64(delay) * s.pollConfig.BackoffMultiplier)
+			if delay > s.pollConfig.MaxInterval {
+				delay = s.pollConfig.MaxInterval
+			}
+		}
+	}
+}
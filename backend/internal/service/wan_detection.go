@@ -22,7 +22,9 @@ type WANDetectionService struct {
 	mu           sync.RWMutex
 	lastUpdate   time.Time
 	websocketMgr *websocket.WebSocketManager
+	notifier     *NotificationService
 	metrics      *WANDetectionMetrics
+	logger       *LoggerService
 }
 
 type WANDetectionCache struct {
@@ -88,6 +90,25 @@ func (s *WANDetectionService) SetWebSocketManager(wsMgr *websocket.WebSocketMana
 	s.websocketMgr = wsMgr
 }
 
+// SetNotifier sets the notification service that receives WAN failover events
+func (s *WANDetectionService) SetNotifier(notifier *NotificationService) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifier = notifier
+}
+
+// SetLogger sets the structured logger of WAN detection
+func (s *WANDetectionService) SetLogger(logger *LoggerService) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logger = logger
+}
+
+// Enabled reports whether WAN detection is turned on (WAN_ENABLED)
+func (s *WANDetectionService) Enabled() bool {
+	return s.config.Enabled
+}
+
 // ensureConnected melakukan lazy connection dan pengecekan nil
 func (s *WANDetectionService) ensureConnected(ctx context.Context) error {
 	s.mu.Lock()
@@ -118,7 +139,7 @@ func (s *WANDetectionService) ensureConnected(ctx context.Context) error {
 func (s *WANDetectionService) DetectWANInterface(ctx context.Context) (*WANInterface, error) {
 	// 1. CEK KONEKSI SEBELUM MULAI (Mencegah Panic)
 	if err := s.ensureConnected(ctx); err != nil {
-		fmt.Printf("[WAN-ERROR] Connection failed: %v\n", err)
+		s.logger.Error(ComponentWAN, "detect", "Connection failed", err, nil)
 		return &WANInterface{
 			Name:        "none",
 			Method:      "error",
@@ -161,14 +182,27 @@ func (s *WANDetectionService) DetectWANInterface(ctx context.Context) (*WANInter
 		bestWAN.LastUpdated = time.Now()
 		bestWAN.ISPName = s.detectISPName(bestWAN.Name)
 
+		previous := s.cache.Interface
 		s.cache.Interface = bestWAN
 		s.cache.LastUpdated = time.Now()
 		s.metrics.RecordDetection(detectionMethod, confidence)
+		s.logger.Debug(ComponentWAN, "detect", "WAN interface detected", map[string]interface{}{
+			"interface":  bestWAN.Name,
+			"method":     detectionMethod,
+			"confidence": confidence,
+			"isp":        bestWAN.ISPName,
+		})
 		s.notifyWANDetected(bestWAN)
+		if previous != nil && previous.Name != bestWAN.Name {
+			s.notifyWANFailover(previous, bestWAN)
+		}
 		return bestWAN, nil
 	}
 
 	s.metrics.RecordDetectionFailure()
+	s.logger.Warn(ComponentWAN, "detect", "No WAN interface found", map[string]interface{}{
+		"method": s.config.DetectionMethod,
+	})
 	return &WANInterface{
 		Name:        "none",
 		Method:      "not_found",
@@ -353,6 +387,35 @@ func (s *WANDetectionService) notifyWANDetected(wan *WANInterface) {
 	}
 }
 
+// notifyWANFailover reports that the active WAN moved to another interface
+func (s *WANDetectionService) notifyWANFailover(from, to *WANInterface) {
+	message := fmt.Sprintf("WAN failover: %s (%s) -> %s (%s)", from.Name, from.ISPName, to.Name, to.ISPName)
+	s.logger.Warn(ComponentWAN, "failover", "WAN failover", map[string]interface{}{
+		"from":     from.Name,
+		"from_isp": from.ISPName,
+		"to":       to.Name,
+		"to_isp":   to.ISPName,
+	})
+	data := map[string]interface{}{
+		"from":     from.Name,
+		"from_isp": from.ISPName,
+		"to":       to.Name,
+		"to_isp":   to.ISPName,
+		"method":   to.Method,
+	}
+	if s.websocketMgr != nil {
+		s.websocketMgr.BroadcastEvent(NotifyEventWANFailover, message, data)
+	}
+	s.notifier.Notify(Notification{
+		Event:         NotifyEventWANFailover,
+		Severity:      AlertSeverityCritical,
+		Title:         fmt.Sprintf("WAN failover to %s", to.Name),
+		Message:       message,
+		InterfaceName: to.Name,
+		Data:          data,
+	})
+}
+
 // --- INTERNAL HELPERS ---
 // Menggunakan InterfaceData yang sudah didefinisikan di mikrotik.go
 
@@ -373,8 +436,8 @@ func (s *WANDetectionService) getInternalInterfaceDetails(ctx context.Context, n
 	re := reply.Re[0].Map
 	return &InterfaceData{
 		Name:    name,
-		RxBytes: parseUint64(re["rx-byte"]),
-		TxBytes: parseUint64(re["tx-byte"]),
+		RxBytes: parseUint64(s.logger, re["rx-byte"]),
+		TxBytes: parseUint64(s.logger, re["tx-byte"]),
 		Status:  re["running"],
 	}, nil
 }
@@ -395,8 +458,8 @@ func (s *WANDetectionService) getAllInternalInterfaces(ctx context.Context) ([]*
 	for _, re := range reply.Re {
 		res = append(res, &InterfaceData{
 			Name:    re.Map["name"],
-			RxBytes: parseUint64(re.Map["rx-byte"]),
-			TxBytes: parseUint64(re.Map["tx-byte"]),
+			RxBytes: parseUint64(s.logger, re.Map["rx-byte"]),
+			TxBytes: parseUint64(s.logger, re.Map["tx-byte"]),
 			Status:  re.Map["running"],
 			Comment: re.Map["comment"], // Pastikan kolom comment diambil
 		})
@@ -21,6 +21,7 @@ type WorkerPool struct {
 	metrics        *WorkerMetrics
 	circuitBreaker *CircuitBreaker
 	loadBalancer   *LoadBalancer
+	logger         *LoggerService
 }
 
 // Worker represents a worker in the pool
@@ -29,7 +30,7 @@ type Worker struct {
 	JobQueue   chan Job
 	WorkerPool chan chan Job
 	Quit       chan bool
-	Service    *MikroTikService
+	Service    RouterCollector
 	ActiveJobs int
 	Stats      *WorkerStats
 }
@@ -113,7 +114,7 @@ const (
 )
 
 // NewWorkerPool creates a new worker pool
-func NewWorkerPool(config config.WorkerPoolConfig, service *MikroTikService) *WorkerPool {
+func NewWorkerPool(config config.WorkerPoolConfig, service RouterCollector) *WorkerPool {
 	pool := &WorkerPool{
 		config:     config,
 		workers:    make([]*Worker, 0, config.MaxWorkers),
@@ -166,6 +167,11 @@ func NewLoadBalancer(strategy LoadBalancingStrategy) *LoadBalancer {
 	}
 }
 
+// SetLogger sets the structured logger of the pool
+func (wp *WorkerPool) SetLogger(logger *LoggerService) {
+	wp.logger = logger
+}
+
 // Start starts the worker pool
 func (wp *WorkerPool) Start() {
 	wp.wg.Add(len(wp.workers))
@@ -180,6 +186,11 @@ func (wp *WorkerPool) Start() {
 
 	// Start circuit breaker monitoring
 	go wp.circuitBreaker.monitor()
+
+	wp.logger.Info(ComponentWorkerPool, "start", "Worker pool started", map[string]interface{}{
+		"workers":    len(wp.workers),
+		"queue_size": cap(wp.jobQueue),
+	})
 }
 
 // monitor monitors the circuit breaker state
@@ -337,6 +348,7 @@ func (wp *WorkerPool) Stop() {
 	}
 
 	wp.wg.Wait()
+	wp.logger.Info(ComponentWorkerPool, "stop", "Worker pool stopped", nil)
 }
 
 // SubmitJob submits a job to the worker pool
@@ -392,6 +404,10 @@ func (wp *WorkerPool) dispatch() {
 				wp.metrics.mu.Lock()
 				wp.metrics.FailedJobs++
 				wp.metrics.mu.Unlock()
+				wp.logger.Debug(ComponentWorkerPool, "dispatch", "Circuit open, job dropped", map[string]interface{}{
+					"interface": job.InterfaceName,
+					"type":      job.Type,
+				})
 				continue
 			}
 
@@ -483,7 +499,20 @@ func (wp *WorkerPool) processJob(worker *Worker, job Job) {
 		wp.metrics.mu.Unlock()
 
 		// Record failure in circuit breaker
+		wasOpen := wp.circuitBreaker.GetState() == CircuitOpen
 		wp.circuitBreaker.RecordFailure()
+		if !wasOpen && wp.circuitBreaker.GetState() == CircuitOpen {
+			wp.logger.Warn(ComponentWorkerPool, "circuit_breaker", "Circuit opened, jobs are dropped until it recovers", map[string]interface{}{
+				"recovery_timeout": wp.circuitBreaker.config.RecoveryTimeout.String(),
+			})
+		}
+		wp.logger.Warn(ComponentWorkerPool, "job", "Job failed", map[string]interface{}{
+			"worker":    worker.ID,
+			"interface": job.InterfaceName,
+			"type":      job.Type,
+			"attempt":   job.RetryCount + 1,
+			"error":     err.Error(),
+		})
 
 		// Exponential backoff retry logic
 		if job.RetryCount < job.MaxRetries {
@@ -545,3 +574,8 @@ func (wp *WorkerPool) GetQueueSize() int {
 func (wp *WorkerPool) GetQueueCapacity() int {
 	return cap(wp.jobQueue)
 }
+
+// GetCircuitState returns the state of the pool's circuit breaker
+func (wp *WorkerPool) GetCircuitState() CircuitState {
+	return wp.circuitBreaker.GetState()
+}
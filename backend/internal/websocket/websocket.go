@@ -3,22 +3,39 @@ package websocket
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 
+	appLogger "monik-enterprise/pkg/logger"
+
 	"github.com/gorilla/websocket"
 )
 
 // WebSocketManager manages WebSocket connections and subscriptions
 type WebSocketManager struct {
 	clients       map[string]*Client
-	subscriptions map[string]map[*Client]bool // interface -> clients
+	subscriptions map[string]map[*Client]bool // subscription key (see SubscriptionKey) -> clients
 	mu            sync.RWMutex
 	broadcast     chan interface{}
 	eventBus      *EventBus
 	metrics       *WebSocketMetrics
+
+	allowedOrigins []string // Browser origins allowed to connect, "*" allows any
+	logger         Logger   // nil logs through the application logger
+}
+
+// LogComponent is the component the manager logs as
+const LogComponent = "websocket"
+
+// Logger is the structured logger the manager reports through, implemented by
+// service.LoggerService
+type Logger interface {
+	Debug(component, operation, message string, metadata map[string]interface{})
+	Info(component, operation, message string, metadata map[string]interface{})
+	Warn(component, operation, message string, metadata map[string]interface{})
 }
 
 // Client represents a WebSocket client connection
@@ -29,10 +46,18 @@ type Client struct {
 	Closed    chan bool
 	Sub       string // Interface ID that is subscribed
 	Connected time.Time
+	Scope     Scope // Limits what the client receives, nil when unrestricted
+}
+
+// Scope limits a client to some routers and interfaces
+type Scope interface {
+	AllowsRouter(routerID uint) bool
+	AllowsInterface(routerID uint, iface string) bool
 }
 
 // RealTimeData represents real-time monitoring data
 type RealTimeData struct {
+	RouterID      uint      `json:"router_id"`
 	InterfaceName string    `json:"interface_name"`
 	RxRate        float64   `json:"rx_rate"`
 	TxRate        float64   `json:"tx_rate"`
@@ -65,6 +90,61 @@ func NewWebSocketManager() *WebSocketManager {
 	}
 }
 
+// SetAllowedOrigins sets the browser origins allowed to open connections. Requests
+// without an Origin header and same-host origins are always accepted.
+func (wm *WebSocketManager) SetAllowedOrigins(origins []string) {
+	wm.allowedOrigins = origins
+}
+
+// SetLogger sets the structured logger of the manager
+func (wm *WebSocketManager) SetLogger(logger Logger) {
+	wm.logger = logger
+}
+
+// logDebug, logInfo and logWarn report through the logger, or the application logger
+// when none is set
+func (wm *WebSocketManager) logDebug(operation, message string, metadata map[string]interface{}) {
+	if wm.logger != nil {
+		wm.logger.Debug(LogComponent, operation, message, metadata)
+	}
+}
+
+func (wm *WebSocketManager) logInfo(operation, message string, metadata map[string]interface{}) {
+	if wm.logger == nil {
+		appLogger.Info("[WEBSOCKET] %s %v", message, metadata)
+		return
+	}
+	wm.logger.Info(LogComponent, operation, message, metadata)
+}
+
+func (wm *WebSocketManager) logWarn(operation, message string, metadata map[string]interface{}) {
+	if wm.logger == nil {
+		appLogger.Warn("[WEBSOCKET] %s %v", message, metadata)
+		return
+	}
+	wm.logger.Warn(LogComponent, operation, message, metadata)
+}
+
+// checkOrigin accepts the upgrade when the Origin header is allowed
+func (wm *WebSocketManager) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range wm.allowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	wm.logWarn("connect", "Connection from disallowed origin rejected", map[string]interface{}{
+		"origin": origin,
+	})
+	return false
+}
+
 // Start starts the WebSocket manager
 func (wm *WebSocketManager) Start() {
 	go wm.run()
@@ -86,24 +166,40 @@ func (wm *WebSocketManager) handleBroadcast(data interface{}) {
 
 	switch dataRealTime := data.(type) {
 	case RealTimeData:
-		if clients, exists := wm.subscriptions[dataRealTime.InterfaceName]; exists {
-			for client := range clients {
-				select {
-				case client.Send <- wm.serializeData(dataRealTime):
-					wm.metrics.RecordMessageSent()
-				case <-client.Closed:
-					// Client disconnected, will be cleaned up
-				default:
-					// Channel full, skip this message
-					wm.metrics.RecordMessageDropped()
-					log.Printf("Client %s channel full, skipping message", client.ID)
-				}
+		// Deliver to router-scoped subscribers and to subscribers of the interface on any router
+		recipients := make(map[*Client]bool)
+		for _, key := range []string{
+			SubscriptionKey(dataRealTime.RouterID, dataRealTime.InterfaceName),
+			SubscriptionKey(0, dataRealTime.InterfaceName),
+		} {
+			for client := range wm.subscriptions[key] {
+				recipients[client] = true
+			}
+		}
+		for client := range recipients {
+			if client.Scope != nil && !client.Scope.AllowsInterface(dataRealTime.RouterID, dataRealTime.InterfaceName) {
+				continue
+			}
+			select {
+			case client.Send <- wm.serializeData(dataRealTime):
+				wm.metrics.RecordMessageSent()
+			case <-client.Closed:
+				// Client disconnected, will be cleaned up
+			default:
+				// Channel full, skip this message
+				wm.metrics.RecordMessageDropped()
+				wm.logDebug("broadcast", "Client channel full, skipping message", map[string]interface{}{
+					"client": client.ID,
+				})
 			}
 		}
 	case EventData:
-		// Broadcast events to all clients
+		// Broadcast events to all clients, restricted clients only get events of their routers
 		jsonData := wm.serializeEvent(dataRealTime)
 		for _, client := range wm.clients {
+			if client.Scope != nil && !eventInScope(client.Scope, dataRealTime) {
+				continue
+			}
 			select {
 			case client.Send <- jsonData:
 				wm.metrics.RecordMessageSent()
@@ -114,17 +210,33 @@ func (wm *WebSocketManager) handleBroadcast(data interface{}) {
 	}
 }
 
-// HandleConnection handles a new WebSocket connection
-func (wm *WebSocketManager) HandleConnection(w http.ResponseWriter, r *http.Request) {
+// eventInScope reports whether an event concerns a router and interface in scope. Events
+// that name no router are not sent to restricted clients.
+func eventInScope(scope Scope, event EventData) bool {
+	routerID, ok := event.Data["router_id"].(uint)
+	if !ok {
+		return false
+	}
+	for _, key := range []string{"interface_name", "interface"} {
+		if iface, ok := event.Data[key].(string); ok && iface != "" {
+			return scope.AllowsInterface(routerID, iface)
+		}
+	}
+	return scope.AllowsRouter(routerID)
+}
+
+// HandleConnection handles a new WebSocket connection. scope limits what the client may
+// subscribe to and receive, nil allows everything.
+func (wm *WebSocketManager) HandleConnection(w http.ResponseWriter, r *http.Request, scope Scope) {
 	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool {
-			return true // Allow all origins for now
-		},
+		CheckOrigin: wm.checkOrigin,
 	}
 
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Printf("WebSocket upgrade failed: %v", err)
+		wm.logWarn("connect", "Upgrade failed", map[string]interface{}{
+			"error": err.Error(),
+		})
 		return
 	}
 
@@ -134,11 +246,18 @@ func (wm *WebSocketManager) HandleConnection(w http.ResponseWriter, r *http.Requ
 		Send:      make(chan []byte, 1024), // Increased buffer for better performance
 		Closed:    make(chan bool),
 		Connected: time.Now(),
+		Scope:     scope,
 	}
 
 	wm.mu.Lock()
 	wm.clients[client.ID] = client
 	wm.mu.Unlock()
+	wm.metrics.RecordConnection()
+	wm.logDebug("connect", "Client connected", map[string]interface{}{
+		"client":     client.ID,
+		"remote":     r.RemoteAddr,
+		"restricted": scope != nil,
+	})
 
 	// Start client handlers
 	go wm.readPump(client)
@@ -166,7 +285,10 @@ func (wm *WebSocketManager) readPump(client *Client) {
 		_, message, err := client.Conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("WebSocket read error: %v", err)
+				wm.logInfo("read", "Connection closed unexpectedly", map[string]interface{}{
+					"client": client.ID,
+					"error":  err.Error(),
+				})
 			}
 			break
 		}
@@ -218,6 +340,7 @@ func (wm *WebSocketManager) writePump(client *Client) {
 func (wm *WebSocketManager) handleMessage(client *Client, message []byte) {
 	var req struct {
 		Action     string   `json:"action"`
+		RouterID   uint     `json:"router_id"` // 0 selects the interface on every router
 		Interface  string   `json:"interface"`
 		Interfaces []string `json:"interfaces"`
 	}
@@ -230,15 +353,15 @@ func (wm *WebSocketManager) handleMessage(client *Client, message []byte) {
 	switch req.Action {
 	case "subscribe":
 		if req.Interface != "" {
-			wm.subscribeClient(client, []string{req.Interface})
+			wm.subscribeClient(client, req.RouterID, []string{req.Interface})
 		} else if len(req.Interfaces) > 0 {
-			wm.subscribeClient(client, req.Interfaces)
+			wm.subscribeClient(client, req.RouterID, req.Interfaces)
 		}
 	case "unsubscribe":
 		if req.Interface != "" {
-			wm.unsubscribeClient(client, []string{req.Interface})
+			wm.unsubscribeClient(client, req.RouterID, []string{req.Interface})
 		} else if len(req.Interfaces) > 0 {
-			wm.unsubscribeClient(client, req.Interfaces)
+			wm.unsubscribeClient(client, req.RouterID, req.Interfaces)
 		}
 	case "ping":
 		wm.sendPong(client)
@@ -249,16 +372,43 @@ func (wm *WebSocketManager) handleMessage(client *Client, message []byte) {
 	}
 }
 
+// SubscriptionKey builds the subscription key for an interface on a router.
+// routerID 0 matches the interface name on every router.
+func SubscriptionKey(routerID uint, iface string) string {
+	if routerID == 0 {
+		return iface
+	}
+	return fmt.Sprintf("%d/%s", routerID, iface)
+}
+
 // subscribeClient subscribes a client to interface updates
-func (wm *WebSocketManager) subscribeClient(client *Client, interfaces []string) {
+func (wm *WebSocketManager) subscribeClient(client *Client, routerID uint, interfaces []string) {
+	// Subscriptions on every router stay allowed, broadcasts are filtered per client
+	if client.Scope != nil && routerID != 0 {
+		allowed := interfaces[:0:0]
+		for _, iface := range interfaces {
+			if client.Scope.AllowsInterface(routerID, iface) {
+				allowed = append(allowed, iface)
+			}
+		}
+		if len(allowed) < len(interfaces) {
+			wm.sendError(client, "Some interfaces are outside your scope")
+		}
+		if len(allowed) == 0 {
+			return
+		}
+		interfaces = allowed
+	}
+
 	wm.mu.Lock()
 	defer wm.mu.Unlock()
 
 	for _, iface := range interfaces {
-		if _, exists := wm.subscriptions[iface]; !exists {
-			wm.subscriptions[iface] = make(map[*Client]bool)
+		key := SubscriptionKey(routerID, iface)
+		if _, exists := wm.subscriptions[key]; !exists {
+			wm.subscriptions[key] = make(map[*Client]bool)
 		}
-		wm.subscriptions[iface][client] = true
+		wm.subscriptions[key][client] = true
 	}
 
 	wm.sendSuccess(client, fmt.Sprintf("Subscribed to interfaces: %v", interfaces))
@@ -270,6 +420,7 @@ func (wm *WebSocketManager) subscribeClient(client *Client, interfaces []string)
 		Timestamp: time.Now(),
 		Data: map[string]interface{}{
 			"client_id":    client.ID,
+			"router_id":    routerID,
 			"interfaces":   interfaces,
 			"connected_at": client.Connected,
 		},
@@ -277,15 +428,16 @@ func (wm *WebSocketManager) subscribeClient(client *Client, interfaces []string)
 }
 
 // unsubscribeClient unsubscribes a client from interface updates
-func (wm *WebSocketManager) unsubscribeClient(client *Client, interfaces []string) {
+func (wm *WebSocketManager) unsubscribeClient(client *Client, routerID uint, interfaces []string) {
 	wm.mu.Lock()
 	defer wm.mu.Unlock()
 
 	for _, iface := range interfaces {
-		if clients, exists := wm.subscriptions[iface]; exists {
+		key := SubscriptionKey(routerID, iface)
+		if clients, exists := wm.subscriptions[key]; exists {
 			delete(clients, client)
 			if len(clients) == 0 {
-				delete(wm.subscriptions, iface)
+				delete(wm.subscriptions, key)
 			}
 		}
 	}
@@ -298,6 +450,13 @@ func (wm *WebSocketManager) unregisterClient(client *Client) {
 	wm.mu.Lock()
 	defer wm.mu.Unlock()
 
+	if _, ok := wm.clients[client.ID]; ok {
+		wm.metrics.RecordDisconnection()
+		wm.logDebug("disconnect", "Client disconnected", map[string]interface{}{
+			"client":    client.ID,
+			"connected": time.Since(client.Connected).Round(time.Second).String(),
+		})
+	}
 	delete(wm.clients, client.ID)
 
 	for iface, clients := range wm.subscriptions {
@@ -371,6 +530,7 @@ func (wm *WebSocketManager) sendStatus(client *Client) {
 func (wm *WebSocketManager) serializeData(data RealTimeData) []byte {
 	resp := map[string]interface{}{
 		"type":       "data",
+		"router_id":  data.RouterID,
 		"interface":  data.InterfaceName,
 		"rx_rate":    data.RxRate,
 		"tx_rate":    data.TxRate,
@@ -406,7 +566,10 @@ func (wm *WebSocketManager) BroadcastData(data RealTimeData) {
 	default:
 		// Channel full, drop message
 		wm.metrics.RecordBroadcastDropped()
-		log.Printf("Broadcast channel full, dropping message for %s", data.InterfaceName)
+		wm.logWarn("broadcast", "Broadcast channel full, dropping message", map[string]interface{}{
+			"router_id": data.RouterID,
+			"interface": data.InterfaceName,
+		})
 	}
 }
 
@@ -424,7 +587,9 @@ func (wm *WebSocketManager) BroadcastEvent(eventType, message string, data inter
 		wm.metrics.RecordEventBroadcast()
 	default:
 		wm.metrics.RecordEventBroadcastDropped()
-		log.Printf("Event broadcast channel full, dropping event: %s", eventType)
+		wm.logWarn("broadcast", "Broadcast channel full, dropping event", map[string]interface{}{
+			"event": eventType,
+		})
 	}
 }
 
@@ -578,6 +743,35 @@ func (wm *WebSocketMetrics) RecordDisconnection() {
 	wm.disconnectionsTotal++
 }
 
+// WebSocketMetricsSnapshot is a point-in-time copy of the WebSocket counters
+type WebSocketMetricsSnapshot struct {
+	MessagesSent        int64
+	MessagesDropped     int64
+	BroadcastsSent      int64
+	BroadcastsDropped   int64
+	EventsSent          int64
+	EventsDropped       int64
+	ConnectionsTotal    int64
+	DisconnectionsTotal int64
+}
+
+// Snapshot returns a copy of the counters
+func (wm *WebSocketMetrics) Snapshot() WebSocketMetricsSnapshot {
+	wm.mu.RLock()
+	defer wm.mu.RUnlock()
+
+	return WebSocketMetricsSnapshot{
+		MessagesSent:        wm.messagesSent,
+		MessagesDropped:     wm.messagesDropped,
+		BroadcastsSent:      wm.broadcastsSent,
+		BroadcastsDropped:   wm.broadcastsDropped,
+		EventsSent:          wm.eventsSent,
+		EventsDropped:       wm.eventsDropped,
+		ConnectionsTotal:    wm.connectionsTotal,
+		DisconnectionsTotal: wm.disconnectionsTotal,
+	}
+}
+
 // GetStats returns current metrics stats
 func (wm *WebSocketMetrics) GetStats() map[string]interface{} {
 	wm.mu.RLock()
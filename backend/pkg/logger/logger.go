@@ -1,9 +1,11 @@
 package logger
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"sync"
 )
 
 // Logger represents the application logger
@@ -11,8 +13,16 @@ type Logger struct {
 	*log.Logger
 }
 
+// Sink receives every message once set, in place of the plain logger. level is
+// debug, info, warn or error.
+type Sink func(level, message string)
+
 // Global logger instance
-var defaultLogger *Logger
+var (
+	defaultLogger *Logger
+	sinkMu        sync.RWMutex
+	sink          Sink
+)
 
 // Init initializes the global logger
 func Init() {
@@ -21,32 +31,46 @@ func Init() {
 	}
 }
 
-// Info logs an info message
-func Info(format string, v ...interface{}) {
+// SetSink routes every message to sink, typically the structured logger. nil restores
+// the plain logger.
+func SetSink(s Sink) {
+	sinkMu.Lock()
+	defer sinkMu.Unlock()
+	sink = s
+}
+
+// output writes a message to the sink, or the plain logger when no sink is set
+func output(level, prefix, format string, v ...interface{}) {
+	sinkMu.RLock()
+	s := sink
+	sinkMu.RUnlock()
+	if s != nil {
+		s(level, fmt.Sprintf(format, v...))
+		return
+	}
 	if defaultLogger != nil {
-		defaultLogger.Printf("[INFO] "+format, v...)
+		defaultLogger.Printf(prefix+format, v...)
 	}
 }
 
+// Info logs an info message
+func Info(format string, v ...interface{}) {
+	output("info", "[INFO] ", format, v...)
+}
+
 // Error logs an error message
 func Error(format string, v ...interface{}) {
-	if defaultLogger != nil {
-		defaultLogger.Printf("[ERROR] "+format, v...)
-	}
+	output("error", "[ERROR] ", format, v...)
 }
 
 // Warn logs a warning message
 func Warn(format string, v ...interface{}) {
-	if defaultLogger != nil {
-		defaultLogger.Printf("[WARN] "+format, v...)
-	}
+	output("warn", "[WARN] ", format, v...)
 }
 
 // Debug logs a debug message
 func Debug(format string, v ...interface{}) {
-	if defaultLogger != nil {
-		defaultLogger.Printf("[DEBUG] "+format, v...)
-	}
+	output("debug", "[DEBUG] ", format, v...)
 }
 
 // SetOutput sets the output destination for the logger